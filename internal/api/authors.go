@@ -1,39 +1,51 @@
 package api
 
 import (
+	"errors"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"github.com/listenarr/listenarr/internal/database"
 	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/monitor"
 )
 
 // CreateAuthorRequest represents the request body for creating an author
 type CreateAuthorRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Biography   string `json:"biography,omitempty"`
-	ImageURL    string `json:"image_url,omitempty"`
-	GoodreadsID string `json:"goodreads_id,omitempty"`
+	Name          string `json:"name" binding:"required"`
+	Biography     string `json:"biography,omitempty"`
+	ImageURL      string `json:"image_url,omitempty"`
+	GoodreadsID   string `json:"goodreads_id,omitempty"`
+	Monitored     bool   `json:"monitored,omitempty"`
+	MonitorOption string `json:"monitor_option,omitempty"` // all, future or none
 }
 
 // UpdateAuthorRequest represents the request body for updating an author
 type UpdateAuthorRequest struct {
-	Name        *string `json:"name,omitempty"`
-	Biography   *string `json:"biography,omitempty"`
-	ImageURL    *string `json:"image_url,omitempty"`
-	GoodreadsID *string `json:"goodreads_id,omitempty"`
+	Name          *string `json:"name,omitempty"`
+	Biography     *string `json:"biography,omitempty"`
+	ImageURL      *string `json:"image_url,omitempty"`
+	GoodreadsID   *string `json:"goodreads_id,omitempty"`
+	Monitored     *bool   `json:"monitored,omitempty"`
+	MonitorOption *string `json:"monitor_option,omitempty"`
 }
 
 // AuthorResponseDetailed represents an author in API responses with timestamps as strings
 type AuthorResponseDetailed struct {
-	ID          uint   `json:"id"`
-	Name        string `json:"name"`
-	Biography   string `json:"biography,omitempty"`
-	ImageURL    string `json:"image_url,omitempty"`
-	GoodreadsID string `json:"goodreads_id,omitempty"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID              uint   `json:"id"`
+	Name            string `json:"name"`
+	Biography       string `json:"biography,omitempty"`
+	ImageURL        string `json:"image_url,omitempty"`
+	GoodreadsID     string `json:"goodreads_id,omitempty"`
+	Monitored       bool   `json:"monitored"`
+	MonitorOption   string `json:"monitor_option"`
+	MonitoredSince  string `json:"monitored_since,omitempty"`
+	LastRefreshedAt string `json:"last_refreshed_at,omitempty"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
 }
 
 // AuthorWithBooksResponse represents an author with their books
@@ -44,15 +56,48 @@ type AuthorWithBooksResponse struct {
 
 // toAuthorResponseDetailed converts an Author model to API response format with string timestamps
 func toAuthorResponseDetailed(author *models.Author) *AuthorResponseDetailed {
-	return &AuthorResponseDetailed{
-		ID:          author.ID,
-		Name:        author.Name,
-		Biography:   author.Biography,
-		ImageURL:    author.ImageURL,
-		GoodreadsID: author.GoodreadsID,
-		CreatedAt:   author.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   author.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	response := &AuthorResponseDetailed{
+		ID:            author.ID,
+		Name:          author.Name,
+		Biography:     author.Biography,
+		ImageURL:      author.ImageURL,
+		GoodreadsID:   author.GoodreadsID,
+		Monitored:     author.Monitored,
+		MonitorOption: string(author.MonitorOption),
+		CreatedAt:     author.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:     author.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
+	if author.MonitoredSince != nil {
+		response.MonitoredSince = author.MonitoredSince.Format("2006-01-02T15:04:05Z07:00")
+	}
+	if author.LastRefreshedAt != nil {
+		response.LastRefreshedAt = author.LastRefreshedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return response
+}
+
+// setAuthorMonitoring applies monitoring settings to an author, recording
+// when monitoring was switched on so "future" can tell new releases apart.
+// Monitoring an author whose option was never set wants all of their books.
+func setAuthorMonitoring(author *models.Author, monitored *bool, option *string) bool {
+	if option != nil {
+		opt := models.AuthorMonitorOption(*option)
+		if !opt.IsValid() {
+			return false
+		}
+		author.MonitorOption = opt
+	}
+	if monitored != nil {
+		if *monitored && !author.Monitored {
+			now := time.Now()
+			author.MonitoredSince = &now
+		}
+		author.Monitored = *monitored
+	}
+	if author.Monitored && author.MonitorOption == "" {
+		author.MonitorOption = models.AuthorMonitorAll
+	}
+	return true
 }
 
 // toAuthorWithBooksResponse converts an Author model with books to API response format
@@ -92,7 +137,12 @@ func (s *Server) getAuthors(c *gin.Context) {
 
 	// Apply search filter
 	if search := c.Query("search"); search != "" {
-		query = query.Where("name LIKE ?", "%"+search+"%")
+		query = query.Where(database.Contains("name", search))
+	}
+	if monitoredStr := c.Query("monitored"); monitoredStr != "" {
+		if monitored, err := strconv.ParseBool(monitoredStr); err == nil {
+			query = query.Where("monitored = ?", monitored)
+		}
 	}
 
 	// Get total count
@@ -145,7 +195,7 @@ func (s *Server) getAuthor(c *gin.Context) {
 	var author models.Author
 	err = s.db.
 		Preload("Books").
-		Preload("Books.Series").
+		Preload("Books.SeriesMemberships.Series").
 		First(&author, uint(id)).Error
 
 	if err != nil {
@@ -186,6 +236,14 @@ func (s *Server) createAuthor(c *gin.Context) {
 		ImageURL:    req.ImageURL,
 		GoodreadsID: req.GoodreadsID,
 	}
+	var option *string
+	if req.MonitorOption != "" {
+		option = &req.MonitorOption
+	}
+	if !setAuthorMonitoring(&author, &req.Monitored, option) {
+		BadRequestResponse(c, "Invalid monitor option")
+		return
+	}
 
 	if err := s.db.Create(&author).Error; err != nil {
 		InternalErrorResponse(c, "Failed to create author")
@@ -247,6 +305,10 @@ func (s *Server) updateAuthor(c *gin.Context) {
 	if req.GoodreadsID != nil {
 		author.GoodreadsID = *req.GoodreadsID
 	}
+	if !setAuthorMonitoring(&author, req.Monitored, req.MonitorOption) {
+		BadRequestResponse(c, "Invalid monitor option")
+		return
+	}
 
 	if err := s.db.Save(&author).Error; err != nil {
 		InternalErrorResponse(c, "Failed to update author")
@@ -294,3 +356,31 @@ func (s *Server) deleteAuthor(c *gin.Context) {
 
 	NoContentResponse(c)
 }
+
+// refreshAuthorBibliography handles POST /api/v1/authors/:id/bibliography/refresh
+// Pulls the author's books from the metadata providers, creates missing books
+// and adds wanted library items according to the author's monitor option.
+func (s *Server) refreshAuthorBibliography(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid author ID")
+		return
+	}
+
+	result, err := s.monitor.RefreshAuthor(uint(id))
+	if err != nil {
+		if errors.Is(err, monitor.ErrAuthorNotFound) {
+			NotFoundResponse(c, "author")
+			return
+		}
+		if errors.Is(err, monitor.ErrMetadataUnavailable) {
+			BadGatewayResponse(c, "Failed to fetch author bibliography")
+			return
+		}
+		InternalErrorResponse(c, "Failed to refresh author bibliography")
+		return
+	}
+
+	SuccessResponse(c, StatusOK, result)
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -10,8 +11,11 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+	"github.com/listenarr/listenarr/internal/services/monitor"
 )
 
 func TestGetAuthors(t *testing.T) {
@@ -251,6 +255,169 @@ func TestUpdateAuthor(t *testing.T) {
 	})
 }
 
+func TestAuthorMonitoring(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+
+	router := gin.New()
+	router.GET("/api/v1/authors", server.getAuthors)
+	router.PUT("/api/v1/authors/:id", server.updateAuthor)
+
+	author := models.Author{Name: "Followed Author"}
+	db.Create(&author)
+	db.Create(&models.Author{Name: "Other Author"})
+
+	t.Run("Enable monitoring", func(t *testing.T) {
+		monitored := true
+		option := "future"
+		body, _ := json.Marshal(UpdateAuthorRequest{Monitored: &monitored, MonitorOption: &option})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/v1/authors/"+strconv.Itoa(int(author.ID)), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var updated models.Author
+		db.First(&updated, author.ID)
+		assert.True(t, updated.Monitored)
+		assert.Equal(t, models.AuthorMonitorFuture, updated.MonitorOption)
+		assert.NotNil(t, updated.MonitoredSince)
+	})
+
+	t.Run("Invalid monitor option", func(t *testing.T) {
+		option := "sometimes"
+		body, _ := json.Marshal(UpdateAuthorRequest{MonitorOption: &option})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/v1/authors/"+strconv.Itoa(int(author.ID)), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Filter monitored authors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/authors?monitored=true", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []AuthorResponseDetailed `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Len(t, response.Data, 1)
+		assert.Equal(t, "Followed Author", response.Data[0].Name)
+		assert.Equal(t, "future", response.Data[0].MonitorOption)
+	})
+
+	t.Run("Monitoring without an option wants all books", func(t *testing.T) {
+		var other models.Author
+		db.Where("name = ?", "Other Author").First(&other)
+		body := []byte(`{"monitored": true}`)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/v1/authors/"+strconv.Itoa(int(other.ID)), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		db.First(&other, other.ID)
+		assert.True(t, other.Monitored)
+		assert.Equal(t, models.AuthorMonitorAll, other.MonitorOption)
+	})
+
+	t.Run("Monitoring keeps an option set to none", func(t *testing.T) {
+		quiet := models.Author{Name: "Quiet Author", MonitorOption: models.AuthorMonitorNone}
+		db.Create(&quiet)
+		body := []byte(`{"monitored": true}`)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/v1/authors/"+strconv.Itoa(int(quiet.ID)), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		db.First(&quiet, quiet.ID)
+		assert.True(t, quiet.Monitored)
+		assert.Equal(t, models.AuthorMonitorNone, quiet.MonitorOption)
+	})
+}
+
+func TestRefreshAuthorBibliography(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+
+	provider := newStubMetadataProvider()
+	provider.works = map[string][]metadata.BookMetadata{
+		"J.K. Rowling": {
+			*provider.books["B017V4IM1G"],
+			{Title: "Harry Potter and the Chamber of Secrets", Authors: []string{"J.K. Rowling"}},
+		},
+	}
+	server.metadata = metadata.NewService(provider)
+	server.monitor = monitor.NewService(db, server.metadata, nil)
+
+	author := models.Author{Name: "J.K. Rowling", Monitored: true, MonitorOption: models.AuthorMonitorAll}
+	db.Create(&author)
+
+	router := gin.New()
+	router.POST("/api/v1/authors/:id/bibliography/refresh", server.refreshAuthorBibliography)
+
+	t.Run("Refresh adds books and wanted items", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/authors/"+strconv.Itoa(int(author.ID))+"/bibliography/refresh", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data monitor.RefreshResult `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, 2, response.Data.BooksAdded)
+		assert.Equal(t, 2, response.Data.ItemsAdded)
+
+		var count int64
+		db.Model(&models.LibraryItem{}).Where("status = ?", models.LibraryItemStatusWanted).Count(&count)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("Refresh non-existent author", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/authors/999/bibliography/refresh", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Metadata provider failure", func(t *testing.T) {
+		provider.err = errors.New("provider down")
+		defer func() { provider.err = nil }()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/authors/"+strconv.Itoa(int(author.ID))+"/bibliography/refresh", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+
+	t.Run("Database failure", func(t *testing.T) {
+		require.NoError(t, db.Migrator().DropTable(&models.LibraryItem{}))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/authors/"+strconv.Itoa(int(author.ID))+"/bibliography/refresh", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
 func TestDeleteAuthor(t *testing.T) {
 	db := setupTestDB(t)
 	server := setupLibraryTestServer(db)
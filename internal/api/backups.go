@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"os"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/listenarr/listenarr/internal/services/backup"
+)
+
+// restoreMessage tells the caller a restore only applies after a restart
+const restoreMessage = "Backup staged, restart Listenarr to restore it"
+
+// getBackups handles GET /api/v1/system/backups
+func (s *Server) getBackups(c *gin.Context) {
+	backups, err := s.backups.List()
+	if err != nil {
+		InternalErrorResponse(c, "Failed to list backups")
+		return
+	}
+
+	SuccessResponse(c, StatusOK, backups)
+}
+
+// createBackup handles POST /api/v1/system/backups
+func (s *Server) createBackup(c *gin.Context) {
+	created, err := s.backups.Create(backup.TypeManual)
+	if errors.Is(err, backup.ErrUnsupported) {
+		BadRequestResponse(c, err.Error())
+		return
+	}
+	if err != nil {
+		InternalErrorResponse(c, "Failed to create backup")
+		return
+	}
+
+	CreatedResponse(c, created)
+}
+
+// downloadBackup handles GET /api/v1/system/backups/:name
+func (s *Server) downloadBackup(c *gin.Context) {
+	path, err := s.backups.Path(c.Param("name"))
+	if err != nil {
+		NotFoundResponse(c, "backup")
+		return
+	}
+
+	c.FileAttachment(path, c.Param("name"))
+}
+
+// deleteBackup handles DELETE /api/v1/system/backups/:name
+func (s *Server) deleteBackup(c *gin.Context) {
+	err := s.backups.Delete(c.Param("name"))
+	if errors.Is(err, backup.ErrBackupNotFound) {
+		NotFoundResponse(c, "backup")
+		return
+	}
+	if err != nil {
+		InternalErrorResponse(c, "Failed to delete backup")
+		return
+	}
+
+	NoContentResponse(c)
+}
+
+// restoreBackup handles POST /api/v1/system/backups/:name/restore
+func (s *Server) restoreBackup(c *gin.Context) {
+	err := s.backups.Restore(c.Param("name"))
+	if errors.Is(err, backup.ErrBackupNotFound) {
+		NotFoundResponse(c, "backup")
+		return
+	}
+	restoreResponse(c, err)
+}
+
+// restoreUploadedBackup handles POST /api/v1/system/backups/restore with
+// the archive in the multipart "file" field
+func (s *Server) restoreUploadedBackup(c *gin.Context) {
+	upload, err := c.FormFile("file")
+	if err != nil {
+		BadRequestResponse(c, "A backup archive is required in the file field")
+		return
+	}
+
+	temp, err := os.CreateTemp("", "listenarr-restore-*.zip")
+	if err != nil {
+		InternalErrorResponse(c, "Failed to receive backup")
+		return
+	}
+	temp.Close()
+	defer os.Remove(temp.Name())
+	if err := c.SaveUploadedFile(upload, temp.Name()); err != nil {
+		InternalErrorResponse(c, "Failed to receive backup")
+		return
+	}
+
+	restoreResponse(c, s.backups.RestoreArchive(temp.Name()))
+}
+
+// restoreResponse answers a restore request with its outcome
+func restoreResponse(c *gin.Context, err error) {
+	if errors.Is(err, backup.ErrInvalidBackup) || errors.Is(err, backup.ErrUnsupported) {
+		BadRequestResponse(c, err.Error())
+		return
+	}
+	if errors.Is(err, backup.ErrInsufficientSpace) {
+		InsufficientStorageResponse(c, "Database folder is too full: "+err.Error())
+		return
+	}
+	if err != nil {
+		InternalErrorResponse(c, "Failed to stage backup")
+		return
+	}
+
+	SuccessResponse(c, StatusAccepted, gin.H{"message": restoreMessage})
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/config"
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/services/backup"
+)
+
+func TestBackups(t *testing.T) {
+	if dbtest.Postgres() {
+		t.Skip("backups are only taken of SQLite databases")
+	}
+	db := setupTestDB(t)
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "listenarr.db")
+	server := setupLibraryTestServer(db)
+	server.backups = backup.NewService(db, backup.Config{
+		DatabasePath: dbPath,
+		Folder:       filepath.Join(dir, "backups"),
+	})
+
+	router := gin.New()
+	router.GET("/api/v1/system/backups", server.getBackups)
+	router.POST("/api/v1/system/backups", server.createBackup)
+	router.POST("/api/v1/system/backups/restore", server.restoreUploadedBackup)
+	router.GET("/api/v1/system/backups/:name", server.downloadBackup)
+	router.DELETE("/api/v1/system/backups/:name", server.deleteBackup)
+	router.POST("/api/v1/system/backups/:name/restore", server.restoreBackup)
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	upload := func(data []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", "backup.zip")
+		require.NoError(t, err)
+		_, err = part.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/system/backups/restore", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Empty list", func(t *testing.T) {
+		w := request("GET", "/api/v1/system/backups")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"data":[]`)
+	})
+
+	var created backup.Backup
+	t.Run("Create", func(t *testing.T) {
+		w := request("POST", "/api/v1/system/backups")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response struct {
+			Data backup.Backup `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		created = response.Data
+		assert.Equal(t, backup.TypeManual, created.Type)
+
+		w = request("GET", "/api/v1/system/backups")
+		assert.Contains(t, w.Body.String(), created.Name)
+	})
+
+	var archive []byte
+	t.Run("Download", func(t *testing.T) {
+		w := request("GET", "/api/v1/system/backups/"+created.Name)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Disposition"), created.Name)
+		archive = w.Body.Bytes()
+		assert.Len(t, archive, int(created.Size))
+
+		w = request("GET", "/api/v1/system/backups/..%2Flistenarr.db")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		w := request("POST", "/api/v1/system/backups/"+created.Name+"/restore")
+		assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "restart")
+		assert.FileExists(t, dbPath+config.RestoreSuffix)
+
+		w = request("POST", "/api/v1/system/backups/missing.zip/restore")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Restore upload", func(t *testing.T) {
+		require.NoError(t, os.Remove(dbPath+config.RestoreSuffix))
+
+		w := upload([]byte("not a zip"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid backup")
+
+		w = upload(archive)
+		assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		assert.FileExists(t, dbPath+config.RestoreSuffix)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		w := request("DELETE", "/api/v1/system/backups/"+created.Name)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		w = request("DELETE", "/api/v1/system/backups/"+created.Name)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestBackups_Unsupported(t *testing.T) {
+	// A PostgreSQL database, never connected to
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1 dbname=listenarr"), &gorm.Config{DisableAutomaticPing: true})
+	require.NoError(t, err)
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "listenarr.db")
+	server := &Server{backups: backup.NewService(db, backup.Config{
+		DatabasePath: dbPath,
+		Folder:       filepath.Join(dir, "backups"),
+	})}
+
+	router := gin.New()
+	router.POST("/api/v1/system/backups", server.createBackup)
+	router.POST("/api/v1/system/backups/:name/restore", server.restoreBackup)
+
+	for _, path := range []string{
+		"/api/v1/system/backups",
+		"/api/v1/system/backups/listenarr_backup_manual_20260101_120000.000.zip/restore",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		assert.Contains(t, w.Body.String(), "only supported for SQLite")
+	}
+	assert.NoFileExists(t, dbPath+config.RestoreSuffix)
+}
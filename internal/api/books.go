@@ -1,83 +1,160 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"github.com/listenarr/listenarr/internal/database"
 	"github.com/listenarr/listenarr/internal/models"
 )
 
 // CreateBookRequest represents the request body for creating a book
 type CreateBookRequest struct {
-	Title          string     `json:"title" binding:"required"`
-	AuthorID       uint       `json:"author_id" binding:"required"`
-	ISBN           *string    `json:"isbn,omitempty"`
-	ASIN           *string    `json:"asin,omitempty"`
-	Description    *string    `json:"description,omitempty"`
-	CoverArtURL    *string    `json:"cover_art_url,omitempty"`
-	ReleaseDate    *time.Time `json:"release_date,omitempty"`
-	Genre          *string    `json:"genre,omitempty"`
-	Language       *string    `json:"language,omitempty"`
-	SeriesID       *uint      `json:"series_id,omitempty"`
-	SeriesPosition *int       `json:"series_position,omitempty"`
+	Title        string                   `json:"title" binding:"required"`
+	AuthorID     uint                     `json:"author_id" binding:"required"`
+	ISBN         *string                  `json:"isbn,omitempty"`
+	ASIN         *string                  `json:"asin,omitempty"`
+	Description  *string                  `json:"description,omitempty"`
+	CoverArtURL  *string                  `json:"cover_art_url,omitempty"`
+	ReleaseDate  *time.Time               `json:"release_date,omitempty"`
+	Genre        *string                  `json:"genre,omitempty"`
+	Language     *string                  `json:"language,omitempty"`
+	Series       []BookSeriesRequest      `json:"series,omitempty"`
+	Contributors []BookContributorRequest `json:"contributors,omitempty"` // Credits besides the primary author
 }
 
 // UpdateBookRequest represents the request body for updating a book
 type UpdateBookRequest struct {
-	Title          *string    `json:"title,omitempty"`
-	AuthorID       *uint      `json:"author_id,omitempty"`
-	ISBN           *string    `json:"isbn,omitempty"`
-	ASIN           *string    `json:"asin,omitempty"`
-	Description    *string    `json:"description,omitempty"`
-	CoverArtURL    *string    `json:"cover_art_url,omitempty"`
-	ReleaseDate    *time.Time `json:"release_date,omitempty"`
-	Genre          *string    `json:"genre,omitempty"`
-	Language       *string    `json:"language,omitempty"`
-	SeriesID       *uint      `json:"series_id,omitempty"`
-	SeriesPosition *int       `json:"series_position,omitempty"`
+	Title        *string                  `json:"title,omitempty"`
+	AuthorID     *uint                    `json:"author_id,omitempty"`
+	ISBN         *string                  `json:"isbn,omitempty"`
+	ASIN         *string                  `json:"asin,omitempty"`
+	Description  *string                  `json:"description,omitempty"`
+	CoverArtURL  *string                  `json:"cover_art_url,omitempty"`
+	ReleaseDate  *time.Time               `json:"release_date,omitempty"`
+	Genre        *string                  `json:"genre,omitempty"`
+	Language     *string                  `json:"language,omitempty"`
+	Series       []BookSeriesRequest      `json:"series,omitempty"`       // Replaces all memberships; [] removes the book from every series
+	Contributors []BookContributorRequest `json:"contributors,omitempty"` // Replaces all credits except the primary author; [] leaves only the primary author
+}
+
+// BookSeriesRequest represents a book's membership in a series in requests
+type BookSeriesRequest struct {
+	SeriesID uint           `json:"series_id"`
+	Position SeriesPosition `json:"position,omitempty"`
+}
+
+// BookContributorRequest credits a person with a role on a book in requests.
+// Narrator credits take a narrator_id; every other role takes an author_id.
+type BookContributorRequest struct {
+	Role       string `json:"role" binding:"required"` // author, narrator, translator or editor
+	AuthorID   *uint  `json:"author_id,omitempty"`
+	NarratorID *uint  `json:"narrator_id,omitempty"`
+}
+
+// SeriesPosition is a series position in requests. Positions are text, but
+// JSON numbers are accepted too so clients can send 2 as well as "2.5".
+type SeriesPosition string
+
+// UnmarshalJSON accepts a JSON string or number
+func (p *SeriesPosition) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*p = SeriesPosition(strings.TrimSpace(text))
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("series position must be a string or a number")
+	}
+	*p = SeriesPosition(number.String())
+	return nil
 }
 
 // BookResponseDetailed represents a book in API responses with full details
 type BookResponseDetailed struct {
-	ID             uint            `json:"id"`
-	Title          string          `json:"title"`
-	ISBN           string          `json:"isbn,omitempty"`
-	ASIN           string          `json:"asin,omitempty"`
-	Description    string          `json:"description,omitempty"`
-	CoverArtURL    string          `json:"cover_art_url,omitempty"`
-	ReleaseDate    *time.Time      `json:"release_date,omitempty"`
-	Genre          string          `json:"genre,omitempty"`
-	Language       string          `json:"language,omitempty"`
-	AuthorID       uint            `json:"author_id"`
-	Author         *AuthorResponse `json:"author,omitempty"`
-	SeriesID       *uint           `json:"series_id,omitempty"`
-	Series         *SeriesResponse `json:"series,omitempty"`
-	SeriesPosition *int            `json:"series_position,omitempty"`
-	Audiobook      interface{}     `json:"audiobook,omitempty"`
-	CreatedAt      time.Time       `json:"created_at"`
-	UpdatedAt      time.Time       `json:"updated_at"`
+	ID           uint                      `json:"id"`
+	Title        string                    `json:"title"`
+	ISBN         string                    `json:"isbn,omitempty"`
+	ASIN         string                    `json:"asin,omitempty"`
+	Description  string                    `json:"description,omitempty"`
+	CoverArtURL  string                    `json:"cover_art_url,omitempty"`
+	ReleaseDate  *time.Time                `json:"release_date,omitempty"`
+	Genre        string                    `json:"genre,omitempty"`
+	Language     string                    `json:"language,omitempty"`
+	AuthorID     uint                      `json:"author_id"`
+	Author       *AuthorResponse           `json:"author,omitempty"`
+	Series       []BookSeriesResponse      `json:"series,omitempty"`
+	Contributors []BookContributorResponse `json:"contributors,omitempty"`
+	Audiobook    interface{}               `json:"audiobook,omitempty"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+}
+
+// BookContributorResponse represents a contributor credit on a book
+type BookContributorResponse struct {
+	Role       string `json:"role"`
+	Name       string `json:"name"`
+	AuthorID   *uint  `json:"author_id,omitempty"`
+	NarratorID *uint  `json:"narrator_id,omitempty"`
+}
+
+// toBookContributorResponses converts a book's contributor credits (with
+// Author and Narrator preloaded) to API response format, listing the primary
+// author first and the other credits in the order they were added
+func toBookContributorResponses(book *models.Book) []BookContributorResponse {
+	if len(book.Contributors) == 0 {
+		return nil
+	}
+	credits := make([]models.BookContributor, len(book.Contributors))
+	copy(credits, book.Contributors)
+	isPrimary := func(credit models.BookContributor) bool {
+		return credit.Role == models.ContributorRoleAuthor && credit.AuthorID != nil && *credit.AuthorID == book.AuthorID
+	}
+	sort.SliceStable(credits, func(i, j int) bool {
+		if isPrimary(credits[i]) != isPrimary(credits[j]) {
+			return isPrimary(credits[i])
+		}
+		return credits[i].ID < credits[j].ID
+	})
+
+	responses := make([]BookContributorResponse, len(credits))
+	for i := range credits {
+		responses[i] = BookContributorResponse{
+			Role:       string(credits[i].Role),
+			Name:       credits[i].Name(),
+			AuthorID:   credits[i].AuthorID,
+			NarratorID: credits[i].NarratorID,
+		}
+	}
+	return responses
 }
 
 // toBookResponseDetailed converts a Book model to detailed API response format
 func toBookResponseDetailed(book *models.Book) *BookResponseDetailed {
 	response := &BookResponseDetailed{
-		ID:             book.ID,
-		Title:          book.Title,
-		ISBN:           book.ISBN,
-		ASIN:           book.ASIN,
-		Description:    book.Description,
-		CoverArtURL:    book.CoverArtURL,
-		ReleaseDate:    book.ReleaseDate,
-		Genre:          book.Genre,
-		Language:       book.Language,
-		AuthorID:       book.AuthorID,
-		SeriesID:       book.SeriesID,
-		SeriesPosition: book.SeriesPosition,
-		CreatedAt:      book.CreatedAt,
-		UpdatedAt:      book.UpdatedAt,
+		ID:           book.ID,
+		Title:        book.Title,
+		ISBN:         book.ISBN,
+		ASIN:         book.ASIN,
+		Description:  book.Description,
+		CoverArtURL:  book.CoverArtURL,
+		ReleaseDate:  book.ReleaseDate,
+		Genre:        book.Genre,
+		Language:     book.Language,
+		AuthorID:     book.AuthorID,
+		Series:       toBookSeriesResponses(book.SeriesMemberships),
+		Contributors: toBookContributorResponses(book),
+		CreatedAt:    book.CreatedAt,
+		UpdatedAt:    book.UpdatedAt,
 	}
 
 	if book.Author.ID != 0 {
@@ -90,15 +167,6 @@ func toBookResponseDetailed(book *models.Book) *BookResponseDetailed {
 		}
 	}
 
-	if book.Series != nil && book.Series.ID != 0 {
-		response.Series = &SeriesResponse{
-			ID:          book.Series.ID,
-			Name:        book.Series.Name,
-			Description: book.Series.Description,
-			TotalBooks:  book.Series.TotalBooks,
-		}
-	}
-
 	if book.Audiobook != nil {
 		response.Audiobook = map[string]interface{}{
 			"id":       book.Audiobook.ID,
@@ -137,16 +205,33 @@ func (s *Server) getBooks(c *gin.Context) {
 
 	// Apply filters
 	if search := c.Query("search"); search != "" {
-		query = query.Where("title LIKE ?", "%"+search+"%")
+		query = query.Where(database.Contains("title", search))
 	}
 	if authorIDStr := c.Query("author_id"); authorIDStr != "" {
 		if authorID, err := strconv.ParseUint(authorIDStr, 10, 32); err == nil {
-			query = query.Where("author_id = ?", uint(authorID))
+			query = query.Where("author_id = ? OR id IN (?)", uint(authorID), s.db.Model(&models.BookContributor{}).
+				Select("book_id").
+				Where("author_id = ?", uint(authorID)))
 		}
 	}
+	if narratorIDStr := c.Query("narrator_id"); narratorIDStr != "" {
+		if narratorID, err := strconv.ParseUint(narratorIDStr, 10, 32); err == nil {
+			query = query.Where("id IN (?)", s.db.Model(&models.BookContributor{}).
+				Select("book_id").
+				Where("narrator_id = ?", uint(narratorID)))
+		}
+	}
+	if narrator := c.Query("narrator"); narrator != "" {
+		query = query.Where("id IN (?)", s.db.Model(&models.BookContributor{}).
+			Select("book_contributors.book_id").
+			Joins("JOIN narrators ON narrators.id = book_contributors.narrator_id AND narrators.deleted_at IS NULL").
+			Where(database.Contains("narrators.name", narrator)))
+	}
 	if seriesIDStr := c.Query("series_id"); seriesIDStr != "" {
 		if seriesID, err := strconv.ParseUint(seriesIDStr, 10, 32); err == nil {
-			query = query.Where("series_id = ?", uint(seriesID))
+			query = query.Where("id IN (?)", s.db.Model(&models.BookSeries{}).
+				Select("book_id").
+				Where("series_id = ?", uint(seriesID)))
 		}
 	}
 
@@ -176,7 +261,9 @@ func (s *Server) getBooks(c *gin.Context) {
 	var books []models.Book
 	err := query.
 		Preload("Author").
-		Preload("Series").
+		Preload("SeriesMemberships.Series").
+		Preload("Contributors.Author").
+		Preload("Contributors.Narrator").
 		Offset(offset).
 		Limit(limit).
 		Find(&books).Error
@@ -207,7 +294,9 @@ func (s *Server) getBook(c *gin.Context) {
 	var book models.Book
 	err = s.db.
 		Preload("Author").
-		Preload("Series").
+		Preload("SeriesMemberships.Series").
+		Preload("Contributors.Author").
+		Preload("Contributors.Narrator").
 		Preload("Audiobook").
 		Preload("Releases").
 		Preload("LibraryItems").
@@ -245,18 +334,12 @@ func (s *Server) createBook(c *gin.Context) {
 		return
 	}
 
-	// Verify series exists if provided
-	if req.SeriesID != nil {
-		var series models.Series
-		err := s.db.First(&series, *req.SeriesID).Error
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				NotFoundResponse(c, "series")
-				return
-			}
-			InternalErrorResponse(c, "Failed to find series")
-			return
-		}
+	// Verify series and contributors exist if provided
+	if !s.checkBookSeriesRequests(c, req.Series) {
+		return
+	}
+	if !s.checkBookContributorRequests(c, req.Contributors) {
+		return
 	}
 
 	// Check for duplicate book (by title + author or ISBN/ASIN)
@@ -280,10 +363,8 @@ func (s *Server) createBook(c *gin.Context) {
 
 	// Create book
 	book := models.Book{
-		Title:          req.Title,
-		AuthorID:       req.AuthorID,
-		SeriesID:       req.SeriesID,
-		SeriesPosition: req.SeriesPosition,
+		Title:    req.Title,
+		AuthorID: req.AuthorID,
 	}
 	if req.ISBN != nil {
 		book.ISBN = *req.ISBN
@@ -307,7 +388,16 @@ func (s *Server) createBook(c *gin.Context) {
 		book.Language = *req.Language
 	}
 
-	if err := s.db.Create(&book).Error; err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&book).Error; err != nil {
+			return err
+		}
+		if err := replaceBookSeries(tx, book.ID, req.Series); err != nil {
+			return err
+		}
+		return replaceBookContributors(tx, &book, req.Contributors)
+	})
+	if err != nil {
 		InternalErrorResponse(c, "Failed to create book")
 		return
 	}
@@ -315,7 +405,9 @@ func (s *Server) createBook(c *gin.Context) {
 	// Reload with relationships
 	err = s.db.
 		Preload("Author").
-		Preload("Series").
+		Preload("SeriesMemberships.Series").
+		Preload("Contributors.Author").
+		Preload("Contributors.Narrator").
 		First(&book, book.ID).Error
 	if err != nil {
 		InternalErrorResponse(c, "Failed to reload book")
@@ -356,6 +448,7 @@ func (s *Server) updateBook(c *gin.Context) {
 	if req.Title != nil {
 		book.Title = *req.Title
 	}
+	previousAuthorID := book.AuthorID
 	if req.AuthorID != nil {
 		// Verify author exists
 		var author models.Author
@@ -391,29 +484,37 @@ func (s *Server) updateBook(c *gin.Context) {
 	if req.Language != nil {
 		book.Language = *req.Language
 	}
-	if req.SeriesID != nil {
-		if *req.SeriesID == 0 {
-			book.SeriesID = nil
-		} else {
-			// Verify series exists
-			var series models.Series
-			err := s.db.First(&series, *req.SeriesID).Error
-			if err != nil {
-				if err == gorm.ErrRecordNotFound {
-					NotFoundResponse(c, "series")
-					return
-				}
-				InternalErrorResponse(c, "Failed to find series")
-				return
-			}
-			book.SeriesID = req.SeriesID
-		}
+	if !s.checkBookSeriesRequests(c, req.Series) {
+		return
 	}
-	if req.SeriesPosition != nil {
-		book.SeriesPosition = req.SeriesPosition
+	if !s.checkBookContributorRequests(c, req.Contributors) {
+		return
 	}
 
-	if err := s.db.Save(&book).Error; err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&book).Error; err != nil {
+			return err
+		}
+		if req.Series != nil {
+			if err := replaceBookSeries(tx, book.ID, req.Series); err != nil {
+				return err
+			}
+		}
+		if req.Contributors != nil {
+			return replaceBookContributors(tx, &book, req.Contributors)
+		}
+		if book.AuthorID != previousAuthorID {
+			// The new primary author takes over the previous one's credit
+			err := tx.Where("book_id = ? AND role = ? AND author_id = ?", book.ID, models.ContributorRoleAuthor, previousAuthorID).
+				Delete(&models.BookContributor{}).Error
+			if err != nil {
+				return err
+			}
+			return models.CreditAuthor(tx, book.ID, book.AuthorID, models.ContributorRoleAuthor)
+		}
+		return nil
+	})
+	if err != nil {
 		InternalErrorResponse(c, "Failed to update book")
 		return
 	}
@@ -421,7 +522,9 @@ func (s *Server) updateBook(c *gin.Context) {
 	// Reload with relationships
 	err = s.db.
 		Preload("Author").
-		Preload("Series").
+		Preload("SeriesMemberships.Series").
+		Preload("Contributors.Author").
+		Preload("Contributors.Narrator").
 		Preload("Audiobook").
 		First(&book, book.ID).Error
 	if err != nil {
@@ -432,6 +535,120 @@ func (s *Server) updateBook(c *gin.Context) {
 	SuccessResponse(c, StatusOK, toBookResponseDetailed(&book))
 }
 
+// checkBookSeriesRequests verifies that every requested series exists and is
+// only listed once, writing an error response and returning false otherwise
+func (s *Server) checkBookSeriesRequests(c *gin.Context, requests []BookSeriesRequest) bool {
+	seen := make(map[uint]bool)
+	for _, request := range requests {
+		if seen[request.SeriesID] {
+			BadRequestResponse(c, "Series listed more than once")
+			return false
+		}
+		seen[request.SeriesID] = true
+
+		var series models.Series
+		err := s.db.First(&series, request.SeriesID).Error
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				NotFoundResponse(c, "series")
+				return false
+			}
+			InternalErrorResponse(c, "Failed to find series")
+			return false
+		}
+	}
+	return true
+}
+
+// replaceBookSeries replaces a book's series memberships with the requested ones
+func replaceBookSeries(tx *gorm.DB, bookID uint, requests []BookSeriesRequest) error {
+	if err := tx.Where("book_id = ?", bookID).Delete(&models.BookSeries{}).Error; err != nil {
+		return err
+	}
+	for _, request := range requests {
+		link := models.BookSeries{
+			BookID:   bookID,
+			SeriesID: request.SeriesID,
+			Position: string(request.Position),
+		}
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkBookContributorRequests verifies that every requested credit has a
+// valid role and references an existing author or narrator, writing an
+// error response and returning false otherwise
+func (s *Server) checkBookContributorRequests(c *gin.Context, requests []BookContributorRequest) bool {
+	for _, request := range requests {
+		role := models.ContributorRole(request.Role)
+		if !role.IsValid() {
+			BadRequestResponse(c, "Invalid contributor role")
+			return false
+		}
+
+		if role.IsNarration() {
+			if request.NarratorID == nil || request.AuthorID != nil {
+				BadRequestResponse(c, "Narrator credits require narrator_id")
+				return false
+			}
+			var narrator models.Narrator
+			err := s.db.First(&narrator, *request.NarratorID).Error
+			if err != nil {
+				if err == gorm.ErrRecordNotFound {
+					NotFoundResponse(c, "narrator")
+					return false
+				}
+				InternalErrorResponse(c, "Failed to find narrator")
+				return false
+			}
+			continue
+		}
+
+		if request.AuthorID == nil || request.NarratorID != nil {
+			BadRequestResponse(c, "Author, translator and editor credits require author_id")
+			return false
+		}
+		var author models.Author
+		err := s.db.First(&author, *request.AuthorID).Error
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				NotFoundResponse(c, "author")
+				return false
+			}
+			InternalErrorResponse(c, "Failed to find author")
+			return false
+		}
+	}
+	return true
+}
+
+// replaceBookContributors replaces a book's contributor credits with the
+// primary author followed by the requested credits
+func replaceBookContributors(tx *gorm.DB, book *models.Book, requests []BookContributorRequest) error {
+	if err := tx.Where("book_id = ?", book.ID).Delete(&models.BookContributor{}).Error; err != nil {
+		return err
+	}
+	if err := models.CreditAuthor(tx, book.ID, book.AuthorID, models.ContributorRoleAuthor); err != nil {
+		return err
+	}
+	for _, request := range requests {
+		role := models.ContributorRole(request.Role)
+		var err error
+		if role.IsNarration() {
+			err = models.CreditNarrator(tx, book.ID, *request.NarratorID)
+		} else {
+			err = models.CreditAuthor(tx, book.ID, *request.AuthorID, role)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // deleteBook handles DELETE /api/v1/books/:id
 func (s *Server) deleteBook(c *gin.Context) {
 	idStr := c.Param("id")
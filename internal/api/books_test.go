@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/listenarr/listenarr/internal/models"
 )
@@ -261,3 +262,191 @@ func TestDeleteBook(t *testing.T) {
 		assert.Equal(t, http.StatusConflict, w.Code)
 	})
 }
+
+func TestBookSeriesMemberships(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+
+	author := models.Author{Name: "Brandon Sanderson"}
+	db.Create(&author)
+	stormlight := models.Series{Name: "The Stormlight Archive"}
+	cosmere := models.Series{Name: "The Cosmere"}
+	db.Create(&stormlight)
+	db.Create(&cosmere)
+
+	router := gin.New()
+	router.POST("/api/v1/books", server.createBook)
+	router.PUT("/api/v1/books/:id", server.updateBook)
+
+	send := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Create book in multiple series", func(t *testing.T) {
+		// Positions are accepted as numbers or strings
+		w := send("POST", "/api/v1/books", `{"title": "Edgedancer", "author_id": 1, "series": [
+			{"series_id": 1, "position": 2.5},
+			{"series_id": 2, "position": "Novella"}
+		]}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response struct {
+			Data BookResponseDetailed `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data.Series, 2)
+
+		positions := map[string]string{}
+		for _, membership := range response.Data.Series {
+			positions[membership.Name] = membership.Position
+		}
+		assert.Equal(t, "2.5", positions["The Stormlight Archive"])
+		assert.Equal(t, "Novella", positions["The Cosmere"])
+	})
+
+	t.Run("Create book with duplicate series", func(t *testing.T) {
+		w := send("POST", "/api/v1/books", `{"title": "Twice", "author_id": 1, "series": [{"series_id": 1}, {"series_id": 1}]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Create book with unknown series", func(t *testing.T) {
+		w := send("POST", "/api/v1/books", `{"title": "Nowhere", "author_id": 1, "series": [{"series_id": 99}]}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Update replaces memberships", func(t *testing.T) {
+		w := send("PUT", "/api/v1/books/1", `{"series": [{"series_id": 2, "position": "3"}]}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var links []models.BookSeries
+		db.Where("book_id = ?", 1).Find(&links)
+		require.Len(t, links, 1)
+		assert.Equal(t, cosmere.ID, links[0].SeriesID)
+		assert.Equal(t, "3", links[0].Position)
+	})
+
+	t.Run("Update without series keeps memberships", func(t *testing.T) {
+		w := send("PUT", "/api/v1/books/1", `{"description": "A novella"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var count int64
+		db.Model(&models.BookSeries{}).Where("book_id = ?", 1).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Update with empty series removes memberships", func(t *testing.T) {
+		w := send("PUT", "/api/v1/books/1", `{"series": []}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var count int64
+		db.Model(&models.BookSeries{}).Where("book_id = ?", 1).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func TestBookContributors(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+
+	gaiman := models.Author{Name: "Neil Gaiman"}
+	pratchett := models.Author{Name: "Terry Pratchett"}
+	db.Create(&gaiman)
+	db.Create(&pratchett)
+	jarvis := models.Narrator{Name: "Martin Jarvis"}
+	db.Create(&jarvis)
+
+	router := gin.New()
+	router.GET("/api/v1/books", server.getBooks)
+	router.POST("/api/v1/books", server.createBook)
+	router.PUT("/api/v1/books/:id", server.updateBook)
+
+	send := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+	listTitles := func(query string) []string {
+		w := send("GET", "/api/v1/books?"+query, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data []BookResponseDetailed `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		titles := make([]string, len(response.Data))
+		for i := range response.Data {
+			titles[i] = response.Data[i].Title
+		}
+		return titles
+	}
+
+	t.Run("Create co-written narrated book", func(t *testing.T) {
+		w := send("POST", "/api/v1/books", `{"title": "Good Omens", "author_id": 1, "contributors": [
+			{"role": "author", "author_id": 2},
+			{"role": "narrator", "narrator_id": 1}
+		]}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response struct {
+			Data BookResponseDetailed `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data.Contributors, 3)
+		assert.Equal(t, BookContributorResponse{Role: "author", Name: "Neil Gaiman", AuthorID: &gaiman.ID}, response.Data.Contributors[0])
+		assert.Equal(t, BookContributorResponse{Role: "author", Name: "Terry Pratchett", AuthorID: &pratchett.ID}, response.Data.Contributors[1])
+		assert.Equal(t, BookContributorResponse{Role: "narrator", Name: "Martin Jarvis", NarratorID: &jarvis.ID}, response.Data.Contributors[2])
+	})
+
+	t.Run("Create book with invalid contributors", func(t *testing.T) {
+		w := send("POST", "/api/v1/books", `{"title": "Bad", "author_id": 1, "contributors": [{"role": "illustrator", "author_id": 2}]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = send("POST", "/api/v1/books", `{"title": "Bad", "author_id": 1, "contributors": [{"role": "narrator", "author_id": 2}]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = send("POST", "/api/v1/books", `{"title": "Bad", "author_id": 1, "contributors": [{"role": "narrator", "narrator_id": 99}]}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Filter books by contributor", func(t *testing.T) {
+		db.Create(&models.Book{Title: "Coraline", AuthorID: gaiman.ID})
+
+		assert.Equal(t, []string{"Good Omens"}, listTitles("narrator_id=1"))
+		assert.Equal(t, []string{"Good Omens"}, listTitles("narrator=jarvis"))
+		assert.Empty(t, listTitles("narrator=Fry"))
+		// Co-authors find the book as well as its primary author
+		assert.Equal(t, []string{"Good Omens"}, listTitles("author_id=2"))
+		assert.Equal(t, []string{"Coraline", "Good Omens"}, listTitles("author_id=1"))
+		assert.Equal(t, []string{"Good Omens"}, listTitles("author_id=1&narrator_id=1"))
+	})
+
+	t.Run("Change primary author", func(t *testing.T) {
+		w := send("PUT", "/api/v1/books/1", `{"author_id": 2}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var credits []models.BookContributor
+		db.Where("book_id = ? AND role = ?", 1, models.ContributorRoleAuthor).Find(&credits)
+		require.Len(t, credits, 1)
+		assert.Equal(t, pratchett.ID, *credits[0].AuthorID)
+	})
+
+	t.Run("Update replaces credits", func(t *testing.T) {
+		w := send("PUT", "/api/v1/books/1", `{"contributors": [{"role": "translator", "author_id": 1}]}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data BookResponseDetailed `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data.Contributors, 2)
+		assert.Equal(t, "Terry Pratchett", response.Data.Contributors[0].Name)
+		assert.Equal(t, "translator", response.Data.Contributors[1].Role)
+		assert.Equal(t, "Neil Gaiman", response.Data.Contributors[1].Name)
+		assert.Empty(t, listTitles("narrator_id=1"))
+	})
+}
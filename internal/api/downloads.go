@@ -1,12 +1,14 @@
 package api
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/download"
 )
 
 // StartDownloadRequest represents the request body for starting a download
@@ -167,7 +169,6 @@ func (s *Server) getDownload(c *gin.Context) {
 }
 
 // startDownload handles POST /api/v1/downloads
-// Note: This is a placeholder implementation. Full qBittorrent integration will be added later.
 func (s *Server) startDownload(c *gin.Context) {
 	var req StartDownloadRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -175,78 +176,51 @@ func (s *Server) startDownload(c *gin.Context) {
 		return
 	}
 
-	// Verify library item exists
-	var libraryItem models.LibraryItem
-	err := s.db.First(&libraryItem, req.LibraryItemID).Error
+	started, err := s.downloads.StartDownload(req.LibraryItemID, req.ReleaseID)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
+		switch {
+		case errors.Is(err, download.ErrLibraryItemNotFound):
 			NotFoundResponse(c, "library item")
-			return
-		}
-		InternalErrorResponse(c, "Failed to find library item")
-		return
-	}
-
-	// Verify release exists
-	var release models.Release
-	err = s.db.First(&release, req.ReleaseID).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
+		case errors.Is(err, download.ErrReleaseNotFound):
 			NotFoundResponse(c, "release")
-			return
+		case errors.Is(err, download.ErrReleaseBlocklisted):
+			ConflictResponse(c, "Release is blocklisted")
+		case errors.Is(err, download.ErrAlreadyDownloading):
+			ConflictResponse(c, "Active download already exists for this library item")
+		case errors.Is(err, download.ErrClientNotConfigured):
+			BadRequestResponse(c, "No download client is configured")
+		case errors.Is(err, download.ErrNoTorrentURL):
+			BadRequestResponse(c, "Release has no torrent or magnet URL")
+		case errors.Is(err, download.ErrNoTorrentHash):
+			BadGatewayResponse(c, "Could not find the info hash of the release's torrent: "+err.Error())
+		case errors.Is(err, download.ErrInsufficientSpace):
+			InsufficientStorageResponse(c, "Download folder is too full: "+err.Error())
+		case errors.Is(err, download.ErrTorrentNotAdded):
+			BadGatewayResponse(c, "qBittorrent did not accept the torrent")
+		default:
+			InternalErrorResponse(c, "Failed to start download")
 		}
-		InternalErrorResponse(c, "Failed to find release")
-		return
-	}
-
-	// Check if there's already an active download for this library item
-	var existingDownload models.Download
-	err = s.db.Where("library_item_id = ? AND status IN ?", req.LibraryItemID, []models.DownloadStatus{
-		models.DownloadStatusQueued,
-		models.DownloadStatusDownloading,
-	}).First(&existingDownload).Error
-	if err == nil {
-		ConflictResponse(c, "Active download already exists for this library item")
-		return
-	} else if err != gorm.ErrRecordNotFound {
-		InternalErrorResponse(c, "Failed to check existing downloads")
-		return
-	}
-
-	// Create download
-	download := models.Download{
-		LibraryItemID: req.LibraryItemID,
-		ReleaseID:     req.ReleaseID,
-		Status:        models.DownloadStatusQueued,
-		Progress:      0,
-	}
-
-	if err := s.db.Create(&download).Error; err != nil {
-		InternalErrorResponse(c, "Failed to create download")
 		return
 	}
 
-	// Update library item status
-	libraryItem.Status = models.LibraryItemStatusDownloading
-	s.db.Save(&libraryItem)
-
-	// TODO: Integrate with qBittorrent service to actually start the download
-	// For now, we just create the download record
-
 	// Reload with relationships
+	var created models.Download
 	err = s.db.
 		Preload("LibraryItem").
 		Preload("Release").
-		First(&download, download.ID).Error
+		First(&created, started.ID).Error
 	if err != nil {
 		InternalErrorResponse(c, "Failed to reload download")
 		return
 	}
 
-	CreatedResponse(c, toDownloadResponse(&download))
+	CreatedResponse(c, toDownloadResponse(&created))
 }
 
 // cancelDownload handles DELETE /api/v1/downloads/:id
+// Query parameters:
+//   - delete_files: also remove downloaded data from the download client
+//   - blocklist: never grab this release again
 func (s *Server) cancelDownload(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -255,38 +229,24 @@ func (s *Server) cancelDownload(c *gin.Context) {
 		return
 	}
 
-	// Check if download exists
-	var download models.Download
-	err = s.db.First(&download, uint(id)).Error
+	deleteFiles, _ := strconv.ParseBool(c.DefaultQuery("delete_files", "false"))
+	blocklist, _ := strconv.ParseBool(c.DefaultQuery("blocklist", "false"))
+
+	_, err = s.downloads.CancelDownload(uint(id), download.CancelOptions{
+		DeleteFiles: deleteFiles,
+		Blocklist:   blocklist,
+	})
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
+		switch {
+		case errors.Is(err, download.ErrDownloadNotFound):
 			NotFoundResponse(c, "download")
-			return
+		case errors.Is(err, download.ErrNotCancellable):
+			BadRequestResponse(c, "Can only cancel queued, downloading or paused downloads")
+		default:
+			InternalErrorResponse(c, "Failed to cancel download")
 		}
-		InternalErrorResponse(c, "Failed to find download")
 		return
 	}
 
-	// Only allow canceling queued or downloading status
-	if download.Status != models.DownloadStatusQueued && download.Status != models.DownloadStatusDownloading {
-		BadRequestResponse(c, "Can only cancel queued or downloading downloads")
-		return
-	}
-
-	// Update status to failed (or we could add a "cancelled" status)
-	download.Status = models.DownloadStatusFailed
-	download.Error = "Download cancelled by user"
-	if err := s.db.Save(&download).Error; err != nil {
-		InternalErrorResponse(c, "Failed to cancel download")
-		return
-	}
-
-	// Update library item status back to wanted
-	var libraryItem models.LibraryItem
-	if err := s.db.First(&libraryItem, download.LibraryItemID).Error; err == nil {
-		libraryItem.Status = models.LibraryItemStatusWanted
-		s.db.Save(&libraryItem)
-	}
-
 	NoContentResponse(c)
 }
@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,6 +13,8 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/download"
+	"github.com/listenarr/listenarr/pkg/qbit"
 )
 
 func TestGetDownloads(t *testing.T) {
@@ -120,6 +123,8 @@ func TestGetDownload(t *testing.T) {
 func TestStartDownload(t *testing.T) {
 	db := setupTestDB(t)
 	server := setupLibraryTestServer(db)
+	torrents := &fakeTorrentClient{torrents: map[string]bool{}}
+	server.downloads = download.NewService(db, torrents, server.events, nil)
 
 	// Create test data
 	author := models.Author{Name: "Test Author"}
@@ -135,52 +140,134 @@ func TestStartDownload(t *testing.T) {
 	}
 	db.Create(&libraryItem)
 
-	release := models.Release{BookID: book.ID, Format: "m4b"}
+	release := models.Release{BookID: book.ID, Format: "m4b", MagnetURL: "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a"}
 	db.Create(&release)
 
 	router := gin.New()
 	router.POST("/api/v1/downloads", server.startDownload)
 
-	t.Run("Start download", func(t *testing.T) {
-		reqBody := StartDownloadRequest{
-			LibraryItemID: libraryItem.ID,
-			ReleaseID:     release.ID,
-		}
-		body, _ := json.Marshal(reqBody)
-
+	start := func(libraryItemID, releaseID uint) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(StartDownloadRequest{LibraryItemID: libraryItemID, ReleaseID: releaseID})
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("POST", "/api/v1/downloads", bytes.NewBuffer(body))
 		req.Header.Set("Content-Type", "application/json")
 		router.ServeHTTP(w, req)
+		return w
+	}
 
-		assert.Equal(t, http.StatusCreated, w.Code)
+	t.Run("Start download", func(t *testing.T) {
+		w := start(libraryItem.ID, release.ID)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, []string{release.MagnetURL}, torrents.added, "the torrent is added")
+
+		var downloads []models.Download
+		db.Find(&downloads)
+		assert.Len(t, downloads, 1)
+
+		var grabs []models.History
+		db.Where("event_type = ?", models.HistoryEventGrabbed).Find(&grabs)
+		if assert.Len(t, grabs, 1) {
+			assert.Equal(t, download.ClientName, grabs[0].DownloadClient)
+		}
+	})
 
-		// Verify download was created
-		var count int64
-		db.Model(&models.Download{}).Count(&count)
-		assert.Equal(t, int64(1), count)
+	t.Run("Start download twice", func(t *testing.T) {
+		w := start(libraryItem.ID, release.ID)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "Active download already exists")
 	})
 
 	t.Run("Start download with invalid library item", func(t *testing.T) {
-		reqBody := StartDownloadRequest{
-			LibraryItemID: 999,
-			ReleaseID:     release.ID,
-		}
-		body, _ := json.Marshal(reqBody)
+		w := start(999, release.ID)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
 
-		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("POST", "/api/v1/downloads", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		router.ServeHTTP(w, req)
+	// The rest are for another item, which has no active download
+	other := models.LibraryItem{BookID: book.ID, Status: models.LibraryItemStatusWanted, AddedDate: time.Now()}
+	db.Create(&other)
 
+	t.Run("Start download with invalid release", func(t *testing.T) {
+		w := start(other.ID, 999)
 		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
+
+	t.Run("Start download with blocklisted release", func(t *testing.T) {
+		blocked := models.Release{BookID: book.ID, Blocklisted: true}
+		db.Create(&blocked)
+
+		w := start(other.ID, blocked.ID)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Start download without a torrent URL", func(t *testing.T) {
+		bare := models.Release{BookID: book.ID}
+		db.Create(&bare)
+
+		w := start(other.ID, bare.ID)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Start download without disk space", func(t *testing.T) {
+		server.downloads = download.NewService(db, torrents, server.events, &download.ServiceConfig{
+			DownloadPath: t.TempDir(),
+			MinFree:      1 << 60,
+		})
+		defer func() { server.downloads = download.NewService(db, torrents, server.events, nil) }()
+
+		big := models.Release{BookID: book.ID, Size: 2 << 30, TorrentURL: "http://indexer.example/big.torrent"}
+		db.Create(&big)
+
+		w := start(other.ID, big.ID)
+		assert.Equal(t, http.StatusInsufficientStorage, w.Code)
+		assert.Contains(t, w.Body.String(), "Download folder is too full")
+		assert.Contains(t, w.Body.String(), "INSUFFICIENT_STORAGE")
+	})
+
+	t.Run("Start download without a download client", func(t *testing.T) {
+		server.downloads = download.NewService(db, nil, server.events, nil)
+
+		w := start(other.ID, release.ID)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "No download client is configured")
+	})
+}
+
+// fakeTorrentClient records calls made by the download service
+type fakeTorrentClient struct {
+	added       []string
+	torrents    map[string]bool
+	deleted     []string
+	deleteFiles bool
+}
+
+func (f *fakeTorrentClient) AddTorrent(torrentURL string, options *qbit.AddTorrentOptions) error {
+	f.added = append(f.added, torrentURL)
+	return nil
+}
+
+func (f *fakeTorrentClient) GetTorrentInfo(hash string) (*qbit.TorrentInfo, error) {
+	if !f.torrents[hash] {
+		return nil, qbit.ErrTorrentNotFound
+	}
+	return &qbit.TorrentInfo{Hash: hash}, nil
+}
+
+func (f *fakeTorrentClient) DeleteTorrent(hashes []string, deleteFiles bool) error {
+	for _, hash := range hashes {
+		delete(f.torrents, hash)
+	}
+	f.deleted = append(f.deleted, hashes...)
+	f.deleteFiles = deleteFiles
+	return nil
 }
 
 func TestCancelDownload(t *testing.T) {
 	db := setupTestDB(t)
 	server := setupLibraryTestServer(db)
 
+	torrents := &fakeTorrentClient{torrents: map[string]bool{"abc123": true}}
+	server.downloads = download.NewService(db, torrents, nil, nil)
+
 	// Create test data
 	author := models.Author{Name: "Test Author"}
 	db.Create(&author)
@@ -199,9 +286,10 @@ func TestCancelDownload(t *testing.T) {
 	db.Create(&release)
 
 	download := models.Download{
-		LibraryItemID: libraryItem.ID,
-		ReleaseID:     release.ID,
-		Status:        models.DownloadStatusDownloading,
+		LibraryItemID:   libraryItem.ID,
+		ReleaseID:       release.ID,
+		Status:          models.DownloadStatusDownloading,
+		QBittorrentHash: "abc123",
 	}
 	db.Create(&download)
 
@@ -210,7 +298,7 @@ func TestCancelDownload(t *testing.T) {
 
 	t.Run("Cancel active download", func(t *testing.T) {
 		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("DELETE", "/api/v1/downloads/1", nil)
+		req, _ := http.NewRequest("DELETE", "/api/v1/downloads/1?delete_files=true&blocklist=true", nil)
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusNoContent, w.Code)
@@ -218,6 +306,68 @@ func TestCancelDownload(t *testing.T) {
 		// Verify download status updated
 		var updatedDownload models.Download
 		db.First(&updatedDownload, 1)
-		assert.Equal(t, models.DownloadStatusFailed, updatedDownload.Status)
+		assert.Equal(t, models.DownloadStatusCancelled, updatedDownload.Status)
+
+		// Verify torrent and data were removed
+		assert.Equal(t, []string{"abc123"}, torrents.deleted)
+		assert.True(t, torrents.deleteFiles)
+
+		// Verify release blocklisted and library item wanted again
+		var updatedRelease models.Release
+		db.First(&updatedRelease, release.ID)
+		assert.True(t, updatedRelease.Blocklisted)
+
+		var updatedItem models.LibraryItem
+		db.First(&updatedItem, libraryItem.ID)
+		assert.Equal(t, models.LibraryItemStatusWanted, updatedItem.Status)
+	})
+
+	t.Run("Cancel is idempotent", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/downloads/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Len(t, torrents.deleted, 1)
+	})
+
+	t.Run("Cancel when torrent already gone", func(t *testing.T) {
+		gone := models.Download{
+			LibraryItemID:   libraryItem.ID,
+			ReleaseID:       release.ID,
+			Status:          models.DownloadStatusQueued,
+			QBittorrentHash: "missing",
+		}
+		db.Create(&gone)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/downloads/%d", gone.ID), nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Len(t, torrents.deleted, 1)
+	})
+
+	t.Run("Cannot cancel completed download", func(t *testing.T) {
+		completed := models.Download{
+			LibraryItemID: libraryItem.ID,
+			ReleaseID:     release.ID,
+			Status:        models.DownloadStatusCompleted,
+		}
+		db.Create(&completed)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/downloads/%d", completed.ID), nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Cancel non-existent download", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/downloads/999", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
 }
@@ -7,13 +7,15 @@ import (
 
 // Error codes for machine-readable error identification
 const (
-	ErrCodeValidation    = "VALIDATION_ERROR"
-	ErrCodeNotFound      = "NOT_FOUND"
-	ErrCodeConflict      = "CONFLICT"
-	ErrCodeUnauthorized  = "UNAUTHORIZED"
-	ErrCodeInternal      = "INTERNAL_ERROR"
-	ErrCodeBadRequest    = "BAD_REQUEST"
-	ErrCodeUnprocessable = "UNPROCESSABLE_ENTITY"
+	ErrCodeValidation          = "VALIDATION_ERROR"
+	ErrCodeNotFound            = "NOT_FOUND"
+	ErrCodeConflict            = "CONFLICT"
+	ErrCodeUnauthorized        = "UNAUTHORIZED"
+	ErrCodeInternal            = "INTERNAL_ERROR"
+	ErrCodeBadRequest          = "BAD_REQUEST"
+	ErrCodeUnprocessable       = "UNPROCESSABLE_ENTITY"
+	ErrCodeBadGateway          = "BAD_GATEWAY"
+	ErrCodeInsufficientStorage = "INSUFFICIENT_STORAGE"
 )
 
 // APIError represents an API error with code and message
@@ -83,6 +85,16 @@ func ErrUnprocessable(message string) *APIError {
 	return NewAPIError(ErrCodeUnprocessable, message)
 }
 
+// ErrBadGateway creates an error for a failing upstream service
+func ErrBadGateway(message string) *APIError {
+	return NewAPIError(ErrCodeBadGateway, message)
+}
+
+// ErrInsufficientStorage creates an error for a folder without enough free space
+func ErrInsufficientStorage(message string) *APIError {
+	return NewAPIError(ErrCodeInsufficientStorage, message)
+}
+
 // ValidationError represents a field validation error
 type ValidationError struct {
 	Field   string
@@ -42,6 +42,7 @@ func TestErrorConstructors(t *testing.T) {
 		{"ErrInternal", ErrInternal, ErrCodeInternal, "internal error"},
 		{"ErrBadRequest", ErrBadRequest, ErrCodeBadRequest, "bad request"},
 		{"ErrUnprocessable", ErrUnprocessable, ErrCodeUnprocessable, "unprocessable"},
+		{"ErrBadGateway", ErrBadGateway, ErrCodeBadGateway, "bad gateway"},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/listenarr/listenarr/internal/events"
+)
+
+// eventKeepAliveInterval is how often an idle event stream sends a comment
+// line so proxies do not close the connection
+var eventKeepAliveInterval = 30 * time.Second
+
+// streamEvents handles GET /api/v1/events
+// Streams events as Server-Sent Events until the client disconnects.
+// Browsers' EventSource cannot set headers, so the API key may be passed
+// as the apikey query parameter.
+// Query parameters:
+//   - types: comma-separated list of event types to receive (default: all)
+func (s *Server) streamEvents(c *gin.Context) {
+	var wanted []events.Type
+	if types := c.Query("types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			wanted = append(wanted, events.Type(strings.TrimSpace(t)))
+		}
+	}
+
+	ch, unsubscribe := s.events.Subscribe(wanted...)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	// Send headers immediately so clients know the stream is open
+	c.Writer.WriteHeader(StatusOK)
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-ch:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(event.Type), event)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
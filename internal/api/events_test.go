@@ -0,0 +1,63 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/internal/events"
+)
+
+func TestStreamEvents_RequiresAuth(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	req, _ := http.NewRequest("GET", "/api/v1/events", nil)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestStreamEvents(t *testing.T) {
+	server, apiKey := setupTestServer(t)
+
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	// EventSource clients pass the API key as a query parameter
+	resp, err := http.Get(httpServer.URL + "/api/v1/events?apikey=" + apiKey + "&types=download.progress")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Wait for the handler to subscribe before publishing
+	require.Eventually(t, func() bool {
+		return server.events.SubscriberCount() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	server.events.Publish(events.LibraryItemAdded, events.LibraryItemPayload{LibraryItemID: 7})
+	server.events.Publish(events.DownloadProgress, events.DownloadPayload{DownloadID: 3, Progress: 42.5})
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 2 {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	// The filtered library event is skipped
+	assert.Equal(t, "event:download.progress", lines[0])
+	assert.Contains(t, lines[1], `"download_id":3`)
+	assert.Contains(t, lines[1], `"progress":42.5`)
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// HistoryResponse represents a history entry in API responses
+type HistoryResponse struct {
+	ID              uint   `json:"id"`
+	EventType       string `json:"event_type"`
+	Date            string `json:"date"`
+	Message         string `json:"message,omitempty"`
+	BookID          *uint  `json:"book_id,omitempty"`
+	BookTitle       string `json:"book_title,omitempty"`
+	AuthorID        *uint  `json:"author_id,omitempty"`
+	AuthorName      string `json:"author_name,omitempty"`
+	LibraryItemID   *uint  `json:"library_item_id,omitempty"`
+	DownloadID      *uint  `json:"download_id,omitempty"`
+	ReleaseTitle    string `json:"release_title,omitempty"`
+	Indexer         string `json:"indexer,omitempty"`
+	DownloadClient  string `json:"download_client,omitempty"`
+	DownloadHash    string `json:"download_hash,omitempty"`
+	Quality         string `json:"quality,omitempty"`
+	SourcePath      string `json:"source_path,omitempty"`
+	DestinationPath string `json:"destination_path,omitempty"`
+	CreatedAt       string `json:"created_at"`
+}
+
+// toHistoryResponse converts a History model to API response format
+func toHistoryResponse(entry *models.History) *HistoryResponse {
+	response := &HistoryResponse{
+		ID:              entry.ID,
+		EventType:       string(entry.EventType),
+		Date:            entry.Date.Format("2006-01-02T15:04:05Z07:00"),
+		Message:         entry.Message,
+		BookID:          entry.BookID,
+		AuthorID:        entry.AuthorID,
+		LibraryItemID:   entry.LibraryItemID,
+		DownloadID:      entry.DownloadID,
+		ReleaseTitle:    entry.ReleaseTitle,
+		Indexer:         entry.Indexer,
+		DownloadClient:  entry.DownloadClient,
+		DownloadHash:    entry.DownloadHash,
+		Quality:         entry.Quality,
+		SourcePath:      entry.SourcePath,
+		DestinationPath: entry.DestinationPath,
+		CreatedAt:       entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	if entry.Book != nil {
+		response.BookTitle = entry.Book.Title
+	}
+	if entry.Author != nil {
+		response.AuthorName = entry.Author.Name
+	}
+
+	return response
+}
+
+// parseDateParam parses a date query parameter in RFC 3339 or YYYY-MM-DD format
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// getHistory handles GET /api/v1/history
+// Query parameters:
+//   - book_id, author_id, event_type: filters
+//   - since, until: date range (RFC 3339 or YYYY-MM-DD, until is inclusive of the whole day)
+func (s *Server) getHistory(c *gin.Context) {
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	// Validate pagination
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset := (page - 1) * limit
+
+	// Build query
+	query := s.db.Model(&models.History{})
+
+	// Apply filters
+	if bookIDStr := c.Query("book_id"); bookIDStr != "" {
+		if bookID, err := strconv.ParseUint(bookIDStr, 10, 32); err == nil {
+			query = query.Where("book_id = ?", uint(bookID))
+		}
+	}
+	if authorIDStr := c.Query("author_id"); authorIDStr != "" {
+		if authorID, err := strconv.ParseUint(authorIDStr, 10, 32); err == nil {
+			query = query.Where("author_id = ?", uint(authorID))
+		}
+	}
+	if eventType := c.Query("event_type"); eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		since, err := parseDateParam(sinceStr)
+		if err != nil {
+			BadRequestResponse(c, "Invalid 'since' date")
+			return
+		}
+		query = query.Where("date >= ?", since)
+	}
+	if untilStr := c.Query("until"); untilStr != "" {
+		until, err := parseDateParam(untilStr)
+		if err != nil {
+			BadRequestResponse(c, "Invalid 'until' date")
+			return
+		}
+		if len(untilStr) == len("2006-01-02") {
+			until = until.AddDate(0, 0, 1)
+		}
+		query = query.Where("date < ?", until)
+	}
+
+	// Get total count
+	var total int64
+	query.Count(&total)
+
+	// Apply sorting (newest first unless asked otherwise)
+	order := c.DefaultQuery("order", "desc")
+	if order != "asc" && order != "desc" {
+		order = "desc"
+	}
+	query = query.Order("date " + order).Order("id " + order)
+
+	// Apply pagination and preload relationships
+	var entries []models.History
+	err := query.
+		Preload("Book").
+		Preload("Author").
+		Offset(offset).
+		Limit(limit).
+		Find(&entries).Error
+
+	if err != nil {
+		InternalErrorResponse(c, "Failed to fetch history")
+		return
+	}
+
+	// Convert to response format
+	responseData := make([]*HistoryResponse, len(entries))
+	for i := range entries {
+		responseData[i] = toHistoryResponse(&entries[i])
+	}
+
+	PaginatedSuccessResponse(c, responseData, page, limit, int(total))
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+func TestGetHistory(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+
+	// Create test data
+	author := models.Author{Name: "Test Author"}
+	db.Create(&author)
+
+	otherAuthor := models.Author{Name: "Other Author"}
+	db.Create(&otherAuthor)
+
+	book := models.Book{Title: "Test Book", AuthorID: author.ID}
+	db.Create(&book)
+
+	otherBook := models.Book{Title: "Other Book", AuthorID: otherAuthor.ID}
+	db.Create(&otherBook)
+
+	libraryItem := models.LibraryItem{
+		BookID:    book.ID,
+		Status:    models.LibraryItemStatusDownloading,
+		AddedDate: time.Now(),
+	}
+	db.Create(&libraryItem)
+
+	release := models.Release{BookID: book.ID, Title: "Test.Book.2020.m4b", Indexer: "test-indexer", Quality: "128kbps"}
+	db.Create(&release)
+
+	download := models.Download{LibraryItemID: libraryItem.ID, ReleaseID: release.ID, QBittorrentHash: "abc123"}
+	db.Create(&download)
+
+	require.NoError(t, server.history.RecordGrab(&download, &release, "qBittorrent"))
+	require.NoError(t, server.history.Record(&models.History{
+		EventType: models.HistoryEventImported,
+		BookID:    &otherBook.ID,
+		Date:      time.Date(2020, 1, 15, 12, 0, 0, 0, time.Local),
+	}))
+
+	router := gin.New()
+	router.GET("/api/v1/history", server.getHistory)
+
+	t.Run("Get all history", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/history", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data       []HistoryResponse `json:"data"`
+			Pagination PaginationInfo    `json:"pagination"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, response.Pagination.Total)
+
+		// Newest first, with book and author resolved from the library item
+		grab := response.Data[0]
+		assert.Equal(t, "grabbed", grab.EventType)
+		assert.Equal(t, "Test.Book.2020.m4b", grab.ReleaseTitle)
+		assert.Equal(t, "test-indexer", grab.Indexer)
+		assert.Equal(t, "qBittorrent", grab.DownloadClient)
+		assert.Equal(t, "Test Book", grab.BookTitle)
+		assert.Equal(t, "Test Author", grab.AuthorName)
+	})
+
+	t.Run("Filter by author", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/history?author_id=2", nil)
+		router.ServeHTTP(w, req)
+
+		var response PaginatedResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, 1, response.Pagination.Total)
+	})
+
+	t.Run("Filter by book and event type", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/history?book_id=1&event_type=imported", nil)
+		router.ServeHTTP(w, req)
+
+		var response PaginatedResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, 0, response.Pagination.Total)
+	})
+
+	t.Run("Filter by date range", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/history?since=2020-01-01&until=2020-01-15", nil)
+		router.ServeHTTP(w, req)
+
+		var response PaginatedResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, 1, response.Pagination.Total)
+	})
+
+	t.Run("Invalid date", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/history?since=yesterday", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
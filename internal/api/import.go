@@ -0,0 +1,346 @@
+package api
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/importer"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+	"github.com/listenarr/listenarr/internal/services/scanner"
+	"github.com/listenarr/listenarr/pkg/probe"
+)
+
+// StartScanRequest represents the request body for starting a library scan
+type StartScanRequest struct {
+	Path           string `json:"path"`            // Defaults to the library path
+	LookupMetadata *bool  `json:"lookup_metadata"` // Defaults to true
+}
+
+// ConfirmImportRequest represents the request body for importing a candidate
+type ConfirmImportRequest struct {
+	BookID *uint `json:"book_id"` // Overrides the matched book
+	Move   bool  `json:"move"`    // Move the files into the library instead of importing them in place
+}
+
+// ManualImportRequest represents the request body for a manual import
+type ManualImportRequest struct {
+	Path        string `json:"path"`         // File or folder to import; not needed with candidate_id
+	BookID      *uint  `json:"book_id"`      // Book the files belong to
+	CandidateID *uint  `json:"candidate_id"` // Scanned candidate to import instead of a path
+	Mode        string `json:"mode"`         // hardlink (default), move or in_place
+}
+
+// ImportCandidateResponse represents an import candidate in API responses
+type ImportCandidateResponse struct {
+	ID            uint                   `json:"id"`
+	Path          string                 `json:"path"`
+	Files         int                    `json:"files"`
+	Size          int64                  `json:"size"`
+	Title         string                 `json:"title,omitempty"`
+	Author        string                 `json:"author,omitempty"`
+	Narrator      string                 `json:"narrator,omitempty"`
+	Series        string                 `json:"series,omitempty"`
+	Sequence      string                 `json:"sequence,omitempty"`
+	ASIN          string                 `json:"asin,omitempty"`
+	Format        string                 `json:"format,omitempty"`
+	Duration      int                    `json:"duration,omitempty"`
+	Bitrate       int                    `json:"bitrate,omitempty"`
+	BookID        *uint                  `json:"book_id,omitempty"`
+	MatchSource   string                 `json:"match_source,omitempty"`
+	Metadata      *metadata.BookMetadata `json:"metadata,omitempty"`
+	Status        string                 `json:"status"`
+	LibraryItemID *uint                  `json:"library_item_id,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	CreatedAt     string                 `json:"created_at"`
+	UpdatedAt     string                 `json:"updated_at"`
+}
+
+// toImportCandidateResponse converts an ImportCandidate model to API response format
+func toImportCandidateResponse(candidate *models.ImportCandidate) *ImportCandidateResponse {
+	return &ImportCandidateResponse{
+		ID:            candidate.ID,
+		Path:          candidate.Path,
+		Files:         candidate.Files,
+		Size:          candidate.Size,
+		Title:         candidate.Title,
+		Author:        candidate.Author,
+		Narrator:      candidate.Narrator,
+		Series:        candidate.Series,
+		Sequence:      candidate.Sequence,
+		ASIN:          candidate.ASIN,
+		Format:        candidate.Format,
+		Duration:      candidate.Duration,
+		Bitrate:       candidate.Bitrate,
+		BookID:        candidate.BookID,
+		MatchSource:   candidate.MatchSource,
+		Metadata:      scanner.Metadata(candidate),
+		Status:        string(candidate.Status),
+		LibraryItemID: candidate.LibraryItemID,
+		Error:         candidate.Error,
+		CreatedAt:     candidate.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:     candidate.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// startImportScan handles POST /api/v1/import/scan
+func (s *Server) startImportScan(c *gin.Context) {
+	var req StartScanRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ValidationErrorResponse(c, err)
+			return
+		}
+	}
+
+	root := req.Path
+	if root == "" {
+		root = s.config.Library.Path
+	}
+	if root == "" {
+		BadRequestResponse(c, "path is required when no library path is configured")
+		return
+	}
+	root = filepath.Clean(root)
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		BadRequestResponse(c, "path is not a readable folder")
+		return
+	}
+
+	lookup := true
+	if req.LookupMetadata != nil {
+		lookup = *req.LookupMetadata
+	}
+
+	err := s.scanner.Start(scanner.Options{Root: root, LookupMetadata: lookup})
+	if errors.Is(err, scanner.ErrScanRunning) {
+		ConflictResponse(c, "A library scan is already running")
+		return
+	}
+	if err != nil {
+		InternalErrorResponse(c, "Failed to start library scan")
+		return
+	}
+
+	SuccessResponse(c, StatusAccepted, s.scanner.Status())
+}
+
+// getImportScanStatus handles GET /api/v1/import/scan/status
+func (s *Server) getImportScanStatus(c *gin.Context) {
+	SuccessResponse(c, StatusOK, s.scanner.Status())
+}
+
+// getImportCandidates handles GET /api/v1/import/scan
+func (s *Server) getImportCandidates(c *gin.Context) {
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	// Validate pagination
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset := (page - 1) * limit
+
+	// Build query
+	query := s.db.Model(&models.ImportCandidate{})
+
+	// Apply filters
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if matched := c.Query("matched"); matched != "" {
+		if want, err := strconv.ParseBool(matched); err == nil {
+			if want {
+				query = query.Where("book_id IS NOT NULL OR metadata <> ''")
+			} else {
+				query = query.Where("book_id IS NULL AND (metadata IS NULL OR metadata = '')")
+			}
+		}
+	}
+
+	// Get total count
+	var total int64
+	query.Count(&total)
+
+	var candidates []models.ImportCandidate
+	err := query.
+		Order("path ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&candidates).Error
+
+	if err != nil {
+		InternalErrorResponse(c, "Failed to fetch import candidates")
+		return
+	}
+
+	// Convert to response format
+	responseData := make([]*ImportCandidateResponse, len(candidates))
+	for i := range candidates {
+		responseData[i] = toImportCandidateResponse(&candidates[i])
+	}
+
+	PaginatedSuccessResponse(c, responseData, page, limit, int(total))
+}
+
+// confirmImportCandidate handles POST /api/v1/import/scan/:id/confirm
+func (s *Server) confirmImportCandidate(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid import candidate ID")
+		return
+	}
+
+	var req ConfirmImportRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ValidationErrorResponse(c, err)
+			return
+		}
+	}
+
+	mode := importer.ImportModeInPlace
+	if req.Move {
+		mode = importer.ImportModeMove
+	}
+	result, err := s.scanner.Confirm(uint(id), scanner.ConfirmOptions{BookID: req.BookID, Mode: mode})
+	if err != nil {
+		importErrorResponse(c, err)
+		return
+	}
+
+	SuccessResponse(c, StatusOK, result)
+}
+
+// skipImportCandidate handles POST /api/v1/import/scan/:id/skip
+func (s *Server) skipImportCandidate(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid import candidate ID")
+		return
+	}
+
+	candidate, err := s.scanner.Skip(uint(id))
+	switch {
+	case errors.Is(err, scanner.ErrCandidateNotFound):
+		NotFoundResponse(c, "import candidate")
+	case errors.Is(err, scanner.ErrNotPending):
+		ConflictResponse(c, "Import candidate was already imported or skipped")
+	case err != nil:
+		InternalErrorResponse(c, "Failed to skip import candidate")
+	default:
+		SuccessResponse(c, StatusOK, toImportCandidateResponse(candidate))
+	}
+}
+
+// identifyManualImport handles GET /api/v1/import/manual
+func (s *Server) identifyManualImport(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		BadRequestResponse(c, "Query parameter 'path' is required")
+		return
+	}
+
+	identification, err := s.importer.Identify(filepath.Clean(path))
+	switch {
+	case errors.Is(err, importer.ErrNoAudioFiles):
+		BadRequestResponse(c, "No audio files found at path")
+	case errors.Is(err, importer.ErrInsufficientSpace):
+		InsufficientStorageResponse(c, err.Error())
+	case errors.Is(err, probe.ErrUnsupportedFormat):
+		BadRequestResponse(c, "Audio files could not be read")
+	case errors.Is(err, os.ErrNotExist):
+		BadRequestResponse(c, "path does not exist")
+	case err != nil:
+		InternalErrorResponse(c, "Failed to identify files")
+	default:
+		SuccessResponse(c, StatusOK, identification)
+	}
+}
+
+// manualImport handles POST /api/v1/import/manual
+func (s *Server) manualImport(c *gin.Context) {
+	var req ManualImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ValidationErrorResponse(c, err)
+		return
+	}
+
+	mode := importer.ImportMode(req.Mode)
+	valErrs := NewValidationErrors()
+	if req.CandidateID == nil {
+		if req.Path == "" {
+			valErrs.Add("path", "path is required when no candidate_id is given")
+		}
+		if req.BookID == nil {
+			valErrs.Add("book_id", "book_id is required when no candidate_id is given")
+		}
+	}
+	switch mode {
+	case "":
+		mode = importer.ImportModeHardlink
+	case importer.ImportModeHardlink, importer.ImportModeMove, importer.ImportModeInPlace:
+	default:
+		valErrs.Add("mode", "mode must be one of: hardlink, move, in_place")
+	}
+	if valErrs.HasErrors() {
+		ValidationErrorResponse(c, valErrs)
+		return
+	}
+
+	var result *importer.Result
+	var err error
+	if req.CandidateID != nil {
+		result, err = s.scanner.Confirm(*req.CandidateID, scanner.ConfirmOptions{BookID: req.BookID, Mode: mode})
+	} else {
+		path := filepath.Clean(req.Path)
+		if _, statErr := os.Stat(path); statErr != nil {
+			BadRequestResponse(c, "path does not exist")
+			return
+		}
+		result, err = s.importer.ImportBook(*req.BookID, path, mode)
+	}
+	if err != nil {
+		importErrorResponse(c, err)
+		return
+	}
+
+	SuccessResponse(c, StatusOK, result)
+}
+
+// importErrorResponse sends the response for a failed candidate or manual import
+func importErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, scanner.ErrCandidateNotFound):
+		NotFoundResponse(c, "import candidate")
+	case errors.Is(err, importer.ErrBookNotFound):
+		NotFoundResponse(c, "book")
+	case errors.Is(err, scanner.ErrNotPending):
+		ConflictResponse(c, "Import candidate was already imported or skipped")
+	case errors.Is(err, importer.ErrAlreadyAvailable):
+		ConflictResponse(c, "Book is already available in the library")
+	case errors.Is(err, scanner.ErrUnidentified):
+		BadRequestResponse(c, "Import candidate has no title or author; choose a book_id")
+	case errors.Is(err, importer.ErrLibraryNotDefined):
+		BadRequestResponse(c, "Library path not configured")
+	case errors.Is(err, importer.ErrNoAudioFiles):
+		BadRequestResponse(c, "No audio files found at path")
+	case errors.Is(err, importer.ErrInsufficientSpace):
+		InsufficientStorageResponse(c, err.Error())
+	default:
+		InternalErrorResponse(c, "Failed to import files")
+	}
+}
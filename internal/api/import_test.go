@@ -0,0 +1,229 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/importer"
+)
+
+func TestImportScan(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+
+	root := t.TempDir()
+	for _, path := range []string{
+		filepath.Join(root, "Terry Pratchett", "Mort", "01.mp3"),
+		filepath.Join(root, "Terry Pratchett", "Mort", "02.mp3"),
+		filepath.Join(root, "Neil Gaiman", "Stardust", "Stardust.m4b"),
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("audio"), 0644))
+	}
+
+	router := gin.New()
+	router.POST("/api/v1/import/scan", server.startImportScan)
+	router.GET("/api/v1/import/scan", server.getImportCandidates)
+	router.GET("/api/v1/import/scan/status", server.getImportScanStatus)
+	router.POST("/api/v1/import/scan/:id/confirm", server.confirmImportCandidate)
+	router.POST("/api/v1/import/scan/:id/skip", server.skipImportCandidate)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Path required without library path", func(t *testing.T) {
+		w := post("/api/v1/import/scan", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = post("/api/v1/import/scan", `{"path": "`+filepath.Join(root, "missing")+`"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Scan folder", func(t *testing.T) {
+		w := post("/api/v1/import/scan", `{"path": "`+root+`", "lookup_metadata": false}`)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+
+		// The scan runs in the background
+		assert.Eventually(t, func() bool { return !server.scanner.Status().Running }, 5*time.Second, 10*time.Millisecond)
+
+		w = httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/import/scan/status", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"found":2`)
+	})
+
+	var candidates []ImportCandidateResponse
+	t.Run("List candidates", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/import/scan?status=pending", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []ImportCandidateResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 2)
+		candidates = response.Data
+
+		assert.Equal(t, filepath.Join(root, "Neil Gaiman", "Stardust"), candidates[0].Path)
+		assert.Equal(t, "Stardust", candidates[0].Title)
+		assert.Equal(t, "Neil Gaiman", candidates[0].Author)
+		assert.Equal(t, 2, candidates[1].Files)
+		assert.Equal(t, "pending", candidates[1].Status)
+	})
+
+	t.Run("Confirm candidate in place", func(t *testing.T) {
+		w := post(fmt.Sprintf("/api/v1/import/scan/%d/confirm", candidates[1].ID), "")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data importer.Result `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		var item models.LibraryItem
+		require.NoError(t, db.Preload("Book.Author").First(&item, response.Data.LibraryItemID).Error)
+		assert.Equal(t, models.LibraryItemStatusAvailable, item.Status)
+		assert.Equal(t, candidates[1].Path, item.FilePath)
+		assert.Equal(t, "Mort", item.Book.Title)
+		assert.Equal(t, "Terry Pratchett", item.Book.Author.Name)
+		assert.FileExists(t, filepath.Join(candidates[1].Path, "01.mp3"))
+
+		w = post(fmt.Sprintf("/api/v1/import/scan/%d/confirm", candidates[1].ID), "")
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Move without library path", func(t *testing.T) {
+		w := post(fmt.Sprintf("/api/v1/import/scan/%d/confirm", candidates[0].ID), `{"move": true}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Skip candidate", func(t *testing.T) {
+		w := post(fmt.Sprintf("/api/v1/import/scan/%d/skip", candidates[0].ID), "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"skipped"`)
+
+		w = post(fmt.Sprintf("/api/v1/import/scan/%d/skip", candidates[0].ID), "")
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Unknown candidate", func(t *testing.T) {
+		w := post("/api/v1/import/scan/999/confirm", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = post("/api/v1/import/scan/abc/skip", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestManualImport(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+	library := t.TempDir()
+	server.config.Library.Path = library
+	server.importer = importer.NewService(db, nil, nil, nil, importer.Config{LibraryPath: library})
+
+	source := filepath.Join(t.TempDir(), "Mort.Unabridged")
+	require.NoError(t, os.MkdirAll(source, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "mort.m4b"), []byte("audio"), 0644))
+
+	author := models.Author{Name: "Terry Pratchett"}
+	require.NoError(t, db.Create(&author).Error)
+	book := models.Book{Title: "Mort", AuthorID: author.ID}
+	require.NoError(t, db.Create(&book).Error)
+
+	router := gin.New()
+	router.GET("/api/v1/import/manual", server.identifyManualImport)
+	router.POST("/api/v1/import/manual", server.manualImport)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/import/manual", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Identify path", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/import/manual?path="+filepath.Join(source, "missing"), nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/v1/import/manual", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		w := post(`{"path": "` + source + `"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "book_id")
+
+		w = post(fmt.Sprintf(`{"path": %q, "book_id": %d, "mode": "symlink"}`, source, book.ID))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		w = post(fmt.Sprintf(`{"path": %q, "book_id": 999}`, source))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = post(fmt.Sprintf(`{"path": %q, "book_id": %d}`, filepath.Join(source, "missing"), book.ID))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Import folder as book", func(t *testing.T) {
+		w := post(fmt.Sprintf(`{"path": %q, "book_id": %d}`, source, book.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		expected := filepath.Join(library, "Terry Pratchett", "Mort", "mort.m4b")
+		assert.FileExists(t, expected)
+		assert.FileExists(t, filepath.Join(source, "mort.m4b"), "hardlinked by default")
+
+		var item models.LibraryItem
+		require.NoError(t, db.Where("book_id = ?", book.ID).First(&item).Error)
+		assert.Equal(t, models.LibraryItemStatusAvailable, item.Status)
+		assert.Equal(t, expected, item.FilePath)
+
+		var entry models.History
+		require.NoError(t, db.Where("event_type = ?", models.HistoryEventImported).First(&entry).Error)
+		assert.Equal(t, source, entry.SourcePath)
+
+		w = post(fmt.Sprintf(`{"path": %q, "book_id": %d}`, source, book.ID))
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Import scanned candidate", func(t *testing.T) {
+		other := models.Book{Title: "Stardust", AuthorID: author.ID}
+		require.NoError(t, db.Create(&other).Error)
+		candidate := models.ImportCandidate{Path: filepath.Join(source, "mort.m4b"), Files: 1, Status: models.ImportCandidateStatusPending}
+		require.NoError(t, db.Create(&candidate).Error)
+
+		w := post(fmt.Sprintf(`{"candidate_id": %d, "book_id": %d, "mode": "in_place"}`, candidate.ID, other.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		db.First(&candidate, candidate.ID)
+		assert.Equal(t, models.ImportCandidateStatusImported, candidate.Status)
+
+		w = post(`{"candidate_id": 999}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -1,23 +1,60 @@
 package api
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"github.com/listenarr/listenarr/internal/events"
 	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+	"github.com/listenarr/listenarr/internal/services/rescan"
 )
 
-// AddToLibraryRequest represents the request body for adding a book to library
+// AddToLibraryRequest represents the request body for adding a book to library.
+// Title and author name are required unless an ISBN or ASIN is given, in which
+// case anything missing is resolved from the metadata providers.
 type AddToLibraryRequest struct {
-	Title          string  `json:"title" binding:"required"`
-	AuthorName     string  `json:"author_name" binding:"required"`
-	ISBN           *string `json:"isbn,omitempty"`
-	ASIN           *string `json:"asin,omitempty"`
-	SeriesName     *string `json:"series_name,omitempty"`
-	SeriesPosition *int    `json:"series_position,omitempty"`
+	Title          string          `json:"title"`
+	AuthorName     string          `json:"author_name"`
+	ISBN           *string         `json:"isbn,omitempty"`
+	ASIN           *string         `json:"asin,omitempty"`
+	SeriesName     *string         `json:"series_name,omitempty"`
+	SeriesPosition *SeriesPosition `json:"series_position,omitempty"`
+}
+
+// LibraryPreviewResponse describes what POST /api/v1/library?dry_run=true
+// would add, including which records already exist
+type LibraryPreviewResponse struct {
+	Title          string                 `json:"title"`
+	AuthorName     string                 `json:"author_name"`
+	ISBN           string                 `json:"isbn,omitempty"`
+	ASIN           string                 `json:"asin,omitempty"`
+	Series         []LibraryPreviewSeries `json:"series,omitempty"`
+	Narrator       string                 `json:"narrator,omitempty"`
+	Description    string                 `json:"description,omitempty"`
+	CoverArtURL    string                 `json:"cover_art_url,omitempty"`
+	ReleaseDate    *time.Time             `json:"release_date,omitempty"`
+	Genre          string                 `json:"genre,omitempty"`
+	Language       string                 `json:"language,omitempty"`
+	Publisher      string                 `json:"publisher,omitempty"`
+	Duration       int                    `json:"duration,omitempty"`
+	MetadataSource string                 `json:"metadata_source,omitempty"`
+	AuthorID       *uint                  `json:"author_id,omitempty"`
+	BookID         *uint                  `json:"book_id,omitempty"`
+	InLibrary      bool                   `json:"in_library"`
+}
+
+// LibraryPreviewSeries is a series membership in a library preview.
+// SeriesID is set when the series already exists.
+type LibraryPreviewSeries struct {
+	Name     string `json:"name"`
+	Position string `json:"position,omitempty"`
+	SeriesID *uint  `json:"series_id,omitempty"`
 }
 
 // LibraryItemResponse represents a library item in API responses
@@ -36,18 +73,17 @@ type LibraryItemResponse struct {
 
 // BookResponse represents a book in API responses
 type BookResponse struct {
-	ID             uint            `json:"id"`
-	Title          string          `json:"title"`
-	ISBN           string          `json:"isbn,omitempty"`
-	ASIN           string          `json:"asin,omitempty"`
-	Description    string          `json:"description,omitempty"`
-	CoverArtURL    string          `json:"cover_art_url,omitempty"`
-	ReleaseDate    *time.Time      `json:"release_date,omitempty"`
-	Genre          string          `json:"genre,omitempty"`
-	Language       string          `json:"language,omitempty"`
-	Author         *AuthorResponse `json:"author,omitempty"`
-	Series         *SeriesResponse `json:"series,omitempty"`
-	SeriesPosition *int            `json:"series_position,omitempty"`
+	ID          uint                 `json:"id"`
+	Title       string               `json:"title"`
+	ISBN        string               `json:"isbn,omitempty"`
+	ASIN        string               `json:"asin,omitempty"`
+	Description string               `json:"description,omitempty"`
+	CoverArtURL string               `json:"cover_art_url,omitempty"`
+	ReleaseDate *time.Time           `json:"release_date,omitempty"`
+	Genre       string               `json:"genre,omitempty"`
+	Language    string               `json:"language,omitempty"`
+	Author      *AuthorResponse      `json:"author,omitempty"`
+	Series      []BookSeriesResponse `json:"series,omitempty"`
 }
 
 // AuthorResponse represents an author in API responses
@@ -59,6 +95,15 @@ type AuthorResponse struct {
 	GoodreadsID string `json:"goodreads_id,omitempty"`
 }
 
+// BookSeriesResponse represents a book's membership in a series
+type BookSeriesResponse struct {
+	ID         uint     `json:"id"` // Series ID
+	Name       string   `json:"name"`
+	Position   string   `json:"position,omitempty"`
+	Sequence   *float64 `json:"sequence,omitempty"`
+	TotalBooks int      `json:"total_books,omitempty"`
+}
+
 // SeriesResponse represents a series in API responses
 type SeriesResponse struct {
 	ID          uint   `json:"id"`
@@ -91,16 +136,16 @@ func toLibraryItemResponse(item *models.LibraryItem) *LibraryItemResponse {
 // toBookResponse converts a Book model to API response format
 func toBookResponse(book *models.Book) *BookResponse {
 	response := &BookResponse{
-		ID:             book.ID,
-		Title:          book.Title,
-		ISBN:           book.ISBN,
-		ASIN:           book.ASIN,
-		Description:    book.Description,
-		CoverArtURL:    book.CoverArtURL,
-		ReleaseDate:    book.ReleaseDate,
-		Genre:          book.Genre,
-		Language:       book.Language,
-		SeriesPosition: book.SeriesPosition,
+		ID:          book.ID,
+		Title:       book.Title,
+		ISBN:        book.ISBN,
+		ASIN:        book.ASIN,
+		Description: book.Description,
+		CoverArtURL: book.CoverArtURL,
+		ReleaseDate: book.ReleaseDate,
+		Genre:       book.Genre,
+		Language:    book.Language,
+		Series:      toBookSeriesResponses(book.SeriesMemberships),
 	}
 
 	if book.Author.ID != 0 {
@@ -113,16 +158,26 @@ func toBookResponse(book *models.Book) *BookResponse {
 		}
 	}
 
-	if book.Series != nil && book.Series.ID != 0 {
-		response.Series = &SeriesResponse{
-			ID:          book.Series.ID,
-			Name:        book.Series.Name,
-			Description: book.Series.Description,
-			TotalBooks:  book.Series.TotalBooks,
+	return response
+}
+
+// toBookSeriesResponses converts a book's series memberships (with Series
+// preloaded) to API response format
+func toBookSeriesResponses(memberships []models.BookSeries) []BookSeriesResponse {
+	if len(memberships) == 0 {
+		return nil
+	}
+	responses := make([]BookSeriesResponse, len(memberships))
+	for i, membership := range memberships {
+		responses[i] = BookSeriesResponse{
+			ID:         membership.SeriesID,
+			Name:       membership.Series.Name,
+			Position:   membership.Position,
+			Sequence:   membership.Sequence,
+			TotalBooks: membership.Series.TotalBooks,
 		}
 	}
-
-	return response
+	return responses
 }
 
 // getLibrary handles GET /api/v1/library
@@ -184,7 +239,7 @@ func (s *Server) getLibrary(c *gin.Context) {
 	err := query.
 		Preload("Book").
 		Preload("Book.Author").
-		Preload("Book.Series").
+		Preload("Book.SeriesMemberships.Series").
 		Offset(offset).
 		Limit(limit).
 		Find(&items).Error
@@ -216,7 +271,7 @@ func (s *Server) getLibraryItem(c *gin.Context) {
 	err = s.db.
 		Preload("Book").
 		Preload("Book.Author").
-		Preload("Book.Series").
+		Preload("Book.SeriesMemberships.Series").
 		Preload("Book.Audiobook").
 		Preload("Downloads").
 		First(&item, uint(id)).Error
@@ -234,12 +289,50 @@ func (s *Server) getLibraryItem(c *gin.Context) {
 }
 
 // addToLibrary handles POST /api/v1/library
+// With ?dry_run=true the resolved book is returned without creating anything.
 func (s *Server) addToLibrary(c *gin.Context) {
 	var req AddToLibraryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ValidationErrorResponse(c, err)
 		return
 	}
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	hasIdentifier := (req.ISBN != nil && *req.ISBN != "") || (req.ASIN != nil && *req.ASIN != "")
+	if !hasIdentifier {
+		valErrs := NewValidationErrors()
+		if req.Title == "" {
+			valErrs.Add("title", "title is required when no ISBN or ASIN is given")
+		}
+		if req.AuthorName == "" {
+			valErrs.Add("author_name", "author_name is required when no ISBN or ASIN is given")
+		}
+		if valErrs.HasErrors() {
+			ValidationErrorResponse(c, valErrs)
+			return
+		}
+	}
+
+	// Resolve anything the caller left out from the metadata providers
+	var md *metadata.BookMetadata
+	if hasIdentifier && (req.Title == "" || req.AuthorName == "" || dryRun) {
+		var err error
+		md, err = s.lookupLibraryMetadata(&req)
+		if err != nil {
+			metadataErrorResponse(c, err)
+			return
+		}
+		applyMetadataToRequest(&req, md)
+		if req.Title == "" || req.AuthorName == "" {
+			BadGatewayResponse(c, "Metadata provider returned no title or author")
+			return
+		}
+	}
+
+	if dryRun {
+		s.previewAddToLibrary(c, &req, md)
+		return
+	}
 
 	// Start transaction
 	tx := s.db.Begin()
@@ -265,61 +358,14 @@ func (s *Server) addToLibrary(c *gin.Context) {
 		return
 	}
 
-	// Find or create series if provided
-	var seriesID *uint
-	if req.SeriesName != nil && *req.SeriesName != "" {
-		var series models.Series
-		err := tx.Where("name = ?", *req.SeriesName).First(&series).Error
-		if err == gorm.ErrRecordNotFound {
-			series = models.Series{Name: *req.SeriesName}
-			if err := tx.Create(&series).Error; err != nil {
-				tx.Rollback()
-				InternalErrorResponse(c, "Failed to create series")
-				return
-			}
-		} else if err != nil {
-			tx.Rollback()
-			InternalErrorResponse(c, "Failed to find series")
-			return
-		}
-		seriesID = &series.ID
-	}
-
-	// Check if book already exists
-	var book models.Book
-	bookQuery := tx.Where("title = ? AND author_id = ?", req.Title, author.ID)
-	if req.ISBN != nil && *req.ISBN != "" {
-		bookQuery = bookQuery.Or("isbn = ?", *req.ISBN)
-	}
-	if req.ASIN != nil && *req.ASIN != "" {
-		bookQuery = bookQuery.Or("asin = ?", *req.ASIN)
-	}
-
-	err = bookQuery.First(&book).Error
-	if err == gorm.ErrRecordNotFound {
-		// Create new book
-		book = models.Book{
-			Title:          req.Title,
-			AuthorID:       author.ID,
-			SeriesID:       seriesID,
-			SeriesPosition: req.SeriesPosition,
-		}
-		if req.ISBN != nil {
-			book.ISBN = *req.ISBN
-		}
-		if req.ASIN != nil {
-			book.ASIN = *req.ASIN
-		}
-		if err := tx.Create(&book).Error; err != nil {
-			tx.Rollback()
-			InternalErrorResponse(c, "Failed to create book")
-			return
-		}
-	} else if err != nil {
+	// Find or create the book with its series, contributors and audiobook
+	found, _, err := metadata.FindOrCreateBook(tx, &author, requestBook(&req, md))
+	if err != nil {
 		tx.Rollback()
-		InternalErrorResponse(c, "Failed to find book")
+		InternalErrorResponse(c, "Failed to create book")
 		return
 	}
+	book := *found
 
 	// Check if library item already exists for this book
 	var existingItem models.LibraryItem
@@ -356,16 +402,135 @@ func (s *Server) addToLibrary(c *gin.Context) {
 	err = s.db.
 		Preload("Book").
 		Preload("Book.Author").
-		Preload("Book.Series").
+		Preload("Book.SeriesMemberships.Series").
 		First(&libraryItem, libraryItem.ID).Error
 	if err != nil {
 		InternalErrorResponse(c, "Failed to reload library item")
 		return
 	}
 
+	s.events.Publish(events.LibraryItemAdded, events.LibraryItemPayload{
+		LibraryItemID: libraryItem.ID,
+		BookID:        libraryItem.BookID,
+		Status:        string(libraryItem.Status),
+	})
+
 	CreatedResponse(c, toLibraryItemResponse(&libraryItem))
 }
 
+// lookupLibraryMetadata resolves book metadata for an add request, preferring
+// the ASIN since it identifies the audiobook edition
+func (s *Server) lookupLibraryMetadata(req *AddToLibraryRequest) (*metadata.BookMetadata, error) {
+	if req.ASIN != nil && *req.ASIN != "" {
+		md, err := s.metadata.LookupASIN(*req.ASIN)
+		if err == nil || !errors.Is(err, metadata.ErrNotFound) || req.ISBN == nil || *req.ISBN == "" {
+			return md, err
+		}
+	}
+	return s.metadata.LookupISBN(*req.ISBN)
+}
+
+// applyMetadataToRequest fills fields the caller left empty from metadata
+func applyMetadataToRequest(req *AddToLibraryRequest, md *metadata.BookMetadata) {
+	if req.Title == "" {
+		req.Title = md.Title
+	}
+	if req.AuthorName == "" && len(md.Authors) > 0 {
+		req.AuthorName = md.Authors[0]
+	}
+	if (req.ISBN == nil || *req.ISBN == "") && md.ISBN != "" {
+		isbn := md.ISBN
+		req.ISBN = &isbn
+	}
+	if (req.ASIN == nil || *req.ASIN == "") && md.ASIN != "" {
+		asin := md.ASIN
+		req.ASIN = &asin
+	}
+}
+
+// requestSeries returns the series a new book should be linked to: the one
+// named in the request, or otherwise every series from the metadata
+func requestSeries(req *AddToLibraryRequest, md *metadata.BookMetadata) []metadata.SeriesInfo {
+	if req.SeriesName != nil && *req.SeriesName != "" {
+		series := metadata.SeriesInfo{Name: *req.SeriesName}
+		if req.SeriesPosition != nil {
+			series.Position = string(*req.SeriesPosition)
+		}
+		return []metadata.SeriesInfo{series}
+	}
+	if md != nil {
+		return md.Series
+	}
+	return nil
+}
+
+// requestBook describes the book of a library request, with what the caller
+// gave taking precedence over the metadata
+func requestBook(req *AddToLibraryRequest, md *metadata.BookMetadata) *metadata.BookMetadata {
+	var book metadata.BookMetadata
+	if md != nil {
+		book = *md
+	}
+	book.Title = req.Title
+	if req.ISBN != nil && *req.ISBN != "" {
+		book.ISBN = *req.ISBN
+	}
+	if req.ASIN != nil && *req.ASIN != "" {
+		book.ASIN = *req.ASIN
+	}
+	book.Series = requestSeries(req, md)
+	return &book
+}
+
+// previewAddToLibrary responds with what addToLibrary would create for req
+func (s *Server) previewAddToLibrary(c *gin.Context, req *AddToLibraryRequest, md *metadata.BookMetadata) {
+	preview := LibraryPreviewResponse{
+		Title:      req.Title,
+		AuthorName: req.AuthorName,
+	}
+	if req.ISBN != nil {
+		preview.ISBN = *req.ISBN
+	}
+	if req.ASIN != nil {
+		preview.ASIN = *req.ASIN
+	}
+	if md != nil {
+		preview.Narrator = strings.Join(md.Narrators, ", ")
+		preview.Description = md.Description
+		preview.CoverArtURL = md.CoverArtURL
+		preview.ReleaseDate = md.ReleaseDate
+		preview.Genre = md.Genre
+		preview.Language = md.Language
+		preview.Publisher = md.Publisher
+		preview.Duration = md.Duration
+		preview.MetadataSource = md.Source
+	}
+
+	// Report which records already exist, mirroring the lookups in addToLibrary
+	var author models.Author
+	if err := s.db.Where("name = ?", req.AuthorName).First(&author).Error; err == nil {
+		preview.AuthorID = &author.ID
+	}
+	for _, info := range requestSeries(req, md) {
+		entry := LibraryPreviewSeries{Name: info.Name, Position: info.Position}
+		var series models.Series
+		if err := s.db.Where("name = ?", info.Name).First(&series).Error; err == nil {
+			entry.SeriesID = &series.ID
+		}
+		preview.Series = append(preview.Series, entry)
+	}
+
+	if book, err := metadata.FindBook(s.db, author.ID, requestBook(req, md)); err == nil {
+		preview.BookID = &book.ID
+
+		var count int64
+		s.db.Model(&models.LibraryItem{}).Where("book_id = ?", book.ID).Count(&count)
+		preview.InLibrary = count > 0
+	}
+
+	SuccessResponse(c, StatusOK, preview)
+}
+
 // removeFromLibrary handles DELETE /api/v1/library/:id
 func (s *Server) removeFromLibrary(c *gin.Context) {
 	idStr := c.Param("id")
@@ -394,5 +559,45 @@ func (s *Server) removeFromLibrary(c *gin.Context) {
 		return
 	}
 
+	_ = s.history.Record(&models.History{
+		EventType:     models.HistoryEventDeleted,
+		LibraryItemID: &item.ID,
+		BookID:        &item.BookID,
+		SourcePath:    item.FilePath,
+		Message:       "Removed from library",
+	})
+	s.events.Publish(events.LibraryItemRemoved, events.LibraryItemPayload{
+		LibraryItemID: item.ID,
+		BookID:        item.BookID,
+		Status:        string(item.Status),
+		FilePath:      item.FilePath,
+	})
+
 	NoContentResponse(c)
 }
+
+// getLibraryRescan handles GET /api/v1/library/rescan
+func (s *Server) getLibraryRescan(c *gin.Context) {
+	result := s.rescan.LastResult()
+	if result == nil {
+		NotFoundResponse(c, "library rescan")
+		return
+	}
+
+	SuccessResponse(c, StatusOK, result)
+}
+
+// rescanLibrary handles POST /api/v1/library/rescan
+func (s *Server) rescanLibrary(c *gin.Context) {
+	result, err := s.rescan.Rescan()
+	if errors.Is(err, rescan.ErrLibraryUnavailable) {
+		BadRequestResponse(c, "Library folder is not available")
+		return
+	}
+	if err != nil {
+		InternalErrorResponse(c, "Failed to rescan library")
+		return
+	}
+
+	SuccessResponse(c, StatusOK, result)
+}
@@ -5,32 +5,42 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 
 	"github.com/listenarr/listenarr/internal/config"
+	"github.com/listenarr/listenarr/internal/database/dbtest"
 	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+	"github.com/listenarr/listenarr/internal/services/rescan"
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	assert.NoError(t, err)
+	db := dbtest.Open(t)
 
 	// Migrate models
-	err = db.AutoMigrate(
+	err := db.AutoMigrate(
 		&models.Author{},
 		&models.Series{},
 		&models.Book{},
+		&models.BookSeries{},
+		&models.Narrator{},
+		&models.BookContributor{},
 		&models.Audiobook{},
 		&models.LibraryItem{},
 		&models.Release{},
 		&models.Download{},
 		&models.ProcessingTask{},
+		&models.History{},
+		&models.ImportCandidate{},
+		&models.ScheduledTask{},
+		&models.Notification{},
 	)
 	assert.NoError(t, err)
 
@@ -233,7 +243,7 @@ func TestAddToLibrary(t *testing.T) {
 			Title:          "Series Book",
 			AuthorName:     "Series Author",
 			SeriesName:     &seriesName,
-			SeriesPosition: func() *int { pos := 1; return &pos }(),
+			SeriesPosition: func() *SeriesPosition { pos := SeriesPosition("1"); return &pos }(),
 		}
 		body, _ := json.Marshal(reqBody)
 
@@ -244,10 +254,32 @@ func TestAddToLibrary(t *testing.T) {
 
 		assert.Equal(t, http.StatusCreated, w.Code)
 
-		// Verify series was created
+		// Verify series was created and the book linked to it
 		var series models.Series
 		err := db.Where("name = ?", seriesName).First(&series).Error
 		assert.NoError(t, err)
+
+		var link models.BookSeries
+		err = db.Where("series_id = ?", series.ID).First(&link).Error
+		assert.NoError(t, err)
+		assert.Equal(t, "1", link.Position)
+	})
+
+	t.Run("Add book with fractional series position", func(t *testing.T) {
+		body := []byte(`{"title": "Novella", "author_name": "Series Author", "series_name": "Test Series", "series_position": 2.5}`)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/library", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var link models.BookSeries
+		err := db.Joins("JOIN books ON books.id = book_series.book_id").
+			Where("books.title = ?", "Novella").First(&link).Error
+		assert.NoError(t, err)
+		assert.Equal(t, "2.5", link.Position)
 	})
 
 	t.Run("Add duplicate book", func(t *testing.T) {
@@ -356,3 +388,183 @@ func TestToLibraryItemResponse(t *testing.T) {
 	assert.NotNil(t, response.Book.Author)
 	assert.Equal(t, "Test Author", response.Book.Author.Name)
 }
+
+func TestAddToLibraryByIdentifier(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+	server.metadata = metadata.NewService(newStubMetadataProvider())
+
+	router := gin.New()
+	router.POST("/api/v1/library", server.addToLibrary)
+
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Dry run previews without creating", func(t *testing.T) {
+		w := post("/api/v1/library?dry_run=true", map[string]string{"asin": "B017V4IM1G"})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data LibraryPreviewResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "Harry Potter and the Sorcerer's Stone", response.Data.Title)
+		assert.Equal(t, "J.K. Rowling", response.Data.AuthorName)
+		assert.Equal(t, "Jim Dale", response.Data.Narrator)
+		require.Len(t, response.Data.Series, 1)
+		assert.Equal(t, "Harry Potter", response.Data.Series[0].Name)
+		assert.Equal(t, "1", response.Data.Series[0].Position)
+		assert.Nil(t, response.Data.Series[0].SeriesID)
+		assert.Nil(t, response.Data.AuthorID)
+		assert.False(t, response.Data.InLibrary)
+
+		var count int64
+		db.Model(&models.Book{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("Add by ISBN", func(t *testing.T) {
+		w := post("/api/v1/library", map[string]string{"isbn": "9781781102367"})
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var book models.Book
+		require.NoError(t, db.Preload("Author").Preload("SeriesMemberships.Series").Preload("Audiobook").
+			Where("isbn = ?", "9781781102367").First(&book).Error)
+		assert.Equal(t, "Harry Potter and the Sorcerer's Stone", book.Title)
+		assert.Equal(t, "J.K. Rowling", book.Author.Name)
+		assert.Equal(t, "B017V4IM1G", book.ASIN)
+		assert.Equal(t, "Harry saw a purple wax seal.", book.Description)
+		require.Len(t, book.SeriesMemberships, 1)
+		assert.Equal(t, "Harry Potter", book.SeriesMemberships[0].Series.Name)
+		assert.Equal(t, "1", book.SeriesMemberships[0].Position)
+		require.NotNil(t, book.Audiobook)
+		assert.Equal(t, "Jim Dale", book.Audiobook.Narrator)
+
+		var credits []models.BookContributor
+		require.NoError(t, db.Preload("Author").Preload("Narrator").
+			Where("book_id = ?", book.ID).Order("id").Find(&credits).Error)
+		require.Len(t, credits, 2)
+		assert.Equal(t, "J.K. Rowling", credits[0].Name())
+		assert.Equal(t, models.ContributorRoleNarrator, credits[1].Role)
+		assert.Equal(t, "Jim Dale", credits[1].Name())
+	})
+
+	t.Run("Dry run reports existing records", func(t *testing.T) {
+		w := post("/api/v1/library?dry_run=true", map[string]string{"asin": "B017V4IM1G"})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data LibraryPreviewResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.NotNil(t, response.Data.AuthorID)
+		require.Len(t, response.Data.Series, 1)
+		assert.NotNil(t, response.Data.Series[0].SeriesID)
+		assert.NotNil(t, response.Data.BookID)
+		assert.True(t, response.Data.InLibrary)
+	})
+
+	t.Run("Add same book by ASIN conflicts", func(t *testing.T) {
+		w := post("/api/v1/library", map[string]string{"asin": "B017V4IM1G"})
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Unknown identifier", func(t *testing.T) {
+		w := post("/api/v1/library", map[string]string{"isbn": "0000000000"})
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Explicit fields win over metadata", func(t *testing.T) {
+		provider := newStubMetadataProvider()
+		provider.books["9780000000001"] = &metadata.BookMetadata{
+			Title:   "Provider Title",
+			Authors: []string{"Provider Author"},
+			ISBN:    "9780000000001",
+		}
+		server.metadata = metadata.NewService(provider)
+
+		w := post("/api/v1/library", map[string]string{"isbn": "9780000000001", "title": "My Title"})
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var book models.Book
+		require.NoError(t, db.Preload("Author").Where("isbn = ?", "9780000000001").First(&book).Error)
+		assert.Equal(t, "My Title", book.Title)
+		assert.Equal(t, "Provider Author", book.Author.Name)
+	})
+}
+
+func TestLibraryRescan(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+	library := t.TempDir()
+
+	author := models.Author{Name: "Terry Pratchett"}
+	require.NoError(t, db.Create(&author).Error)
+	book := models.Book{Title: "Mort", AuthorID: author.ID}
+	require.NoError(t, db.Create(&book).Error)
+	item := models.LibraryItem{
+		BookID:    book.ID,
+		Status:    models.LibraryItemStatusAvailable,
+		FilePath:  filepath.Join(library, "Terry Pratchett", "Mort", "mort.m4b"),
+		AddedDate: time.Now(),
+	}
+	require.NoError(t, db.Create(&item).Error)
+
+	router := gin.New()
+	router.GET("/api/v1/library/rescan", server.getLibraryRescan)
+	router.POST("/api/v1/library/rescan", server.rescanLibrary)
+
+	request := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/api/v1/library/rescan", nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("No rescan yet", func(t *testing.T) {
+		w := request("GET")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Library unavailable", func(t *testing.T) {
+		server.rescan = rescan.NewService(db, nil, rescan.Config{LibraryPath: filepath.Join(library, "unmounted")})
+
+		w := request("POST")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Rescan library", func(t *testing.T) {
+		server.rescan = rescan.NewService(db, nil, rescan.Config{LibraryPath: library})
+
+		w := request("POST")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data rescan.Result `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Data.Checked)
+		require.Len(t, response.Data.Missing, 1)
+		assert.Equal(t, item.ID, response.Data.Missing[0].LibraryItemID)
+
+		var updated models.LibraryItem
+		require.NoError(t, db.First(&updated, item.ID).Error)
+		assert.Equal(t, models.LibraryItemStatusMissing, updated.Status)
+
+		w = request("GET")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"checked":1`)
+	})
+}
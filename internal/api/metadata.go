@@ -0,0 +1,207 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+)
+
+// MetadataSearchResponse represents metadata search results
+type MetadataSearchResponse struct {
+	Query   string                  `json:"query"`
+	Results []metadata.BookMetadata `json:"results"`
+	Total   int                     `json:"total"`
+}
+
+// metadataErrorResponse maps metadata service errors to API responses
+func metadataErrorResponse(c *gin.Context, err error) {
+	if errors.Is(err, metadata.ErrNotFound) {
+		NotFoundResponse(c, "metadata")
+		return
+	}
+	BadGatewayResponse(c, "Metadata provider request failed")
+}
+
+// searchMetadata handles GET /api/v1/metadata/search
+func (s *Server) searchMetadata(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		BadRequestResponse(c, "Search query parameter 'q' is required")
+		return
+	}
+
+	results, err := s.metadata.Search(query)
+	if err != nil {
+		metadataErrorResponse(c, err)
+		return
+	}
+
+	SuccessResponse(c, StatusOK, MetadataSearchResponse{
+		Query:   query,
+		Results: results,
+		Total:   len(results),
+	})
+}
+
+// lookupMetadataByISBN handles GET /api/v1/metadata/isbn/:isbn
+func (s *Server) lookupMetadataByISBN(c *gin.Context) {
+	book, err := s.metadata.LookupISBN(c.Param("isbn"))
+	if err != nil {
+		metadataErrorResponse(c, err)
+		return
+	}
+
+	SuccessResponse(c, StatusOK, book)
+}
+
+// lookupMetadataByASIN handles GET /api/v1/metadata/asin/:asin
+func (s *Server) lookupMetadataByASIN(c *gin.Context) {
+	book, err := s.metadata.LookupASIN(c.Param("asin"))
+	if err != nil {
+		metadataErrorResponse(c, err)
+		return
+	}
+
+	SuccessResponse(c, StatusOK, book)
+}
+
+// refreshBookMetadata handles POST /api/v1/books/:id/refresh
+// Fills empty book and audiobook fields from the metadata providers;
+// ?overwrite=true replaces existing values as well.
+func (s *Server) refreshBookMetadata(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid book ID")
+		return
+	}
+	overwrite, _ := strconv.ParseBool(c.DefaultQuery("overwrite", "false"))
+
+	var book models.Book
+	err = s.db.
+		Preload("Author").
+		Preload("Audiobook").
+		First(&book, uint(id)).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			NotFoundResponse(c, "book")
+			return
+		}
+		InternalErrorResponse(c, "Failed to find book")
+		return
+	}
+
+	md, err := s.metadata.LookupBook(&book)
+	if err != nil {
+		metadataErrorResponse(c, err)
+		return
+	}
+
+	metadata.ApplyToBook(&book, md, overwrite)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Author", "Audiobook").Save(&book).Error; err != nil {
+			return err
+		}
+		if err := refreshNarratorCredits(tx, &book, md.Narrators, overwrite); err != nil {
+			return err
+		}
+
+		// Only create an audiobook record when there is audiobook data to store
+		audiobook := book.Audiobook
+		if audiobook == nil {
+			if len(md.Narrators) == 0 && md.Duration == 0 {
+				return nil
+			}
+			audiobook = &models.Audiobook{BookID: book.ID}
+		}
+		metadata.ApplyToAudiobook(audiobook, md, overwrite)
+		return tx.Omit("Book").Save(audiobook).Error
+	})
+	if err != nil {
+		InternalErrorResponse(c, "Failed to update book")
+		return
+	}
+
+	// Reload with relationships
+	err = s.db.
+		Preload("Author").
+		Preload("SeriesMemberships.Series").
+		Preload("Contributors.Author").
+		Preload("Contributors.Narrator").
+		Preload("Audiobook").
+		First(&book, book.ID).Error
+	if err != nil {
+		InternalErrorResponse(c, "Failed to reload book")
+		return
+	}
+
+	SuccessResponse(c, StatusOK, toBookResponseDetailed(&book))
+}
+
+// refreshNarratorCredits credits the narrators found by a metadata lookup on a
+// book that has none yet; with overwrite they replace the existing ones
+func refreshNarratorCredits(tx *gorm.DB, book *models.Book, narrators []string, overwrite bool) error {
+	if len(narrators) == 0 {
+		return nil
+	}
+	credits := tx.Where("book_id = ? AND role = ?", book.ID, models.ContributorRoleNarrator)
+	if overwrite {
+		if err := credits.Delete(&models.BookContributor{}).Error; err != nil {
+			return err
+		}
+	} else {
+		var count int64
+		if err := credits.Model(&models.BookContributor{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+	}
+	return models.CreditContributors(tx, book, nil, narrators)
+}
+
+// refreshAuthorMetadata handles POST /api/v1/authors/:id/refresh
+// Fills empty author fields from the metadata providers;
+// ?overwrite=true replaces existing values as well.
+func (s *Server) refreshAuthorMetadata(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid author ID")
+		return
+	}
+	overwrite, _ := strconv.ParseBool(c.DefaultQuery("overwrite", "false"))
+
+	var author models.Author
+	err = s.db.First(&author, uint(id)).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			NotFoundResponse(c, "author")
+			return
+		}
+		InternalErrorResponse(c, "Failed to find author")
+		return
+	}
+
+	md, err := s.metadata.LookupAuthor(author.Name)
+	if err != nil {
+		metadataErrorResponse(c, err)
+		return
+	}
+
+	metadata.ApplyToAuthor(&author, md, overwrite)
+
+	if err := s.db.Save(&author).Error; err != nil {
+		InternalErrorResponse(c, "Failed to update author")
+		return
+	}
+
+	SuccessResponse(c, StatusOK, toAuthorResponseDetailed(&author))
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+)
+
+// stubMetadataProvider serves canned metadata keyed by ISBN, ASIN or author name
+type stubMetadataProvider struct {
+	books   map[string]*metadata.BookMetadata
+	authors map[string]*metadata.AuthorMetadata
+	works   map[string][]metadata.BookMetadata // keyed by author name
+	err     error
+}
+
+func (p *stubMetadataProvider) Name() string { return "stub" }
+
+func (p *stubMetadataProvider) Search(query string) ([]metadata.BookMetadata, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	results := make([]metadata.BookMetadata, 0)
+	for _, book := range p.books {
+		if book.Title == query {
+			results = append(results, *book)
+		}
+	}
+	return results, nil
+}
+
+func (p *stubMetadataProvider) LookupISBN(isbn string) (*metadata.BookMetadata, error) {
+	return p.lookup(isbn)
+}
+
+func (p *stubMetadataProvider) LookupASIN(asin string) (*metadata.BookMetadata, error) {
+	return p.lookup(asin)
+}
+
+func (p *stubMetadataProvider) LookupAuthor(name string) (*metadata.AuthorMetadata, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if author, ok := p.authors[name]; ok {
+		return author, nil
+	}
+	return nil, metadata.ErrNotFound
+}
+
+func (p *stubMetadataProvider) LookupAuthorBooks(name string) ([]metadata.BookMetadata, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.works[name], nil
+}
+
+func (p *stubMetadataProvider) lookup(id string) (*metadata.BookMetadata, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if book, ok := p.books[id]; ok {
+		copied := *book
+		return &copied, nil
+	}
+	return nil, metadata.ErrNotFound
+}
+
+// newStubMetadataProvider returns a provider that knows one audiobook and its author
+func newStubMetadataProvider() *stubMetadataProvider {
+	released := time.Date(2015, 11, 20, 0, 0, 0, 0, time.UTC)
+	book := &metadata.BookMetadata{
+		Source:      "stub",
+		Title:       "Harry Potter and the Sorcerer's Stone",
+		Authors:     []string{"J.K. Rowling"},
+		Narrators:   []string{"Jim Dale"},
+		Series:      []metadata.SeriesInfo{{Name: "Harry Potter", Position: "1"}},
+		ISBN:        "9781781102367",
+		ASIN:        "B017V4IM1G",
+		Description: "Harry saw a purple wax seal.",
+		CoverArtURL: "https://example.com/cover.jpg",
+		ReleaseDate: &released,
+		Genre:       "Fantasy",
+		Publisher:   "Pottermore Publishing",
+		Duration:    29880,
+	}
+	return &stubMetadataProvider{
+		books: map[string]*metadata.BookMetadata{
+			"9781781102367": book,
+			"B017V4IM1G":    book,
+		},
+		authors: map[string]*metadata.AuthorMetadata{
+			"J.K. Rowling": {Source: "stub", Name: "J.K. Rowling", Biography: "British author.", ImageURL: "https://example.com/jkr.jpg"},
+		},
+	}
+}
+
+func TestMetadataLookups(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+	server.metadata = metadata.NewService(newStubMetadataProvider())
+
+	router := gin.New()
+	router.GET("/api/v1/metadata/search", server.searchMetadata)
+	router.GET("/api/v1/metadata/isbn/:isbn", server.lookupMetadataByISBN)
+	router.GET("/api/v1/metadata/asin/:asin", server.lookupMetadataByASIN)
+
+	t.Run("Search requires query", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/metadata/search", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/metadata/search?q=Harry+Potter+and+the+Sorcerer%27s+Stone", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data MetadataSearchResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Data.Total)
+	})
+
+	t.Run("Lookup by ISBN", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/metadata/isbn/9781781102367", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Jim Dale")
+	})
+
+	t.Run("Lookup by ASIN not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/metadata/asin/B000000000", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Provider failure", func(t *testing.T) {
+		server.metadata = metadata.NewService(&stubMetadataProvider{err: errors.New("timeout")})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/metadata/asin/B017V4IM1G", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+}
+
+func TestRefreshBookMetadata(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+	server.metadata = metadata.NewService(newStubMetadataProvider())
+
+	author := models.Author{Name: "J.K. Rowling"}
+	db.Create(&author)
+
+	book := models.Book{Title: "Harry Potter and the Sorcerer's Stone", ASIN: "B017V4IM1G", Genre: "Kept", AuthorID: author.ID}
+	db.Create(&book)
+
+	router := gin.New()
+	router.POST("/api/v1/books/:id/refresh", server.refreshBookMetadata)
+
+	t.Run("Refresh fills empty fields", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/books/1/refresh", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var updated models.Book
+		db.Preload("Audiobook").First(&updated, book.ID)
+		assert.Equal(t, "Harry saw a purple wax seal.", updated.Description)
+		assert.Equal(t, "https://example.com/cover.jpg", updated.CoverArtURL)
+		assert.Equal(t, "9781781102367", updated.ISBN)
+		assert.Equal(t, "Kept", updated.Genre)
+		require.NotNil(t, updated.ReleaseDate)
+		require.NotNil(t, updated.Audiobook)
+		assert.Equal(t, "Jim Dale", updated.Audiobook.Narrator)
+		assert.Equal(t, 29880, updated.Audiobook.Duration)
+	})
+
+	t.Run("Refresh with overwrite", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/books/1/refresh?overwrite=true", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var updated models.Book
+		db.First(&updated, book.ID)
+		assert.Equal(t, "Fantasy", updated.Genre)
+
+		// The existing audiobook is updated, not duplicated
+		var count int64
+		db.Model(&models.Audiobook{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Refresh non-existent book", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/books/999/refresh", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRefreshAuthorMetadata(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+	server.metadata = metadata.NewService(newStubMetadataProvider())
+
+	author := models.Author{Name: "J.K. Rowling"}
+	db.Create(&author)
+
+	unknown := models.Author{Name: "Nobody"}
+	db.Create(&unknown)
+
+	router := gin.New()
+	router.POST("/api/v1/authors/:id/refresh", server.refreshAuthorMetadata)
+
+	t.Run("Refresh author", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/authors/1/refresh", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var updated models.Author
+		db.First(&updated, author.ID)
+		assert.Equal(t, "British author.", updated.Biography)
+		assert.Equal(t, "https://example.com/jkr.jpg", updated.ImageURL)
+	})
+
+	t.Run("No metadata for author", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/authors/2/refresh", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,292 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database"
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// CreateNarratorRequest represents the request body for creating a narrator
+type CreateNarratorRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Biography string `json:"biography,omitempty"`
+	ImageURL  string `json:"image_url,omitempty"`
+}
+
+// UpdateNarratorRequest represents the request body for updating a narrator
+type UpdateNarratorRequest struct {
+	Name      *string `json:"name,omitempty"`
+	Biography *string `json:"biography,omitempty"`
+	ImageURL  *string `json:"image_url,omitempty"`
+}
+
+// NarratorResponseDetailed represents a narrator in API responses with timestamps as strings
+type NarratorResponseDetailed struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Biography string `json:"biography,omitempty"`
+	ImageURL  string `json:"image_url,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// NarratorWithBooksResponse represents a narrator with the books they narrated
+type NarratorWithBooksResponse struct {
+	NarratorResponseDetailed
+	Books []BookResponse `json:"books,omitempty"`
+}
+
+// toNarratorResponseDetailed converts a Narrator model to API response format with string timestamps
+func toNarratorResponseDetailed(narrator *models.Narrator) *NarratorResponseDetailed {
+	return &NarratorResponseDetailed{
+		ID:        narrator.ID,
+		Name:      narrator.Name,
+		Biography: narrator.Biography,
+		ImageURL:  narrator.ImageURL,
+		CreatedAt: narrator.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: narrator.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// toNarratorWithBooksResponse converts a Narrator model with credits (and
+// their books) to API response format, skipping deleted books
+func toNarratorWithBooksResponse(narrator *models.Narrator) *NarratorWithBooksResponse {
+	response := &NarratorWithBooksResponse{
+		NarratorResponseDetailed: *toNarratorResponseDetailed(narrator),
+		Books:                    make([]BookResponse, 0, len(narrator.Credits)),
+	}
+
+	for i := range narrator.Credits {
+		if narrator.Credits[i].Book.ID == 0 {
+			continue
+		}
+		response.Books = append(response.Books, *toBookResponse(&narrator.Credits[i].Book))
+	}
+
+	return response
+}
+
+// getNarrators handles GET /api/v1/narrators
+func (s *Server) getNarrators(c *gin.Context) {
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	// Validate pagination
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset := (page - 1) * limit
+
+	// Build query
+	query := s.db.Model(&models.Narrator{})
+
+	// Apply search filter
+	if search := c.Query("search"); search != "" {
+		query = query.Where(database.Contains("name", search))
+	}
+
+	// Get total count
+	var total int64
+	query.Count(&total)
+
+	// Apply sorting
+	sortBy := c.DefaultQuery("sort", "name")
+	order := c.DefaultQuery("order", "asc")
+	if order != "asc" && order != "desc" {
+		order = "asc"
+	}
+
+	switch sortBy {
+	case "name":
+		query = query.Order("name " + order)
+	case "created_at":
+		query = query.Order("created_at " + order)
+	default:
+		query = query.Order("name " + order)
+	}
+
+	// Apply pagination
+	var narrators []models.Narrator
+	err := query.Offset(offset).Limit(limit).Find(&narrators).Error
+
+	if err != nil {
+		InternalErrorResponse(c, "Failed to fetch narrators")
+		return
+	}
+
+	// Convert to response format
+	responseData := make([]*NarratorResponseDetailed, len(narrators))
+	for i := range narrators {
+		responseData[i] = toNarratorResponseDetailed(&narrators[i])
+	}
+
+	PaginatedSuccessResponse(c, responseData, page, limit, int(total))
+}
+
+// getNarrator handles GET /api/v1/narrators/:id
+func (s *Server) getNarrator(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid narrator ID")
+		return
+	}
+
+	var narrator models.Narrator
+	err = s.db.
+		Preload("Credits.Book.Author").
+		Preload("Credits.Book.SeriesMemberships.Series").
+		First(&narrator, uint(id)).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			NotFoundResponse(c, "narrator")
+			return
+		}
+		InternalErrorResponse(c, "Failed to fetch narrator")
+		return
+	}
+
+	SuccessResponse(c, StatusOK, toNarratorWithBooksResponse(&narrator))
+}
+
+// createNarrator handles POST /api/v1/narrators
+func (s *Server) createNarrator(c *gin.Context) {
+	var req CreateNarratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ValidationErrorResponse(c, err)
+		return
+	}
+
+	// Check if narrator already exists
+	var existingNarrator models.Narrator
+	err := s.db.Where("name = ?", req.Name).First(&existingNarrator).Error
+	if err == nil {
+		ConflictResponse(c, "Narrator with this name already exists")
+		return
+	} else if err != gorm.ErrRecordNotFound {
+		InternalErrorResponse(c, "Failed to check existing narrator")
+		return
+	}
+
+	narrator := models.Narrator{
+		Name:      req.Name,
+		Biography: req.Biography,
+		ImageURL:  req.ImageURL,
+	}
+
+	if err := s.db.Create(&narrator).Error; err != nil {
+		InternalErrorResponse(c, "Failed to create narrator")
+		return
+	}
+
+	CreatedResponse(c, toNarratorResponseDetailed(&narrator))
+}
+
+// updateNarrator handles PUT /api/v1/narrators/:id
+func (s *Server) updateNarrator(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid narrator ID")
+		return
+	}
+
+	var req UpdateNarratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ValidationErrorResponse(c, err)
+		return
+	}
+
+	// Check if narrator exists
+	var narrator models.Narrator
+	err = s.db.First(&narrator, uint(id)).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			NotFoundResponse(c, "narrator")
+			return
+		}
+		InternalErrorResponse(c, "Failed to find narrator")
+		return
+	}
+
+	// Update fields if provided
+	if req.Name != nil {
+		// Check for duplicate name if changing
+		if *req.Name != narrator.Name {
+			var existingNarrator models.Narrator
+			err := s.db.Where("name = ? AND id != ?", *req.Name, uint(id)).First(&existingNarrator).Error
+			if err == nil {
+				ConflictResponse(c, "Narrator with this name already exists")
+				return
+			} else if err != gorm.ErrRecordNotFound {
+				InternalErrorResponse(c, "Failed to check existing narrator")
+				return
+			}
+		}
+		narrator.Name = *req.Name
+	}
+	if req.Biography != nil {
+		narrator.Biography = *req.Biography
+	}
+	if req.ImageURL != nil {
+		narrator.ImageURL = *req.ImageURL
+	}
+
+	if err := s.db.Save(&narrator).Error; err != nil {
+		InternalErrorResponse(c, "Failed to update narrator")
+		return
+	}
+
+	SuccessResponse(c, StatusOK, toNarratorResponseDetailed(&narrator))
+}
+
+// deleteNarrator handles DELETE /api/v1/narrators/:id
+func (s *Server) deleteNarrator(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid narrator ID")
+		return
+	}
+
+	// Check if narrator exists
+	var narrator models.Narrator
+	err = s.db.First(&narrator, uint(id)).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			NotFoundResponse(c, "narrator")
+			return
+		}
+		InternalErrorResponse(c, "Failed to find narrator")
+		return
+	}
+
+	// Check if narrator is credited on any book
+	var creditCount int64
+	s.db.Model(&models.BookContributor{}).Where("narrator_id = ?", id).Count(&creditCount)
+	if creditCount > 0 {
+		ConflictResponse(c, "Cannot delete narrator credited on existing books")
+		return
+	}
+
+	// Soft delete (GORM handles this automatically with DeletedAt)
+	err = s.db.Delete(&narrator).Error
+	if err != nil {
+		InternalErrorResponse(c, "Failed to delete narrator")
+		return
+	}
+
+	NoContentResponse(c)
+}
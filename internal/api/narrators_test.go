@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+func TestGetNarrators(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+
+	db.Create(&models.Narrator{Name: "Jim Dale"})
+	db.Create(&models.Narrator{Name: "Stephen Fry"})
+
+	router := gin.New()
+	router.GET("/api/v1/narrators", server.getNarrators)
+
+	t.Run("Get narrators", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/narrators", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response PaginatedResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Pagination.Total)
+	})
+
+	t.Run("Get narrators with search", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/narrators?search=Fry", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []NarratorResponseDetailed `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, "Stephen Fry", response.Data[0].Name)
+	})
+}
+
+func TestGetNarrator(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+
+	author := models.Author{Name: "J.K. Rowling"}
+	db.Create(&author)
+	narrator := models.Narrator{Name: "Jim Dale"}
+	db.Create(&narrator)
+
+	narrated := models.Book{Title: "Narrated", AuthorID: author.ID}
+	deleted := models.Book{Title: "Deleted", AuthorID: author.ID}
+	other := models.Book{Title: "Other", AuthorID: author.ID}
+	db.Create(&narrated)
+	db.Create(&deleted)
+	db.Create(&other)
+	require.NoError(t, models.CreditNarrator(db, narrated.ID, narrator.ID))
+	require.NoError(t, models.CreditNarrator(db, deleted.ID, narrator.ID))
+	db.Delete(&deleted)
+
+	router := gin.New()
+	router.GET("/api/v1/narrators/:id", server.getNarrator)
+
+	t.Run("Get narrator with books", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/narrators/"+strconv.Itoa(int(narrator.ID)), nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data NarratorWithBooksResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "Jim Dale", response.Data.Name)
+		require.Len(t, response.Data.Books, 1)
+		assert.Equal(t, "Narrated", response.Data.Books[0].Title)
+		require.NotNil(t, response.Data.Books[0].Author)
+		assert.Equal(t, "J.K. Rowling", response.Data.Books[0].Author.Name)
+	})
+
+	t.Run("Get non-existent narrator", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/narrators/999", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestCreateUpdateDeleteNarrator(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+
+	router := gin.New()
+	router.POST("/api/v1/narrators", server.createNarrator)
+	router.PUT("/api/v1/narrators/:id", server.updateNarrator)
+	router.DELETE("/api/v1/narrators/:id", server.deleteNarrator)
+
+	send := func(method, url string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Create narrator", func(t *testing.T) {
+		w := send("POST", "/api/v1/narrators", CreateNarratorRequest{Name: "Kate Reading"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("Create duplicate narrator", func(t *testing.T) {
+		w := send("POST", "/api/v1/narrators", CreateNarratorRequest{Name: "Kate Reading"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Create narrator with missing name", func(t *testing.T) {
+		w := send("POST", "/api/v1/narrators", map[string]interface{}{"biography": "No name"})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("Update narrator", func(t *testing.T) {
+		bio := "American narrator."
+		w := send("PUT", "/api/v1/narrators/1", UpdateNarratorRequest{Biography: &bio})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var narrator models.Narrator
+		db.First(&narrator, 1)
+		assert.Equal(t, bio, narrator.Biography)
+	})
+
+	t.Run("Delete credited narrator", func(t *testing.T) {
+		author := models.Author{Name: "Author"}
+		db.Create(&author)
+		book := models.Book{Title: "Narrated", AuthorID: author.ID}
+		db.Create(&book)
+		require.NoError(t, models.CreditNarrator(db, book.ID, 1))
+
+		w := send("DELETE", "/api/v1/narrators/1", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Delete uncredited narrator", func(t *testing.T) {
+		narrator := models.Narrator{Name: "Uncredited"}
+		db.Create(&narrator)
+
+		w := send("DELETE", "/api/v1/narrators/"+strconv.Itoa(int(narrator.ID)), nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/notification"
+)
+
+// NotificationRequest represents the request body for creating, replacing or
+// testing a notification
+type NotificationRequest struct {
+	Name          string                      `json:"name"`
+	Type          string                      `json:"type"`    // webhook, discord, email, gotify, ntfy, pushover or custom_script
+	Enabled       *bool                       `json:"enabled"` // Defaults to true
+	OnGrab        bool                        `json:"on_grab"`
+	OnImport      bool                        `json:"on_import"`
+	OnUpgrade     bool                        `json:"on_upgrade"`
+	OnDelete      bool                        `json:"on_delete"`
+	OnFailure     bool                        `json:"on_failure"`
+	OnHealthIssue bool                        `json:"on_health_issue"`
+	Settings      models.NotificationSettings `json:"settings"`
+}
+
+// secretMask replaces secret settings in responses. Sent back unchanged in
+// an update, it keeps the stored secret.
+const secretMask = "********"
+
+// maskSecrets hides the passwords, tokens and keys of settings
+func maskSecrets(settings models.NotificationSettings) models.NotificationSettings {
+	for _, secret := range []*string{&settings.Token, &settings.UserKey, &settings.Password} {
+		if *secret != "" {
+			*secret = secretMask
+		}
+	}
+	settings.URL = maskURL(settings.URL)
+	return settings
+}
+
+// maskURL hides the credentials a URL may carry: its user info, and its path
+// and query, which hold the token of Discord and Slack-style webhooks. Only
+// the scheme and host are kept.
+func maskURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	if u.User == nil && strings.Trim(u.Path, "/") == "" && u.RawQuery == "" && u.Fragment == "" {
+		return rawURL
+	}
+	masked := u.Scheme + "://"
+	if u.User != nil {
+		masked += secretMask + "@"
+	}
+	return masked + u.Host + "/" + secretMask
+}
+
+// keepSecrets puts the stored secrets back in settings where the request
+// sent the mask
+func keepSecrets(settings, stored models.NotificationSettings) models.NotificationSettings {
+	if settings.Token == secretMask {
+		settings.Token = stored.Token
+	}
+	if settings.UserKey == secretMask {
+		settings.UserKey = stored.UserKey
+	}
+	if settings.Password == secretMask {
+		settings.Password = stored.Password
+	}
+	if settings.URL != stored.URL && settings.URL == maskURL(stored.URL) {
+		settings.URL = stored.URL
+	}
+	return settings
+}
+
+// NotificationResponse represents a notification in API responses
+type NotificationResponse struct {
+	ID            uint                        `json:"id"`
+	Name          string                      `json:"name"`
+	Type          string                      `json:"type"`
+	Enabled       bool                        `json:"enabled"`
+	OnGrab        bool                        `json:"on_grab"`
+	OnImport      bool                        `json:"on_import"`
+	OnUpgrade     bool                        `json:"on_upgrade"`
+	OnDelete      bool                        `json:"on_delete"`
+	OnFailure     bool                        `json:"on_failure"`
+	OnHealthIssue bool                        `json:"on_health_issue"`
+	Settings      models.NotificationSettings `json:"settings"`
+	CreatedAt     string                      `json:"created_at"`
+	UpdatedAt     string                      `json:"updated_at"`
+}
+
+// toNotificationResponse converts a Notification model to API response format
+func toNotificationResponse(n *models.Notification) *NotificationResponse {
+	return &NotificationResponse{
+		ID:            n.ID,
+		Name:          n.Name,
+		Type:          string(n.Type),
+		Enabled:       n.Enabled,
+		OnGrab:        n.OnGrab,
+		OnImport:      n.OnImport,
+		OnUpgrade:     n.OnUpgrade,
+		OnDelete:      n.OnDelete,
+		OnFailure:     n.OnFailure,
+		OnHealthIssue: n.OnHealthIssue,
+		Settings:      maskSecrets(n.GetSettings()),
+		CreatedAt:     n.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:     n.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// bindNotification reads and validates a notification request into n,
+// keeping the secrets of a saved n the request sent masked. It sends the
+// error response and returns false when the request is invalid.
+func bindNotification(c *gin.Context, n *models.Notification) bool {
+	var req NotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ValidationErrorResponse(c, err)
+		return false
+	}
+
+	n.Name = req.Name
+	n.Type = models.NotificationType(req.Type)
+	n.Enabled = req.Enabled == nil || *req.Enabled
+	n.OnGrab = req.OnGrab
+	n.OnImport = req.OnImport
+	n.OnUpgrade = req.OnUpgrade
+	n.OnDelete = req.OnDelete
+	n.OnFailure = req.OnFailure
+	n.OnHealthIssue = req.OnHealthIssue
+	n.SetSettings(keepSecrets(req.Settings, n.GetSettings()))
+
+	valErrs := NewValidationErrors()
+	if n.Name == "" {
+		valErrs.Add("name", "name is required")
+	}
+	if !n.Type.IsValid() {
+		valErrs.Add("type", "type must be one of: webhook, discord, email, gotify, ntfy, pushover, custom_script")
+	} else if _, err := notification.New(n); err != nil {
+		valErrs.Add("settings", err.Error())
+	}
+	if valErrs.HasErrors() {
+		ValidationErrorResponse(c, valErrs)
+		return false
+	}
+
+	return true
+}
+
+// findNotification loads the notification named by the :id parameter.
+// It sends the error response and returns false when there is none.
+func (s *Server) findNotification(c *gin.Context, n *models.Notification) bool {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid notification ID")
+		return false
+	}
+
+	if err := s.db.First(n, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			NotFoundResponse(c, "notification")
+			return false
+		}
+		InternalErrorResponse(c, "Failed to find notification")
+		return false
+	}
+
+	return true
+}
+
+// getNotifications handles GET /api/v1/notifications
+func (s *Server) getNotifications(c *gin.Context) {
+	var notifications []models.Notification
+	if err := s.db.Order("name ASC").Find(&notifications).Error; err != nil {
+		InternalErrorResponse(c, "Failed to fetch notifications")
+		return
+	}
+
+	responseData := make([]*NotificationResponse, len(notifications))
+	for i := range notifications {
+		responseData[i] = toNotificationResponse(&notifications[i])
+	}
+
+	SuccessResponse(c, StatusOK, responseData)
+}
+
+// getNotification handles GET /api/v1/notifications/:id
+func (s *Server) getNotification(c *gin.Context) {
+	var n models.Notification
+	if !s.findNotification(c, &n) {
+		return
+	}
+
+	SuccessResponse(c, StatusOK, toNotificationResponse(&n))
+}
+
+// createNotification handles POST /api/v1/notifications
+func (s *Server) createNotification(c *gin.Context) {
+	var n models.Notification
+	if !bindNotification(c, &n) {
+		return
+	}
+
+	if err := s.db.Create(&n).Error; err != nil {
+		InternalErrorResponse(c, "Failed to create notification")
+		return
+	}
+
+	CreatedResponse(c, toNotificationResponse(&n))
+}
+
+// updateNotification handles PUT /api/v1/notifications/:id
+func (s *Server) updateNotification(c *gin.Context) {
+	var n models.Notification
+	if !s.findNotification(c, &n) {
+		return
+	}
+	if !bindNotification(c, &n) {
+		return
+	}
+
+	if err := s.db.Save(&n).Error; err != nil {
+		InternalErrorResponse(c, "Failed to update notification")
+		return
+	}
+
+	SuccessResponse(c, StatusOK, toNotificationResponse(&n))
+}
+
+// deleteNotification handles DELETE /api/v1/notifications/:id
+func (s *Server) deleteNotification(c *gin.Context) {
+	var n models.Notification
+	if !s.findNotification(c, &n) {
+		return
+	}
+
+	if err := s.db.Delete(&n).Error; err != nil {
+		InternalErrorResponse(c, "Failed to delete notification")
+		return
+	}
+
+	NoContentResponse(c)
+}
+
+// testNewNotification handles POST /api/v1/notifications/test
+func (s *Server) testNewNotification(c *gin.Context) {
+	var n models.Notification
+	if !bindNotification(c, &n) {
+		return
+	}
+
+	s.sendTestNotification(c, &n)
+}
+
+// testNotification handles POST /api/v1/notifications/:id/test
+func (s *Server) testNotification(c *gin.Context) {
+	var n models.Notification
+	if !s.findNotification(c, &n) {
+		return
+	}
+
+	s.sendTestNotification(c, &n)
+}
+
+// sendTestNotification sends a test message and reports the outcome
+func (s *Server) sendTestNotification(c *gin.Context, n *models.Notification) {
+	if err := s.notifications.Test(n); err != nil {
+		BadGatewayResponse(c, "Test notification failed: "+err.Error())
+		return
+	}
+
+	SuccessResponse(c, StatusOK, gin.H{"message": "Test notification sent"})
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// PlexSectionResponse represents a Plex library section in API responses
+type PlexSectionResponse struct {
+	Key       string   `json:"key"`
+	Title     string   `json:"title"`
+	Type      string   `json:"type"`
+	Locations []string `json:"locations"`
+}
+
+// getPlexSections handles GET /api/v1/plex/sections
+// Lists the Plex library sections so the audiobook library can be picked
+// as plex.section in the configuration.
+func (s *Server) getPlexSections(c *gin.Context) {
+	if s.plex == nil {
+		BadRequestResponse(c, "Plex is not configured")
+		return
+	}
+
+	sections, err := s.plex.GetSections()
+	if err != nil {
+		BadGatewayResponse(c, "Failed to list Plex library sections")
+		return
+	}
+
+	responseData := make([]PlexSectionResponse, len(sections))
+	for i, section := range sections {
+		responseData[i] = PlexSectionResponse{
+			Key:       section.Key,
+			Title:     section.Title,
+			Type:      section.Type,
+			Locations: make([]string, len(section.Locations)),
+		}
+		for j, location := range section.Locations {
+			responseData[i].Locations[j] = location.Path
+		}
+	}
+
+	SuccessResponse(c, StatusOK, responseData)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/pkg/plex"
+)
+
+func TestGetPlexSections(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+
+	router := gin.New()
+	router.GET("/api/v1/plex/sections", server.getPlexSections)
+
+	t.Run("Plex not configured", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/plex/sections", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("List sections", func(t *testing.T) {
+		plexServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"MediaContainer": {"Directory": [
+				{"key": "3", "title": "Audiobooks", "type": "artist", "Location": [{"id": 1, "path": "/data/audiobooks"}]}
+			]}}`))
+		}))
+		defer plexServer.Close()
+		server.plex = plex.NewClient(plexServer.URL, "token")
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/plex/sections", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []PlexSectionResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, PlexSectionResponse{Key: "3", Title: "Audiobooks", Type: "artist", Locations: []string{"/data/audiobooks"}}, response.Data[0])
+	})
+
+	t.Run("Plex unreachable", func(t *testing.T) {
+		server.plex = plex.NewClient("http://127.0.0.1:1", "token")
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/plex/sections", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+}
@@ -5,7 +5,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/listenarr/listenarr/internal/events"
 	"github.com/listenarr/listenarr/internal/models"
 )
 
@@ -82,7 +84,11 @@ func (s *Server) getProcessingQueue(c *gin.Context) {
 	query.Count(&total)
 
 	// Apply sorting (default: pending first, then by created_at)
-	query = query.Order("CASE WHEN status = 'pending' THEN 0 WHEN status = 'processing' THEN 1 ELSE 2 END, created_at ASC")
+	query = query.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:                "CASE WHEN status = ? THEN 0 WHEN status = ? THEN 1 ELSE 2 END, created_at ASC, id ASC",
+		Vars:               []interface{}{string(models.ProcessingStatusPending), string(models.ProcessingStatusProcessing)},
+		WithoutParentheses: true,
+	}})
 
 	// Apply pagination and preload relationships
 	var tasks []models.ProcessingTask
@@ -177,5 +183,11 @@ func (s *Server) retryProcessingTask(c *gin.Context) {
 		return
 	}
 
+	s.events.Publish(events.ProcessingStatusChanged, events.ProcessingPayload{
+		TaskID:     task.ID,
+		DownloadID: task.DownloadID,
+		Status:     string(task.Status),
+	})
+
 	SuccessResponse(c, StatusOK, toProcessingTaskResponse(&task))
 }
@@ -37,6 +37,7 @@ type PaginatedResponse struct {
 const (
 	StatusOK                  = http.StatusOK                  // 200
 	StatusCreated             = http.StatusCreated             // 201
+	StatusAccepted            = http.StatusAccepted            // 202
 	StatusNoContent           = http.StatusNoContent           // 204
 	StatusBadRequest          = http.StatusBadRequest          // 400
 	StatusUnauthorized        = http.StatusUnauthorized        // 401
@@ -44,6 +45,8 @@ const (
 	StatusConflict            = http.StatusConflict            // 409
 	StatusUnprocessableEntity = http.StatusUnprocessableEntity // 422
 	StatusInternalServerError = http.StatusInternalServerError // 500
+	StatusBadGateway          = http.StatusBadGateway          // 502
+	StatusInsufficientStorage = http.StatusInsufficientStorage // 507
 )
 
 // SuccessResponse sends a successful response
@@ -138,6 +141,19 @@ func UnauthorizedResponse(c *gin.Context, message string) {
 	ErrorResponse(c, StatusUnauthorized, err)
 }
 
+// BadGatewayResponse sends a bad gateway response for upstream service failures
+func BadGatewayResponse(c *gin.Context, message string) {
+	err := ErrBadGateway(message)
+	ErrorResponse(c, StatusBadGateway, err)
+}
+
+// InsufficientStorageResponse sends an insufficient storage response when a
+// folder is too full for the request
+func InsufficientStorageResponse(c *gin.Context, message string) {
+	err := ErrInsufficientStorage(message)
+	ErrorResponse(c, StatusInsufficientStorage, err)
+}
+
 // PaginatedSuccessResponse sends a successful paginated response
 func PaginatedSuccessResponse(c *gin.Context, data interface{}, page, limit, total int) {
 	totalPages := (total + limit - 1) / limit // Ceiling division
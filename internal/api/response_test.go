@@ -191,6 +191,24 @@ func TestInternalErrorResponse(t *testing.T) {
 	assert.Equal(t, StatusInternalServerError, w.Code)
 }
 
+func TestBadGatewayResponse(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/test", func(c *gin.Context) {
+		BadGatewayResponse(c, "upstream failed")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, StatusBadGateway, w.Code)
+
+	var response Response
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrCodeBadGateway, response.Code)
+}
+
 func TestUnauthorizedResponse(t *testing.T) {
 	router := setupTestRouter()
 	router.GET("/test", func(c *gin.Context) {
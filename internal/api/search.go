@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/listenarr/listenarr/internal/database"
 	"github.com/listenarr/listenarr/internal/models"
 )
 
@@ -54,7 +55,7 @@ func (s *Server) searchAudiobooks(c *gin.Context) {
 	// Search books
 	var books []models.Book
 	bookQuery := s.db.Model(&models.Book{}).
-		Where("title LIKE ?", "%"+query+"%").
+		Where(database.Contains("title", query)).
 		Or("isbn = ?", query).
 		Or("asin = ?", query).
 		Preload("Author").
@@ -80,7 +81,7 @@ func (s *Server) searchAudiobooks(c *gin.Context) {
 	// Search authors
 	var authors []models.Author
 	authorQuery := s.db.Model(&models.Author{}).
-		Where("name LIKE ?", "%"+query+"%").
+		Where(database.Contains("name", query)).
 		Limit(limit).
 		Offset(offset)
 
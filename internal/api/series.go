@@ -0,0 +1,482 @@
+package api
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database"
+	"github.com/listenarr/listenarr/internal/events"
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// CreateSeriesRequest represents the request body for creating a series
+type CreateSeriesRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description,omitempty"`
+	TotalBooks  int    `json:"total_books,omitempty"`
+}
+
+// UpdateSeriesRequest represents the request body for updating a series
+type UpdateSeriesRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	TotalBooks  *int    `json:"total_books,omitempty"`
+}
+
+// SeriesResponseDetailed represents a series in API responses with timestamps as strings
+type SeriesResponseDetailed struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	TotalBooks  int    `json:"total_books,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// SeriesBookResponse represents a book within a series and its library status
+type SeriesBookResponse struct {
+	ID            uint     `json:"id"`
+	Title         string   `json:"title"`
+	Position      string   `json:"position,omitempty"`
+	Sequence      *float64 `json:"sequence,omitempty"`
+	AuthorID      uint     `json:"author_id"`
+	AuthorName    string   `json:"author_name,omitempty"`
+	LibraryItemID *uint    `json:"library_item_id,omitempty"`
+	LibraryStatus string   `json:"library_status,omitempty"` // Empty when the book is not in the library
+}
+
+// SeriesWithBooksResponse represents a series with its books ordered by position.
+// MissingPositions lists whole-number positions up to the series length that
+// are not available in the library; UnknownPositions lists those with no book
+// record, which have to be added before they can be monitored. Fractional
+// positions such as novellas are listed with the books but never count as gaps.
+type SeriesWithBooksResponse struct {
+	SeriesResponseDetailed
+	Books            []SeriesBookResponse `json:"books"`
+	MissingPositions []int                `json:"missing_positions"`
+	UnknownPositions []int                `json:"unknown_positions"`
+}
+
+// MonitorSeriesResponse represents the result of monitoring a whole series
+type MonitorSeriesResponse struct {
+	SeriesID         uint                   `json:"series_id"`
+	Added            []*LibraryItemResponse `json:"added"`
+	UnknownPositions []int                  `json:"unknown_positions"`
+}
+
+// toSeriesResponseDetailed converts a Series model to API response format with string timestamps
+func toSeriesResponseDetailed(series *models.Series) *SeriesResponseDetailed {
+	return &SeriesResponseDetailed{
+		ID:          series.ID,
+		Name:        series.Name,
+		Description: series.Description,
+		TotalBooks:  series.TotalBooks,
+		CreatedAt:   series.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   series.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// toSeriesWithBooksResponse converts a Series with its memberships (including
+// Book, Book.Author and Book.LibraryItems) to API response format and detects gaps
+func toSeriesWithBooksResponse(series *models.Series) *SeriesWithBooksResponse {
+	memberships := make([]models.BookSeries, 0, len(series.Books))
+	for _, membership := range series.Books {
+		// Skip memberships of deleted books
+		if membership.Book.ID != 0 {
+			memberships = append(memberships, membership)
+		}
+	}
+	sortByPosition(memberships)
+
+	response := &SeriesWithBooksResponse{
+		SeriesResponseDetailed: *toSeriesResponseDetailed(series),
+		Books:                  make([]SeriesBookResponse, len(memberships)),
+	}
+
+	known := make(map[int]bool)
+	available := make(map[int]bool)
+	for i := range memberships {
+		membership := &memberships[i]
+		book := &membership.Book
+		entry := SeriesBookResponse{
+			ID:         book.ID,
+			Title:      book.Title,
+			Position:   membership.Position,
+			Sequence:   membership.Sequence,
+			AuthorID:   book.AuthorID,
+			AuthorName: book.Author.Name,
+		}
+		if len(book.LibraryItems) > 0 {
+			item := book.LibraryItems[0]
+			entry.LibraryItemID = &item.ID
+			entry.LibraryStatus = string(item.Status)
+		}
+		response.Books[i] = entry
+
+		if membership.IsWhole() {
+			position := int(*membership.Sequence)
+			known[position] = true
+			if entry.LibraryStatus == string(models.LibraryItemStatusAvailable) {
+				available[position] = true
+			}
+		}
+	}
+
+	response.MissingPositions = missingPositions(series.TotalBooks, known, available)
+	response.UnknownPositions = missingPositions(series.TotalBooks, known, known)
+
+	return response
+}
+
+// missingPositions returns the positions from 1 to the series length that are
+// not in have. The length is the larger of total and the highest known position.
+func missingPositions(total int, known, have map[int]bool) []int {
+	length := total
+	for position := range known {
+		if position > length {
+			length = position
+		}
+	}
+
+	missing := make([]int, 0)
+	for position := 1; position <= length; position++ {
+		if !have[position] {
+			missing = append(missing, position)
+		}
+	}
+	return missing
+}
+
+// sortByPosition orders series memberships by numeric position, then by
+// position text, with unnumbered books last
+func sortByPosition(memberships []models.BookSeries) {
+	sort.SliceStable(memberships, func(i, j int) bool {
+		a, b := memberships[i].Sequence, memberships[j].Sequence
+		if a == nil || b == nil {
+			if a == nil && b == nil {
+				return memberships[i].Position < memberships[j].Position
+			}
+			return a != nil
+		}
+		return *a < *b
+	})
+}
+
+// loadSeriesWithBooks loads a series with its books, their authors and library items
+func (s *Server) loadSeriesWithBooks(id uint) (*models.Series, error) {
+	var series models.Series
+	err := s.db.
+		Preload("Books.Book.Author").
+		Preload("Books.Book.LibraryItems").
+		First(&series, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// getSeriesList handles GET /api/v1/series
+func (s *Server) getSeriesList(c *gin.Context) {
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	// Validate pagination
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset := (page - 1) * limit
+
+	// Build query
+	query := s.db.Model(&models.Series{})
+
+	// Apply search filter
+	if search := c.Query("search"); search != "" {
+		query = query.Where(database.Contains("name", search))
+	}
+
+	// Get total count
+	var total int64
+	query.Count(&total)
+
+	// Apply sorting
+	sortBy := c.DefaultQuery("sort", "name")
+	order := c.DefaultQuery("order", "asc")
+	if order != "asc" && order != "desc" {
+		order = "asc"
+	}
+
+	switch sortBy {
+	case "created_at":
+		query = query.Order("created_at " + order)
+	default:
+		query = query.Order("name " + order)
+	}
+
+	// Apply pagination
+	var seriesList []models.Series
+	err := query.Offset(offset).Limit(limit).Find(&seriesList).Error
+
+	if err != nil {
+		InternalErrorResponse(c, "Failed to fetch series")
+		return
+	}
+
+	// Convert to response format
+	responseData := make([]*SeriesResponseDetailed, len(seriesList))
+	for i := range seriesList {
+		responseData[i] = toSeriesResponseDetailed(&seriesList[i])
+	}
+
+	PaginatedSuccessResponse(c, responseData, page, limit, int(total))
+}
+
+// getSeries handles GET /api/v1/series/:id
+func (s *Server) getSeries(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid series ID")
+		return
+	}
+
+	series, err := s.loadSeriesWithBooks(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			NotFoundResponse(c, "series")
+			return
+		}
+		InternalErrorResponse(c, "Failed to fetch series")
+		return
+	}
+
+	SuccessResponse(c, StatusOK, toSeriesWithBooksResponse(series))
+}
+
+// createSeries handles POST /api/v1/series
+func (s *Server) createSeries(c *gin.Context) {
+	var req CreateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ValidationErrorResponse(c, err)
+		return
+	}
+	if req.TotalBooks < 0 {
+		BadRequestResponse(c, "Total books cannot be negative")
+		return
+	}
+
+	// Check if series already exists
+	var existingSeries models.Series
+	err := s.db.Where("name = ?", req.Name).First(&existingSeries).Error
+	if err == nil {
+		ConflictResponse(c, "Series with this name already exists")
+		return
+	} else if err != gorm.ErrRecordNotFound {
+		InternalErrorResponse(c, "Failed to check existing series")
+		return
+	}
+
+	series := models.Series{
+		Name:        req.Name,
+		Description: req.Description,
+		TotalBooks:  req.TotalBooks,
+	}
+
+	if err := s.db.Create(&series).Error; err != nil {
+		InternalErrorResponse(c, "Failed to create series")
+		return
+	}
+
+	CreatedResponse(c, toSeriesResponseDetailed(&series))
+}
+
+// updateSeries handles PUT /api/v1/series/:id
+func (s *Server) updateSeries(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid series ID")
+		return
+	}
+
+	var req UpdateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ValidationErrorResponse(c, err)
+		return
+	}
+
+	// Check if series exists
+	var series models.Series
+	err = s.db.First(&series, uint(id)).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			NotFoundResponse(c, "series")
+			return
+		}
+		InternalErrorResponse(c, "Failed to find series")
+		return
+	}
+
+	// Update fields if provided
+	if req.Name != nil {
+		// Check for duplicate name if changing
+		if *req.Name != series.Name {
+			var existingSeries models.Series
+			err := s.db.Where("name = ? AND id != ?", *req.Name, uint(id)).First(&existingSeries).Error
+			if err == nil {
+				ConflictResponse(c, "Series with this name already exists")
+				return
+			} else if err != gorm.ErrRecordNotFound {
+				InternalErrorResponse(c, "Failed to check existing series")
+				return
+			}
+		}
+		series.Name = *req.Name
+	}
+	if req.Description != nil {
+		series.Description = *req.Description
+	}
+	if req.TotalBooks != nil {
+		if *req.TotalBooks < 0 {
+			BadRequestResponse(c, "Total books cannot be negative")
+			return
+		}
+		series.TotalBooks = *req.TotalBooks
+	}
+
+	if err := s.db.Save(&series).Error; err != nil {
+		InternalErrorResponse(c, "Failed to update series")
+		return
+	}
+
+	SuccessResponse(c, StatusOK, toSeriesResponseDetailed(&series))
+}
+
+// deleteSeries handles DELETE /api/v1/series/:id
+func (s *Server) deleteSeries(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid series ID")
+		return
+	}
+
+	// Check if series exists
+	var series models.Series
+	err = s.db.First(&series, uint(id)).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			NotFoundResponse(c, "series")
+			return
+		}
+		InternalErrorResponse(c, "Failed to find series")
+		return
+	}
+
+	// Check if series has books, links to deleted books do not count
+	var bookCount int64
+	err = s.db.Model(&models.BookSeries{}).
+		Joins("JOIN books ON books.id = book_series.book_id AND books.deleted_at IS NULL").
+		Where("book_series.series_id = ?", id).
+		Count(&bookCount).Error
+	if err != nil {
+		InternalErrorResponse(c, "Failed to check series books")
+		return
+	}
+	if bookCount > 0 {
+		ConflictResponse(c, "Cannot delete series with existing books")
+		return
+	}
+
+	// Soft delete (GORM handles this automatically with DeletedAt)
+	err = s.db.Delete(&series).Error
+	if err != nil {
+		InternalErrorResponse(c, "Failed to delete series")
+		return
+	}
+
+	NoContentResponse(c)
+}
+
+// monitorSeries handles POST /api/v1/series/:id/monitor
+// Adds a wanted library item for every book in the series that is not in the library.
+// Books whose library item was removed are not re-added.
+func (s *Server) monitorSeries(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		BadRequestResponse(c, "Invalid series ID")
+		return
+	}
+
+	series, err := s.loadSeriesWithBooks(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			NotFoundResponse(c, "series")
+			return
+		}
+		InternalErrorResponse(c, "Failed to fetch series")
+		return
+	}
+
+	added := make([]models.LibraryItem, 0)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range series.Books {
+			book := series.Books[i].Book
+			if book.ID == 0 || len(book.LibraryItems) > 0 {
+				continue
+			}
+
+			// A removed library item means the user does not want the book
+			var count int64
+			if err := tx.Unscoped().Model(&models.LibraryItem{}).Where("book_id = ?", book.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+			item := models.LibraryItem{
+				BookID:    book.ID,
+				Status:    models.LibraryItemStatusWanted,
+				AddedDate: time.Now(),
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+			item.Book = book
+			added = append(added, item)
+		}
+		return nil
+	})
+	if err != nil {
+		InternalErrorResponse(c, "Failed to add library items")
+		return
+	}
+
+	response := MonitorSeriesResponse{
+		SeriesID:         series.ID,
+		Added:            make([]*LibraryItemResponse, len(added)),
+		UnknownPositions: toSeriesWithBooksResponse(series).UnknownPositions,
+	}
+	for i := range added {
+		response.Added[i] = toLibraryItemResponse(&added[i])
+
+		s.events.Publish(events.LibraryItemAdded, events.LibraryItemPayload{
+			LibraryItemID: added[i].ID,
+			BookID:        added[i].BookID,
+			Status:        string(added[i].Status),
+		})
+	}
+
+	SuccessResponse(c, StatusOK, response)
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// createSeriesFixture creates a five book series with books at positions
+// 1, 2 and 4, where 1 is available, 2 is wanted and 4 is not in the library
+func createSeriesFixture(t *testing.T, db *gorm.DB) models.Series {
+	author := models.Author{Name: "Series Author"}
+	require.NoError(t, db.Create(&author).Error)
+
+	series := models.Series{Name: "Gap Series", TotalBooks: 5}
+	require.NoError(t, db.Create(&series).Error)
+
+	for _, pos := range []int{4, 1, 2} {
+		book := models.Book{
+			Title:    "Book " + strconv.Itoa(pos),
+			AuthorID: author.ID,
+		}
+		require.NoError(t, db.Create(&book).Error)
+		require.NoError(t, db.Create(&models.BookSeries{BookID: book.ID, SeriesID: series.ID, Position: strconv.Itoa(pos)}).Error)
+
+		status := models.LibraryItemStatus("")
+		switch pos {
+		case 1:
+			status = models.LibraryItemStatusAvailable
+		case 2:
+			status = models.LibraryItemStatusWanted
+		}
+		if status != "" {
+			require.NoError(t, db.Create(&models.LibraryItem{BookID: book.ID, Status: status, AddedDate: time.Now()}).Error)
+		}
+	}
+
+	return series
+}
+
+func TestGetSeries(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+	series := createSeriesFixture(t, db)
+
+	router := gin.New()
+	router.GET("/api/v1/series", server.getSeriesList)
+	router.GET("/api/v1/series/:id", server.getSeries)
+
+	t.Run("List series", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/series?search=Gap", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []SeriesResponseDetailed `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, "Gap Series", response.Data[0].Name)
+	})
+
+	t.Run("Get series with gaps", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/series/"+strconv.Itoa(int(series.ID)), nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data SeriesWithBooksResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		require.Len(t, response.Data.Books, 3)
+		assert.Equal(t, "Book 1", response.Data.Books[0].Title)
+		assert.Equal(t, "available", response.Data.Books[0].LibraryStatus)
+		assert.Equal(t, "Book 2", response.Data.Books[1].Title)
+		assert.Equal(t, "wanted", response.Data.Books[1].LibraryStatus)
+		assert.Equal(t, "Book 4", response.Data.Books[2].Title)
+		assert.Empty(t, response.Data.Books[2].LibraryStatus)
+
+		assert.Equal(t, []int{2, 3, 4, 5}, response.Data.MissingPositions)
+		assert.Equal(t, []int{3, 5}, response.Data.UnknownPositions)
+	})
+
+	t.Run("Fractional positions are not gaps", func(t *testing.T) {
+		book := models.Book{Title: "Book 2.5", AuthorID: 1}
+		require.NoError(t, db.Create(&book).Error)
+		require.NoError(t, db.Create(&models.BookSeries{BookID: book.ID, SeriesID: series.ID, Position: "2.5"}).Error)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/series/"+strconv.Itoa(int(series.ID)), nil)
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data SeriesWithBooksResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		require.Len(t, response.Data.Books, 4)
+		assert.Equal(t, "Book 2.5", response.Data.Books[2].Title)
+		assert.Equal(t, "2.5", response.Data.Books[2].Position)
+		assert.Equal(t, []int{3, 5}, response.Data.UnknownPositions)
+	})
+
+	t.Run("Get non-existent series", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/series/999", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestMissingPositions(t *testing.T) {
+	known := map[int]bool{1: true, 2: true, 4: true}
+
+	// The series length grows to the highest known position
+	assert.Equal(t, []int{3}, missingPositions(0, known, known))
+	assert.Equal(t, []int{3, 5}, missingPositions(5, known, known))
+	assert.Equal(t, []int{}, missingPositions(0, map[int]bool{}, map[int]bool{}))
+}
+
+func TestCreateUpdateDeleteSeries(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+
+	router := gin.New()
+	router.POST("/api/v1/series", server.createSeries)
+	router.PUT("/api/v1/series/:id", server.updateSeries)
+	router.DELETE("/api/v1/series/:id", server.deleteSeries)
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Create series", func(t *testing.T) {
+		w := send("POST", "/api/v1/series", CreateSeriesRequest{Name: "New Series", TotalBooks: 3})
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("Create duplicate series", func(t *testing.T) {
+		w := send("POST", "/api/v1/series", CreateSeriesRequest{Name: "New Series"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Create series with missing name", func(t *testing.T) {
+		w := send("POST", "/api/v1/series", map[string]interface{}{"total_books": 2})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("Update series", func(t *testing.T) {
+		total := 7
+		w := send("PUT", "/api/v1/series/1", UpdateSeriesRequest{TotalBooks: &total})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var series models.Series
+		db.First(&series, 1)
+		assert.Equal(t, 7, series.TotalBooks)
+	})
+
+	t.Run("Delete series with books", func(t *testing.T) {
+		author := models.Author{Name: "Author"}
+		db.Create(&author)
+		book := models.Book{Title: "In Series", AuthorID: author.ID}
+		db.Create(&book)
+		db.Create(&models.BookSeries{BookID: book.ID, SeriesID: 1, Position: "1"})
+
+		w := send("DELETE", "/api/v1/series/1", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Delete series whose books are deleted", func(t *testing.T) {
+		require.NoError(t, db.Where("title = ?", "In Series").Delete(&models.Book{}).Error)
+
+		w := send("DELETE", "/api/v1/series/1", nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Delete empty series", func(t *testing.T) {
+		series := models.Series{Name: "Empty Series"}
+		db.Create(&series)
+
+		w := send("DELETE", "/api/v1/series/"+strconv.Itoa(int(series.ID)), nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
+
+func TestMonitorSeries(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+	series := createSeriesFixture(t, db)
+
+	// Book 5 was in the library but the user removed it
+	removed := models.Book{Title: "Book 5", AuthorID: 1}
+	require.NoError(t, db.Create(&removed).Error)
+	require.NoError(t, db.Create(&models.BookSeries{BookID: removed.ID, SeriesID: series.ID, Position: "5"}).Error)
+	item := models.LibraryItem{BookID: removed.ID, Status: models.LibraryItemStatusWanted, AddedDate: time.Now()}
+	require.NoError(t, db.Create(&item).Error)
+	require.NoError(t, db.Delete(&item).Error)
+
+	router := gin.New()
+	router.POST("/api/v1/series/:id/monitor", server.monitorSeries)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/series/"+strconv.Itoa(int(series.ID))+"/monitor", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data MonitorSeriesResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data.Added, 1)
+	assert.Equal(t, "wanted", response.Data.Added[0].Status)
+	assert.Equal(t, "Book 4", response.Data.Added[0].Book.Title)
+	assert.Equal(t, []int{3}, response.Data.UnknownPositions)
+
+	var count int64
+	db.Model(&models.LibraryItem{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+}
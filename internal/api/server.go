@@ -2,20 +2,52 @@ package api
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"github.com/listenarr/listenarr/internal/auth"
 	"github.com/listenarr/listenarr/internal/config"
+	"github.com/listenarr/listenarr/internal/events"
+	"github.com/listenarr/listenarr/internal/services/backup"
+	"github.com/listenarr/listenarr/internal/services/download"
+	"github.com/listenarr/listenarr/internal/services/health"
+	"github.com/listenarr/listenarr/internal/services/history"
+	"github.com/listenarr/listenarr/internal/services/importer"
+	"github.com/listenarr/listenarr/internal/services/mediaserver"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+	"github.com/listenarr/listenarr/internal/services/monitor"
+	"github.com/listenarr/listenarr/internal/services/notification"
+	"github.com/listenarr/listenarr/internal/services/rescan"
+	"github.com/listenarr/listenarr/internal/services/scanner"
+	"github.com/listenarr/listenarr/internal/services/scheduler"
+	"github.com/listenarr/listenarr/internal/services/tagger"
+	"github.com/listenarr/listenarr/pkg/jackett"
+	"github.com/listenarr/listenarr/pkg/plex"
+	"github.com/listenarr/listenarr/pkg/qbit"
 )
 
 // Server represents the API server
 type Server struct {
-	config *config.Config
-	db     *gorm.DB
-	router *gin.Engine
+	config        *config.Config
+	db            *gorm.DB
+	router        *gin.Engine
+	events        *events.Bus
+	downloads     *download.Service
+	history       *history.Service
+	metadata      *metadata.Service
+	monitor       *monitor.Service
+	importer      *importer.Service
+	scanner       *scanner.Service
+	rescan        *rescan.Service
+	scheduler     *scheduler.Service
+	notifications *notification.Service
+	health        *health.Service
+	backups       *backup.Service
+	plex          *plex.Client // nil when Plex is not configured
 }
 
 // NewServer creates a new API server instance
@@ -27,17 +59,100 @@ func NewServer(cfg *config.Config, db *gorm.DB) *Server {
 
 	router := gin.Default()
 
+	// Only talk to qBittorrent and Jackett when they are configured
+	var torrentClient download.TorrentClient
+	var healthDownloadClient health.DownloadClient
+	if cfg.QBittorrent.URL != "" {
+		client := qbit.NewClient(cfg.QBittorrent.URL, cfg.QBittorrent.Username, cfg.QBittorrent.Password)
+		torrentClient = client
+		healthDownloadClient = client
+	}
+	var healthIndexer health.Indexer
+	if cfg.Jackett.URL != "" {
+		healthIndexer = jackett.NewClient(cfg.Jackett.URL, cfg.Jackett.APIKey)
+	}
+
+	// Media servers are told about every import
+	notifiers := mediaserver.NewNotifiersFromConfig(cfg)
+
+	bus := events.NewBus()
+	metadataService := metadata.NewServiceFromConfig(cfg.Metadata)
+
+	// Imported files are only tagged when it is enabled
+	var fileTagger importer.Tagger
+	if cfg.Processing.EmbedMetadata {
+		fileTagger = tagger.NewService(db, metadataService)
+	}
+
+	downloads := download.NewService(db, torrentClient, bus, &download.ServiceConfig{
+		Category:     "Listenarr",
+		PollInterval: cfg.QBittorrent.MonitorInterval,
+		DownloadPath: cfg.QBittorrent.DownloadPath,
+		MinFree:      megabytes(cfg.DiskSpace.MinFreeDownload),
+	})
+
+	imports := importer.NewService(db, fileTagger, notifiers, bus, importer.Config{
+		LibraryPath:    cfg.Library.Path,
+		TempPath:       cfg.Processing.TempPath,
+		MinFreeLibrary: megabytes(cfg.DiskSpace.MinFreeLibrary),
+		MinFreeTemp:    megabytes(cfg.DiskSpace.MinFreeTemp),
+	})
+
+	rescans := rescan.NewService(db, bus, rescan.Config{
+		LibraryPath:   cfg.Library.Path,
+		MissingAction: cfg.Library.MissingAction,
+	})
+
+	checks := health.NewService(db, bus, healthDownloadClient, healthIndexer, health.Config{
+		DownloadPath:    cfg.QBittorrent.DownloadPath,
+		LibraryPath:     cfg.Library.Path,
+		TempPath:        cfg.Processing.TempPath,
+		MinFreeDownload: megabytes(cfg.DiskSpace.MinFreeDownload),
+		MinFreeLibrary:  megabytes(cfg.DiskSpace.MinFreeLibrary),
+		MinFreeTemp:     megabytes(cfg.DiskSpace.MinFreeTemp),
+		Tools:           health.ProcessingTools,
+	})
+
+	backups := backup.NewService(db, backup.Config{
+		DatabasePath: cfg.Database.Path,
+		ConfigFile:   config.File(),
+		Folder:       cfg.Backup.Folder,
+		Retention:    cfg.Backup.Retention,
+	})
+
 	server := &Server{
-		config: cfg,
-		db:     db,
-		router: router,
+		config:        cfg,
+		db:            db,
+		router:        router,
+		events:        bus,
+		downloads:     downloads,
+		history:       history.NewService(db),
+		metadata:      metadataService,
+		monitor:       monitor.NewService(db, metadataService, bus),
+		importer:      imports,
+		scanner:       scanner.NewService(db, metadataService, imports),
+		rescan:        rescans,
+		scheduler:     scheduler.NewService(db),
+		notifications: notification.NewService(db, bus),
+		health:        checks,
+		backups:       backups,
+		plex:          mediaserver.FindPlexClient(notifiers),
 	}
 
+	server.registerTasks()
 	server.setupRoutes()
 
 	return server
 }
 
+// megabytes converts a threshold from the config to bytes
+func megabytes(n int64) uint64 {
+	if n <= 0 {
+		return 0
+	}
+	return uint64(n) << 20
+}
+
 // setupRoutes configures all API routes
 func (s *Server) setupRoutes() {
 	// Health check endpoint (no auth required)
@@ -56,6 +171,8 @@ func (s *Server) setupRoutes() {
 		v1.GET("/library/:id", s.getLibraryItem)
 		v1.POST("/library", s.addToLibrary)
 		v1.DELETE("/library/:id", s.removeFromLibrary)
+		v1.GET("/library/rescan", s.getLibraryRescan)
+		v1.POST("/library/rescan", s.rescanLibrary)
 
 		// Author routes
 		v1.GET("/authors", s.getAuthors)
@@ -63,6 +180,15 @@ func (s *Server) setupRoutes() {
 		v1.POST("/authors", s.createAuthor)
 		v1.PUT("/authors/:id", s.updateAuthor)
 		v1.DELETE("/authors/:id", s.deleteAuthor)
+		v1.POST("/authors/:id/refresh", s.refreshAuthorMetadata)
+		v1.POST("/authors/:id/bibliography/refresh", s.refreshAuthorBibliography)
+
+		// Narrator routes
+		v1.GET("/narrators", s.getNarrators)
+		v1.GET("/narrators/:id", s.getNarrator)
+		v1.POST("/narrators", s.createNarrator)
+		v1.PUT("/narrators/:id", s.updateNarrator)
+		v1.DELETE("/narrators/:id", s.deleteNarrator)
 
 		// Book routes
 		v1.GET("/books", s.getBooks)
@@ -70,6 +196,15 @@ func (s *Server) setupRoutes() {
 		v1.POST("/books", s.createBook)
 		v1.PUT("/books/:id", s.updateBook)
 		v1.DELETE("/books/:id", s.deleteBook)
+		v1.POST("/books/:id/refresh", s.refreshBookMetadata)
+
+		// Series routes
+		v1.GET("/series", s.getSeriesList)
+		v1.GET("/series/:id", s.getSeries)
+		v1.POST("/series", s.createSeries)
+		v1.PUT("/series/:id", s.updateSeries)
+		v1.DELETE("/series/:id", s.deleteSeries)
+		v1.POST("/series/:id/monitor", s.monitorSeries)
 
 		// Download routes
 		v1.GET("/downloads", s.getDownloads)
@@ -82,30 +217,167 @@ func (s *Server) setupRoutes() {
 		v1.GET("/processing/:id", s.getProcessingTask)
 		v1.POST("/processing/:id/retry", s.retryProcessingTask)
 
+		// Import routes
+		v1.POST("/import/scan", s.startImportScan)
+		v1.GET("/import/scan", s.getImportCandidates)
+		v1.GET("/import/scan/status", s.getImportScanStatus)
+		v1.POST("/import/scan/:id/confirm", s.confirmImportCandidate)
+		v1.POST("/import/scan/:id/skip", s.skipImportCandidate)
+		v1.GET("/import/manual", s.identifyManualImport)
+		v1.POST("/import/manual", s.manualImport)
+
+		// Notification routes
+		v1.GET("/notifications", s.getNotifications)
+		v1.GET("/notifications/:id", s.getNotification)
+		v1.POST("/notifications", s.createNotification)
+		v1.PUT("/notifications/:id", s.updateNotification)
+		v1.DELETE("/notifications/:id", s.deleteNotification)
+		v1.POST("/notifications/test", s.testNewNotification)
+		v1.POST("/notifications/:id/test", s.testNotification)
+
+		// History routes
+		v1.GET("/history", s.getHistory)
+
+		// Event stream (Server-Sent Events)
+		v1.GET("/events", s.streamEvents)
+
+		// Metadata routes
+		v1.GET("/metadata/search", s.searchMetadata)
+		v1.GET("/metadata/isbn/:isbn", s.lookupMetadataByISBN)
+		v1.GET("/metadata/asin/:asin", s.lookupMetadataByASIN)
+
+		// Plex routes
+		v1.GET("/plex/sections", s.getPlexSections)
+
 		// Search routes
 		v1.GET("/search", s.searchAudiobooks)
+
+		// System routes
+		v1.GET("/system/tasks", s.getSystemTasks)
+		v1.POST("/system/tasks/:name/run", s.runSystemTask)
+		v1.GET("/system/commands", s.getSystemCommands)
+		v1.GET("/system/health", s.getSystemHealth)
+		v1.GET("/system/diskspace", s.getSystemDiskSpace)
+		v1.GET("/system/backups", s.getBackups)
+		v1.POST("/system/backups", s.createBackup)
+		v1.POST("/system/backups/restore", s.restoreUploadedBackup)
+		v1.GET("/system/backups/:name", s.downloadBackup)
+		v1.DELETE("/system/backups/:name", s.deleteBackup)
+		v1.POST("/system/backups/:name/restore", s.restoreBackup)
 	}
 }
 
-// healthCheck returns the health status of the API
+// healthCheck returns the health status of the API. It only says the
+// process is alive, unless ?ready=true asks whether it can serve requests.
 func (s *Server) healthCheck(c *gin.Context) {
 	// Health check uses simple format (not standard API response format)
+	if ready, _ := strconv.ParseBool(c.Query("ready")); ready {
+		if err := s.health.Ready(); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "unavailable",
+				"service": "listenarr",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "healthy",
 		"service": "listenarr",
 	})
 }
 
-// Start starts the HTTP server
+// Start starts the background tasks and the HTTP server
 func (s *Server) Start() error {
+	s.startBackgroundTasks()
+
 	addr := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
 	return s.router.Run(addr)
 }
 
+// registerTasks adds the periodic jobs to the scheduler. A task with no
+// interval configured only runs when it is triggered.
+func (s *Server) registerTasks() {
+	tasks := []scheduler.Task{
+		{
+			Name:     scheduler.TaskRefreshAuthors,
+			Interval: s.config.Metadata.AuthorRefreshInterval,
+			Run: func() error {
+				s.monitor.RefreshMonitored()
+				return nil
+			},
+		},
+		{
+			Name:     scheduler.TaskMonitorDownloads,
+			Interval: s.config.QBittorrent.MonitorInterval,
+			Run:      s.downloads.MonitorDownloads,
+		},
+		{
+			Name:     scheduler.TaskProcessImports,
+			Interval: s.config.Processing.ImportInterval,
+			Run: func() error {
+				s.importer.ProcessPending()
+				return nil
+			},
+		},
+		{
+			Name:     scheduler.TaskLibraryRescan,
+			Interval: s.config.Library.RescanInterval,
+			Run: func() error {
+				result, err := s.rescan.Rescan()
+				if err != nil {
+					return err
+				}
+				log.Printf("rescan: %s", result.Summary())
+				return nil
+			},
+		},
+		{
+			Name:     scheduler.TaskHealthCheck,
+			Interval: s.config.Health.CheckInterval,
+			Run: func() error {
+				s.health.Check()
+				return nil
+			},
+		},
+		{
+			Name:     scheduler.TaskBackup,
+			Interval: s.config.Backup.Interval,
+			Run: func() error {
+				_, err := s.backups.Create(backup.TypeScheduled)
+				return err
+			},
+		},
+	}
+	for _, task := range tasks {
+		if err := s.scheduler.Register(task); err != nil {
+			log.Printf("scheduler: failed to register task %s: %v", task.Name, err)
+		}
+	}
+}
+
+// startBackgroundTasks launches the scheduler that runs the periodic jobs
+// alongside the API, and the notifications
+func (s *Server) startBackgroundTasks() {
+	go s.scheduler.Run(nil)
+	go s.notifications.Run(nil)
+}
+
 // All handlers are implemented in separate files:
 // - Library handlers: library.go
 // - Author handlers: authors.go
+// - Narrator handlers: narrators.go
 // - Book handlers: books.go
+// - Series handlers: series.go
 // - Download handlers: downloads.go
 // - Processing handlers: processing.go
+// - Import handlers: import.go
+// - Notification handlers: notifications.go
+// - Event stream handler: events.go
+// - History handler: history.go
+// - Metadata handlers: metadata.go
+// - Plex handlers: plex.go
 // - Search handler: search.go
+// - System handlers: system.go
+// - Backup handlers: backups.go
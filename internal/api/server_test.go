@@ -3,35 +3,42 @@ package api
 import (
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 
 	"github.com/listenarr/listenarr/internal/config"
+	"github.com/listenarr/listenarr/internal/database/dbtest"
 	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/health"
 )
 
 func setupTestServer(t *testing.T) (*Server, string) {
 	gin.SetMode(gin.TestMode)
 
 	// Create test database
-	testDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	require.NoError(t, err)
+	testDB := dbtest.Open(t)
 
 	// Migrate models
-	err = testDB.AutoMigrate(
+	err := testDB.AutoMigrate(
 		&models.Author{},
 		&models.Series{},
 		&models.Book{},
+		&models.BookSeries{},
+		&models.Narrator{},
+		&models.BookContributor{},
 		&models.Audiobook{},
 		&models.LibraryItem{},
 		&models.Release{},
 		&models.Download{},
 		&models.ProcessingTask{},
+		&models.History{},
+		&models.ImportCandidate{},
+		&models.ScheduledTask{},
+		&models.Notification{},
 	)
 	require.NoError(t, err)
 
@@ -61,6 +68,27 @@ func TestHealthCheck(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "healthy")
 	assert.Contains(t, w.Body.String(), "listenarr")
+
+	t.Run("Readiness", func(t *testing.T) {
+		server.config.Library.Path = t.TempDir()
+		server.health = health.NewService(server.db, nil, nil, nil, health.Config{LibraryPath: server.config.Library.Path})
+
+		req, _ := http.NewRequest("GET", "/api/health?ready=true", nil)
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		server.health = health.NewService(server.db, nil, nil, nil, health.Config{LibraryPath: filepath.Join(t.TempDir(), "unmounted")})
+		w = httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "library folder is not available")
+
+		req, _ = http.NewRequest("GET", "/api/health", nil)
+		w = httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "liveness does not check dependencies")
+	})
 }
 
 func TestGetLibrary_RequiresAuth(t *testing.T) {
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/scheduler"
+	"github.com/listenarr/listenarr/pkg/diskspace"
+)
+
+// ScheduledTaskResponse represents a scheduled task in API responses
+type ScheduledTaskResponse struct {
+	Name         string `json:"name"`
+	Interval     int    `json:"interval"` // Seconds; 0 when the task only runs when triggered
+	LastRunAt    string `json:"last_run_at,omitempty"`
+	NextRunAt    string `json:"next_run_at,omitempty"`
+	LastDuration int64  `json:"last_duration"` // Milliseconds
+	LastError    string `json:"last_error,omitempty"`
+	Running      bool   `json:"running"`
+}
+
+// DiskSpaceResponse represents the free space of a folder in API responses
+type DiskSpaceResponse struct {
+	Name    string `json:"name"` // download, temp or library
+	Path    string `json:"path"`
+	Free    uint64 `json:"free"`     // Bytes
+	Total   uint64 `json:"total"`    // Bytes
+	MinFree uint64 `json:"min_free"` // Bytes; 0 when there is no threshold
+	Low     bool   `json:"low"`      // Free space is below min_free
+	Error   string `json:"error,omitempty"`
+}
+
+// toScheduledTaskResponse converts a ScheduledTask model to API response format
+func toScheduledTaskResponse(task *models.ScheduledTask, running bool) *ScheduledTaskResponse {
+	response := &ScheduledTaskResponse{
+		Name:         task.Name,
+		Interval:     task.Interval,
+		LastDuration: task.LastDuration,
+		LastError:    task.LastError,
+		Running:      running,
+	}
+	if task.LastRunAt != nil {
+		response.LastRunAt = task.LastRunAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	if task.NextRunAt != nil {
+		response.NextRunAt = task.NextRunAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return response
+}
+
+// getSystemTasks handles GET /api/v1/system/tasks
+func (s *Server) getSystemTasks(c *gin.Context) {
+	tasks, err := s.scheduler.Tasks()
+	if err != nil {
+		InternalErrorResponse(c, "Failed to fetch scheduled tasks")
+		return
+	}
+
+	running := make(map[string]bool)
+	for _, command := range s.scheduler.Commands() {
+		if command.IsActive() {
+			running[command.Name] = true
+		}
+	}
+
+	responseData := make([]*ScheduledTaskResponse, len(tasks))
+	for i := range tasks {
+		responseData[i] = toScheduledTaskResponse(&tasks[i], running[tasks[i].Name])
+	}
+
+	SuccessResponse(c, StatusOK, responseData)
+}
+
+// runSystemTask handles POST /api/v1/system/tasks/:name/run
+func (s *Server) runSystemTask(c *gin.Context) {
+	command, err := s.scheduler.Trigger(c.Param("name"))
+	switch {
+	case errors.Is(err, scheduler.ErrTaskNotFound):
+		NotFoundResponse(c, "scheduled task")
+	case errors.Is(err, scheduler.ErrTaskRunning):
+		ConflictResponse(c, "Task is already running")
+	case err != nil:
+		InternalErrorResponse(c, "Failed to run task")
+	default:
+		SuccessResponse(c, StatusAccepted, command)
+	}
+}
+
+// getSystemCommands handles GET /api/v1/system/commands
+func (s *Server) getSystemCommands(c *gin.Context) {
+	commands := s.scheduler.Commands()
+	if status := c.Query("status"); status != "" {
+		filtered := make([]scheduler.Command, 0, len(commands))
+		for _, command := range commands {
+			if string(command.Status) == status {
+				filtered = append(filtered, command)
+			}
+		}
+		commands = filtered
+	}
+
+	SuccessResponse(c, StatusOK, commands)
+}
+
+// getSystemHealth handles GET /api/v1/system/health. The checks run when
+// they never ran before or ?refresh=true asks for it, otherwise the report
+// of the last scheduled run is returned.
+func (s *Server) getSystemHealth(c *gin.Context) {
+	report := s.health.Last()
+	if refresh, _ := strconv.ParseBool(c.Query("refresh")); refresh || report == nil {
+		report = s.health.Check()
+	}
+
+	SuccessResponse(c, StatusOK, report)
+}
+
+// getSystemDiskSpace handles GET /api/v1/system/diskspace
+func (s *Server) getSystemDiskSpace(c *gin.Context) {
+	folders := []DiskSpaceResponse{
+		{Name: "download", Path: s.config.QBittorrent.DownloadPath, MinFree: megabytes(s.config.DiskSpace.MinFreeDownload)},
+		{Name: "temp", Path: s.config.Processing.TempPath, MinFree: megabytes(s.config.DiskSpace.MinFreeTemp)},
+		{Name: "library", Path: s.config.Library.Path, MinFree: megabytes(s.config.DiskSpace.MinFreeLibrary)},
+	}
+
+	responseData := make([]DiskSpaceResponse, 0, len(folders))
+	for _, folder := range folders {
+		if folder.Path == "" {
+			continue
+		}
+		usage, err := diskspace.Get(folder.Path)
+		if err != nil {
+			folder.Error = err.Error()
+		} else {
+			folder.Free = usage.Free
+			folder.Total = usage.Total
+			folder.Low = usage.Free < folder.MinFree
+		}
+		responseData = append(responseData, folder)
+	}
+
+	SuccessResponse(c, StatusOK, responseData)
+}
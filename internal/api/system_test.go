@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/internal/services/health"
+	"github.com/listenarr/listenarr/internal/services/scheduler"
+)
+
+func TestSystemTasks(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+
+	router := gin.New()
+	router.GET("/api/v1/system/tasks", server.getSystemTasks)
+	router.POST("/api/v1/system/tasks/:name/run", server.runSystemTask)
+	router.GET("/api/v1/system/commands", server.getSystemCommands)
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("List tasks", func(t *testing.T) {
+		w := request("GET", "/api/v1/system/tasks")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []ScheduledTaskResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 6)
+		assert.Equal(t, scheduler.TaskBackup, response.Data[0].Name)
+		assert.Equal(t, scheduler.TaskHealthCheck, response.Data[1].Name)
+		assert.Equal(t, scheduler.TaskLibraryRescan, response.Data[2].Name)
+		assert.Equal(t, scheduler.TaskMonitorDownloads, response.Data[3].Name)
+		assert.Equal(t, scheduler.TaskProcessImports, response.Data[4].Name)
+		assert.Equal(t, scheduler.TaskRefreshAuthors, response.Data[5].Name)
+		assert.Empty(t, response.Data[0].NextRunAt, "no interval configured")
+	})
+
+	t.Run("Run task", func(t *testing.T) {
+		release := make(chan struct{})
+		server.scheduler = scheduler.NewService(db)
+		require.NoError(t, server.scheduler.Register(scheduler.Task{
+			Name: scheduler.TaskLibraryRescan,
+			Run:  func() error { <-release; return nil },
+		}))
+
+		w := request("POST", "/api/v1/system/tasks/library_rescan/run")
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Contains(t, w.Body.String(), `"trigger":"manual"`)
+
+		w = request("POST", "/api/v1/system/tasks/library_rescan/run")
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = request("GET", "/api/v1/system/tasks")
+		assert.Contains(t, w.Body.String(), `"running":true`)
+
+		w = request("GET", "/api/v1/system/commands?status=completed")
+		assert.Contains(t, w.Body.String(), `"data":[]`)
+
+		close(release)
+		server.scheduler.Wait()
+
+		w = request("GET", "/api/v1/system/commands?status=completed")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"library_rescan"`)
+	})
+
+	t.Run("Unknown task", func(t *testing.T) {
+		w := request("POST", "/api/v1/system/tasks/unknown/run")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSystemHealth(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+	server.health = health.NewService(db, nil, nil, nil, health.Config{LibraryPath: t.TempDir(), TempPath: t.TempDir()})
+
+	router := gin.New()
+	router.GET("/api/v1/system/health", server.getSystemHealth)
+
+	request := func(path string) health.Report {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data health.Report `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Data
+	}
+
+	report := request("/api/v1/system/health")
+	assert.Equal(t, health.StatusWarning, report.Status, "no download client or indexer proxy configured")
+	checks := make(map[string]health.Result)
+	for _, result := range report.Checks {
+		checks[result.Name] = result
+	}
+	assert.Equal(t, health.StatusOK, checks[health.CheckDatabase].Status)
+	assert.Equal(t, health.StatusOK, checks[health.CheckLibraryPath].Status)
+	assert.Equal(t, health.StatusWarning, checks[health.CheckDownloadClient].Status)
+	assert.Equal(t, "No download client is configured", checks[health.CheckDownloadClient].Message)
+
+	again := request("/api/v1/system/health")
+	assert.True(t, again.CheckedAt.Equal(report.CheckedAt), "the last report is reused")
+
+	refreshed := request("/api/v1/system/health?refresh=true")
+	assert.True(t, refreshed.CheckedAt.After(report.CheckedAt))
+}
+
+func TestSystemDiskSpace(t *testing.T) {
+	db := setupTestDB(t)
+	server := setupLibraryTestServer(db)
+	server.config.Library.Path = t.TempDir()
+	server.config.Processing.TempPath = filepath.Join(t.TempDir(), "missing")
+	server.config.DiskSpace.MinFreeLibrary = 1 << 40
+
+	router := gin.New()
+	router.GET("/api/v1/system/diskspace", server.getSystemDiskSpace)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/system/diskspace", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data []DiskSpaceResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data, 2, "no download folder configured")
+
+	temp := response.Data[0]
+	assert.Equal(t, "temp", temp.Name)
+	assert.Contains(t, temp.Error, "failed to read free space")
+
+	library := response.Data[1]
+	assert.Equal(t, "library", library.Name)
+	assert.Equal(t, server.config.Library.Path, library.Path)
+	assert.NotZero(t, library.Total)
+	assert.Equal(t, uint64(1<<60), library.MinFree)
+	assert.True(t, library.Low)
+}
@@ -4,20 +4,26 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server      ServerConfig      `mapstructure:"server"`
-	Database    DatabaseConfig    `mapstructure:"database"`
-	Auth        AuthConfig        `mapstructure:"auth"`
-	QBittorrent QBittorrentConfig `mapstructure:"qbittorrent"`
-	Jackett     JackettConfig     `mapstructure:"jackett"`
-	Plex        PlexConfig        `mapstructure:"plex"`
-	Library     LibraryConfig     `mapstructure:"library"`
-	Processing  ProcessingConfig  `mapstructure:"processing"`
+	Server       ServerConfig        `mapstructure:"server"`
+	Database     DatabaseConfig      `mapstructure:"database"`
+	Auth         AuthConfig          `mapstructure:"auth"`
+	QBittorrent  QBittorrentConfig   `mapstructure:"qbittorrent"`
+	Jackett      JackettConfig       `mapstructure:"jackett"`
+	Plex         PlexConfig          `mapstructure:"plex"`
+	MediaServers []MediaServerConfig `mapstructure:"media_servers"`
+	Library      LibraryConfig       `mapstructure:"library"`
+	Processing   ProcessingConfig    `mapstructure:"processing"`
+	Metadata     MetadataConfig      `mapstructure:"metadata"`
+	Health       HealthConfig        `mapstructure:"health"`
+	DiskSpace    DiskSpaceConfig     `mapstructure:"disk_space"`
+	Backup       BackupConfig        `mapstructure:"backup"`
 }
 
 // ServerConfig holds server configuration
@@ -28,7 +34,13 @@ type ServerConfig struct {
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	Path string `mapstructure:"path"`
+	Driver   string `mapstructure:"driver"`    // sqlite or postgres
+	Path     string `mapstructure:"path"`      // SQLite database file
+	DSN      string `mapstructure:"dsn"`       // PostgreSQL connection string
+	LogLevel string `mapstructure:"log_level"` // silent, error, warn or info
+
+	// How long SQLite waits for a lock held by another process
+	BusyTimeout time.Duration `mapstructure:"busy_timeout"`
 }
 
 // AuthConfig holds authentication configuration
@@ -42,6 +54,13 @@ type QBittorrentConfig struct {
 	URL      string `mapstructure:"url"`
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
+
+	// Folder qBittorrent saves downloads in, as Listenarr sees it; only used
+	// to check free space, so it may be left empty
+	DownloadPath string `mapstructure:"download_path"`
+
+	// How often active downloads are checked for progress and completion; 0 disables it
+	MonitorInterval time.Duration `mapstructure:"monitor_interval"`
 }
 
 // JackettConfig holds Jackett configuration
@@ -52,18 +71,77 @@ type JackettConfig struct {
 
 // PlexConfig holds Plex configuration
 type PlexConfig struct {
-	URL   string `mapstructure:"url"`
-	Token string `mapstructure:"token"`
+	URL     string `mapstructure:"url"`
+	Token   string `mapstructure:"token"`
+	Section string `mapstructure:"section"` // Library section key to scan; empty picks the section containing the imported folder
+}
+
+// MediaServerConfig holds the configuration of a media server that is told
+// about imported audiobooks
+type MediaServerConfig struct {
+	Type    string `mapstructure:"type"` // plex, audiobookshelf or jellyfin
+	Name    string `mapstructure:"name"` // Shown in history; defaults to the type
+	URL     string `mapstructure:"url"`
+	Token   string `mapstructure:"token"`   // Plex token, Audiobookshelf API token or Jellyfin API key
+	Library string `mapstructure:"library"` // Plex section key or Audiobookshelf library ID; empty picks the one containing the folder
 }
 
 // LibraryConfig holds library configuration
 type LibraryConfig struct {
 	Path string `mapstructure:"path"`
+
+	// How often available items are checked against the files on disk; 0 disables it
+	RescanInterval time.Duration `mapstructure:"rescan_interval"`
+
+	// What happens to items whose files are gone: "missing" marks them
+	// missing, "wanted" puts them back on the wanted list
+	MissingAction string `mapstructure:"missing_action"`
 }
 
 // ProcessingConfig holds processing configuration
 type ProcessingConfig struct {
 	TempPath string `mapstructure:"temp_path"`
+
+	// How often pending processing tasks are imported into the library; 0 disables it
+	ImportInterval time.Duration `mapstructure:"import_interval"`
+
+	// Write book metadata, cover art and chapters into imported M4B/M4A files
+	EmbedMetadata bool `mapstructure:"embed_metadata"`
+}
+
+// MetadataConfig holds metadata provider configuration
+type MetadataConfig struct {
+	Providers         []string `mapstructure:"providers"` // Lookup order: audnexus, googlebooks, openlibrary
+	GoogleBooksAPIKey string   `mapstructure:"google_books_api_key"`
+	AudnexusRegion    string   `mapstructure:"audnexus_region"`
+
+	// How often monitored authors' bibliographies are refreshed; 0 disables it
+	AuthorRefreshInterval time.Duration `mapstructure:"author_refresh_interval"`
+}
+
+// HealthConfig holds health check configuration
+type HealthConfig struct {
+	// How often the health checks run; 0 disables it
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+}
+
+// DiskSpaceConfig holds the free space each path must keep, in megabytes;
+// 0 disables the threshold
+type DiskSpaceConfig struct {
+	MinFreeDownload int64 `mapstructure:"min_free_download"`
+	MinFreeLibrary  int64 `mapstructure:"min_free_library"`
+	MinFreeTemp     int64 `mapstructure:"min_free_temp"`
+}
+
+// BackupConfig holds backup configuration
+type BackupConfig struct {
+	Folder string `mapstructure:"folder"`
+
+	// How often a backup is taken; 0 disables it
+	Interval time.Duration `mapstructure:"interval"`
+
+	// How many scheduled backups are kept; older ones are deleted
+	Retention int `mapstructure:"retention"`
 }
 
 // Load loads configuration from file and environment variables
@@ -72,13 +150,16 @@ func Load() (*Config, error) {
 	viper.SetConfigType("yaml")
 
 	// Set default config path
-	configPath := os.Getenv("CONFIG_PATH")
-	if configPath == "" {
-		configPath = "./config"
-	}
+	configPath := configDir()
 	viper.AddConfigPath(configPath)
 	viper.AddConfigPath(".")
 
+	// Swap in the database and config file a restore staged before either
+	// is read
+	if err := ApplyRestore(findConfigFile(configPath)); err != nil {
+		return nil, fmt.Errorf("error applying restore: %w", err)
+	}
+
 	// Set defaults
 	setDefaults()
 
@@ -121,22 +202,27 @@ func setDefaults() {
 	viper.SetDefault("server.port", 8686)
 
 	// Database defaults
-	configPath := os.Getenv("CONFIG_PATH")
-	if configPath == "" {
-		configPath = "./config"
-	}
+	configPath := configDir()
+	viper.SetDefault("database.driver", "sqlite")
 	viper.SetDefault("database.path", filepath.Join(configPath, "listenarr.db"))
+	viper.SetDefault("database.log_level", "warn")
+	viper.SetDefault("database.busy_timeout", 5*time.Second)
 
 	// Auth defaults
 	viper.SetDefault("auth.enabled", true)
 	// API key will be generated if not set
 
+	// qBittorrent defaults
+	viper.SetDefault("qbittorrent.monitor_interval", 30*time.Second)
+
 	// Library defaults
 	libraryPath := os.Getenv("LIBRARY_PATH")
 	if libraryPath == "" {
 		libraryPath = "./library"
 	}
 	viper.SetDefault("library.path", libraryPath)
+	viper.SetDefault("library.rescan_interval", 12*time.Hour)
+	viper.SetDefault("library.missing_action", "missing")
 
 	// Processing defaults
 	processingPath := os.Getenv("PROCESSING_PATH")
@@ -144,4 +230,53 @@ func setDefaults() {
 		processingPath = "./processing"
 	}
 	viper.SetDefault("processing.temp_path", processingPath)
+	viper.SetDefault("processing.import_interval", time.Minute)
+	viper.SetDefault("processing.embed_metadata", true)
+
+	// Metadata defaults
+	viper.SetDefault("metadata.providers", []string{"audnexus", "googlebooks", "openlibrary"})
+	viper.SetDefault("metadata.audnexus_region", "us")
+	viper.SetDefault("metadata.author_refresh_interval", 24*time.Hour)
+
+	// Health defaults
+	viper.SetDefault("health.check_interval", 30*time.Minute)
+
+	// Disk space defaults
+	viper.SetDefault("disk_space.min_free_download", 1024)
+	viper.SetDefault("disk_space.min_free_library", 1024)
+	viper.SetDefault("disk_space.min_free_temp", 2048)
+
+	// Backup defaults
+	viper.SetDefault("backup.folder", filepath.Join(configPath, "backups"))
+	viper.SetDefault("backup.interval", 7*24*time.Hour)
+	viper.SetDefault("backup.retention", 7)
+}
+
+// File returns the path of the config file in use, or of the one that would
+// be written when none exists yet
+func File() string {
+	if file := viper.ConfigFileUsed(); file != "" {
+		return file
+	}
+	return filepath.Join(configDir(), "config.yml")
+}
+
+// configDir returns the folder of the config file, CONFIG_PATH or ./config
+func configDir() string {
+	if configPath := os.Getenv("CONFIG_PATH"); configPath != "" {
+		return configPath
+	}
+	return "./config"
+}
+
+// findConfigFile returns the config file Load reads: the one in configPath,
+// else the one in the working directory, else the one that would be written
+func findConfigFile(configPath string) string {
+	for _, dir := range []string{configPath, "."} {
+		file := filepath.Join(dir, "config.yml")
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+	return filepath.Join(configPath, "config.yml")
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,7 +26,25 @@ func TestLoad_Defaults(t *testing.T) {
 	assert.Equal(t, "0.0.0.0", cfg.Server.Host)
 	assert.Equal(t, 8686, cfg.Server.Port)
 	assert.True(t, cfg.Auth.Enabled)
+	assert.Equal(t, "sqlite", cfg.Database.Driver)
+	assert.Equal(t, filepath.Join(testConfigPath, "listenarr.db"), cfg.Database.Path)
+	assert.Equal(t, "warn", cfg.Database.LogLevel)
+	assert.Equal(t, 5*time.Second, cfg.Database.BusyTimeout)
 	assert.NotEmpty(t, cfg.Auth.APIKey)
+	assert.Equal(t, []string{"audnexus", "googlebooks", "openlibrary"}, cfg.Metadata.Providers)
+	assert.Equal(t, 24*time.Hour, cfg.Metadata.AuthorRefreshInterval)
+	assert.Equal(t, time.Minute, cfg.Processing.ImportInterval)
+	assert.Equal(t, 30*time.Second, cfg.QBittorrent.MonitorInterval)
+	assert.True(t, cfg.Processing.EmbedMetadata)
+	assert.Equal(t, 12*time.Hour, cfg.Library.RescanInterval)
+	assert.Equal(t, "missing", cfg.Library.MissingAction)
+	assert.Equal(t, 30*time.Minute, cfg.Health.CheckInterval)
+	assert.Equal(t, int64(1024), cfg.DiskSpace.MinFreeDownload)
+	assert.Equal(t, int64(1024), cfg.DiskSpace.MinFreeLibrary)
+	assert.Equal(t, int64(2048), cfg.DiskSpace.MinFreeTemp)
+	assert.Equal(t, filepath.Join(testConfigPath, "backups"), cfg.Backup.Folder)
+	assert.Equal(t, 7*24*time.Hour, cfg.Backup.Interval)
+	assert.Equal(t, 7, cfg.Backup.Retention)
 }
 
 func TestLoad_EnvironmentVariables(t *testing.T) {
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+// RestoreSuffix is appended to the path of a file staged by a restore. The
+// staged database and config file replace the current ones together the next
+// time Listenarr starts.
+const RestoreSuffix = ".restore"
+
+// ApplyRestore swaps in the database and config file a restore staged, if
+// any. The staged database is found next to the database of the current
+// config file, where the backup service staged it, and replaces the database
+// the restored config file names, so a restore that changes database.path is
+// not lost. The replaced files are kept next to them with a .pre-restore
+// suffix, the database along with its write-ahead log, which may hold its
+// latest changes and must not be replayed into the restored database.
+func ApplyRestore(configFile string) error {
+	currentPath, err := databasePath(configFile)
+	if err != nil {
+		return err
+	}
+	stagedDB := currentPath + RestoreSuffix
+	stagedConfig := configFile + RestoreSuffix
+
+	hasDB, err := exists(stagedDB)
+	if err != nil {
+		return err
+	}
+	hasConfig, err := exists(stagedConfig)
+	if err != nil {
+		return err
+	}
+	if !hasDB && !hasConfig {
+		return nil
+	}
+
+	dbPath := currentPath
+	if hasConfig {
+		if dbPath, err = databasePath(stagedConfig); err != nil {
+			return fmt.Errorf("staged config file is not valid: %w", err)
+		}
+		if err := replace(configFile, stagedConfig); err != nil {
+			return err
+		}
+		log.Printf("config: restored %s", configFile)
+	}
+
+	if hasDB {
+		for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+			err := os.Rename(dbPath+suffix, dbPath+".pre-restore"+suffix)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(stagedDB, dbPath); err != nil {
+			return err
+		}
+		log.Printf("config: restored database %s", dbPath)
+	}
+	return nil
+}
+
+// databasePath returns the SQLite database path a config file names, or the
+// default when the file does not exist or does not set one
+func databasePath(file string) (string, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	v.SetDefault("database.path", filepath.Join(configDir(), "listenarr.db"))
+	if ok, err := exists(file); err != nil {
+		return "", err
+	} else if ok {
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return "", err
+		}
+	}
+	return v.GetString("database.path"), nil
+}
+
+// replace moves staged over path, keeping path with a .pre-restore suffix
+func replace(path, staged string) error {
+	if err := os.Rename(path, path+".pre-restore"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(staged, path)
+}
+
+// exists returns true if there is a file at path
+func exists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyRestore(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yml")
+	current := filepath.Join(dir, "current.db")
+	moved := filepath.Join(dir, "data", "restored.db")
+
+	t.Run("Nothing staged", func(t *testing.T) {
+		require.NoError(t, ApplyRestore(configFile))
+		assert.NoFileExists(t, configFile)
+	})
+
+	require.NoError(t, os.WriteFile(configFile, []byte("database:\n  path: "+current+"\n"), 0644))
+	require.NoError(t, os.WriteFile(current, []byte("current"), 0644))
+	require.NoError(t, os.WriteFile(current+"-wal", []byte("current log"), 0644))
+
+	// The restored config moves the database, the staged one is next to the current
+	require.NoError(t, os.WriteFile(configFile+RestoreSuffix, []byte("database:\n  path: "+moved+"\n"), 0644))
+	require.NoError(t, os.WriteFile(current+RestoreSuffix, []byte("restored"), 0644))
+
+	require.NoError(t, ApplyRestore(configFile))
+
+	data, err := os.ReadFile(moved)
+	require.NoError(t, err)
+	assert.Equal(t, "restored", string(data))
+	data, err = os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), moved)
+	assert.FileExists(t, configFile+".pre-restore")
+	assert.NoFileExists(t, configFile+RestoreSuffix)
+	assert.NoFileExists(t, current+RestoreSuffix)
+
+	// The database the restored config does not name is left alone
+	assert.FileExists(t, current)
+	assert.FileExists(t, current+"-wal")
+}
+
+func TestApplyRestore_DatabaseOnly(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yml")
+	dbPath := filepath.Join(dir, "listenarr.db")
+	require.NoError(t, os.WriteFile(configFile, []byte("database:\n  path: "+dbPath+"\n"), 0644))
+	require.NoError(t, os.WriteFile(dbPath, []byte("current"), 0644))
+	require.NoError(t, os.WriteFile(dbPath+"-wal", []byte("current log"), 0644))
+	require.NoError(t, os.WriteFile(dbPath+RestoreSuffix, []byte("restored"), 0644))
+
+	require.NoError(t, ApplyRestore(configFile))
+
+	data, err := os.ReadFile(dbPath)
+	require.NoError(t, err)
+	assert.Equal(t, "restored", string(data))
+	assert.FileExists(t, dbPath+".pre-restore")
+	assert.FileExists(t, dbPath+".pre-restore-wal", "the log of the replaced database is not replayed")
+	assert.NoFileExists(t, dbPath+"-wal")
+	assert.NoFileExists(t, configFile+".pre-restore")
+}
+
+func TestLoad_AppliesStagedRestore(t *testing.T) {
+	testConfigPath := t.TempDir()
+	os.Setenv("CONFIG_PATH", testConfigPath)
+	defer os.Unsetenv("CONFIG_PATH")
+
+	configFile := filepath.Join(testConfigPath, "config.yml")
+	require.NoError(t, os.WriteFile(configFile, []byte("library:\n  path: /current\n"), 0644))
+	require.NoError(t, os.WriteFile(configFile+RestoreSuffix, []byte("library:\n  path: /restored\n"), 0644))
+	dbPath := filepath.Join(testConfigPath, "listenarr.db")
+	require.NoError(t, os.WriteFile(dbPath+RestoreSuffix, []byte("restored"), 0644))
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/restored", cfg.Library.Path)
+	assert.FileExists(t, dbPath)
+	assert.NoFileExists(t, dbPath+RestoreSuffix)
+}
@@ -0,0 +1,355 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The baseline schema is the models as they were when versioned migrations
+// were introduced, copied so that later changes to the models do not change
+// what migration 1 creates. Never edit these; change the schema with a new
+// migration instead. Table and field names match the models, which keeps the
+// index and foreign key names GORM derives from them the same.
+
+type baselineAuthor struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Name        string `gorm:"not null;index"`
+	Biography   string `gorm:"type:text"`
+	ImageURL    string
+	GoodreadsID string `gorm:"index"`
+
+	Monitored       bool   `gorm:"index;default:false"`
+	MonitorOption   string `gorm:"default:'none'"`
+	MonitoredSince  *time.Time
+	LastRefreshedAt *time.Time
+
+	Books []baselineBook `gorm:"foreignKey:AuthorID"`
+}
+
+func (baselineAuthor) TableName() string { return "authors" }
+
+type baselineSeries struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Name        string `gorm:"not null;index"`
+	Description string `gorm:"type:text"`
+	TotalBooks  int
+
+	Books []baselineBookSeries `gorm:"foreignKey:SeriesID"`
+}
+
+func (baselineSeries) TableName() string { return "series" }
+
+type baselineBook struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Title       string `gorm:"not null;index"`
+	ISBN        string `gorm:"index"`
+	ASIN        string `gorm:"index"`
+	Description string `gorm:"type:text"`
+	CoverArtURL string
+	ReleaseDate *time.Time
+	Genre       string
+	Language    string
+
+	AuthorID uint           `gorm:"not null;index"`
+	Author   baselineAuthor `gorm:"foreignKey:AuthorID"`
+
+	SeriesMemberships []baselineBookSeries      `gorm:"foreignKey:BookID"`
+	Contributors      []baselineBookContributor `gorm:"foreignKey:BookID"`
+
+	Audiobook    *baselineAudiobook    `gorm:"foreignKey:BookID"`
+	Releases     []baselineRelease     `gorm:"foreignKey:BookID"`
+	LibraryItems []baselineLibraryItem `gorm:"foreignKey:BookID"`
+}
+
+func (baselineBook) TableName() string { return "books" }
+
+type baselineBookSeries struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	BookID   uint           `gorm:"not null;uniqueIndex:idx_book_series_book_series"`
+	Book     baselineBook   `gorm:"foreignKey:BookID"`
+	SeriesID uint           `gorm:"not null;uniqueIndex:idx_book_series_book_series;index"`
+	Series   baselineSeries `gorm:"foreignKey:SeriesID"`
+
+	Position string
+	Sequence *float64
+}
+
+func (baselineBookSeries) TableName() string { return "book_series" }
+
+type baselineNarrator struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Name      string `gorm:"not null;index"`
+	Biography string `gorm:"type:text"`
+	ImageURL  string
+
+	Credits []baselineBookContributor `gorm:"foreignKey:NarratorID"`
+}
+
+func (baselineNarrator) TableName() string { return "narrators" }
+
+type baselineBookContributor struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	BookID uint         `gorm:"not null;index"`
+	Book   baselineBook `gorm:"foreignKey:BookID"`
+	Role   string       `gorm:"not null;index"`
+
+	AuthorID   *uint             `gorm:"index"`
+	Author     *baselineAuthor   `gorm:"foreignKey:AuthorID"`
+	NarratorID *uint             `gorm:"index"`
+	Narrator   *baselineNarrator `gorm:"foreignKey:NarratorID"`
+}
+
+func (baselineBookContributor) TableName() string { return "book_contributors" }
+
+type baselineAudiobook struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	BookID uint         `gorm:"not null;uniqueIndex;index"`
+	Book   baselineBook `gorm:"foreignKey:BookID"`
+
+	Narrator  string
+	Publisher string
+	Duration  int
+	Format    string
+	Bitrate   int
+	Language  string
+	ASIN      string `gorm:"index"`
+}
+
+func (baselineAudiobook) TableName() string { return "audiobooks" }
+
+type baselineRelease struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	BookID uint         `gorm:"not null;index"`
+	Book   baselineBook `gorm:"foreignKey:BookID"`
+
+	Title       string
+	Quality     string
+	Format      string
+	Size        int64
+	Indexer     string
+	IndexerID   string `gorm:"index"`
+	MagnetURL   string `gorm:"type:text"`
+	TorrentURL  string `gorm:"type:text"`
+	TorrentHash string `gorm:"index"`
+	Seeders     int
+	Leechers    int
+	PublishedAt *time.Time
+	Blocklisted bool `gorm:"index;default:false"`
+}
+
+func (baselineRelease) TableName() string { return "releases" }
+
+type baselineLibraryItem struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	BookID uint         `gorm:"not null;index"`
+	Book   baselineBook `gorm:"foreignKey:BookID"`
+
+	Status        string `gorm:"not null;index;default:'wanted'"`
+	FilePath      string `gorm:"type:text"`
+	FileSize      int64
+	AddedDate     time.Time `gorm:"not null"`
+	CompletedDate *time.Time
+
+	Downloads       []baselineDownload       `gorm:"foreignKey:LibraryItemID"`
+	ProcessingTasks []baselineProcessingTask `gorm:"foreignKey:DownloadID"`
+}
+
+func (baselineLibraryItem) TableName() string { return "library_items" }
+
+type baselineDownload struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	LibraryItemID uint                `gorm:"not null;index"`
+	LibraryItem   baselineLibraryItem `gorm:"foreignKey:LibraryItemID"`
+	ReleaseID     uint                `gorm:"not null;index"`
+	Release       baselineRelease     `gorm:"foreignKey:ReleaseID"`
+
+	Status          string  `gorm:"not null;index;default:'queued'"`
+	Progress        float64 `gorm:"default:0"`
+	Speed           int64
+	Size            int64
+	Downloaded      int64
+	Error           string `gorm:"type:text"`
+	QBittorrentHash string `gorm:"index"`
+	DownloadPath    string `gorm:"type:text"`
+	CompletedAt     *time.Time
+}
+
+func (baselineDownload) TableName() string { return "downloads" }
+
+type baselineProcessingTask struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	DownloadID uint             `gorm:"not null;index"`
+	Download   baselineDownload `gorm:"foreignKey:DownloadID"`
+
+	Status      string  `gorm:"not null;index;default:'pending'"`
+	Progress    float64 `gorm:"default:0"`
+	InputPath   string  `gorm:"type:text;not null"`
+	OutputPath  string  `gorm:"type:text"`
+	Error       string  `gorm:"type:text"`
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+}
+
+func (baselineProcessingTask) TableName() string { return "processing_tasks" }
+
+type baselineHistory struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	EventType string    `gorm:"not null;index"`
+	Date      time.Time `gorm:"not null;index"`
+	Message   string    `gorm:"type:text"`
+
+	BookID        *uint           `gorm:"index"`
+	Book          *baselineBook   `gorm:"foreignKey:BookID"`
+	AuthorID      *uint           `gorm:"index"`
+	Author        *baselineAuthor `gorm:"foreignKey:AuthorID"`
+	LibraryItemID *uint           `gorm:"index"`
+	DownloadID    *uint           `gorm:"index"`
+
+	ReleaseTitle   string
+	Indexer        string
+	DownloadClient string
+	DownloadHash   string `gorm:"index"`
+	Quality        string
+
+	SourcePath      string `gorm:"type:text"`
+	DestinationPath string `gorm:"type:text"`
+}
+
+func (baselineHistory) TableName() string { return "history" }
+
+type baselineImportCandidate struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Path  string `gorm:"type:text;not null;uniqueIndex"`
+	Files int
+	Size  int64
+
+	Title    string
+	Author   string
+	Narrator string
+	Series   string
+	Sequence string
+	ASIN     string
+	Format   string
+	Duration int
+	Bitrate  int
+
+	BookID        *uint `gorm:"index"`
+	MatchSource   string
+	Metadata      string `gorm:"type:text"`
+	Status        string `gorm:"not null;index;default:'pending'"`
+	LibraryItemID *uint
+	Error         string `gorm:"type:text"`
+}
+
+func (baselineImportCandidate) TableName() string { return "import_candidates" }
+
+type baselineScheduledTask struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Name         string `gorm:"not null;uniqueIndex"`
+	Interval     int    `gorm:"not null;default:0"`
+	LastRunAt    *time.Time
+	NextRunAt    *time.Time `gorm:"index"`
+	LastDuration int64
+	LastError    string `gorm:"type:text"`
+}
+
+func (baselineScheduledTask) TableName() string { return "scheduled_tasks" }
+
+type baselineNotification struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Name    string `gorm:"not null"`
+	Type    string `gorm:"not null"`
+	Enabled bool   `gorm:"not null"`
+
+	OnGrab        bool `gorm:"not null"`
+	OnImport      bool `gorm:"not null"`
+	OnUpgrade     bool `gorm:"not null"`
+	OnDelete      bool `gorm:"not null;default:false"`
+	OnFailure     bool `gorm:"not null"`
+	OnHealthIssue bool `gorm:"not null"`
+
+	Settings string `gorm:"type:text"`
+}
+
+func (baselineNotification) TableName() string { return "notifications" }
+
+// baselineModels returns the baseline schema, in the order its tables are
+// created
+func baselineModels() []interface{} {
+	return []interface{}{
+		&baselineAuthor{},
+		&baselineSeries{},
+		&baselineBook{},
+		&baselineBookSeries{},
+		&baselineNarrator{},
+		&baselineBookContributor{},
+		&baselineAudiobook{},
+		&baselineRelease{},
+		&baselineLibraryItem{},
+		&baselineDownload{},
+		&baselineProcessingTask{},
+		&baselineHistory{},
+		&baselineImportCandidate{},
+		&baselineScheduledTask{},
+		&baselineNotification{},
+	}
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/config"
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+func TestOpen_SQLiteSettings(t *testing.T) {
+	db, err := Open(config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "listenarr.db"), BusyTimeout: 2 * time.Second})
+	require.NoError(t, err)
+
+	var journalMode string
+	require.NoError(t, db.Raw("PRAGMA journal_mode").Scan(&journalMode).Error)
+	assert.Equal(t, "wal", journalMode)
+
+	var busyTimeout, foreignKeys int
+	require.NoError(t, db.Raw("PRAGMA busy_timeout").Scan(&busyTimeout).Error)
+	assert.Equal(t, 2000, busyTimeout)
+	require.NoError(t, db.Raw("PRAGMA foreign_keys").Scan(&foreignKeys).Error)
+	assert.Equal(t, 1, foreignKeys, "turned back on after migrating")
+
+	err = db.Create(&models.LibraryItem{BookID: 999, AddedDate: time.Now()}).Error
+	assert.ErrorContains(t, err, "FOREIGN KEY constraint failed")
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	assert.Equal(t, 1, sqlDB.Stats().MaxOpenConnections, "a single writer")
+}
+
+func TestOpen_LogLevel(t *testing.T) {
+	_, err := Open(config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "listenarr.db"), LogLevel: "Info"})
+	assert.NoError(t, err)
+
+	_, err = Open(config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "listenarr.db"), LogLevel: "loud"})
+	assert.ErrorContains(t, err, `unknown database log level "loud"`)
+}
+
+// TestConcurrentWrites hammers the database the way the download monitor,
+// the processing worker and the API do at the same time
+func TestConcurrentWrites(t *testing.T) {
+	db, err := Open(config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "listenarr.db"), LogLevel: "silent"})
+	require.NoError(t, err)
+
+	const workers = 8
+	const rounds = 25
+
+	author := models.Author{Name: "Terry Pratchett"}
+	require.NoError(t, db.Create(&author).Error)
+	downloads := make([]models.Download, workers)
+	for i := range downloads {
+		book := models.Book{Title: fmt.Sprintf("Discworld %d", i+1), AuthorID: author.ID}
+		require.NoError(t, db.Create(&book).Error)
+		item := models.LibraryItem{BookID: book.ID, Status: models.LibraryItemStatusDownloading, AddedDate: time.Now()}
+		require.NoError(t, db.Create(&item).Error)
+		release := models.Release{BookID: book.ID, Title: book.Title, Indexer: "test-indexer"}
+		require.NoError(t, db.Create(&release).Error)
+		downloads[i] = models.Download{LibraryItemID: item.ID, ReleaseID: release.ID, Status: models.DownloadStatusDownloading}
+		require.NoError(t, db.Create(&downloads[i]).Error)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds*4)
+
+	// Download monitor: progress updates
+	for i := range downloads {
+		wg.Add(1)
+		go func(download models.Download) {
+			defer wg.Done()
+			for round := 1; round <= rounds; round++ {
+				err := db.Model(&models.Download{}).Where("id = ?", download.ID).
+					Updates(map[string]interface{}{"progress": float64(round * 100 / rounds)}).Error
+				if err != nil {
+					errs <- fmt.Errorf("download %d progress: %w", download.ID, err)
+				}
+			}
+		}(downloads[i])
+	}
+
+	// Processing worker: a task per round, moved along in a transaction
+	// together with its download. Two transactions that read and then write
+	// deadlock on SQLite's lock upgrade unless writes are serialized.
+	for i := range downloads {
+		wg.Add(1)
+		go func(download models.Download) {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				err := db.Transaction(func(tx *gorm.DB) error {
+					// Read before writing, as the importer does
+					var current models.Download
+					if err := tx.First(&current, download.ID).Error; err != nil {
+						return err
+					}
+					task := models.ProcessingTask{DownloadID: download.ID, InputPath: fmt.Sprintf("/downloads/%d/%d", download.ID, round)}
+					if err := tx.Create(&task).Error; err != nil {
+						return err
+					}
+					if err := tx.Model(&task).Update("status", models.ProcessingStatusProcessing).Error; err != nil {
+						return err
+					}
+					if err := tx.Model(&task).Updates(map[string]interface{}{"status": models.ProcessingStatusCompleted, "progress": 100}).Error; err != nil {
+						return err
+					}
+					return tx.Model(&models.Download{}).Where("id = ?", download.ID).Update("status", models.DownloadStatusCompleted).Error
+				})
+				if err != nil {
+					errs <- fmt.Errorf("download %d processing: %w", download.ID, err)
+				}
+			}
+		}(downloads[i])
+	}
+
+	// API: reads of the queue
+	for i := 0; i < workers/2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				var tasks []models.ProcessingTask
+				if err := db.Preload("Download.LibraryItem.Book").Limit(20).Find(&tasks).Error; err != nil {
+					errs <- fmt.Errorf("queue read: %w", err)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var tasks int64
+	require.NoError(t, db.Model(&models.ProcessingTask{}).Where("status = ?", models.ProcessingStatusCompleted).Count(&tasks).Error)
+	assert.Equal(t, int64(workers*rounds), tasks)
+
+	var finished []models.Download
+	require.NoError(t, db.Find(&finished).Error)
+	for _, download := range finished {
+		assert.Equal(t, models.DownloadStatusCompleted, download.Status)
+		assert.Equal(t, 100.0, download.Progress)
+	}
+}
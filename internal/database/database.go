@@ -1,49 +1,286 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
-	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/config"
 )
 
-// Initialize creates and returns a database connection
+// Supported database drivers
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// ErrUnknownDriver is returned for a driver that is not supported
+var ErrUnknownDriver = errors.New("unknown database driver")
+
+// DefaultBusyTimeout is how long SQLite waits for a lock when no timeout is set
+const DefaultBusyTimeout = 5 * time.Second
+
+// logLevels maps the names of database.log_level to GORM's levels
+var logLevels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
+// Initialize creates and returns a connection to the SQLite database at dbPath
 func Initialize(dbPath string) (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+	return Open(config.DatabaseConfig{Driver: DriverSQLite, Path: dbPath})
+}
+
+// Open connects to the configured database and migrates it. An empty driver
+// means SQLite, and an empty log level means warn.
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	level := logger.Warn
+	if cfg.LogLevel != "" {
+		var ok bool
+		if level, ok = logLevels[strings.ToLower(cfg.LogLevel)]; !ok {
+			return nil, fmt.Errorf("unknown database log level %q", cfg.LogLevel)
+		}
+	}
+
+	var dialector gorm.Dialector
+	switch cfg.Driver {
+	case DriverSQLite, "":
+		dialector = sqlite.Open(sqliteDSN(cfg.Path, cfg.BusyTimeout))
+	case DriverPostgres:
+		if cfg.DSN == "" {
+			return nil, errors.New("database.dsn is required for postgres")
+		}
+		dialector = postgres.Open(cfg.DSN)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, cfg.Driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logger.Default.LogMode(level),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Auto-migrate all models
+	if db.Dialector.Name() == DriverSQLite {
+		// SQLite lets one connection write at a time and fails the others
+		// with "database is locked" once the busy timeout runs out. The
+		// API, the scheduler and the download monitor all write, so they
+		// share a single connection that queues them instead.
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	if err := backupBeforeMigrating(db, cfg.Path); err != nil {
+		return nil, err
+	}
 	if err := migrate(db); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	// Create additional indexes
-	if err := CreateIndexes(db); err != nil {
-		return nil, fmt.Errorf("failed to create indexes: %w", err)
+	return db, nil
+}
+
+// sqliteDSN adds the connection settings to the database path, so every
+// connection of the pool gets them: write-ahead logging lets readers carry on
+// while a write is in progress, the busy timeout makes a connection wait for
+// a lock held by another process instead of failing, and foreign keys are
+// enforced, which SQLite does not do by default.
+func sqliteDSN(path string, busyTimeout time.Duration) string {
+	if busyTimeout <= 0 {
+		busyTimeout = DefaultBusyTimeout
 	}
+	params := url.Values{}
+	params.Set("_journal_mode", "WAL")
+	params.Set("_busy_timeout", strconv.FormatInt(busyTimeout.Milliseconds(), 10))
+	params.Set("_foreign_keys", "1")
+	// Take the write lock when a transaction begins, since SQLite cannot
+	// wait for it when a reading transaction later tries to write
+	params.Set("_txlock", "immediate")
+	return path + "?" + params.Encode()
+}
 
-	return db, nil
+// migrateBookSeries moves the legacy books.series_id and books.series_position
+// columns into book_series rows and then drops them. It is a no-op once the
+// columns are gone.
+func migrateBookSeries(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&baselineBook{}, "series_id") {
+		return nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var rows []struct {
+			ID             uint
+			SeriesID       uint
+			SeriesPosition *int
+		}
+		err := tx.Table("books").
+			Select("id, series_id, series_position").
+			Where("series_id IS NOT NULL AND deleted_at IS NULL").
+			Scan(&rows).Error
+		if err != nil {
+			return fmt.Errorf("failed to read legacy series data: %w", err)
+		}
+
+		for _, row := range rows {
+			link := baselineBookSeries{BookID: row.ID, SeriesID: row.SeriesID}
+			if row.SeriesPosition != nil {
+				link.Position = strconv.Itoa(*row.SeriesPosition)
+				link.Sequence = parseSeriesSequence(link.Position)
+			}
+			err := tx.Where("book_id = ? AND series_id = ?", row.ID, row.SeriesID).
+				FirstOrCreate(&link).Error
+			if err != nil {
+				return fmt.Errorf("failed to migrate series of book %d: %w", row.ID, err)
+			}
+		}
+
+		migrator := tx.Migrator()
+		if migrator.HasIndex(&baselineBook{}, "idx_books_series_id") {
+			if err := migrator.DropIndex(&baselineBook{}, "idx_books_series_id"); err != nil {
+				return fmt.Errorf("failed to drop legacy series index: %w", err)
+			}
+		}
+		for _, column := range []string{"series_position", "series_id"} {
+			if err := migrator.DropColumn(&baselineBook{}, column); err != nil {
+				return fmt.Errorf("failed to drop books.%s: %w", column, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// SQLite drops columns by rebuilding the table, which loses its indexes.
+	// They are recreated as they were in the baseline, like the rest of the
+	// migration.
+	return db.AutoMigrate(&baselineBook{})
+}
+
+// migrateContributors credits the primary author and the free-text
+// audiobook narrator of books that have no contributor credits yet, e.g.
+// books created before contributors were introduced
+func migrateContributors(db *gorm.DB) error {
+	var books []baselineBook
+	err := db.Preload("Audiobook").
+		Where("id NOT IN (?)", db.Model(&baselineBookContributor{}).Select("book_id")).
+		Find(&books).Error
+	if err != nil {
+		return fmt.Errorf("failed to find books without contributors: %w", err)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, book := range books {
+			authorID := book.AuthorID
+			credit := baselineBookContributor{BookID: book.ID, Role: "author", AuthorID: &authorID}
+			err := tx.Where("book_id = ? AND role = ? AND author_id = ?", book.ID, "author", authorID).
+				FirstOrCreate(&credit).Error
+			if err != nil {
+				return fmt.Errorf("failed to credit contributors of book %d: %w", book.ID, err)
+			}
+			if book.Audiobook == nil {
+				continue
+			}
+
+			for _, name := range splitCredits(book.Audiobook.Narrator) {
+				narrator := baselineNarrator{Name: name}
+				if err := tx.Where("name = ?", name).FirstOrCreate(&narrator).Error; err != nil {
+					return fmt.Errorf("failed to credit contributors of book %d: %w", book.ID, err)
+				}
+				credit := baselineBookContributor{BookID: book.ID, Role: "narrator", NarratorID: &narrator.ID}
+				err := tx.Where("book_id = ? AND role = ? AND narrator_id = ?", book.ID, "narrator", narrator.ID).
+					FirstOrCreate(&credit).Error
+				if err != nil {
+					return fmt.Errorf("failed to credit contributors of book %d: %w", book.ID, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// migrateAuthorMonitorOption drops the 'none' default of
+// authors.monitor_option, so an option that was never set can be told apart
+// from one set to none, and clears it where it most likely came from the
+// default: authors that are not monitored, for whom it made no difference.
+func migrateAuthorMonitorOption(db *gorm.DB) error {
+	if db.Dialector.Name() == DriverSQLite {
+		// SQLite cannot change a default without rebuilding the table, which
+		// loses its indexes, so they are recreated as they were
+		var indexes []string
+		err := db.Raw("SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = 'authors' AND sql IS NOT NULL").
+			Scan(&indexes).Error
+		if err != nil {
+			return fmt.Errorf("failed to read the indexes of authors: %w", err)
+		}
+		if err := db.Migrator().AlterColumn(&authorMonitorOption{}, "MonitorOption"); err != nil {
+			return fmt.Errorf("failed to alter authors.monitor_option: %w", err)
+		}
+		for _, index := range indexes {
+			if err := db.Exec(index).Error; err != nil {
+				return fmt.Errorf("failed to recreate index of authors: %w", err)
+			}
+		}
+	} else if err := db.Exec("ALTER TABLE authors ALTER COLUMN monitor_option DROP DEFAULT").Error; err != nil {
+		return fmt.Errorf("failed to alter authors.monitor_option: %w", err)
+	}
+
+	err := db.Exec("UPDATE authors SET monitor_option = '' WHERE monitored = ? AND monitor_option = 'none'", false).Error
+	if err != nil {
+		return fmt.Errorf("failed to clear default monitor options: %w", err)
+	}
+	return nil
 }
 
-// migrate runs database migrations for all models
-func migrate(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&models.Author{},
-		&models.Series{},
-		&models.Book{},
-		&models.Audiobook{},
-		&models.Release{},
-		&models.LibraryItem{},
-		&models.Download{},
-		&models.ProcessingTask{},
-	)
+// authorMonitorOption is authors.monitor_option as migration 5 leaves it
+type authorMonitorOption struct {
+	MonitorOption string
+}
+
+func (authorMonitorOption) TableName() string { return "authors" }
+
+// splitCredits splits a free-text credit such as "A, B and C" into names.
+// It is a copy of models.SplitCredits as it was when migrateContributors was
+// written, so later changes to it do not change what the migration does.
+func splitCredits(credit string) []string {
+	replacer := strings.NewReplacer(" and ", ",", " & ", ",", ";", ",")
+	var names []string
+	for _, name := range strings.Split(replacer.Replace(credit), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseSeriesSequence returns the number of a series position such as "2" or
+// "2,5", or nil if it is not a number. It is a copy of
+// models.ParseSeriesSequence as it was when migrateBookSeries was written.
+func parseSeriesSequence(position string) *float64 {
+	position = strings.ReplaceAll(strings.TrimSpace(position), ",", ".")
+	if position == "" {
+		return nil
+	}
+	sequence, err := strconv.ParseFloat(position, 64)
+	if err != nil {
+		return nil
+	}
+	return &sequence
 }
 
 // CreateIndexes creates additional indexes for performance
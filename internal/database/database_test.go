@@ -7,12 +7,17 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/config"
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/models"
 )
 
 func TestInitialize(t *testing.T) {
 	// Create a temporary database file
-	testDBPath := filepath.Join(os.TempDir(), "test_listenarr.db")
-	defer os.Remove(testDBPath)
+	testDBPath := filepath.Join(t.TempDir(), "test_listenarr.db")
 
 	db, err := Initialize(testDBPath)
 	require.NoError(t, err)
@@ -38,11 +43,7 @@ func TestInitialize_InvalidPath(t *testing.T) {
 }
 
 func TestInitialize_CreatesFile(t *testing.T) {
-	testDBPath := filepath.Join(os.TempDir(), "test_creates.db")
-	defer os.Remove(testDBPath)
-
-	// Ensure file doesn't exist
-	os.Remove(testDBPath)
+	testDBPath := filepath.Join(t.TempDir(), "test_creates.db")
 
 	db, err := Initialize(testDBPath)
 	require.NoError(t, err)
@@ -52,3 +53,155 @@ func TestInitialize_CreatesFile(t *testing.T) {
 	_, err = os.Stat(testDBPath)
 	assert.NoError(t, err, "Database file should be created")
 }
+
+func TestMigrate_LegacySeriesColumns(t *testing.T) {
+	testDBPath := filepath.Join(t.TempDir(), "legacy.db")
+
+	// Create the pre-join-table schema with a book in a series
+	legacy, err := gorm.Open(sqlite.Open(testDBPath), &gorm.Config{})
+	require.NoError(t, err)
+	statements := []string{
+		"CREATE TABLE `authors` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text NOT NULL)",
+		"CREATE TABLE `series` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text NOT NULL)",
+		"CREATE TABLE `books` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`title` text NOT NULL,`author_id` integer NOT NULL,`series_id` integer,`series_position` integer)",
+		"CREATE INDEX `idx_books_series_id` ON `books`(`series_id`)",
+		"INSERT INTO authors (id, name) VALUES (1, 'Author')",
+		"INSERT INTO series (id, name) VALUES (1, 'Series')",
+		"INSERT INTO books (id, title, author_id, series_id, series_position) VALUES (1, 'First', 1, 1, 1)",
+		"INSERT INTO books (id, title, author_id, series_id, series_position) VALUES (2, 'Unnumbered', 1, 1, NULL)",
+		"INSERT INTO books (id, title, author_id) VALUES (3, 'Standalone', 1)",
+	}
+	for _, statement := range statements {
+		require.NoError(t, legacy.Exec(statement).Error)
+	}
+	sqlDB, _ := legacy.DB()
+	sqlDB.Close()
+
+	db, err := Initialize(testDBPath)
+	require.NoError(t, err)
+
+	assert.False(t, db.Migrator().HasColumn(&models.Book{}, "series_id"))
+	assert.False(t, db.Migrator().HasColumn(&models.Book{}, "series_position"))
+	assert.True(t, db.Migrator().HasIndex(&models.Book{}, "idx_books_title"))
+
+	var links []models.BookSeries
+	require.NoError(t, db.Order("book_id").Find(&links).Error)
+	require.Len(t, links, 2)
+	assert.Equal(t, uint(1), links[0].BookID)
+	assert.Equal(t, "1", links[0].Position)
+	require.NotNil(t, links[0].Sequence)
+	assert.Equal(t, 1.0, *links[0].Sequence)
+	assert.Equal(t, uint(2), links[1].BookID)
+	assert.Empty(t, links[1].Position)
+	assert.Nil(t, links[1].Sequence)
+
+	// Book data survives the column drop
+	var count int64
+	db.Model(&models.Book{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+
+	// Running the migration again is a no-op
+	require.NoError(t, migrate(db))
+	db.Model(&models.BookSeries{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestMigrate_CreditsExistingContributors(t *testing.T) {
+	testDBPath := filepath.Join(t.TempDir(), "credits.db")
+
+	db, err := Initialize(testDBPath)
+	require.NoError(t, err)
+
+	// Books created without contributor credits, as before they existed
+	author := models.Author{Name: "Author"}
+	require.NoError(t, db.Create(&author).Error)
+	book := models.Book{Title: "Full Cast", AuthorID: author.ID}
+	require.NoError(t, db.Create(&book).Error)
+	require.NoError(t, db.Create(&models.Audiobook{BookID: book.ID, Narrator: "Jim Dale, Stephen Fry & Kate Reading"}).Error)
+	standalone := models.Book{Title: "Standalone", AuthorID: author.ID}
+	require.NoError(t, db.Create(&standalone).Error)
+
+	require.NoError(t, migrateContributors(db))
+
+	var credits []models.BookContributor
+	require.NoError(t, db.Preload("Narrator").Where("book_id = ?", book.ID).Order("id").Find(&credits).Error)
+	require.Len(t, credits, 4)
+	assert.Equal(t, models.ContributorRoleAuthor, credits[0].Role)
+	assert.Equal(t, author.ID, *credits[0].AuthorID)
+	assert.Equal(t, "Jim Dale", credits[1].Name())
+	assert.Equal(t, "Stephen Fry", credits[2].Name())
+	assert.Equal(t, "Kate Reading", credits[3].Name())
+
+	var count int64
+	db.Model(&models.BookContributor{}).Where("book_id = ?", standalone.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// Running the migration again is a no-op
+	require.NoError(t, migrateContributors(db))
+	db.Model(&models.BookContributor{}).Count(&count)
+	assert.Equal(t, int64(5), count)
+}
+
+func TestMigrate_AuthorMonitorOption(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "authors.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(baselineModels()...))
+
+	// Options left at the baseline default and options that were set
+	require.NoError(t, db.Exec("INSERT INTO authors (id, name, monitored) VALUES (1, 'Default', false)").Error)
+	require.NoError(t, db.Exec("INSERT INTO authors (id, name, monitored, monitor_option) VALUES (2, 'Monitored', true, 'none')").Error)
+	require.NoError(t, db.Exec("INSERT INTO authors (id, name, monitored, monitor_option) VALUES (3, 'Future', false, 'future')").Error)
+	require.NoError(t, db.Create(&baselineBook{Title: "Book", AuthorID: 2}).Error)
+
+	require.NoError(t, migrateAuthorMonitorOption(db))
+
+	options := map[uint]models.AuthorMonitorOption{}
+	var authors []models.Author
+	require.NoError(t, db.Order("id").Find(&authors).Error)
+	for _, author := range authors {
+		options[author.ID] = author.MonitorOption
+	}
+	assert.Equal(t, map[uint]models.AuthorMonitorOption{1: "", 2: models.AuthorMonitorNone, 3: models.AuthorMonitorFuture}, options)
+
+	// New authors no longer get none, and the table kept its indexes and books
+	require.NoError(t, db.Exec("INSERT INTO authors (id, name) VALUES (4, 'New')").Error)
+	var added models.Author
+	require.NoError(t, db.First(&added, 4).Error)
+	assert.Empty(t, added.MonitorOption)
+	for _, index := range []string{"idx_authors_name", "idx_authors_monitored", "idx_authors_goodreads_id", "idx_authors_deleted_at"} {
+		assert.True(t, db.Migrator().HasIndex("authors", index), index)
+	}
+	var count int64
+	require.NoError(t, db.Model(&baselineBook{}).Where("author_id = ?", 2).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestOpen_Drivers(t *testing.T) {
+	_, err := Open(config.DatabaseConfig{Driver: "mysql"})
+	assert.ErrorIs(t, err, ErrUnknownDriver)
+
+	_, err = Open(config.DatabaseConfig{Driver: DriverPostgres})
+	assert.ErrorContains(t, err, "database.dsn is required")
+
+	db, err := Open(config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "listenarr.db")})
+	require.NoError(t, err)
+	assert.Equal(t, DriverSQLite, db.Dialector.Name(), "SQLite by default")
+}
+
+func TestMigrate_Postgres(t *testing.T) {
+	dsn := os.Getenv(dbtest.PostgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", dbtest.PostgresDSNEnv)
+	}
+
+	// Migrate a schema of its own
+	db := dbtest.Open(t)
+	require.NoError(t, migrate(db))
+	version, err := schemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, migrations[len(migrations)-1].Version, version)
+	assert.True(t, db.Migrator().HasIndex(&models.Book{}, "idx_books_title_author"))
+
+	// Running again is a no-op
+	require.NoError(t, migrate(db))
+}
@@ -0,0 +1,285 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// ErrSchemaTooNew is returned when the database was migrated by a newer
+// version of Listenarr than this one
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of Listenarr")
+
+// Migration is one versioned change to the schema. SQL runs first when set,
+// then Up. Both run in a transaction with the recording of the version, so a
+// migration that fails leaves no trace.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+	Up      func(tx *gorm.DB) error
+}
+
+// SchemaMigration records a migration that was applied
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"not null"`
+	AppliedAt time.Time
+}
+
+// TableName overrides the pluralized default
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// allModels returns every model, in the order their tables are created
+func allModels() []interface{} {
+	return []interface{}{
+		&models.Author{},
+		&models.Series{},
+		&models.Book{},
+		&models.BookSeries{},
+		&models.Narrator{},
+		&models.BookContributor{},
+		&models.Audiobook{},
+		&models.Release{},
+		&models.LibraryItem{},
+		&models.Download{},
+		&models.ProcessingTask{},
+		&models.History{},
+		&models.ImportCandidate{},
+		&models.ScheduledTask{},
+		&models.Notification{},
+	}
+}
+
+// migrations lists every migration in version order. Append new ones to the
+// end and never change one that was released: a new database is created
+// from the models and skips them all, an existing one runs those it is
+// missing.
+var migrations = []Migration{
+	{
+		// Brings databases from before versioned migrations up to the
+		// schema of the models at the time, frozen in baseline.go
+		Version: 1,
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(baselineModels()...)
+		},
+	},
+	{
+		Version: 2,
+		Name:    "book_series_join_table",
+		Up:      migrateBookSeries,
+	},
+	{
+		Version: 3,
+		Name:    "credit_contributors",
+		Up:      migrateContributors,
+	},
+	{
+		Version: 4,
+		Name:    "books_title_author_index",
+		SQL:     "CREATE INDEX IF NOT EXISTS idx_books_title_author ON books(title, author_id)",
+	},
+	{
+		Version: 5,
+		Name:    "author_monitor_option_unset",
+		Up:      migrateAuthorMonitorOption,
+	},
+}
+
+// LatestVersion returns the schema version this version of Listenarr
+// migrates databases to
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// CheckVersion returns ErrSchemaTooNew when the database was migrated by a
+// newer version of Listenarr than this one, which could not open it
+func CheckVersion(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return nil // From before versioned migrations
+	}
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if latest := LatestVersion(); current > latest {
+		return fmt.Errorf("%w: version %d, this version of Listenarr knows up to %d", ErrSchemaTooNew, current, latest)
+	}
+	return nil
+}
+
+// migrate brings the database to the latest schema version
+func migrate(db *gorm.DB) error {
+	return runMigrations(db, migrations)
+}
+
+// runMigrations applies the migrations the database is missing. A new
+// database gets the tables of the models and is recorded as up to date.
+func runMigrations(db *gorm.DB, migrations []Migration) error {
+	if db.Dialector.Name() != DriverSQLite {
+		return applyMigrations(db, migrations)
+	}
+
+	// SQLite changes columns by rebuilding the table, which enforced foreign
+	// keys do not allow. They are turned off on the connection migrating,
+	// and checked at the end of each migration instead.
+	return db.Connection(func(conn *gorm.DB) error {
+		conn = conn.Session(&gorm.Session{})
+		if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+			return err
+		}
+		defer conn.Exec("PRAGMA foreign_keys = ON")
+		return applyMigrations(conn, migrations)
+	})
+}
+
+// applyMigrations applies the migrations newer than the database's version
+func applyMigrations(db *gorm.DB, migrations []Migration) error {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	latest := migrations[len(migrations)-1].Version
+	if current > latest {
+		return fmt.Errorf("%w: version %d, this version of Listenarr knows up to %d", ErrSchemaTooNew, current, latest)
+	}
+
+	if current == 0 && isNew(db) {
+		return initSchema(db, migrations)
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if migration.SQL != "" {
+				if err := tx.Exec(migration.SQL).Error; err != nil {
+					return err
+				}
+			}
+			if migration.Up != nil {
+				if err := migration.Up(tx); err != nil {
+					return err
+				}
+			}
+			if err := checkForeignKeys(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d %s failed: %w", migration.Version, migration.Name, err)
+		}
+		log.Printf("database: applied migration %d %s", migration.Version, migration.Name)
+	}
+	return nil
+}
+
+// initSchema creates the tables of a new database from the models and
+// records every migration as applied, since the models already include them
+func initSchema(db *gorm.DB, migrations []Migration) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(allModels()...); err != nil {
+			return err
+		}
+		if err := CreateIndexes(tx); err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, migration := range migrations {
+			err := tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: now}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// checkForeignKeys fails when SQLite has rows whose foreign keys point
+// nowhere. Other databases enforce them during the migration.
+func checkForeignKeys(tx *gorm.DB) error {
+	if tx.Dialector.Name() != DriverSQLite {
+		return nil
+	}
+	var violations []struct {
+		Table  string
+		Parent string
+	}
+	if err := tx.Raw("PRAGMA foreign_key_check").Scan(&violations).Error; err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%d row(s) of %s reference missing rows of %s",
+			len(violations), violations[0].Table, violations[0].Parent)
+	}
+	return nil
+}
+
+// schemaVersion returns the latest migration applied, 0 for none
+func schemaVersion(db *gorm.DB) (int, error) {
+	var version int
+	err := db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// isNew returns true if the database has none of Listenarr's tables
+func isNew(db *gorm.DB) bool {
+	return !db.Migrator().HasTable(&models.Book{})
+}
+
+// pendingMigrations returns the version of an existing database and whether
+// it has migrations to run. A new database has none.
+func pendingMigrations(db *gorm.DB) (int, bool, error) {
+	if isNew(db) {
+		return 0, false, nil
+	}
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return 0, true, nil
+	}
+	current, err := schemaVersion(db)
+	if err != nil {
+		return 0, false, err
+	}
+	return current, current < migrations[len(migrations)-1].Version, nil
+}
+
+// backupBeforeMigrating copies an existing SQLite database that has
+// migrations to run next to it, so a migration that goes wrong can be undone
+// by hand. PostgreSQL databases are left to the server's own backups.
+func backupBeforeMigrating(db *gorm.DB, dbPath string) error {
+	current, pending, err := pendingMigrations(db)
+	if err != nil || !pending {
+		return err
+	}
+	if db.Dialector.Name() != DriverSQLite {
+		log.Printf("database: migrating version %d without a backup, which is only taken of SQLite databases", current)
+		return nil
+	}
+
+	backupPath := fmt.Sprintf("%s.pre-migration-v%d-%s", dbPath, current, time.Now().Format("20060102_150405"))
+	if _, err := os.Stat(backupPath); err == nil {
+		return nil // Taken moments ago by a start that failed
+	}
+	if err := db.Exec("VACUUM INTO ?", backupPath).Error; err != nil {
+		return fmt.Errorf("failed to back up database before migrating: %w", err)
+	}
+	log.Printf("database: backed up version %d to %s before migrating", current, backupPath)
+	return nil
+}
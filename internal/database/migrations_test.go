@@ -0,0 +1,200 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// loadFixture creates a database at path from a SQL file in testdata
+func loadFixture(t *testing.T, path, fixture string) {
+	statements, err := os.ReadFile(filepath.Join("testdata", fixture))
+	require.NoError(t, err)
+
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+	_, err = sqlDB.Exec(string(statements))
+	require.NoError(t, err)
+}
+
+// appliedVersions returns the versions recorded in schema_migrations
+func appliedVersions(t *testing.T, db *gorm.DB) []int {
+	var versions []int
+	require.NoError(t, db.Model(&SchemaMigration{}).Order("version").Pluck("version", &versions).Error)
+	return versions
+}
+
+// latestVersions returns the versions of every migration
+func latestVersions() []int {
+	versions := make([]int, len(migrations))
+	for i, migration := range migrations {
+		versions[i] = migration.Version
+	}
+	return versions
+}
+
+// sqliteSchema returns the columns of every table and the names of every
+// index of a SQLite database
+func sqliteSchema(t *testing.T, db *gorm.DB) map[string][]string {
+	var tables []string
+	require.NoError(t, db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'").Scan(&tables).Error)
+	schema := make(map[string][]string)
+	for _, table := range tables {
+		columns, err := db.Migrator().ColumnTypes(table)
+		require.NoError(t, err)
+		for _, column := range columns {
+			schema[table] = append(schema[table], column.Name())
+		}
+	}
+	var indexes []string
+	require.NoError(t, db.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND name NOT LIKE 'sqlite_%' ORDER BY name").Scan(&indexes).Error)
+	schema["indexes"] = indexes
+	return schema
+}
+
+func TestMigrations_Ordered(t *testing.T) {
+	for i := 1; i < len(migrations); i++ {
+		assert.Greater(t, migrations[i].Version, migrations[i-1].Version, migrations[i].Name)
+	}
+}
+
+func TestInitialize_NewDatabase(t *testing.T) {
+	dir := t.TempDir()
+	db, err := Initialize(filepath.Join(dir, "listenarr.db"))
+	require.NoError(t, err)
+
+	assert.Equal(t, latestVersions(), appliedVersions(t, db))
+	assert.True(t, db.Migrator().HasIndex(&models.Book{}, "idx_books_title_author"))
+
+	backups, err := filepath.Glob(filepath.Join(dir, "*.pre-migration-*"))
+	require.NoError(t, err)
+	assert.Empty(t, backups, "a new database is not backed up")
+}
+
+func TestInitialize_MigratesLegacyFixture(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "listenarr.db")
+	loadFixture(t, dbPath, "legacy.sql")
+
+	db, err := Initialize(dbPath)
+	require.NoError(t, err)
+	assert.Equal(t, latestVersions(), appliedVersions(t, db))
+
+	// The schema is that of the models
+	for _, model := range allModels() {
+		assert.True(t, db.Migrator().HasTable(model))
+	}
+	assert.False(t, db.Migrator().HasColumn(&models.Book{}, "series_id"))
+	assert.True(t, db.Migrator().HasIndex(&models.Book{}, "idx_books_title_author"))
+
+	// The frozen baseline and the migrations after it add up to the schema a
+	// new database gets, a model change without a migration fails here
+	fresh, err := Initialize(filepath.Join(t.TempDir(), "fresh.db"))
+	require.NoError(t, err)
+	want := sqliteSchema(t, fresh)
+	got := sqliteSchema(t, db)
+	for table, columns := range want {
+		assert.ElementsMatch(t, columns, got[table], table)
+	}
+	assert.Len(t, got, len(want))
+
+	// And the data followed it
+	var links []models.BookSeries
+	require.NoError(t, db.Order("book_id").Find(&links).Error)
+	require.Len(t, links, 2)
+	assert.Equal(t, "1", links[0].Position)
+	assert.Equal(t, "2", links[1].Position)
+
+	var credits []models.BookContributor
+	require.NoError(t, db.Preload("Narrator").Where("book_id = ? AND role = ?", 2, models.ContributorRoleNarrator).Order("id").Find(&credits).Error)
+	require.Len(t, credits, 2)
+	assert.Equal(t, "Jim Dale", credits[0].Name())
+	assert.Equal(t, "Stephen Fry", credits[1].Name())
+
+	var item models.LibraryItem
+	require.NoError(t, db.First(&item, 1).Error)
+	assert.Equal(t, models.LibraryItemStatus("downloaded"), item.Status)
+
+	// The database was backed up as it was before migrating
+	backups, err := filepath.Glob(dbPath + ".pre-migration-v0-*")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	backup, err := gorm.Open(sqlite.Open(backups[0]), &gorm.Config{})
+	require.NoError(t, err)
+	assert.True(t, backup.Migrator().HasColumn("books", "series_id"))
+	assert.False(t, backup.Migrator().HasTable(&SchemaMigration{}))
+	sqlDB, _ := backup.DB()
+	sqlDB.Close()
+
+	// Starting again has nothing to migrate or back up
+	sqlDB, _ = db.DB()
+	require.NoError(t, sqlDB.Close())
+	db, err = Initialize(dbPath)
+	require.NoError(t, err)
+	backups, _ = filepath.Glob(dbPath + ".pre-migration-*")
+	assert.Len(t, backups, 1)
+}
+
+func TestRunMigrations_Pending(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "listenarr.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, runMigrations(db, migrations))
+
+	var ran []string
+	next := append(migrations, Migration{
+		Version: 100,
+		Name:    "rename_narrators",
+		SQL:     "ALTER TABLE narrators RENAME COLUMN name TO full_name",
+		Up: func(tx *gorm.DB) error {
+			ran = append(ran, "rename_narrators")
+			return nil
+		},
+	})
+	require.NoError(t, runMigrations(db, next))
+	assert.Equal(t, []string{"rename_narrators"}, ran)
+	assert.True(t, db.Migrator().HasColumn("narrators", "full_name"))
+
+	// Applied migrations do not run again
+	require.NoError(t, runMigrations(db, next))
+	assert.Len(t, ran, 1)
+}
+
+func TestRunMigrations_FailureRollsBack(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "listenarr.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, runMigrations(db, migrations))
+
+	next := append(migrations, Migration{
+		Version: 100,
+		Name:    "broken",
+		SQL:     "ALTER TABLE narrators ADD COLUMN born integer",
+		Up: func(tx *gorm.DB) error {
+			return errors.New("backfill failed")
+		},
+	})
+	err = runMigrations(db, next)
+	assert.ErrorContains(t, err, "migration 100 broken failed: backfill failed")
+	assert.False(t, db.Migrator().HasColumn("narrators", "born"))
+	assert.Equal(t, latestVersions(), appliedVersions(t, db))
+}
+
+func TestRunMigrations_SchemaTooNew(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "listenarr.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, runMigrations(db, migrations))
+	require.NoError(t, db.Create(&SchemaMigration{Version: 1000, Name: "from_the_future"}).Error)
+
+	err = runMigrations(db, migrations)
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+}
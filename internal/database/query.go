@@ -0,0 +1,20 @@
+package database
+
+import (
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// likeEscaper escapes the LIKE wildcards, so they match themselves
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Contains returns a condition matching rows where column contains value,
+// ignoring case. LIKE ignores case on SQLite but not on PostgreSQL, so both
+// sides are lowered. column must not come from user input.
+func Contains(column, value string) clause.Expr {
+	return clause.Expr{
+		SQL:  "LOWER(" + column + `) LIKE LOWER(?) ESCAPE '\'`,
+		Vars: []interface{}{"%" + likeEscaper.Replace(value) + "%"},
+	}
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+func TestContains(t *testing.T) {
+	db := dbtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&models.Author{}))
+	for _, name := range []string{"Terry Pratchett", "Neil Gaiman", "100% Fact_Checked", "100 Facts"} {
+		require.NoError(t, db.Create(&models.Author{Name: name}).Error)
+	}
+
+	tests := []struct {
+		search string
+		want   []string
+	}{
+		{"pratchett", []string{"Terry Pratchett"}},
+		{"NEIL", []string{"Neil Gaiman"}},
+		{"100%", []string{"100% Fact_Checked"}},
+		{"t_c", []string{"100% Fact_Checked"}},
+		{"", []string{"Terry Pratchett", "Neil Gaiman", "100% Fact_Checked", "100 Facts"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.search, func(t *testing.T) {
+			var names []string
+			require.NoError(t, db.Model(&models.Author{}).Where(Contains("name", tt.search)).Order("id").Pluck("name", &names).Error)
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+-- A database from before versioned migrations, when a book had a single
+-- series in books.series_id and narrators were free text on audiobooks
+CREATE TABLE `authors` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text NOT NULL);
+CREATE TABLE `series` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text NOT NULL);
+CREATE TABLE `books` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`title` text NOT NULL,`author_id` integer NOT NULL,`series_id` integer,`series_position` integer);
+CREATE INDEX `idx_books_series_id` ON `books`(`series_id`);
+CREATE TABLE `audiobooks` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`book_id` integer NOT NULL,`narrator` text);
+CREATE TABLE `library_items` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`book_id` integer NOT NULL,`status` text NOT NULL DEFAULT 'wanted',`added_date` datetime NOT NULL);
+
+INSERT INTO authors (id, name) VALUES (1, 'J.K. Rowling');
+INSERT INTO series (id, name) VALUES (1, 'Harry Potter');
+INSERT INTO books (id, title, author_id, series_id, series_position) VALUES (1, 'Philosopher''s Stone', 1, 1, 1);
+INSERT INTO books (id, title, author_id, series_id, series_position) VALUES (2, 'Chamber of Secrets', 1, 1, 2);
+INSERT INTO books (id, title, author_id) VALUES (3, 'The Casual Vacancy', 1);
+INSERT INTO audiobooks (id, book_id, narrator) VALUES (1, 1, 'Stephen Fry');
+INSERT INTO audiobooks (id, book_id, narrator) VALUES (2, 2, 'Jim Dale & Stephen Fry');
+INSERT INTO library_items (id, book_id, status, added_date) VALUES (1, 1, 'downloaded', '2023-01-01 00:00:00');
+INSERT INTO library_items (id, book_id, status, added_date) VALUES (2, 3, 'wanted', '2023-01-02 00:00:00');
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Type identifies the kind of event
+type Type string
+
+const (
+	DownloadProgress        Type = "download.progress"
+	DownloadStatusChanged   Type = "download.status_changed"
+	DownloadGrabbed         Type = "download.grabbed"
+	DownloadFailed          Type = "download.failed"
+	ProcessingStatusChanged Type = "processing.status_changed"
+	ProcessingTaskFailed    Type = "processing.task_failed"
+	LibraryItemAdded        Type = "library.item_added"
+	LibraryItemRemoved      Type = "library.item_removed"
+	LibraryItemAvailable    Type = "library.item_available"
+	LibraryItemMissing      Type = "library.item_missing"
+	LibraryItemImported     Type = "library.item_imported"
+	LibraryItemUpgraded     Type = "library.item_upgraded"
+	HealthIssue             Type = "health.issue"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before further events are dropped for it
+const subscriberBuffer = 64
+
+// Event is a single message published on the bus
+type Event struct {
+	Type Type        `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// DownloadPayload is the data for download events
+type DownloadPayload struct {
+	DownloadID    uint    `json:"download_id"`
+	LibraryItemID uint    `json:"library_item_id"`
+	Status        string  `json:"status"`
+	Progress      float64 `json:"progress"`
+	Speed         int64   `json:"speed,omitempty"`
+	Size          int64   `json:"size,omitempty"`
+	Downloaded    int64   `json:"downloaded,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// ProcessingPayload is the data for processing task events
+type ProcessingPayload struct {
+	TaskID     uint    `json:"task_id"`
+	DownloadID uint    `json:"download_id"`
+	Status     string  `json:"status"`
+	Progress   float64 `json:"progress"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// LibraryItemPayload is the data for library item events
+type LibraryItemPayload struct {
+	LibraryItemID uint   `json:"library_item_id"`
+	BookID        uint   `json:"book_id"`
+	Status        string `json:"status"`
+	FilePath      string `json:"file_path,omitempty"`
+	DownloadID    uint   `json:"download_id,omitempty"` // Download the files came from, if any
+}
+
+// HealthPayload is the data for health events
+type HealthPayload struct {
+	Source  string `json:"source"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// Bus is an in-process publish/subscribe event bus.
+// A nil *Bus is valid and discards all events.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[int]*subscriber
+	nextID      int
+}
+
+// subscriber is the channel of a subscription and the types it receives
+type subscriber struct {
+	ch      chan Event
+	types   map[Type]bool // nil receives every type
+	dropped atomic.Int64  // Events missed since the last one delivered
+}
+
+// NewBus creates a new event bus
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[int]*subscriber),
+	}
+}
+
+// Subscribe registers a new subscriber for the given types of events, or
+// every type when none are given. Subscribing only to the types it handles
+// keeps a subscriber's buffer from filling up with high-volume events such
+// as download progress. The returned function unsubscribes and closes the
+// channel; it is safe to call more than once. Subscribing to a nil *Bus
+// returns a closed channel.
+func (b *Bus) Subscribe(types ...Type) (<-chan Event, func()) {
+	if b == nil {
+		ch := make(chan Event)
+		close(ch)
+		return ch, func() {}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	sub := &subscriber{ch: ch}
+	if len(types) > 0 {
+		sub.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = sub
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, id)
+			close(ch)
+			b.mu.Unlock()
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish sends an event to all subscribers of its type without blocking.
+// Subscribers whose buffer is full miss the event, which is logged once
+// when they fall behind and again with the number missed when they catch up.
+func (b *Bus) Publish(eventType Type, data interface{}) {
+	if b == nil {
+		return
+	}
+
+	event := Event{
+		Type: eventType,
+		Time: time.Now(),
+		Data: data,
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for id, sub := range b.subscribers {
+		if sub.types != nil && !sub.types[eventType] {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+			if missed := sub.dropped.Swap(0); missed > 0 {
+				log.Printf("events: subscriber %d caught up after missing %d events", id, missed)
+			}
+		default:
+			if sub.dropped.Add(1) == 1 {
+				log.Printf("events: subscriber %d is falling behind, dropping %s and later events", id, eventType)
+			}
+		}
+	}
+}
+
+// SubscriberCount returns the number of active subscribers
+func (b *Bus) SubscriberCount() int {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers)
+}
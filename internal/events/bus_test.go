@@ -0,0 +1,91 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus_PublishSubscribe(t *testing.T) {
+	bus := NewBus()
+
+	ch, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+	assert.Equal(t, 1, bus.SubscriberCount())
+
+	bus.Publish(DownloadProgress, DownloadPayload{DownloadID: 1, Progress: 50})
+
+	event := <-ch
+	assert.Equal(t, DownloadProgress, event.Type)
+	assert.False(t, event.Time.IsZero())
+	payload, ok := event.Data.(DownloadPayload)
+	require.True(t, ok)
+	assert.Equal(t, uint(1), payload.DownloadID)
+	assert.Equal(t, 50.0, payload.Progress)
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	bus := NewBus()
+
+	ch, unsubscribe := bus.Subscribe()
+	unsubscribe()
+	unsubscribe() // Safe to call twice
+
+	assert.Equal(t, 0, bus.SubscriberCount())
+	_, open := <-ch
+	assert.False(t, open)
+
+	// Publishing with no subscribers must not panic
+	bus.Publish(LibraryItemAdded, nil)
+}
+
+func TestBus_SlowSubscriberDoesNotBlock(t *testing.T) {
+	bus := NewBus()
+
+	ch, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	for i := 0; i < subscriberBuffer*2; i++ {
+		bus.Publish(DownloadProgress, nil)
+	}
+
+	assert.Len(t, ch, subscriberBuffer)
+	assert.Equal(t, int64(subscriberBuffer), bus.subscribers[0].dropped.Load())
+
+	// Catching up resets the count of missed events
+	<-ch
+	bus.Publish(DownloadProgress, nil)
+	assert.Len(t, ch, subscriberBuffer)
+	assert.Zero(t, bus.subscribers[0].dropped.Load())
+}
+
+func TestBus_SubscribeToTypes(t *testing.T) {
+	bus := NewBus()
+
+	ch, unsubscribe := bus.Subscribe(DownloadGrabbed, DownloadFailed)
+	defer unsubscribe()
+
+	// Progress the subscriber did not ask for does not fill its buffer
+	for i := 0; i < subscriberBuffer*2; i++ {
+		bus.Publish(DownloadProgress, nil)
+	}
+	bus.Publish(DownloadGrabbed, nil)
+	bus.Publish(DownloadFailed, nil)
+
+	require.Len(t, ch, 2)
+	assert.Equal(t, DownloadGrabbed, (<-ch).Type)
+	assert.Equal(t, DownloadFailed, (<-ch).Type)
+}
+
+func TestBus_Nil(t *testing.T) {
+	var bus *Bus
+	bus.Publish(DownloadProgress, nil)
+	assert.Equal(t, 0, bus.SubscriberCount())
+
+	ch, unsubscribe := bus.Subscribe()
+	unsubscribe()
+	unsubscribe()
+	_, open := <-ch
+	assert.False(t, open)
+}
@@ -18,7 +18,7 @@ type Audiobook struct {
 	Book   Book `gorm:"foreignKey:BookID" json:"book,omitempty"`
 
 	// Audiobook-specific information
-	Narrator  string `json:"narrator,omitempty"`
+	Narrator  string `json:"narrator,omitempty"` // As credited; Book.Contributors links Narrator records
 	Publisher string `json:"publisher,omitempty"`
 	Duration  int    `json:"duration,omitempty"` // Duration in seconds
 	Format    string `json:"format,omitempty"`   // mp3, m4b, m4a, etc.
@@ -6,6 +6,23 @@ import (
 	"gorm.io/gorm"
 )
 
+// AuthorMonitorOption controls which books of a monitored author are wanted:
+// every book, only books released after monitoring started, or none (the
+// bibliography is kept up to date but nothing is added to the library). It
+// is empty until it is first set.
+type AuthorMonitorOption string
+
+const (
+	AuthorMonitorAll    AuthorMonitorOption = "all"
+	AuthorMonitorFuture AuthorMonitorOption = "future"
+	AuthorMonitorNone   AuthorMonitorOption = "none"
+)
+
+// IsValid returns true if the option is a known monitor option
+func (o AuthorMonitorOption) IsValid() bool {
+	return o == AuthorMonitorAll || o == AuthorMonitorFuture || o == AuthorMonitorNone
+}
+
 // Author represents an author of books
 type Author struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
@@ -19,6 +36,12 @@ type Author struct {
 	ImageURL    string `json:"image_url,omitempty"`
 	GoodreadsID string `gorm:"index" json:"goodreads_id,omitempty"`
 
+	// Monitoring
+	Monitored       bool                `gorm:"index;default:false" json:"monitored"`
+	MonitorOption   AuthorMonitorOption `json:"monitor_option"`
+	MonitoredSince  *time.Time          `json:"monitored_since,omitempty"`
+	LastRefreshedAt *time.Time          `json:"last_refreshed_at,omitempty"`
+
 	// Relationships
 	Books []Book `gorm:"foreignKey:AuthorID" json:"books,omitempty"`
 }
@@ -24,12 +24,11 @@ type Book struct {
 	Language    string     `json:"language,omitempty"`
 
 	// Relationships
-	AuthorID uint   `gorm:"not null;index" json:"author_id"`
+	AuthorID uint   `gorm:"not null;index" json:"author_id"` // Primary author; co-authors are in Contributors
 	Author   Author `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
 
-	SeriesID       *uint   `gorm:"index" json:"series_id,omitempty"`
-	Series         *Series `gorm:"foreignKey:SeriesID" json:"series,omitempty"`
-	SeriesPosition *int    `json:"series_position,omitempty"`
+	SeriesMemberships []BookSeries      `gorm:"foreignKey:BookID" json:"series,omitempty"`
+	Contributors      []BookContributor `gorm:"foreignKey:BookID" json:"contributors,omitempty"`
 
 	// Related models
 	Audiobook    *Audiobook    `gorm:"foreignKey:BookID" json:"audiobook,omitempty"`
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ContributorRole is the part a person played in making a book
+type ContributorRole string
+
+const (
+	ContributorRoleAuthor     ContributorRole = "author"
+	ContributorRoleNarrator   ContributorRole = "narrator"
+	ContributorRoleTranslator ContributorRole = "translator"
+	ContributorRoleEditor     ContributorRole = "editor"
+)
+
+// IsValid returns true if the role is a known contributor role
+func (r ContributorRole) IsValid() bool {
+	switch r {
+	case ContributorRoleAuthor, ContributorRoleNarrator, ContributorRoleTranslator, ContributorRoleEditor:
+		return true
+	}
+	return false
+}
+
+// IsNarration returns true if the role is credited to a Narrator rather than an Author
+func (r ContributorRole) IsNarration() bool {
+	return r == ContributorRoleNarrator
+}
+
+// BookContributor credits a person with a role on a book. Narrator credits
+// reference a Narrator; author, translator and editor credits reference an
+// Author. Credits are listed in the order they were added, so the first
+// author credit is the book's primary author (Book.AuthorID).
+type BookContributor struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	BookID uint            `gorm:"not null;index" json:"book_id"`
+	Book   Book            `gorm:"foreignKey:BookID" json:"book,omitempty"`
+	Role   ContributorRole `gorm:"not null;index" json:"role"`
+
+	AuthorID   *uint     `gorm:"index" json:"author_id,omitempty"`
+	Author     *Author   `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	NarratorID *uint     `gorm:"index" json:"narrator_id,omitempty"`
+	Narrator   *Narrator `gorm:"foreignKey:NarratorID" json:"narrator,omitempty"`
+}
+
+// TableName specifies the table name for BookContributor
+func (BookContributor) TableName() string {
+	return "book_contributors"
+}
+
+// Name returns the credited person's name, if Author or Narrator is loaded
+func (bc *BookContributor) Name() string {
+	if bc.Narrator != nil {
+		return bc.Narrator.Name
+	}
+	if bc.Author != nil {
+		return bc.Author.Name
+	}
+	return ""
+}
+
+// FindOrCreateAuthor returns the author with the given name, creating it if needed
+func FindOrCreateAuthor(db *gorm.DB, name string) (*Author, error) {
+	var author Author
+	err := db.Where("name = ?", name).First(&author).Error
+	if err == gorm.ErrRecordNotFound {
+		author = Author{Name: name}
+		err = db.Create(&author).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+// FindOrCreateNarrator returns the narrator with the given name, creating it if needed
+func FindOrCreateNarrator(db *gorm.DB, name string) (*Narrator, error) {
+	var narrator Narrator
+	err := db.Where("name = ?", name).First(&narrator).Error
+	if err == gorm.ErrRecordNotFound {
+		narrator = Narrator{Name: name}
+		err = db.Create(&narrator).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &narrator, nil
+}
+
+// CreditAuthor credits an author with a role on a book. Crediting the same
+// author with the same role again is a no-op.
+func CreditAuthor(db *gorm.DB, bookID, authorID uint, role ContributorRole) error {
+	credit := BookContributor{BookID: bookID, Role: role, AuthorID: &authorID}
+	return db.Where("book_id = ? AND role = ? AND author_id = ?", bookID, role, authorID).
+		FirstOrCreate(&credit).Error
+}
+
+// CreditNarrator credits a narrator on a book. Crediting the same narrator
+// again is a no-op.
+func CreditNarrator(db *gorm.DB, bookID, narratorID uint) error {
+	credit := BookContributor{BookID: bookID, Role: ContributorRoleNarrator, NarratorID: &narratorID}
+	return db.Where("book_id = ? AND role = ? AND narrator_id = ?", bookID, ContributorRoleNarrator, narratorID).
+		FirstOrCreate(&credit).Error
+}
+
+// CreditContributors credits a book's primary author followed by the named
+// authors and narrators, creating Author and Narrator rows as needed.
+// Typically used with the Authors and Narrators of a metadata lookup.
+func CreditContributors(db *gorm.DB, book *Book, authors, narrators []string) error {
+	if err := CreditAuthor(db, book.ID, book.AuthorID, ContributorRoleAuthor); err != nil {
+		return err
+	}
+	for _, name := range authors {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		author, err := FindOrCreateAuthor(db, name)
+		if err != nil {
+			return err
+		}
+		if err := CreditAuthor(db, book.ID, author.ID, ContributorRoleAuthor); err != nil {
+			return err
+		}
+	}
+	for _, name := range narrators {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		narrator, err := FindOrCreateNarrator(db, name)
+		if err != nil {
+			return err
+		}
+		if err := CreditNarrator(db, book.ID, narrator.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SplitCredits splits a free-text credit such as "Jim Dale, Stephen Fry and
+// Kate Reading" into individual names
+func SplitCredits(credit string) []string {
+	replacer := strings.NewReplacer(" and ", ",", " & ", ",", ";", ",")
+	var names []string
+	for _, name := range strings.Split(replacer.Replace(credit), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
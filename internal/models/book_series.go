@@ -0,0 +1,91 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BookSeries links a book to a series it belongs to. A book can be part of
+// several series (e.g. a sub-series and the wider universe), and positions
+// are kept as text since values like "2.5" or "0.5" are common for novellas.
+type BookSeries struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	BookID   uint   `gorm:"not null;uniqueIndex:idx_book_series_book_series" json:"book_id"`
+	Book     Book   `gorm:"foreignKey:BookID" json:"book,omitempty"`
+	SeriesID uint   `gorm:"not null;uniqueIndex:idx_book_series_book_series;index" json:"series_id"`
+	Series   Series `gorm:"foreignKey:SeriesID" json:"series,omitempty"`
+
+	Position string   `json:"position,omitempty"` // As published: "1", "2.5", "0"
+	Sequence *float64 `json:"sequence,omitempty"` // Numeric position for ordering, nil if Position is not a number
+}
+
+// TableName specifies the table name for BookSeries
+func (BookSeries) TableName() string {
+	return "book_series"
+}
+
+// BeforeSave keeps Sequence in sync with Position
+func (bs *BookSeries) BeforeSave(tx *gorm.DB) error {
+	bs.Sequence = ParseSeriesSequence(bs.Position)
+	return nil
+}
+
+// IsWhole returns true if the position is a whole number, i.e. a main
+// entry of the series rather than a novella or an unnumbered book
+func (bs *BookSeries) IsWhole() bool {
+	return bs.Sequence != nil && *bs.Sequence >= 1 && *bs.Sequence == float64(int(*bs.Sequence))
+}
+
+// ParseSeriesSequence returns the numeric value of a series position such
+// as "2", "2.5" or "2,5", or nil if it is not a number
+func ParseSeriesSequence(position string) *float64 {
+	position = strings.ReplaceAll(strings.TrimSpace(position), ",", ".")
+	if position == "" {
+		return nil
+	}
+	sequence, err := strconv.ParseFloat(position, 64)
+	if err != nil {
+		return nil
+	}
+	return &sequence
+}
+
+// LinkBookToSeries adds a book to the named series, creating the series if
+// needed. An existing membership has its position updated.
+func LinkBookToSeries(db *gorm.DB, bookID uint, seriesName, position string) (*BookSeries, error) {
+	var series Series
+	err := db.Where("name = ?", seriesName).First(&series).Error
+	if err == gorm.ErrRecordNotFound {
+		series = Series{Name: seriesName}
+		if err := db.Create(&series).Error; err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	var link BookSeries
+	err = db.Where("book_id = ? AND series_id = ?", bookID, series.ID).First(&link).Error
+	if err == gorm.ErrRecordNotFound {
+		link = BookSeries{BookID: bookID, SeriesID: series.ID, Position: position}
+		if err := db.Create(&link).Error; err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else if link.Position != position {
+		link.Position = position
+		if err := db.Save(&link).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	link.Series = series
+	return &link, nil
+}
@@ -15,6 +15,7 @@ const (
 	DownloadStatusCompleted   DownloadStatus = "completed"
 	DownloadStatusFailed      DownloadStatus = "failed"
 	DownloadStatusPaused      DownloadStatus = "paused"
+	DownloadStatusCancelled   DownloadStatus = "cancelled"
 )
 
 // Download represents a download task
@@ -61,3 +62,8 @@ func (d *Download) IsComplete() bool {
 func (d *Download) IsFailed() bool {
 	return d.Status == DownloadStatusFailed
 }
+
+// IsCancelled returns true if download was cancelled by the user
+func (d *Download) IsCancelled() bool {
+	return d.Status == DownloadStatusCancelled
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HistoryEventType represents the kind of event recorded in history
+type HistoryEventType string
+
+const (
+	HistoryEventGrabbed        HistoryEventType = "grabbed"
+	HistoryEventDownloadFailed HistoryEventType = "download_failed"
+	HistoryEventImported       HistoryEventType = "imported"
+	HistoryEventUpgraded       HistoryEventType = "upgraded"
+	HistoryEventDeleted        HistoryEventType = "deleted"
+	HistoryEventRenamed        HistoryEventType = "renamed"
+
+	// A media server could not be told about an import; the import itself succeeded
+	HistoryEventMediaServerFailed HistoryEventType = "media_server_failed"
+	// Metadata could not be embedded into an imported file; the import itself succeeded
+	HistoryEventTaggingFailed HistoryEventType = "tagging_failed"
+	// A library rescan found an item's files gone, changed in size, or back on disk
+	HistoryEventFileMissing  HistoryEventType = "file_missing"
+	HistoryEventFileChanged  HistoryEventType = "file_changed"
+	HistoryEventFileRestored HistoryEventType = "file_restored"
+	// Summary of a library rescan, including audio files that belong to no library item
+	HistoryEventLibraryRescanned HistoryEventType = "library_rescanned"
+	// A notification could not be sent
+	HistoryEventNotificationFailed HistoryEventType = "notification_failed"
+	// A custom script ran for an event, with its exit status and output
+	HistoryEventScriptRan HistoryEventType = "script_ran"
+)
+
+// History represents an entry in the download and activity log
+type History struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Event information
+	EventType HistoryEventType `gorm:"not null;index" json:"event_type"`
+	Date      time.Time        `gorm:"not null;index" json:"date"`
+	Message   string           `gorm:"type:text" json:"message,omitempty"`
+
+	// Relationships (all optional, an event may outlive the records it refers to)
+	BookID        *uint   `gorm:"index" json:"book_id,omitempty"`
+	Book          *Book   `gorm:"foreignKey:BookID" json:"book,omitempty"`
+	AuthorID      *uint   `gorm:"index" json:"author_id,omitempty"`
+	Author        *Author `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	LibraryItemID *uint   `gorm:"index" json:"library_item_id,omitempty"`
+	DownloadID    *uint   `gorm:"index" json:"download_id,omitempty"`
+
+	// Release and download details
+	ReleaseTitle   string `json:"release_title,omitempty"`
+	Indexer        string `json:"indexer,omitempty"`
+	DownloadClient string `json:"download_client,omitempty"`
+	DownloadHash   string `gorm:"index" json:"download_hash,omitempty"`
+	Quality        string `json:"quality,omitempty"`
+
+	// File details
+	SourcePath      string `gorm:"type:text" json:"source_path,omitempty"`
+	DestinationPath string `gorm:"type:text" json:"destination_path,omitempty"`
+}
+
+// TableName specifies the table name for History
+func (History) TableName() string {
+	return "history"
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImportCandidateStatus represents the review status of an import candidate
+type ImportCandidateStatus string
+
+const (
+	ImportCandidateStatusPending  ImportCandidateStatus = "pending"
+	ImportCandidateStatusImported ImportCandidateStatus = "imported"
+	ImportCandidateStatusSkipped  ImportCandidateStatus = "skipped"
+)
+
+// ImportCandidate is a book found on disk by a library scan, waiting to be
+// confirmed or skipped
+type ImportCandidate struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Files on disk
+	Path  string `gorm:"type:text;not null;uniqueIndex" json:"path"` // Book folder, or a single file
+	Files int    `json:"files"`
+	Size  int64  `json:"size"` // Size in bytes
+
+	// What the tags (or folder names) say about the book
+	Title    string `json:"title,omitempty"`
+	Author   string `json:"author,omitempty"`
+	Narrator string `json:"narrator,omitempty"`
+	Series   string `json:"series,omitempty"`
+	Sequence string `json:"sequence,omitempty"`
+	ASIN     string `json:"asin,omitempty"`
+	Format   string `json:"format,omitempty"`
+	Duration int    `json:"duration,omitempty"` // Duration in seconds
+	Bitrate  int    `json:"bitrate,omitempty"`  // kbps
+
+	// Match
+	BookID        *uint                 `gorm:"index" json:"book_id,omitempty"` // Existing book the files belong to
+	MatchSource   string                `json:"match_source,omitempty"`         // Metadata provider of the match
+	Metadata      string                `gorm:"type:text" json:"-"`             // JSON encoded provider metadata
+	Status        ImportCandidateStatus `gorm:"not null;index;default:'pending'" json:"status"`
+	LibraryItemID *uint                 `json:"library_item_id,omitempty"` // Set once imported
+	Error         string                `gorm:"type:text" json:"error,omitempty"`
+}
+
+// TableName specifies the table name for ImportCandidate
+func (ImportCandidate) TableName() string {
+	return "import_candidates"
+}
+
+// IsPending returns true if the candidate has not been reviewed yet
+func (c *ImportCandidate) IsPending() bool {
+	return c.Status == ImportCandidateStatusPending
+}
@@ -15,6 +15,7 @@ const (
 	LibraryItemStatusProcessing  LibraryItemStatus = "processing"
 	LibraryItemStatusAvailable   LibraryItemStatus = "available"
 	LibraryItemStatusError       LibraryItemStatus = "error"
+	LibraryItemStatusMissing     LibraryItemStatus = "missing" // Files were available but are gone from disk
 )
 
 // LibraryItem represents an item in the user's library
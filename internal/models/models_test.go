@@ -5,24 +5,31 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database/dbtest"
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	assert.NoError(t, err)
+	db := dbtest.Open(t)
 
 	// Migrate all models
-	err = db.AutoMigrate(
+	err := db.AutoMigrate(
 		&Author{},
 		&Series{},
 		&Book{},
+		&BookSeries{},
+		&Narrator{},
+		&BookContributor{},
 		&Audiobook{},
 		&Release{},
 		&LibraryItem{},
 		&Download{},
 		&ProcessingTask{},
+		&History{},
+		&ImportCandidate{},
+		&ScheduledTask{},
+		&Notification{},
 	)
 	assert.NoError(t, err)
 
@@ -48,6 +55,15 @@ func TestAuthor(t *testing.T) {
 	err = db.First(&retrieved, author.ID).Error
 	assert.NoError(t, err)
 	assert.Equal(t, author.Name, retrieved.Name)
+	assert.False(t, retrieved.Monitored)
+	assert.Empty(t, retrieved.MonitorOption, "never set")
+}
+
+func TestAuthorMonitorOption_IsValid(t *testing.T) {
+	assert.True(t, AuthorMonitorAll.IsValid())
+	assert.True(t, AuthorMonitorFuture.IsValid())
+	assert.True(t, AuthorMonitorNone.IsValid())
+	assert.False(t, AuthorMonitorOption("sometimes").IsValid())
 }
 
 func TestBook_WithAuthor(t *testing.T) {
@@ -85,23 +101,134 @@ func TestBook_WithSeries(t *testing.T) {
 	series := Series{Name: "Test Series"}
 	db.Create(&series)
 
-	position := 1
 	book := Book{
-		Title:          "Test Book",
-		AuthorID:       author.ID,
-		SeriesID:       &series.ID,
-		SeriesPosition: &position,
+		Title:    "Test Book",
+		AuthorID: author.ID,
 	}
 
 	err := db.Create(&book).Error
 	assert.NoError(t, err)
 
+	link := BookSeries{BookID: book.ID, SeriesID: series.ID, Position: "1"}
+	err = db.Create(&link).Error
+	assert.NoError(t, err)
+
 	// Retrieve with series
 	var retrieved Book
-	err = db.Preload("Series").First(&retrieved, book.ID).Error
+	err = db.Preload("SeriesMemberships.Series").First(&retrieved, book.ID).Error
+	assert.NoError(t, err)
+	assert.Len(t, retrieved.SeriesMemberships, 1)
+	assert.Equal(t, series.Name, retrieved.SeriesMemberships[0].Series.Name)
+	assert.Equal(t, "1", retrieved.SeriesMemberships[0].Position)
+}
+
+func TestBook_WithMultipleSeries(t *testing.T) {
+	db := setupTestDB(t)
+
+	author := Author{Name: "Test Author"}
+	db.Create(&author)
+
+	book := Book{Title: "Edgedancer", AuthorID: author.ID}
+	db.Create(&book)
+
+	link, err := LinkBookToSeries(db, book.ID, "The Stormlight Archive", "2.5")
+	assert.NoError(t, err)
+	assert.NotNil(t, link.Sequence)
+	assert.Equal(t, 2.5, *link.Sequence)
+	assert.False(t, link.IsWhole())
+
+	_, err = LinkBookToSeries(db, book.ID, "The Cosmere", "")
+	assert.NoError(t, err)
+
+	// Linking again updates the position instead of adding a duplicate
+	link, err = LinkBookToSeries(db, book.ID, "The Stormlight Archive", "3")
+	assert.NoError(t, err)
+	assert.True(t, link.IsWhole())
+
+	var retrieved Book
+	err = db.Preload("SeriesMemberships.Series").First(&retrieved, book.ID).Error
+	assert.NoError(t, err)
+	assert.Len(t, retrieved.SeriesMemberships, 2)
+
+	var seriesCount int64
+	db.Model(&Series{}).Count(&seriesCount)
+	assert.Equal(t, int64(2), seriesCount)
+}
+
+func TestParseSeriesSequence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected *float64
+	}{
+		{"1", floatPtr(1)},
+		{" 2.5 ", floatPtr(2.5)},
+		{"2,5", floatPtr(2.5)},
+		{"0", floatPtr(0)},
+		{"", nil},
+		{"Prequel", nil},
+		{"1-3", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseSeriesSequence(tt.input))
+		})
+	}
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestContributorRole_IsValid(t *testing.T) {
+	assert.True(t, ContributorRoleAuthor.IsValid())
+	assert.True(t, ContributorRoleNarrator.IsValid())
+	assert.True(t, ContributorRoleTranslator.IsValid())
+	assert.True(t, ContributorRoleEditor.IsValid())
+	assert.False(t, ContributorRole("illustrator").IsValid())
+}
+
+func TestCreditContributors(t *testing.T) {
+	db := setupTestDB(t)
+
+	author := Author{Name: "Neil Gaiman"}
+	db.Create(&author)
+
+	book := Book{Title: "Good Omens", AuthorID: author.ID}
+	db.Create(&book)
+
+	authors := []string{"Neil Gaiman", "Terry Pratchett"}
+	narrators := []string{"Martin Jarvis", " "}
+	err := CreditContributors(db, &book, authors, narrators)
+	assert.NoError(t, err)
+
+	// Crediting again does not add duplicates
+	err = CreditContributors(db, &book, authors, narrators)
+	assert.NoError(t, err)
+
+	var retrieved Book
+	err = db.Preload("Contributors.Author").Preload("Contributors.Narrator").First(&retrieved, book.ID).Error
 	assert.NoError(t, err)
-	assert.Equal(t, series.Name, retrieved.Series.Name)
-	assert.Equal(t, 1, *retrieved.SeriesPosition)
+	assert.Len(t, retrieved.Contributors, 3)
+
+	names := make(map[string]ContributorRole)
+	for i := range retrieved.Contributors {
+		names[retrieved.Contributors[i].Name()] = retrieved.Contributors[i].Role
+	}
+	assert.Equal(t, ContributorRoleAuthor, names["Neil Gaiman"])
+	assert.Equal(t, ContributorRoleAuthor, names["Terry Pratchett"])
+	assert.Equal(t, ContributorRoleNarrator, names["Martin Jarvis"])
+
+	var narratorCount int64
+	db.Model(&Narrator{}).Count(&narratorCount)
+	assert.Equal(t, int64(1), narratorCount)
+}
+
+func TestSplitCredits(t *testing.T) {
+	assert.Equal(t, []string{"Jim Dale"}, SplitCredits("Jim Dale"))
+	assert.Equal(t, []string{"Jim Dale", "Stephen Fry", "Kate Reading"}, SplitCredits("Jim Dale, Stephen Fry and Kate Reading"))
+	assert.Equal(t, []string{"A", "B"}, SplitCredits("A & B;"))
+	assert.Nil(t, SplitCredits(""))
 }
 
 func TestAudiobook_WithBook(t *testing.T) {
@@ -337,3 +464,45 @@ func TestRelease(t *testing.T) {
 	assert.Equal(t, release.Indexer, retrieved.Indexer)
 	assert.Equal(t, book.Title, retrieved.Book.Title)
 }
+
+func TestScheduledTask_IsDue(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	task := ScheduledTask{Name: "library_rescan"}
+	assert.NoError(t, db.Create(&task).Error)
+	assert.False(t, task.IsEnabled())
+	assert.False(t, task.IsDue(now))
+
+	next := now.Add(time.Minute)
+	task.Interval = 60
+	task.NextRunAt = &next
+	assert.NoError(t, db.Save(&task).Error)
+	assert.True(t, task.IsEnabled())
+	assert.False(t, task.IsDue(now))
+	assert.True(t, task.IsDue(next))
+
+	// Names are unique
+	assert.Error(t, db.Create(&ScheduledTask{Name: "library_rescan"}).Error)
+}
+
+func TestNotification_Settings(t *testing.T) {
+	db := setupTestDB(t)
+
+	notification := Notification{Name: "Email", Type: NotificationTypeEmail, Enabled: true, OnImport: true}
+	notification.SetSettings(NotificationSettings{Host: "smtp.example.com", Port: 587, To: []string{"me@example.com"}})
+	assert.NoError(t, db.Create(&notification).Error)
+
+	var loaded Notification
+	assert.NoError(t, db.First(&loaded, notification.ID).Error)
+	settings := loaded.GetSettings()
+	assert.Equal(t, "smtp.example.com", settings.Host)
+	assert.Equal(t, 587, settings.Port)
+	assert.Equal(t, []string{"me@example.com"}, settings.To)
+	assert.True(t, loaded.OnImport)
+	assert.False(t, loaded.OnGrab)
+
+	assert.True(t, NotificationTypeNtfy.IsValid())
+	assert.True(t, NotificationTypeScript.IsValid())
+	assert.False(t, NotificationType("slack").IsValid())
+}
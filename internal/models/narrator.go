@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Narrator represents a narrator of audiobooks
+type Narrator struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Narrator information
+	Name      string `gorm:"not null;index" json:"name"`
+	Biography string `gorm:"type:text" json:"biography,omitempty"`
+	ImageURL  string `json:"image_url,omitempty"`
+
+	// Relationships
+	Credits []BookContributor `gorm:"foreignKey:NarratorID" json:"credits,omitempty"`
+}
+
+// TableName specifies the table name for Narrator
+func (Narrator) TableName() string {
+	return "narrators"
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NotificationType identifies where a notification connection sends messages
+type NotificationType string
+
+const (
+	NotificationTypeWebhook  NotificationType = "webhook"
+	NotificationTypeDiscord  NotificationType = "discord"
+	NotificationTypeEmail    NotificationType = "email"
+	NotificationTypeGotify   NotificationType = "gotify"
+	NotificationTypeNtfy     NotificationType = "ntfy"
+	NotificationTypePushover NotificationType = "pushover"
+	NotificationTypeScript   NotificationType = "custom_script"
+)
+
+// IsValid returns true if t is a known notification type
+func (t NotificationType) IsValid() bool {
+	switch t {
+	case NotificationTypeWebhook, NotificationTypeDiscord, NotificationTypeEmail,
+		NotificationTypeGotify, NotificationTypeNtfy, NotificationTypePushover,
+		NotificationTypeScript:
+		return true
+	}
+	return false
+}
+
+// NotificationSettings holds the connection details of a notification. Which
+// fields are used depends on the type.
+type NotificationSettings struct {
+	URL      string `json:"url,omitempty"`      // Webhook, Discord, Gotify and ntfy server; Pushover API override
+	Token    string `json:"token,omitempty"`    // Gotify app token, ntfy access token, Pushover app token
+	Topic    string `json:"topic,omitempty"`    // ntfy topic
+	UserKey  string `json:"user_key,omitempty"` // Pushover user or group key
+	Priority int    `json:"priority,omitempty"` // Gotify, ntfy and Pushover priority
+
+	// Email
+	Host     string   `json:"host,omitempty"`
+	Port     int      `json:"port,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	TLS      bool     `json:"tls,omitempty"` // Implicit TLS (port 465); otherwise STARTTLS is used when offered
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+
+	// Custom script, run directly without a shell
+	Path      string   `json:"path,omitempty"`      // Absolute path of the executable
+	Arguments []string `json:"arguments,omitempty"` // Passed as they are, no interpolation
+	Timeout   int      `json:"timeout,omitempty"`   // Seconds; 0 uses the default
+}
+
+// Notification is a connection that is told about grabs, imports, upgrades,
+// deletions, failures and health issues it is subscribed to
+type Notification struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Name    string           `gorm:"not null" json:"name"`
+	Type    NotificationType `gorm:"not null" json:"type"`
+	Enabled bool             `gorm:"not null" json:"enabled"`
+
+	// Event subscriptions
+	OnGrab        bool `gorm:"not null" json:"on_grab"`
+	OnImport      bool `gorm:"not null" json:"on_import"`
+	OnUpgrade     bool `gorm:"not null" json:"on_upgrade"`
+	OnDelete      bool `gorm:"not null;default:false" json:"on_delete"`
+	OnFailure     bool `gorm:"not null" json:"on_failure"`
+	OnHealthIssue bool `gorm:"not null" json:"on_health_issue"`
+
+	Settings string `gorm:"type:text" json:"-"` // JSON encoded NotificationSettings
+}
+
+// TableName specifies the table name for Notification
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// GetSettings decodes the connection settings
+func (n *Notification) GetSettings() NotificationSettings {
+	var settings NotificationSettings
+	if n.Settings != "" {
+		_ = json.Unmarshal([]byte(n.Settings), &settings)
+	}
+	return settings
+}
+
+// SetSettings encodes the connection settings
+func (n *Notification) SetSettings(settings NotificationSettings) {
+	data, _ := json.Marshal(settings)
+	n.Settings = string(data)
+}
@@ -18,6 +18,7 @@ type Release struct {
 	Book   Book `gorm:"foreignKey:BookID" json:"book,omitempty"`
 
 	// Release information
+	Title       string     `json:"title,omitempty"`                   // Release name as reported by the indexer
 	Quality     string     `json:"quality,omitempty"`                 // 64kbps, 128kbps, etc.
 	Format      string     `json:"format,omitempty"`                  // mp3, m4b, etc.
 	Size        int64      `json:"size,omitempty"`                    // Size in bytes
@@ -29,6 +30,7 @@ type Release struct {
 	Seeders     int        `json:"seeders,omitempty"`
 	Leechers    int        `json:"leechers,omitempty"`
 	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Blocklisted bool       `gorm:"index;default:false" json:"blocklisted"` // Never grab this release again
 }
 
 // TableName specifies the table name for Release
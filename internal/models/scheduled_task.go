@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ScheduledTask records when a periodic background task last ran and when
+// it runs next
+type ScheduledTask struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Name         string     `gorm:"not null;uniqueIndex" json:"name"`
+	Interval     int        `gorm:"not null;default:0" json:"interval"` // Seconds between runs; 0 runs only when triggered
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	NextRunAt    *time.Time `gorm:"index" json:"next_run_at,omitempty"`
+	LastDuration int64      `json:"last_duration"` // Milliseconds
+	LastError    string     `gorm:"type:text" json:"last_error,omitempty"`
+}
+
+// TableName specifies the table name for ScheduledTask
+func (ScheduledTask) TableName() string {
+	return "scheduled_tasks"
+}
+
+// IsEnabled returns true if the task runs on a schedule
+func (t *ScheduledTask) IsEnabled() bool {
+	return t.Interval > 0
+}
+
+// IsDue returns true if the task is scheduled to run at or before now
+func (t *ScheduledTask) IsDue(now time.Time) bool {
+	return t.IsEnabled() && t.NextRunAt != nil && !t.NextRunAt.After(now)
+}
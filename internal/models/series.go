@@ -19,7 +19,7 @@ type Series struct {
 	TotalBooks  int    `json:"total_books,omitempty"`
 
 	// Relationships
-	Books []Book `gorm:"foreignKey:SeriesID" json:"books,omitempty"`
+	Books []BookSeries `gorm:"foreignKey:SeriesID" json:"books,omitempty"`
 }
 
 // TableName specifies the table name for Series
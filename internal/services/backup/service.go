@@ -0,0 +1,459 @@
+// Package backup takes zip backups of the database and config file, and
+// stages backups to be restored on the next start
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/listenarr/listenarr/internal/config"
+	"github.com/listenarr/listenarr/internal/database"
+	"github.com/listenarr/listenarr/pkg/diskspace"
+)
+
+var (
+	// ErrBackupNotFound is returned for names no backup exists under
+	ErrBackupNotFound = errors.New("backup not found")
+
+	// ErrInvalidBackup is returned when an archive is not a usable backup
+	ErrInvalidBackup = errors.New("invalid backup")
+
+	// ErrUnsupported is returned when the database cannot be backed up
+	ErrUnsupported = errors.New("backups are only supported for SQLite databases")
+
+	// ErrInsufficientSpace is returned when the database folder has no room
+	// for the restored database
+	ErrInsufficientSpace = diskspace.ErrInsufficientSpace
+)
+
+// What started a backup
+const (
+	TypeScheduled = "scheduled"
+	TypeManual    = "manual"
+)
+
+// Names of the files inside a backup archive
+const (
+	DatabaseEntry = "listenarr.db"
+	ConfigEntry   = "config.yml"
+)
+
+// Largest entries a restore extracts, so an archive cannot fill the disk
+const (
+	MaxDatabaseSize = 16 << 30 // 16 GiB
+	MaxConfigSize   = 1 << 20  // 1 MiB
+)
+
+// Tables a database must have to be restored
+var requiredTables = []string{"authors", "books", "library_items"}
+
+// Backup archives are named listenarr_backup_<type>_<time>.zip
+const timeFormat = "20060102_150405.000"
+
+var namePattern = regexp.MustCompile(`^listenarr_backup_(scheduled|manual)_(\d{8}_\d{6}\.\d{3})\.zip$`)
+
+// Config holds configuration for the backup service
+type Config struct {
+	DatabasePath string
+	ConfigFile   string // Left out of backups when empty or missing
+	Folder       string
+	Retention    int // Scheduled backups kept; 0 keeps them all
+}
+
+// Backup is an archive in the backups folder
+type Backup struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Service creates, lists and restores backups
+type Service struct {
+	db     *gorm.DB
+	config Config
+	mu     sync.Mutex // Held while a backup is written or staged
+}
+
+// NewService creates a new backup service
+func NewService(db *gorm.DB, config Config) *Service {
+	return &Service{db: db, config: config}
+}
+
+// Create takes a backup of the database and config file. Scheduled backups
+// beyond the retention are deleted afterwards.
+func (s *Service) Create(backupType string) (*Backup, error) {
+	if !s.supported() {
+		return nil, ErrUnsupported
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.config.Folder, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup folder: %w", err)
+	}
+
+	// VACUUM INTO writes a consistent copy while the database stays in use,
+	// but refuses to overwrite a file
+	snapshot, err := os.CreateTemp(s.config.Folder, ".listenarr-backup-*.db")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database snapshot: %w", err)
+	}
+	snapshot.Close()
+	os.Remove(snapshot.Name())
+	defer os.Remove(snapshot.Name())
+	if err := s.db.Exec("VACUUM INTO ?", snapshot.Name()).Error; err != nil {
+		return nil, fmt.Errorf("failed to create database snapshot: %w", err)
+	}
+
+	created := time.Now()
+	name := fmt.Sprintf("listenarr_backup_%s_%s.zip", backupType, created.Format(timeFormat))
+	path := filepath.Join(s.config.Folder, name)
+	if err := s.writeArchive(path, snapshot.Name()); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("backup: created %s", name)
+
+	if backupType == TypeScheduled {
+		s.prune()
+	}
+	return &Backup{Name: name, Type: backupType, Size: info.Size(), CreatedAt: created}, nil
+}
+
+// supported returns true if the database is one backups can be taken of
+// and restored to; only SQLite databases are files Listenarr can swap
+func (s *Service) supported() bool {
+	return s.db.Dialector.Name() == database.DriverSQLite
+}
+
+// List returns the backups in the backup folder, newest first
+func (s *Service) List() ([]Backup, error) {
+	entries, err := os.ReadDir(s.config.Folder)
+	if os.IsNotExist(err) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup folder: %w", err)
+	}
+
+	backups := []Backup{}
+	for _, entry := range entries {
+		match := namePattern.FindStringSubmatch(entry.Name())
+		if match == nil || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Deleted while listing
+		}
+		created, err := time.ParseInLocation(timeFormat, match[2], time.Local)
+		if err != nil {
+			created = info.ModTime()
+		}
+		backups = append(backups, Backup{Name: entry.Name(), Type: match[1], Size: info.Size(), CreatedAt: created})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// Path returns the path of the named backup. Only names of backups in the
+// backup folder are accepted, so the path never leaves it.
+func (s *Service) Path(name string) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", ErrBackupNotFound
+	}
+	path := filepath.Join(s.config.Folder, name)
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return "", ErrBackupNotFound
+	}
+	return path, nil
+}
+
+// Delete removes the named backup
+func (s *Service) Delete(name string) error {
+	path, err := s.Path(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Restore stages the named backup to replace the database and config file
+// on the next start
+func (s *Service) Restore(name string) error {
+	if !s.supported() {
+		return ErrUnsupported
+	}
+	path, err := s.Path(name)
+	if err != nil {
+		return err
+	}
+	return s.RestoreArchive(path)
+}
+
+// RestoreArchive checks that the archive at path is a backup with an intact
+// database, and stages it to replace the database and config file on the
+// next start. Nothing is staged when the check fails.
+func (s *Service) RestoreArchive(path string) error {
+	if !s.supported() {
+		return ErrUnsupported
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	defer archive.Close()
+
+	var dbEntry, configEntry *zip.File
+	for _, file := range archive.File {
+		switch file.Name {
+		case DatabaseEntry:
+			dbEntry = file
+		case ConfigEntry:
+			configEntry = file
+		}
+	}
+	if dbEntry == nil {
+		return fmt.Errorf("%w: archive has no %s", ErrInvalidBackup, DatabaseEntry)
+	}
+
+	dbStaged := s.config.DatabasePath + config.RestoreSuffix
+	partial := dbStaged + ".partial"
+	defer os.Remove(partial)
+	if err := extract(dbEntry, partial); err != nil {
+		return err
+	}
+	if err := checkDatabase(partial); err != nil {
+		return err
+	}
+
+	var configData []byte
+	if configEntry != nil && s.config.ConfigFile != "" {
+		if configData, err = readEntry(configEntry); err != nil {
+			return err
+		}
+		v := viper.New()
+		v.SetConfigType("yaml")
+		if err := v.ReadConfig(bytes.NewReader(configData)); err != nil {
+			return fmt.Errorf("%w: %s is not valid: %v", ErrInvalidBackup, ConfigEntry, err)
+		}
+	}
+
+	if err := os.Rename(partial, dbStaged); err != nil {
+		return fmt.Errorf("failed to stage database: %w", err)
+	}
+	if configData != nil {
+		if err := os.WriteFile(s.config.ConfigFile+config.RestoreSuffix, configData, 0644); err != nil {
+			os.Remove(dbStaged)
+			return fmt.Errorf("failed to stage config file: %w", err)
+		}
+	} else if s.config.ConfigFile != "" {
+		// A config file staged by an earlier restore does not belong to this database
+		if err := os.Remove(s.config.ConfigFile + config.RestoreSuffix); err != nil && !os.IsNotExist(err) {
+			os.Remove(dbStaged)
+			return fmt.Errorf("failed to unstage config file: %w", err)
+		}
+	}
+	log.Printf("backup: staged %s, it is restored on the next start", filepath.Base(path))
+	return nil
+}
+
+// writeArchive zips the database snapshot and the config file into path.
+// The archive is written under a temporary name, so a backup that failed
+// halfway is never listed.
+func (s *Service) writeArchive(path, snapshot string) error {
+	partial := path + ".partial"
+	out, err := os.Create(partial)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	defer os.Remove(partial)
+
+	archive := zip.NewWriter(out)
+	err = addFile(archive, DatabaseEntry, snapshot)
+	if err == nil && s.config.ConfigFile != "" {
+		if _, statErr := os.Stat(s.config.ConfigFile); statErr == nil {
+			err = addFile(archive, ConfigEntry, s.config.ConfigFile)
+		}
+	}
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return os.Rename(partial, path)
+}
+
+// prune deletes the oldest scheduled backups beyond the retention
+func (s *Service) prune() {
+	if s.config.Retention <= 0 {
+		return
+	}
+	backups, err := s.List()
+	if err != nil {
+		log.Printf("backup: failed to prune backups: %v", err)
+		return
+	}
+	kept := 0
+	for _, backup := range backups {
+		if backup.Type != TypeScheduled {
+			continue
+		}
+		kept++
+		if kept <= s.config.Retention {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.config.Folder, backup.Name)); err != nil {
+			log.Printf("backup: failed to delete %s: %v", backup.Name, err)
+			continue
+		}
+		log.Printf("backup: deleted %s", backup.Name)
+	}
+}
+
+// addFile copies the file at path into the archive as name
+func addFile(archive *zip.Writer, name, path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	w, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, in)
+	return err
+}
+
+// extract writes an archive entry to path
+func extract(file *zip.File, path string) error {
+	size := file.UncompressedSize64
+	if size > MaxDatabaseSize {
+		return fmt.Errorf("%w: %s is larger than %s", ErrInvalidBackup, file.Name, diskspace.FormatBytes(MaxDatabaseSize))
+	}
+	if err := diskspace.Require(filepath.Dir(path), size, 0); err != nil {
+		return err
+	}
+
+	in, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to stage database: %w", err)
+	}
+	// The archive may understate the size, never copy more than it states
+	n, err := io.Copy(out, io.LimitReader(in, int64(size)+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && uint64(n) > size {
+		err = fmt.Errorf("%s is larger than the archive states", file.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	return nil
+}
+
+// readEntry reads a whole archive entry
+func readEntry(file *zip.File) ([]byte, error) {
+	if file.UncompressedSize64 > MaxConfigSize {
+		return nil, fmt.Errorf("%w: %s is larger than %s", ErrInvalidBackup, file.Name, diskspace.FormatBytes(MaxConfigSize))
+	}
+	in, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	defer in.Close()
+
+	data, err := io.ReadAll(io.LimitReader(in, MaxConfigSize+1))
+	if err == nil && len(data) > MaxConfigSize {
+		err = fmt.Errorf("%s is larger than %s", file.Name, diskspace.FormatBytes(MaxConfigSize))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	return data, nil
+}
+
+// checkDatabase makes sure the file at path is an intact Listenarr database
+func checkDatabase(path string) error {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	defer sqlDB.Close()
+
+	var problems []string
+	if err := db.Raw("PRAGMA integrity_check").Scan(&problems).Error; err != nil {
+		return fmt.Errorf("%w: %s is not a database: %v", ErrInvalidBackup, DatabaseEntry, err)
+	}
+	if len(problems) != 1 || problems[0] != "ok" {
+		return fmt.Errorf("%w: %s is corrupt: %s", ErrInvalidBackup, DatabaseEntry, strings.Join(problems, "; "))
+	}
+	for _, table := range requiredTables {
+		if !db.Migrator().HasTable(table) {
+			return fmt.Errorf("%w: %s has no %s table", ErrInvalidBackup, DatabaseEntry, table)
+		}
+	}
+	// A newer version's database would keep Listenarr from starting
+	if err := database.CheckVersion(db); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidBackup, DatabaseEntry, err)
+	}
+	return nil
+}
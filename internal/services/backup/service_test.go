@@ -0,0 +1,300 @@
+package backup
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/config"
+	"github.com/listenarr/listenarr/internal/database"
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// setupService creates a database with an author in a temporary folder and
+// a backup service for it
+func setupService(t *testing.T, retention int) (*Service, *gorm.DB, Config) {
+	dir := t.TempDir()
+	db, err := database.Initialize(filepath.Join(dir, "listenarr.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	require.NoError(t, db.Create(&models.Author{Name: "Backed Up"}).Error)
+
+	configFile := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(configFile, configYAML(filepath.Join(dir, "listenarr.db"), "/audiobooks"), 0644))
+
+	cfg := Config{
+		DatabasePath: filepath.Join(dir, "listenarr.db"),
+		ConfigFile:   configFile,
+		Folder:       filepath.Join(dir, "backups"),
+		Retention:    retention,
+	}
+	return NewService(db, cfg), db, cfg
+}
+
+// configYAML returns a config file with the database and library paths
+func configYAML(dbPath, libraryPath string) []byte {
+	return []byte("database:\n  path: " + dbPath + "\nlibrary:\n  path: " + libraryPath + "\n")
+}
+
+// writeZip writes an archive with the given entries
+func writeZip(t *testing.T, path string, entries map[string][]byte) {
+	out, err := os.Create(path)
+	require.NoError(t, err)
+	archive := zip.NewWriter(out)
+	for name, data := range entries {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	require.NoError(t, out.Close())
+}
+
+func TestCreate(t *testing.T) {
+	service, _, cfg := setupService(t, 7)
+
+	backup, err := service.Create(TypeManual)
+	require.NoError(t, err)
+	assert.Equal(t, TypeManual, backup.Type)
+	assert.Regexp(t, `^listenarr_backup_manual_\d{8}_\d{6}\.\d{3}\.zip$`, backup.Name)
+	assert.Positive(t, backup.Size)
+
+	archive, err := zip.OpenReader(filepath.Join(cfg.Folder, backup.Name))
+	require.NoError(t, err)
+	defer archive.Close()
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	assert.ElementsMatch(t, []string{DatabaseEntry, ConfigEntry}, names)
+
+	entries, err := os.ReadDir(cfg.Folder)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no snapshot or partial archive is left behind")
+}
+
+func TestCreate_Retention(t *testing.T) {
+	service, _, _ := setupService(t, 2)
+
+	manual, err := service.Create(TypeManual)
+	require.NoError(t, err)
+	var scheduled []string
+	for i := 0; i < 3; i++ {
+		time.Sleep(2 * time.Millisecond) // Names have millisecond precision
+		backup, err := service.Create(TypeScheduled)
+		require.NoError(t, err)
+		scheduled = append(scheduled, backup.Name)
+	}
+
+	backups, err := service.List()
+	require.NoError(t, err)
+	var names []string
+	for _, backup := range backups {
+		names = append(names, backup.Name)
+	}
+	assert.Equal(t, []string{scheduled[2], scheduled[1], manual.Name}, names,
+		"newest first, oldest scheduled backup pruned, manual backups kept")
+}
+
+func TestPath(t *testing.T) {
+	service, _, cfg := setupService(t, 7)
+	backup, err := service.Create(TypeManual)
+	require.NoError(t, err)
+
+	path, err := service.Path(backup.Name)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cfg.Folder, backup.Name), path)
+
+	for _, name := range []string{"../listenarr.db", "listenarr_backup_manual_20240101_000000.000.zip", "config.yml"} {
+		_, err := service.Path(name)
+		assert.ErrorIs(t, err, ErrBackupNotFound, name)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	service, db, cfg := setupService(t, 7)
+	backup, err := service.Create(TypeManual)
+	require.NoError(t, err)
+
+	// Changes after the backup are undone by the restore
+	require.NoError(t, db.Create(&models.Author{Name: "After Backup"}).Error)
+	require.NoError(t, os.WriteFile(cfg.ConfigFile, configYAML(cfg.DatabasePath, "/changed"), 0644))
+
+	// Both are staged, nothing changes before the restart
+	require.NoError(t, service.Restore(backup.Name))
+	assert.FileExists(t, cfg.DatabasePath+config.RestoreSuffix)
+	assert.FileExists(t, cfg.ConfigFile+config.RestoreSuffix)
+	data, err := os.ReadFile(cfg.ConfigFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "/changed")
+
+	// Restoring again replaces what was staged
+	require.NoError(t, service.Restore(backup.Name))
+	assert.NoFileExists(t, cfg.ConfigFile+".pre-restore")
+
+	// Restart
+	sqlDB, _ := db.DB()
+	require.NoError(t, sqlDB.Close())
+	require.NoError(t, config.ApplyRestore(cfg.ConfigFile))
+	restored, err := database.Initialize(cfg.DatabasePath)
+	require.NoError(t, err)
+	defer func() {
+		sqlDB, _ := restored.DB()
+		sqlDB.Close()
+	}()
+
+	var authors []models.Author
+	require.NoError(t, restored.Find(&authors).Error)
+	require.Len(t, authors, 1)
+	assert.Equal(t, "Backed Up", authors[0].Name)
+	assert.FileExists(t, cfg.DatabasePath+".pre-restore")
+
+	data, err = os.ReadFile(cfg.ConfigFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "/audiobooks")
+	previous, err := os.ReadFile(cfg.ConfigFile + ".pre-restore")
+	require.NoError(t, err)
+	assert.Contains(t, string(previous), "/changed")
+}
+
+func TestRestoreArchive_Invalid(t *testing.T) {
+	service, _, cfg := setupService(t, 7)
+	dir := t.TempDir()
+
+	notZip := filepath.Join(dir, "not.zip")
+	require.NoError(t, os.WriteFile(notZip, []byte("not a zip"), 0644))
+
+	noDatabase := filepath.Join(dir, "empty.zip")
+	writeZip(t, noDatabase, map[string][]byte{ConfigEntry: []byte("library: {}\n")})
+
+	notDatabase := filepath.Join(dir, "garbage.zip")
+	writeZip(t, notDatabase, map[string][]byte{DatabaseEntry: []byte("this is not an SQLite database, just some text")})
+
+	for name, path := range map[string]string{"not a zip": notZip, "no database": noDatabase, "not a database": notDatabase} {
+		t.Run(name, func(t *testing.T) {
+			err := service.RestoreArchive(path)
+			assert.ErrorIs(t, err, ErrInvalidBackup)
+		})
+	}
+
+	t.Run("not a Listenarr database", func(t *testing.T) {
+		other := filepath.Join(dir, "other.db")
+		db, err := database.Initialize(other)
+		require.NoError(t, err)
+		require.NoError(t, db.Exec("DROP TABLE library_items").Error)
+		sqlDB, _ := db.DB()
+		require.NoError(t, sqlDB.Close())
+		data, err := os.ReadFile(other)
+		require.NoError(t, err)
+
+		path := filepath.Join(dir, "other.zip")
+		writeZip(t, path, map[string][]byte{DatabaseEntry: data})
+		err = service.RestoreArchive(path)
+		assert.ErrorIs(t, err, ErrInvalidBackup)
+		assert.Contains(t, err.Error(), "library_items")
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		// A small archive claiming a database too large to extract
+		path := filepath.Join(dir, "bomb.zip")
+		out, err := os.Create(path)
+		require.NoError(t, err)
+		archive := zip.NewWriter(out)
+		w, err := archive.CreateRaw(&zip.FileHeader{
+			Name:               DatabaseEntry,
+			Method:             zip.Store,
+			CompressedSize64:   4,
+			UncompressedSize64: MaxDatabaseSize + 1,
+		})
+		require.NoError(t, err)
+		_, err = w.Write([]byte("data"))
+		require.NoError(t, err)
+		require.NoError(t, archive.Close())
+		require.NoError(t, out.Close())
+
+		err = service.RestoreArchive(path)
+		assert.ErrorIs(t, err, ErrInvalidBackup)
+		assert.Contains(t, err.Error(), "larger than 16.0 GiB")
+
+		// A config file that compresses well but is too large to read
+		backup, err := service.Create(TypeManual)
+		require.NoError(t, err)
+		archivePath, err := service.Path(backup.Name)
+		require.NoError(t, err)
+		reader, err := zip.OpenReader(archivePath)
+		require.NoError(t, err)
+		var db []byte
+		for _, file := range reader.File {
+			if file.Name == DatabaseEntry {
+				in, err := file.Open()
+				require.NoError(t, err)
+				db, err = io.ReadAll(in)
+				require.NoError(t, err)
+				in.Close()
+			}
+		}
+		reader.Close()
+		path = filepath.Join(dir, "large-config.zip")
+		writeZip(t, path, map[string][]byte{DatabaseEntry: db, ConfigEntry: make([]byte, MaxConfigSize+1)})
+
+		err = service.RestoreArchive(path)
+		assert.ErrorIs(t, err, ErrInvalidBackup)
+		assert.Contains(t, err.Error(), "larger than 1.0 MiB")
+	})
+
+	t.Run("from a newer version", func(t *testing.T) {
+		newer := filepath.Join(dir, "newer.db")
+		db, err := database.Initialize(newer)
+		require.NoError(t, err)
+		next := database.LatestVersion() + 1
+		require.NoError(t, db.Create(&database.SchemaMigration{Version: next, Name: "from_the_future", AppliedAt: time.Now()}).Error)
+		sqlDB, _ := db.DB()
+		require.NoError(t, sqlDB.Close())
+		data, err := os.ReadFile(newer)
+		require.NoError(t, err)
+
+		path := filepath.Join(dir, "newer.zip")
+		writeZip(t, path, map[string][]byte{DatabaseEntry: data})
+		err = service.RestoreArchive(path)
+		assert.ErrorIs(t, err, ErrInvalidBackup)
+		assert.Contains(t, err.Error(), "newer than this version")
+	})
+
+	_, err := os.Stat(cfg.DatabasePath + config.RestoreSuffix)
+	assert.True(t, os.IsNotExist(err), "nothing is staged")
+	_, err = os.Stat(cfg.ConfigFile + config.RestoreSuffix)
+	assert.True(t, os.IsNotExist(err), "nothing is staged")
+}
+
+func TestUnsupportedDatabase(t *testing.T) {
+	service, _, cfg := setupService(t, 7)
+	backup, err := service.Create(TypeManual)
+	require.NoError(t, err)
+	archive, err := service.Path(backup.Name)
+	require.NoError(t, err)
+
+	// A PostgreSQL database, never connected to
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1 dbname=listenarr"), &gorm.Config{DisableAutomaticPing: true})
+	require.NoError(t, err)
+	service = NewService(db, cfg)
+
+	_, err = service.Create(TypeManual)
+	assert.ErrorIs(t, err, ErrUnsupported)
+	assert.ErrorIs(t, service.Restore(backup.Name), ErrUnsupported)
+	assert.ErrorIs(t, service.RestoreArchive(archive), ErrUnsupported)
+
+	_, err = os.Stat(cfg.DatabasePath + config.RestoreSuffix)
+	assert.True(t, os.IsNotExist(err), "nothing is staged")
+}
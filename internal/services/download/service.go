@@ -1,20 +1,61 @@
 package download
 
 import (
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 
+	"github.com/listenarr/listenarr/internal/events"
 	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/history"
+	"github.com/listenarr/listenarr/pkg/diskspace"
 	"github.com/listenarr/listenarr/pkg/qbit"
 )
 
+// ClientName identifies the download client in history entries
+const ClientName = "qBittorrent"
+
+// Errors returned by the download service
+var (
+	ErrDownloadNotFound    = errors.New("download not found")
+	ErrNotCancellable      = errors.New("download cannot be cancelled in its current state")
+	ErrReleaseBlocklisted  = errors.New("release is blocklisted")
+	ErrClientNotConfigured = errors.New("download client not configured")
+	ErrLibraryItemNotFound = errors.New("library item not found")
+	ErrReleaseNotFound     = errors.New("release not found")
+	ErrAlreadyDownloading  = errors.New("library item already has an active download")
+	ErrNoTorrentURL        = errors.New("release has no torrent or magnet URL")
+	ErrNoTorrentHash       = errors.New("could not find the info hash of the release's torrent")
+	ErrTorrentNotAdded     = errors.New("failed to add torrent")
+)
+
+// ErrInsufficientSpace is returned when the download folder has no room for
+// a release
+var ErrInsufficientSpace = diskspace.ErrInsufficientSpace
+
+// TorrentClient is the subset of the qBittorrent client used by the download service
+type TorrentClient interface {
+	AddTorrent(torrentURL string, options *qbit.AddTorrentOptions) error
+	GetTorrentInfo(hash string) (*qbit.TorrentInfo, error)
+	DeleteTorrent(hashes []string, deleteFiles bool) error
+}
+
 // Service handles download operations
 type Service struct {
-	db     *gorm.DB
-	qbit   *qbit.Client
-	config *ServiceConfig
+	db         *gorm.DB
+	qbit       TorrentClient
+	config     *ServiceConfig
+	history    *history.Service
+	events     *events.Bus
+	httpClient *http.Client
 }
 
 // ServiceConfig holds configuration for the download service
@@ -22,10 +63,18 @@ type ServiceConfig struct {
 	Category     string
 	SavePath     string
 	PollInterval time.Duration
+
+	// Folder downloads end up in as seen from here, and the free space it
+	// must keep besides the release; an empty path skips the check
+	DownloadPath string
+	MinFree      uint64
 }
 
-// NewService creates a new download service
-func NewService(db *gorm.DB, qbitClient *qbit.Client, config *ServiceConfig) *Service {
+// NewService creates a new download service.
+// qbitClient may be nil when no download client is configured; remote
+// operations are then skipped or rejected with ErrClientNotConfigured.
+// bus may be nil when nobody listens for download events.
+func NewService(db *gorm.DB, qbitClient TorrentClient, bus *events.Bus, config *ServiceConfig) *Service {
 	if config == nil {
 		config = &ServiceConfig{
 			Category:     "Listenarr",
@@ -33,35 +82,82 @@ func NewService(db *gorm.DB, qbitClient *qbit.Client, config *ServiceConfig) *Se
 		}
 	}
 	return &Service{
-		db:     db,
-		qbit:   qbitClient,
-		config: config,
+		db:         db,
+		qbit:       qbitClient,
+		config:     config,
+		history:    history.NewService(db),
+		events:     bus,
+		httpClient: newTorrentHTTPClient(),
 	}
 }
 
-// StartDownload starts a download for a library item
+// StartDownload sends a release to qBittorrent for a library item and
+// records the download. It fails with ErrLibraryItemNotFound or
+// ErrReleaseNotFound for rows that do not exist, ErrReleaseBlocklisted,
+// ErrClientNotConfigured, ErrAlreadyDownloading when the item has an active
+// download, ErrInsufficientSpace, ErrNoTorrentURL, ErrNoTorrentHash when the
+// torrent's info hash cannot be found, or ErrTorrentNotAdded.
 func (s *Service) StartDownload(libraryItemID, releaseID uint) (*models.Download, error) {
+	var libraryItem models.LibraryItem
+	if err := s.db.First(&libraryItem, libraryItemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLibraryItemNotFound
+		}
+		return nil, fmt.Errorf("failed to find library item: %w", err)
+	}
+
 	// Get release to get torrent URL
 	var release models.Release
 	if err := s.db.First(&release, releaseID).Error; err != nil {
-		return nil, fmt.Errorf("release not found: %w", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReleaseNotFound
+		}
+		return nil, fmt.Errorf("failed to find release: %w", err)
+	}
+	if release.Blocklisted {
+		return nil, ErrReleaseBlocklisted
+	}
+	if s.qbit == nil {
+		return nil, ErrClientNotConfigured
+	}
+
+	// One active download per library item
+	var active int64
+	err := s.db.Model(&models.Download{}).
+		Where("library_item_id = ? AND status IN ?", libraryItemID, []models.DownloadStatus{
+			models.DownloadStatusQueued,
+			models.DownloadStatusDownloading,
+		}).
+		Count(&active).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing downloads: %w", err)
+	}
+	if active > 0 {
+		return nil, ErrAlreadyDownloading
+	}
+
+	if err := diskspace.Require(s.config.DownloadPath, uint64(max(release.Size, 0)), s.config.MinFree); err != nil {
+		return nil, err
 	}
 
-	// Determine torrent URL (prefer magnet, fallback to torrent URL)
-	torrentURL := release.MagnetURL
-	if torrentURL == "" {
-		torrentURL = release.TorrentURL
+	if release.MagnetURL == "" && release.TorrentURL == "" {
+		return nil, ErrNoTorrentURL
 	}
-	if torrentURL == "" {
-		return nil, fmt.Errorf("no torrent URL or magnet URL available for release")
+
+	// The hash is what the monitor follows the torrent by, a download
+	// without one would never leave the queue
+	torrentURL, hash, err := s.resolveTorrent(&release)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create download record
 	download := models.Download{
-		LibraryItemID: libraryItemID,
-		ReleaseID:     releaseID,
-		Status:        models.DownloadStatusQueued,
-		Progress:      0,
+		LibraryItemID:   libraryItemID,
+		ReleaseID:       releaseID,
+		Status:          models.DownloadStatusQueued,
+		Progress:        0,
+		QBittorrentHash: hash,
 	}
 
 	if err := s.db.Create(&download).Error; err != nil {
@@ -79,26 +175,50 @@ func (s *Service) StartDownload(libraryItemID, releaseID uint) (*models.Download
 		download.Status = models.DownloadStatusFailed
 		download.Error = fmt.Sprintf("Failed to add torrent to qBittorrent: %v", err)
 		s.db.Save(&download)
-		return nil, fmt.Errorf("failed to add torrent: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrTorrentNotAdded, err)
 	}
 
-	// Get torrent hash from qBittorrent (we'll need to match by name or URL)
-	// For now, we'll update it later when we poll
-	// TODO: Get hash from qBittorrent response or match by name
-
 	// Update library item status
-	var libraryItem models.LibraryItem
-	if err := s.db.First(&libraryItem, libraryItemID).Error; err == nil {
-		libraryItem.Status = models.LibraryItemStatusDownloading
-		s.db.Save(&libraryItem)
-	}
+	libraryItem.Status = models.LibraryItemStatusDownloading
+	s.db.Save(&libraryItem)
+
+	// History is best effort and never fails the grab
+	_ = s.history.RecordGrab(&download, &release, ClientName)
+	s.publish(events.DownloadStatusChanged, &download)
+	s.publish(events.DownloadGrabbed, &download)
 
 	return &download, nil
 }
 
+// magnetHash returns the info hash of a magnet link as qBittorrent reports
+// it, in lowercase hex, or "" when there is none
+func magnetHash(magnetURL string) string {
+	u, err := url.Parse(magnetURL)
+	if err != nil || u.Scheme != "magnet" {
+		return ""
+	}
+	for _, xt := range u.Query()["xt"] {
+		hash, ok := strings.CutPrefix(strings.ToLower(xt), "urn:btih:")
+		if !ok {
+			continue
+		}
+		switch len(hash) {
+		case 40:
+			if _, err := hex.DecodeString(hash); err == nil {
+				return hash
+			}
+		case 32:
+			if raw, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash)); err == nil {
+				return hex.EncodeToString(raw)
+			}
+		}
+	}
+	return ""
+}
+
 // UpdateDownloadStatus updates download status from qBittorrent
 func (s *Service) UpdateDownloadStatus(download *models.Download) error {
-	if download.QBittorrentHash == "" {
+	if s.qbit == nil || download.QBittorrentHash == "" {
 		// Try to find torrent by matching release info
 		// This is a simplified approach - in production, we'd match more reliably
 		return nil
@@ -109,6 +229,8 @@ func (s *Service) UpdateDownloadStatus(download *models.Download) error {
 		return fmt.Errorf("failed to get torrent info: %w", err)
 	}
 
+	previousStatus := download.Status
+
 	// Update download progress
 	download.Progress = torrent.Progress * 100 // Convert 0-1 to 0-100
 	download.Speed = torrent.DownloadSpeed
@@ -138,15 +260,45 @@ func (s *Service) UpdateDownloadStatus(download *models.Download) error {
 		download.DownloadPath = torrent.ContentPath
 	}
 
-	return s.db.Save(download).Error
+	if err := s.db.Save(download).Error; err != nil {
+		return err
+	}
+
+	s.publish(events.DownloadProgress, download)
+	if download.Status != previousStatus {
+		s.publish(events.DownloadStatusChanged, download)
+	}
+
+	if download.IsFailed() && previousStatus != models.DownloadStatusFailed {
+		_ = s.history.RecordDownloadFailed(download, ClientName)
+		s.publish(events.DownloadFailed, download)
+	}
+
+	return nil
 }
 
-// MonitorDownloads monitors active downloads and updates their status
+// publish sends a download event on the bus
+func (s *Service) publish(eventType events.Type, download *models.Download) {
+	s.events.Publish(eventType, events.DownloadPayload{
+		DownloadID:    download.ID,
+		LibraryItemID: download.LibraryItemID,
+		Status:        string(download.Status),
+		Progress:      download.Progress,
+		Speed:         download.Speed,
+		Size:          download.Size,
+		Downloaded:    download.Downloaded,
+		Error:         download.Error,
+	})
+}
+
+// MonitorDownloads monitors active downloads and updates their status.
+// Paused downloads are followed too, so they pick up again when resumed.
 func (s *Service) MonitorDownloads() error {
 	var downloads []models.Download
 	err := s.db.Where("status IN ?", []models.DownloadStatus{
 		models.DownloadStatusQueued,
 		models.DownloadStatusDownloading,
+		models.DownloadStatusPaused,
 	}).Find(&downloads).Error
 
 	if err != nil {
@@ -156,6 +308,7 @@ func (s *Service) MonitorDownloads() error {
 	for i := range downloads {
 		if err := s.UpdateDownloadStatus(&downloads[i]); err != nil {
 			// Log error but continue with other downloads
+			log.Printf("downloads: failed to update download %d: %v", downloads[i].ID, err)
 			continue
 		}
 
@@ -191,6 +344,12 @@ func (s *Service) triggerProcessing(download *models.Download) {
 		return
 	}
 
+	s.events.Publish(events.ProcessingStatusChanged, events.ProcessingPayload{
+		TaskID:     task.ID,
+		DownloadID: task.DownloadID,
+		Status:     string(task.Status),
+	})
+
 	// Update library item status
 	var libraryItem models.LibraryItem
 	if err := s.db.First(&libraryItem, download.LibraryItemID).Error; err == nil {
@@ -199,32 +358,87 @@ func (s *Service) triggerProcessing(download *models.Download) {
 	}
 }
 
-// CancelDownload cancels a download
-func (s *Service) CancelDownload(downloadID uint) error {
+// CancelOptions controls what CancelDownload does besides updating the database
+type CancelOptions struct {
+	DeleteFiles bool // Remove downloaded data along with the torrent
+	Blocklist   bool // Mark the release so it is never grabbed again
+}
+
+// CancelDownload cancels a download, removing its torrent from qBittorrent.
+// Cancelling an already cancelled download is a no-op, and a torrent that is
+// already gone from qBittorrent is treated as removed.
+func (s *Service) CancelDownload(downloadID uint, opts CancelOptions) (*models.Download, error) {
 	var download models.Download
 	if err := s.db.First(&download, downloadID).Error; err != nil {
-		return fmt.Errorf("download not found: %w", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDownloadNotFound
+		}
+		return nil, fmt.Errorf("failed to find download: %w", err)
 	}
 
-	// Delete from qBittorrent if hash is available
-	if download.QBittorrentHash != "" {
-		if err := s.qbit.DeleteTorrent([]string{download.QBittorrentHash}, false); err != nil {
-			// Log error but continue
+	if download.IsCancelled() {
+		return &download, nil
+	}
+
+	switch download.Status {
+	case models.DownloadStatusQueued, models.DownloadStatusDownloading, models.DownloadStatusPaused:
+	default:
+		return nil, ErrNotCancellable
+	}
+
+	if err := s.removeTorrent(download.QBittorrentHash, opts.DeleteFiles); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		download.Status = models.DownloadStatusCancelled
+		download.Error = "Cancelled by user"
+		if err := tx.Save(&download).Error; err != nil {
+			return fmt.Errorf("failed to update download: %w", err)
+		}
+
+		if opts.Blocklist {
+			err := tx.Model(&models.Release{}).
+				Where("id = ?", download.ReleaseID).
+				Update("blocklisted", true).Error
+			if err != nil {
+				return fmt.Errorf("failed to blocklist release: %w", err)
+			}
+		}
+
+		err := tx.Model(&models.LibraryItem{}).
+			Where("id = ?", download.LibraryItemID).
+			Update("status", models.LibraryItemStatusWanted).Error
+		if err != nil {
+			return fmt.Errorf("failed to update library item: %w", err)
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.DownloadStatusChanged, &download)
+
+	return &download, nil
+}
+
+// removeTorrent deletes a torrent from qBittorrent if it still exists
+func (s *Service) removeTorrent(hash string, deleteFiles bool) error {
+	if s.qbit == nil || hash == "" {
+		return nil
 	}
 
-	// Update download status
-	download.Status = models.DownloadStatusFailed
-	download.Error = "Cancelled by user"
-	if err := s.db.Save(&download).Error; err != nil {
-		return fmt.Errorf("failed to update download: %w", err)
+	if _, err := s.qbit.GetTorrentInfo(hash); err != nil {
+		if errors.Is(err, qbit.ErrTorrentNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to look up torrent: %w", err)
 	}
 
-	// Update library item status
-	var libraryItem models.LibraryItem
-	if err := s.db.First(&libraryItem, download.LibraryItemID).Error; err == nil {
-		libraryItem.Status = models.LibraryItemStatusWanted
-		s.db.Save(&libraryItem)
+	if err := s.qbit.DeleteTorrent([]string{hash}, deleteFiles); err != nil {
+		return fmt.Errorf("failed to remove torrent: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,264 @@
+package download
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/events"
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/pkg/qbit"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
+
+	err := db.AutoMigrate(
+		&models.Author{},
+		&models.Book{},
+		&models.LibraryItem{},
+		&models.Release{},
+		&models.Download{},
+		&models.ProcessingTask{},
+		&models.History{},
+	)
+	require.NoError(t, err)
+
+	return db
+}
+
+// fakeTorrentClient serves torrents from a map keyed by hash
+type fakeTorrentClient struct {
+	added    []string
+	torrents map[string]*qbit.TorrentInfo
+}
+
+func (f *fakeTorrentClient) AddTorrent(torrentURL string, options *qbit.AddTorrentOptions) error {
+	f.added = append(f.added, torrentURL)
+	return nil
+}
+
+func (f *fakeTorrentClient) GetTorrentInfo(hash string) (*qbit.TorrentInfo, error) {
+	torrent, ok := f.torrents[hash]
+	if !ok {
+		return nil, qbit.ErrTorrentNotFound
+	}
+	return torrent, nil
+}
+
+func (f *fakeTorrentClient) DeleteTorrent(hashes []string, deleteFiles bool) error {
+	for _, hash := range hashes {
+		delete(f.torrents, hash)
+	}
+	return nil
+}
+
+// createWanted creates a wanted library item and a release for it
+func createWanted(t *testing.T, db *gorm.DB, magnetURL string) (models.LibraryItem, models.Release) {
+	author := models.Author{Name: "Ursula K. Le Guin"}
+	require.NoError(t, db.Create(&author).Error)
+	book := models.Book{Title: "A Wizard of Earthsea", AuthorID: author.ID}
+	require.NoError(t, db.Create(&book).Error)
+	item := models.LibraryItem{BookID: book.ID, Status: models.LibraryItemStatusWanted, AddedDate: time.Now()}
+	require.NoError(t, db.Create(&item).Error)
+	release := models.Release{BookID: book.ID, Title: book.Title, Indexer: "test-indexer", MagnetURL: magnetURL}
+	require.NoError(t, db.Create(&release).Error)
+	return item, release
+}
+
+func TestMagnetHash(t *testing.T) {
+	hash := "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	assert.Equal(t, hash, magnetHash("magnet:?xt=urn:btih:"+hash+"&dn=Earthsea"))
+	assert.Equal(t, hash, magnetHash("magnet:?xt=urn:btih:C12FE1C06BBA254A9DC9F519B335AA7C1367A88A"))
+	assert.Equal(t, hash, magnetHash("magnet:?xt=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK"), "base32")
+	assert.Empty(t, magnetHash("http://indexer.example/download/1.torrent"))
+	assert.Empty(t, magnetHash("magnet:?xt=urn:btih:nothex"))
+	assert.Empty(t, magnetHash(""))
+}
+
+// testTorrent is a .torrent file and the hash of its info dictionary
+var (
+	testTorrentInfo = "d6:lengthi1024e4:name12:earthsea.m4b12:piece lengthi16384e6:pieces20:" + strings.Repeat("x", 20) + "e"
+	testTorrent     = "d8:announce24:http://tracker.example/a7:comment10:nested:d1e4:info" + testTorrentInfo + "e"
+)
+
+func TestInfoHash(t *testing.T) {
+	sum := sha1.Sum([]byte(testTorrentInfo))
+	hash, err := infoHash([]byte(testTorrent))
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(sum[:]), hash)
+
+	for _, invalid := range []string{"", "not a torrent", "d8:announce3:abce", "d4:infod4:name", "d4:info99:short"} {
+		_, err := infoHash([]byte(invalid))
+		assert.ErrorIs(t, err, errInvalidTorrent, invalid)
+	}
+}
+
+func TestStartDownload_TorrentFile(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/earthsea.torrent", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testTorrent))
+	})
+	mux.HandleFunc("/magnet", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a", http.StatusFound)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/earthsea.torrent", http.StatusMovedPermanently)
+	})
+	indexer := httptest.NewServer(mux)
+	defer indexer.Close()
+	sum := sha1.Sum([]byte(testTorrentInfo))
+	fileHash := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name, torrentURL, torrentHash string
+		wantAdded, wantHash           string
+	}{
+		{"hash read from the file", indexer.URL + "/earthsea.torrent", "", indexer.URL + "/earthsea.torrent", fileHash},
+		{"redirects are followed", indexer.URL + "/moved", "", indexer.URL + "/moved", fileHash},
+		{"redirect to a magnet link", indexer.URL + "/magnet", "", "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a", "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"},
+		{"hash reported by the indexer", "http://indexer.invalid/1.torrent", "C12FE1C06BBA254A9DC9F519B335AA7C1367A88A", "http://indexer.invalid/1.torrent", "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			client := &fakeTorrentClient{}
+			service := NewService(db, client, nil, nil)
+			item, release := createWanted(t, db, "")
+			require.NoError(t, db.Model(&release).Updates(models.Release{TorrentURL: tt.torrentURL, TorrentHash: tt.torrentHash}).Error)
+
+			download, err := service.StartDownload(item.ID, release.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHash, download.QBittorrentHash)
+			assert.Equal(t, []string{tt.wantAdded}, client.added)
+		})
+	}
+
+	t.Run("no hash fails the grab", func(t *testing.T) {
+		db := setupTestDB(t)
+		client := &fakeTorrentClient{}
+		service := NewService(db, client, nil, nil)
+		item, release := createWanted(t, db, "")
+		require.NoError(t, db.Model(&release).Update("torrent_url", indexer.URL+"/missing.torrent").Error)
+
+		_, err := service.StartDownload(item.ID, release.ID)
+		assert.ErrorIs(t, err, ErrNoTorrentHash)
+		assert.ErrorContains(t, err, "status 404")
+		assert.Empty(t, client.added, "nothing is added")
+		var count int64
+		require.NoError(t, db.Model(&models.Download{}).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+}
+
+func TestMonitorDownloads_CompletedTorrentIsProcessed(t *testing.T) {
+	db := setupTestDB(t)
+	bus := events.NewBus()
+	received, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	hash := "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	item, release := createWanted(t, db, "magnet:?xt=urn:btih:"+hash)
+	client := &fakeTorrentClient{torrents: map[string]*qbit.TorrentInfo{}}
+	service := NewService(db, client, bus, nil)
+
+	download, err := service.StartDownload(item.ID, release.ID)
+	require.NoError(t, err)
+	assert.Equal(t, hash, download.QBittorrentHash)
+
+	// Still downloading: progress only
+	client.torrents[hash] = &qbit.TorrentInfo{Hash: hash, State: "downloading", Progress: 0.5}
+	require.NoError(t, service.MonitorDownloads())
+	var count int64
+	require.NoError(t, db.Model(&models.ProcessingTask{}).Count(&count).Error)
+	assert.Zero(t, count)
+
+	// Seeding: done, and queued for import once
+	client.torrents[hash] = &qbit.TorrentInfo{Hash: hash, State: "uploading", Progress: 1, ContentPath: "/downloads/A Wizard of Earthsea"}
+	require.NoError(t, service.MonitorDownloads())
+	require.NoError(t, service.MonitorDownloads())
+
+	var tasks []models.ProcessingTask
+	require.NoError(t, db.Find(&tasks).Error)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, download.ID, tasks[0].DownloadID)
+	assert.Equal(t, models.ProcessingStatusPending, tasks[0].Status)
+	assert.Equal(t, "/downloads/A Wizard of Earthsea", tasks[0].InputPath)
+
+	var finished models.Download
+	require.NoError(t, db.First(&finished, download.ID).Error)
+	assert.Equal(t, models.DownloadStatusCompleted, finished.Status)
+	assert.Equal(t, 100.0, finished.Progress)
+
+	require.NoError(t, db.First(&item, item.ID).Error)
+	assert.Equal(t, models.LibraryItemStatusProcessing, item.Status)
+
+	seen := map[events.Type]bool{}
+	for len(received) > 0 {
+		seen[(<-received).Type] = true
+	}
+	assert.True(t, seen[events.DownloadProgress])
+	assert.True(t, seen[events.ProcessingStatusChanged])
+}
+
+func TestMonitorDownloads_PausedTorrentIsFollowed(t *testing.T) {
+	db := setupTestDB(t)
+
+	hash := "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	item, release := createWanted(t, db, "magnet:?xt=urn:btih:"+hash)
+	client := &fakeTorrentClient{torrents: map[string]*qbit.TorrentInfo{}}
+	service := NewService(db, client, nil, nil)
+
+	download, err := service.StartDownload(item.ID, release.ID)
+	require.NoError(t, err)
+
+	client.torrents[hash] = &qbit.TorrentInfo{Hash: hash, State: "pausedDL", Progress: 0.3}
+	require.NoError(t, service.MonitorDownloads())
+	require.NoError(t, db.First(download, download.ID).Error)
+	assert.Equal(t, models.DownloadStatusPaused, download.Status)
+
+	// Resumed and finished while paused in Listenarr's view
+	client.torrents[hash] = &qbit.TorrentInfo{Hash: hash, State: "uploading", Progress: 1}
+	require.NoError(t, service.MonitorDownloads())
+	require.NoError(t, db.First(download, download.ID).Error)
+	assert.Equal(t, models.DownloadStatusCompleted, download.Status)
+
+	var count int64
+	require.NoError(t, db.Model(&models.ProcessingTask{}).Where("download_id = ?", download.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMonitorDownloads_FailedTorrent(t *testing.T) {
+	db := setupTestDB(t)
+
+	hash := "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	item, release := createWanted(t, db, "magnet:?xt=urn:btih:"+hash)
+	client := &fakeTorrentClient{torrents: map[string]*qbit.TorrentInfo{}}
+	service := NewService(db, client, nil, nil)
+
+	download, err := service.StartDownload(item.ID, release.ID)
+	require.NoError(t, err)
+
+	client.torrents[hash] = &qbit.TorrentInfo{Hash: hash, State: "missingFiles"}
+	require.NoError(t, service.MonitorDownloads())
+
+	require.NoError(t, db.First(download, download.ID).Error)
+	assert.Equal(t, models.DownloadStatusFailed, download.Status)
+
+	var failures int64
+	require.NoError(t, db.Model(&models.History{}).Where("event_type = ?", models.HistoryEventDownloadFailed).Count(&failures).Error)
+	assert.Equal(t, int64(1), failures)
+
+	var count int64
+	require.NoError(t, db.Model(&models.ProcessingTask{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
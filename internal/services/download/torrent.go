@@ -0,0 +1,160 @@
+package download
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// maxTorrentFileSize is the largest .torrent file fetched to read its hash
+const maxTorrentFileSize = 10 << 20
+
+// newTorrentHTTPClient returns the client .torrent files are fetched with. It
+// follows redirects except to magnet links, which resolveTorrent reads from
+// the redirect instead.
+func newTorrentHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme == "magnet" {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// errInvalidTorrent is returned for a .torrent file that cannot be parsed
+var errInvalidTorrent = errors.New("not a valid torrent file")
+
+// resolveTorrent returns the URL to add a release by and the info hash the
+// monitor follows its torrent by. Magnet links carry the hash, otherwise the
+// hash the indexer reported is used, and failing that the .torrent file is
+// fetched to compute it. Indexers that redirect their download link to a
+// magnet link have that magnet link added instead.
+func (s *Service) resolveTorrent(release *models.Release) (string, string, error) {
+	if hash := magnetHash(release.MagnetURL); hash != "" {
+		return release.MagnetURL, hash, nil
+	}
+	if release.MagnetURL != "" && release.TorrentURL == "" {
+		return "", "", fmt.Errorf("%w: magnet link has no info hash", ErrNoTorrentHash)
+	}
+	if hash := strings.ToLower(release.TorrentHash); len(hash) == 40 {
+		if _, err := hex.DecodeString(hash); err == nil {
+			return release.TorrentURL, hash, nil
+		}
+	}
+
+	resp, err := s.httpClient.Get(release.TorrentURL)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: failed to fetch torrent file: %v", ErrNoTorrentHash, err)
+	}
+	defer resp.Body.Close()
+
+	if location := resp.Header.Get("Location"); resp.StatusCode/100 == 3 {
+		if hash := magnetHash(location); hash != "" {
+			return location, hash, nil
+		}
+		return "", "", fmt.Errorf("%w: torrent URL redirects to %q", ErrNoTorrentHash, location)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("%w: fetching torrent file failed with status %d", ErrNoTorrentHash, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTorrentFileSize+1))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: failed to fetch torrent file: %v", ErrNoTorrentHash, err)
+	}
+	if len(data) > maxTorrentFileSize {
+		return "", "", fmt.Errorf("%w: torrent file is larger than %d bytes", ErrNoTorrentHash, maxTorrentFileSize)
+	}
+	hash, err := infoHash(data)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrNoTorrentHash, err)
+	}
+	return release.TorrentURL, hash, nil
+}
+
+// infoHash returns the info hash of a .torrent file, the SHA-1 of its
+// bencoded info dictionary, in lowercase hex
+func infoHash(data []byte) (string, error) {
+	if len(data) == 0 || data[0] != 'd' {
+		return "", errInvalidTorrent
+	}
+	pos := 1
+	for pos < len(data) && data[pos] != 'e' {
+		key, next, err := bencodeString(data, pos)
+		if err != nil {
+			return "", err
+		}
+		end, err := skipBencode(data, next, 0)
+		if err != nil {
+			return "", err
+		}
+		if key == "info" {
+			sum := sha1.Sum(data[next:end])
+			return hex.EncodeToString(sum[:]), nil
+		}
+		pos = end
+	}
+	return "", fmt.Errorf("%w: no info dictionary", errInvalidTorrent)
+}
+
+// bencodeString reads the byte string at pos, returning it and the position
+// after it
+func bencodeString(data []byte, pos int) (string, int, error) {
+	colon := bytes.IndexByte(data[pos:], ':')
+	if colon < 1 {
+		return "", 0, errInvalidTorrent
+	}
+	length, err := strconv.Atoi(string(data[pos : pos+colon]))
+	start := pos + colon + 1
+	if err != nil || length < 0 || length > len(data)-start {
+		return "", 0, errInvalidTorrent
+	}
+	return string(data[start : start+length]), start + length, nil
+}
+
+// skipBencode returns the position after the value at pos. depth bounds the
+// nesting of lists and dictionaries.
+func skipBencode(data []byte, pos, depth int) (int, error) {
+	if pos >= len(data) || depth > 64 {
+		return 0, errInvalidTorrent
+	}
+	switch data[pos] {
+	case 'i':
+		end := bytes.IndexByte(data[pos:], 'e')
+		if end < 0 {
+			return 0, errInvalidTorrent
+		}
+		return pos + end + 1, nil
+	case 'l', 'd':
+		pos++
+		for pos < len(data) && data[pos] != 'e' {
+			next, err := skipBencode(data, pos, depth+1)
+			if err != nil {
+				return 0, err
+			}
+			pos = next
+		}
+		if pos >= len(data) {
+			return 0, errInvalidTorrent
+		}
+		return pos + 1, nil
+	default:
+		_, next, err := bencodeString(data, pos)
+		return next, err
+	}
+}
@@ -0,0 +1,366 @@
+// Package health checks that the services and folders Listenarr depends on
+// are usable
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/events"
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/notification"
+	"github.com/listenarr/listenarr/pkg/diskspace"
+	"github.com/listenarr/listenarr/pkg/jackett"
+)
+
+// Status is the outcome of a check, from best to worst
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusWarning Status = "warning"
+	StatusError   Status = "error"
+)
+
+// worse returns true if s is worse than other
+func (s Status) worse(other Status) bool {
+	rank := map[Status]int{StatusOK: 0, StatusWarning: 1, StatusError: 2}
+	return rank[s] > rank[other]
+}
+
+// Names of the checks
+const (
+	CheckDatabase       = "database"
+	CheckDownloadClient = "download_client"
+	CheckIndexers       = "indexers"
+	CheckLibraryPath    = "library_path"
+	CheckTempPath       = "temp_path"
+	CheckDiskSpace      = "disk_space"
+	CheckScripts        = "scripts"
+	CheckTools          = "processing_tools"
+)
+
+// ProcessingTools are the executables audiobooks are converted and
+// chapterized with, installed in the Docker image
+var ProcessingTools = []string{"ffmpeg", "m4b-tool"}
+
+// pingTimeout bounds how long a database ping may take, so a hung database
+// fails the checks instead of blocking them
+const pingTimeout = 2 * time.Second
+
+// DownloadClient is the part of the download client the checks use
+type DownloadClient interface {
+	Login() error
+}
+
+// Indexer is the part of the indexer proxy the checks use
+type Indexer interface {
+	Search(req jackett.SearchRequest) (*jackett.SearchResponse, error)
+}
+
+// Config holds configuration for the health service
+type Config struct {
+	DownloadPath    string // May be empty when it is not known
+	LibraryPath     string
+	TempPath        string
+	MinFreeDownload uint64   // Bytes; 0 disables the threshold
+	MinFreeLibrary  uint64   // Bytes; 0 disables the threshold
+	MinFreeTemp     uint64   // Bytes; 0 disables the threshold
+	Tools           []string // Executables looked up on PATH, usually ProcessingTools
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name      string    `json:"name"`
+	Status    Status    `json:"status"`
+	Message   string    `json:"message"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the outcome of a run of all checks
+type Report struct {
+	Status    Status    `json:"status"` // The worst status of the checks
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Result  `json:"checks"`
+}
+
+// check is a named check returning its status and a message
+type check struct {
+	name string
+	run  func() (Status, string)
+}
+
+// Service runs the health checks and keeps the latest report
+type Service struct {
+	db       *gorm.DB
+	events   *events.Bus
+	config   Config
+	download DownloadClient
+	indexer  Indexer
+	checks   []check
+
+	running sync.Mutex // Held for the duration of a run
+	mu      sync.Mutex // Guards last
+	last    *Report
+}
+
+// NewService creates a new health service.
+// downloadClient and indexer may be nil when they are not configured, and
+// bus may be nil when nobody listens for health issues.
+func NewService(db *gorm.DB, bus *events.Bus, downloadClient DownloadClient, indexer Indexer, config Config) *Service {
+	s := &Service{
+		db:       db,
+		events:   bus,
+		config:   config,
+		download: downloadClient,
+		indexer:  indexer,
+	}
+	s.checks = []check{
+		{CheckDatabase, s.checkDatabase},
+		{CheckDownloadClient, s.checkDownloadClient},
+		{CheckIndexers, s.checkIndexers},
+		{CheckLibraryPath, func() (Status, string) { return checkWritable("Library", config.LibraryPath) }},
+		{CheckTempPath, func() (Status, string) { return checkWritable("Temp", config.TempPath) }},
+		{CheckDiskSpace, s.checkDiskSpace},
+		{CheckScripts, s.checkScripts},
+		{CheckTools, s.checkTools},
+	}
+	return s
+}
+
+// Check runs every check and returns the report. A health issue is
+// published for each check that has become worse since the last run.
+func (s *Service) Check() *Report {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	previous := make(map[string]Status)
+	if last := s.Last(); last != nil {
+		for _, result := range last.Checks {
+			previous[result.Name] = result.Status
+		}
+	}
+
+	report := &Report{Status: StatusOK, CheckedAt: time.Now()}
+	for _, c := range s.checks {
+		status, message := c.run()
+		result := Result{Name: c.name, Status: status, Message: message, CheckedAt: time.Now()}
+		report.Checks = append(report.Checks, result)
+		if status.worse(report.Status) {
+			report.Status = status
+		}
+
+		last, ok := previous[c.name]
+		if status != StatusOK && (!ok || status.worse(last)) {
+			log.Printf("health: %s: %s", c.name, message)
+			s.events.Publish(events.HealthIssue, events.HealthPayload{
+				Source:  c.name,
+				Level:   string(status),
+				Message: message,
+			})
+		}
+	}
+
+	s.mu.Lock()
+	s.last = report
+	s.mu.Unlock()
+	return report
+}
+
+// Last returns the report of the last run, or nil before the first
+func (s *Service) Last() *Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Ready returns an error when Listenarr cannot serve requests: the database
+// does not answer or the library folder is gone. It is cheap enough to call
+// from a readiness probe.
+func (s *Service) Ready() error {
+	if err := s.ping(); err != nil {
+		return fmt.Errorf("database is not available: %w", err)
+	}
+	if s.config.LibraryPath != "" {
+		info, err := os.Stat(s.config.LibraryPath)
+		if err != nil {
+			return fmt.Errorf("library folder is not available: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("library folder is not available: %s is not a folder", s.config.LibraryPath)
+		}
+	}
+	return nil
+}
+
+// ping checks that the database answers within pingTimeout
+func (s *Service) ping() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// checkDatabase pings the database and, for SQLite, checks its integrity
+func (s *Service) checkDatabase() (Status, string) {
+	if err := s.ping(); err != nil {
+		return StatusError, fmt.Sprintf("Database is not available: %v", err)
+	}
+
+	if s.db.Dialector.Name() == "sqlite" {
+		var problems []string
+		if err := s.db.Raw("PRAGMA quick_check").Scan(&problems).Error; err != nil {
+			return StatusError, fmt.Sprintf("Database integrity check failed: %v", err)
+		}
+		if len(problems) != 1 || problems[0] != "ok" {
+			return StatusError, fmt.Sprintf("Database is corrupt: %s", strings.Join(problems, "; "))
+		}
+	}
+	return StatusOK, "Database is reachable and intact"
+}
+
+// checkDownloadClient logs in to the download client
+func (s *Service) checkDownloadClient() (Status, string) {
+	if s.download == nil {
+		return StatusWarning, "No download client is configured"
+	}
+	if err := s.download.Login(); err != nil {
+		return StatusError, fmt.Sprintf("Download client is not available: %v", err)
+	}
+	return StatusOK, "Download client is reachable and logged in"
+}
+
+// checkIndexers searches all indexers and reports those that fail
+func (s *Service) checkIndexers() (Status, string) {
+	if s.indexer == nil {
+		return StatusWarning, "No indexer proxy is configured"
+	}
+	response, err := s.indexer.Search(jackett.SearchRequest{Query: "test"})
+	if err != nil {
+		return StatusError, fmt.Sprintf("Indexer proxy is not available: %v", err)
+	}
+	if len(response.Indexers) == 0 {
+		return StatusWarning, "No indexers are configured in the indexer proxy"
+	}
+
+	var failing []string
+	for _, indexer := range response.Indexers {
+		if indexer.Error != "" {
+			failing = append(failing, fmt.Sprintf("%s (%s)", indexer.Name, indexer.Error))
+		}
+	}
+	switch {
+	case len(failing) == len(response.Indexers):
+		return StatusError, "All indexers are failing: " + strings.Join(failing, ", ")
+	case len(failing) > 0:
+		return StatusWarning, "Indexers are failing: " + strings.Join(failing, ", ")
+	}
+	return StatusOK, fmt.Sprintf("%d indexer(s) responding", len(response.Indexers))
+}
+
+// checkDiskSpace compares the free space of the download, library and temp
+// folders with their thresholds
+func (s *Service) checkDiskSpace() (Status, string) {
+	var problems []string
+	status := StatusOK
+	for _, path := range []struct {
+		label   string
+		path    string
+		minimum uint64
+	}{
+		{"Download", s.config.DownloadPath, s.config.MinFreeDownload},
+		{"Library", s.config.LibraryPath, s.config.MinFreeLibrary},
+		{"Temp", s.config.TempPath, s.config.MinFreeTemp},
+	} {
+		if path.path == "" || path.minimum == 0 {
+			continue
+		}
+		usage, err := diskspace.Get(path.path)
+		if errors.Is(err, diskspace.ErrUnsupported) {
+			return StatusOK, "Free space cannot be checked on this platform"
+		}
+		if err != nil {
+			// The path checks report folders that are missing
+			problems = append(problems, fmt.Sprintf("%s folder: %v", path.label, err))
+			status = StatusWarning
+			continue
+		}
+		if usage.Free < path.minimum {
+			problems = append(problems, fmt.Sprintf("%s folder has %s free, below the minimum of %s",
+				path.label, diskspace.FormatBytes(usage.Free), diskspace.FormatBytes(path.minimum)))
+			status = StatusError
+		}
+	}
+	if len(problems) > 0 {
+		return status, strings.Join(problems, "; ")
+	}
+	return StatusOK, "Enough free space"
+}
+
+// checkScripts makes sure the executables of enabled custom scripts exist
+func (s *Service) checkScripts() (Status, string) {
+	var scripts []models.Notification
+	err := s.db.Where("type = ? AND enabled = ?", models.NotificationTypeScript, true).Order("id").Find(&scripts).Error
+	if err != nil {
+		return StatusError, fmt.Sprintf("Failed to list custom scripts: %v", err)
+	}
+
+	var problems []string
+	for i := range scripts {
+		if _, err := notification.New(&scripts[i]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", scripts[i].Name, err))
+		}
+	}
+	if len(problems) > 0 {
+		return StatusWarning, "Custom scripts cannot run: " + strings.Join(problems, ", ")
+	}
+	return StatusOK, fmt.Sprintf("%d custom script(s) ready", len(scripts))
+}
+
+// checkTools makes sure the processing tools can be found on PATH
+func (s *Service) checkTools() (Status, string) {
+	var missing []string
+	for _, tool := range s.config.Tools {
+		if _, err := exec.LookPath(tool); err != nil {
+			missing = append(missing, tool)
+		}
+	}
+	if len(missing) > 0 {
+		return StatusWarning, "Processing tools are missing: " + strings.Join(missing, ", ")
+	}
+	return StatusOK, fmt.Sprintf("%d processing tool(s) found", len(s.config.Tools))
+}
+
+// checkWritable makes sure path is a folder files can be created in
+func checkWritable(label, path string) (Status, string) {
+	if path == "" {
+		return StatusError, fmt.Sprintf("%s folder is not configured", label)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return StatusError, fmt.Sprintf("%s folder %s is not available: %v", label, path, err)
+	}
+	if !info.IsDir() {
+		return StatusError, fmt.Sprintf("%s folder %s is not a folder", label, path)
+	}
+
+	file, err := os.CreateTemp(path, ".listenarr-health-*")
+	if err != nil {
+		return StatusError, fmt.Sprintf("%s folder %s is not writable: %v", label, path, err)
+	}
+	file.Close()
+	os.Remove(file.Name())
+	return StatusOK, fmt.Sprintf("%s folder %s is writable", label, path)
+}
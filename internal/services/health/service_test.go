@@ -0,0 +1,189 @@
+package health
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/events"
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/pkg/jackett"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
+
+	require.NoError(t, db.AutoMigrate(&models.Notification{}))
+	return db
+}
+
+// fakeDownloadClient fails to log in with err
+type fakeDownloadClient struct {
+	err error
+}
+
+func (f *fakeDownloadClient) Login() error {
+	return f.err
+}
+
+// fakeIndexer answers searches with the given indexers
+type fakeIndexer struct {
+	indexers []jackett.IndexerInfo
+	err      error
+}
+
+func (f *fakeIndexer) Search(req jackett.SearchRequest) (*jackett.SearchResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &jackett.SearchResponse{Indexers: f.indexers}, nil
+}
+
+// results indexes the checks of a report by name
+func results(report *Report) map[string]Result {
+	byName := make(map[string]Result)
+	for _, result := range report.Checks {
+		byName[result.Name] = result
+	}
+	return byName
+}
+
+func TestCheck_Healthy(t *testing.T) {
+	db := setupTestDB(t)
+	tools := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tools, "ffmpeg"), []byte("#!/bin/sh\n"), 0o755))
+	t.Setenv("PATH", tools)
+
+	service := NewService(db, nil, &fakeDownloadClient{}, &fakeIndexer{indexers: []jackett.IndexerInfo{{Name: "AudioBookBay"}}}, Config{
+		LibraryPath:    t.TempDir(),
+		TempPath:       t.TempDir(),
+		MinFreeLibrary: 1,
+		MinFreeTemp:    1,
+		Tools:          []string{"ffmpeg"},
+	})
+	assert.Nil(t, service.Last())
+
+	report := service.Check()
+	assert.Equal(t, StatusOK, report.Status)
+	require.Len(t, report.Checks, 8)
+	for _, result := range report.Checks {
+		assert.Equal(t, StatusOK, result.Status, "%s: %s", result.Name, result.Message)
+	}
+	assert.Equal(t, "1 indexer(s) responding", results(report)[CheckIndexers].Message)
+	assert.Equal(t, "1 processing tool(s) found", results(report)[CheckTools].Message)
+	assert.Same(t, report, service.Last())
+
+	entries, err := os.ReadDir(service.config.LibraryPath)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the write test cleans up after itself")
+}
+
+func TestCheck_Problems(t *testing.T) {
+	db := setupTestDB(t)
+	script := models.Notification{Name: "Hook", Type: models.NotificationTypeScript, Enabled: true}
+	script.SetSettings(models.NotificationSettings{Path: filepath.Join(t.TempDir(), "gone.sh")})
+	require.NoError(t, db.Create(&script).Error)
+
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	service := NewService(db, nil,
+		&fakeDownloadClient{err: errors.New("login failed: Fails.")},
+		&fakeIndexer{indexers: []jackett.IndexerInfo{{Name: "AudioBookBay"}, {Name: "MyAnonamouse", Error: "cookie expired"}}},
+		Config{
+			LibraryPath:    t.TempDir(),
+			TempPath:       file,
+			MinFreeLibrary: 1 << 62,
+			Tools:          []string{"listenarr-missing-tool"},
+		})
+
+	report := service.Check()
+	assert.Equal(t, StatusError, report.Status)
+	checks := results(report)
+	assert.Equal(t, StatusOK, checks[CheckDatabase].Status)
+	assert.Equal(t, StatusError, checks[CheckDownloadClient].Status)
+	assert.Contains(t, checks[CheckDownloadClient].Message, "login failed: Fails.")
+	assert.Equal(t, StatusWarning, checks[CheckIndexers].Status)
+	assert.Equal(t, "Indexers are failing: MyAnonamouse (cookie expired)", checks[CheckIndexers].Message)
+	assert.Equal(t, StatusOK, checks[CheckLibraryPath].Status)
+	assert.Equal(t, StatusError, checks[CheckTempPath].Status)
+	assert.Contains(t, checks[CheckTempPath].Message, "is not a folder")
+	assert.Equal(t, StatusError, checks[CheckDiskSpace].Status)
+	assert.Contains(t, checks[CheckDiskSpace].Message, "below the minimum of 4.0 EiB")
+	assert.Equal(t, StatusWarning, checks[CheckScripts].Status)
+	assert.Contains(t, checks[CheckScripts].Message, "Hook: path is not an executable file")
+	assert.Equal(t, StatusWarning, checks[CheckTools].Status)
+	assert.Equal(t, "Processing tools are missing: listenarr-missing-tool", checks[CheckTools].Message)
+
+	t.Run("Not configured", func(t *testing.T) {
+		service := NewService(db, nil, nil, &fakeIndexer{err: errors.New("connection refused")}, Config{})
+		checks := results(service.Check())
+		assert.Equal(t, StatusWarning, checks[CheckDownloadClient].Status)
+		assert.Equal(t, StatusError, checks[CheckIndexers].Status)
+		assert.Equal(t, "Library folder is not configured", checks[CheckLibraryPath].Message)
+		assert.Equal(t, StatusOK, checks[CheckDiskSpace].Status, "no folders to check")
+	})
+}
+
+func TestCheck_PublishesNewIssues(t *testing.T) {
+	db := setupTestDB(t)
+	bus := events.NewBus()
+	sub, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	client := &fakeDownloadClient{err: errors.New("connection refused")}
+	service := NewService(db, bus, client, &fakeIndexer{indexers: []jackett.IndexerInfo{{Name: "AudioBookBay"}}}, Config{LibraryPath: t.TempDir(), TempPath: t.TempDir()})
+
+	issues := func() []events.HealthPayload {
+		var payloads []events.HealthPayload
+		for {
+			select {
+			case event := <-sub:
+				if event.Type == events.HealthIssue {
+					payloads = append(payloads, event.Data.(events.HealthPayload))
+				}
+			case <-time.After(50 * time.Millisecond):
+				return payloads
+			}
+		}
+	}
+
+	service.Check()
+	published := issues()
+	require.Len(t, published, 1)
+	assert.Equal(t, CheckDownloadClient, published[0].Source)
+	assert.Equal(t, "error", published[0].Level)
+	assert.Contains(t, published[0].Message, "connection refused")
+
+	service.Check()
+	assert.Empty(t, issues(), "known issues are not published again")
+
+	client.err = nil
+	service.Check()
+	client.err = errors.New("connection refused")
+	service.Check()
+	assert.Len(t, issues(), 1, "an issue that comes back is published again")
+}
+
+func TestReady(t *testing.T) {
+	db := setupTestDB(t)
+
+	service := NewService(db, nil, nil, nil, Config{LibraryPath: t.TempDir()})
+	assert.NoError(t, service.Ready())
+
+	service = NewService(db, nil, nil, nil, Config{LibraryPath: filepath.Join(t.TempDir(), "unmounted")})
+	assert.ErrorContains(t, service.Ready(), "library folder is not available")
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+	service = NewService(db, nil, nil, nil, Config{})
+	assert.ErrorContains(t, service.Ready(), "database is not available")
+}
@@ -0,0 +1,84 @@
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// Service records events in the activity log
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a new history service
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Record stores a history entry. The date defaults to now, and the book and
+// author are filled in from the library item or book when not set.
+func (s *Service) Record(entry *models.History) error {
+	if entry.Date.IsZero() {
+		entry.Date = time.Now()
+	}
+
+	if entry.BookID == nil && entry.LibraryItemID != nil {
+		var item models.LibraryItem
+		if err := s.db.Unscoped().First(&item, *entry.LibraryItemID).Error; err == nil {
+			entry.BookID = &item.BookID
+		}
+	}
+
+	if entry.AuthorID == nil && entry.BookID != nil {
+		var book models.Book
+		if err := s.db.Unscoped().First(&book, *entry.BookID).Error; err == nil {
+			entry.AuthorID = &book.AuthorID
+		}
+	}
+
+	if err := s.db.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to record history: %w", err)
+	}
+
+	return nil
+}
+
+// RecordGrab records that a release was sent to the download client
+func (s *Service) RecordGrab(download *models.Download, release *models.Release, client string) error {
+	return s.Record(&models.History{
+		EventType:      models.HistoryEventGrabbed,
+		LibraryItemID:  &download.LibraryItemID,
+		DownloadID:     &download.ID,
+		ReleaseTitle:   release.Title,
+		Indexer:        release.Indexer,
+		Quality:        release.Quality,
+		DownloadClient: client,
+		DownloadHash:   download.QBittorrentHash,
+	})
+}
+
+// RecordDownloadFailed records that a download failed in the download client
+func (s *Service) RecordDownloadFailed(download *models.Download, client string) error {
+	entry := &models.History{
+		EventType:      models.HistoryEventDownloadFailed,
+		LibraryItemID:  &download.LibraryItemID,
+		DownloadID:     &download.ID,
+		DownloadClient: client,
+		DownloadHash:   download.QBittorrentHash,
+		SourcePath:     download.DownloadPath,
+		Message:        download.Error,
+	}
+
+	var release models.Release
+	if err := s.db.Unscoped().First(&release, download.ReleaseID).Error; err == nil {
+		entry.ReleaseTitle = release.Title
+		entry.Indexer = release.Indexer
+		entry.Quality = release.Quality
+	}
+
+	return s.Record(entry)
+}
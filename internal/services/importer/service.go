@@ -0,0 +1,721 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/events"
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/history"
+	"github.com/listenarr/listenarr/internal/services/mediaserver"
+	"github.com/listenarr/listenarr/internal/services/tagger"
+	"github.com/listenarr/listenarr/pkg/diskspace"
+	"github.com/listenarr/listenarr/pkg/probe"
+)
+
+// Errors returned by the import service
+var (
+	ErrTaskNotFound      = errors.New("processing task not found")
+	ErrNoAudioFiles      = errors.New("no audio files found")
+	ErrNotImportable     = errors.New("processing task is not pending")
+	ErrLibraryNotDefined = errors.New("library path not configured")
+	ErrBookNotFound      = errors.New("book not found")
+	ErrAlreadyAvailable  = errors.New("book is already available in the library")
+
+	// ErrInsufficientSpace is returned when the library or temp folder is too
+	// full; processing tasks stay pending and are tried again
+	ErrInsufficientSpace = diskspace.ErrInsufficientSpace
+)
+
+// audioExtensions are the file types imported into the library
+var audioExtensions = map[string]bool{
+	".m4b":  true,
+	".m4a":  true,
+	".mp3":  true,
+	".flac": true,
+	".ogg":  true,
+	".opus": true,
+	".aac":  true,
+	".wma":  true,
+}
+
+// ImportMode is how files get from the import source into the library
+type ImportMode string
+
+const (
+	// ImportModeHardlink hardlinks (or copies) files into the library,
+	// leaving the source in place so torrents keep seeding
+	ImportModeHardlink ImportMode = "hardlink"
+	// ImportModeMove moves files into the library
+	ImportModeMove ImportMode = "move"
+	// ImportModeInPlace leaves files where they are; the source becomes the
+	// library item's path and files are not tagged
+	ImportModeInPlace ImportMode = "in_place"
+)
+
+// Tagger embeds book metadata into an imported file
+type Tagger interface {
+	TagFile(path string, book *models.Book) error
+}
+
+// Config holds configuration for the import service
+type Config struct {
+	LibraryPath string
+
+	// Free space the library and temp folders must keep, in bytes; 0
+	// disables the check. The library must also have room for the files,
+	// even when they end up hardlinked.
+	TempPath       string
+	MinFreeLibrary uint64
+	MinFreeTemp    uint64
+}
+
+// Service moves processed audiobooks into the library
+type Service struct {
+	db        *gorm.DB
+	config    Config
+	tagger    Tagger
+	notifiers []mediaserver.Notifier
+	history   *history.Service
+	events    *events.Bus
+}
+
+// Identification is what the tags of the audio files at a path say about
+// the book they hold, and the book in the database it matches
+type Identification struct {
+	Path     string `json:"path"`
+	Files    int    `json:"files"`
+	Title    string `json:"title,omitempty"`
+	Author   string `json:"author,omitempty"`
+	Narrator string `json:"narrator,omitempty"`
+	Series   string `json:"series,omitempty"`
+	Sequence string `json:"sequence,omitempty"`
+	ASIN     string `json:"asin,omitempty"`
+	Format   string `json:"format,omitempty"`
+	Duration int    `json:"duration,omitempty"` // Seconds
+	Bitrate  int    `json:"bitrate,omitempty"`  // kbps
+	BookID   *uint  `json:"book_id,omitempty"`  // nil when no book matches
+}
+
+// Result describes a completed import
+type Result struct {
+	LibraryItemID uint     `json:"library_item_id"`
+	BookID        uint     `json:"book_id"`
+	SourcePath    string   `json:"source_path"`
+	Destination   string   `json:"destination"`
+	Files         []string `json:"files"`
+	Size          int64    `json:"size"`
+	Upgrade       bool     `json:"upgrade,omitempty"` // The item already had files, which these replace
+}
+
+// NewService creates a new import service.
+// fileTagger may be nil when metadata should not be embedded; notifiers are the
+// media servers told about each import; bus may be nil when nobody listens
+// for library events.
+func NewService(db *gorm.DB, fileTagger Tagger, notifiers []mediaserver.Notifier, bus *events.Bus, config Config) *Service {
+	return &Service{
+		db:        db,
+		config:    config,
+		tagger:    fileTagger,
+		notifiers: notifiers,
+		history:   history.NewService(db),
+		events:    bus,
+	}
+}
+
+// ImportTask imports the output of a pending processing task (or its input
+// when it produced no output file) and marks the task completed. A failed
+// import marks the task and its library item failed.
+func (s *Service) ImportTask(taskID uint) (*Result, error) {
+	var task models.ProcessingTask
+	err := s.db.Preload("Download.Release").First(&task, taskID).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+	if task.Status != models.ProcessingStatusPending {
+		return nil, ErrNotImportable
+	}
+
+	var item models.LibraryItem
+	if err := s.db.Preload("Book.Author").First(&item, task.Download.LibraryItemID).Error; err != nil {
+		return nil, fmt.Errorf("failed to find library item: %w", err)
+	}
+
+	source := task.OutputPath
+	if source == "" {
+		source = task.InputPath
+	}
+
+	// Without room the task waits for the next run instead of failing
+	if err := s.requireSpace(source); err != nil {
+		if task.Error != err.Error() {
+			task.Error = err.Error()
+			s.db.Omit("Download").Save(&task)
+			s.publishTask(events.ProcessingStatusChanged, &task)
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	task.Status = models.ProcessingStatusProcessing
+	task.StartedAt = &now
+	if err := s.db.Omit("Download").Save(&task).Error; err != nil {
+		return nil, err
+	}
+	s.publishTask(events.ProcessingStatusChanged, &task)
+
+	result, importErr := s.Import(&item, source, &task.Download)
+
+	completed := time.Now()
+	task.CompletedAt = &completed
+	if importErr != nil {
+		task.Status = models.ProcessingStatusFailed
+		task.Error = importErr.Error()
+		item.Status = models.LibraryItemStatusError
+		s.db.Omit("Book").Save(&item)
+	} else {
+		task.Status = models.ProcessingStatusCompleted
+		task.Progress = 100
+		task.Error = ""
+	}
+	if err := s.db.Omit("Download").Save(&task).Error; err != nil {
+		return nil, err
+	}
+	s.publishTask(events.ProcessingStatusChanged, &task)
+	if importErr != nil {
+		s.publishTask(events.ProcessingTaskFailed, &task)
+		return nil, importErr
+	}
+
+	return result, nil
+}
+
+// ProcessPending imports every pending processing task. Failures are logged
+// and do not stop the remaining tasks.
+func (s *Service) ProcessPending() {
+	var tasks []models.ProcessingTask
+	if err := s.db.Where("status = ?", models.ProcessingStatusPending).Order("id").Find(&tasks).Error; err != nil {
+		log.Printf("importer: failed to list pending tasks: %v", err)
+		return
+	}
+
+	for _, task := range tasks {
+		_, err := s.ImportTask(task.ID)
+		switch {
+		case errors.Is(err, ErrInsufficientSpace):
+			log.Printf("importer: postponed task %d: %v", task.ID, err)
+		case err != nil:
+			log.Printf("importer: failed to import task %d: %v", task.ID, err)
+		}
+	}
+}
+
+// Import copies the audio files at sourcePath (a file or a folder) into the
+// book's library folder, marks the library item available and records the
+// import in history. download may be nil for imports outside a download.
+// A single M4B or M4A file is tagged with the book's metadata, and media
+// servers are notified afterwards; failures of either are recorded in
+// history but do not fail the import.
+func (s *Service) Import(item *models.LibraryItem, sourcePath string, download *models.Download) (*Result, error) {
+	return s.ImportWith(item, sourcePath, download, ImportModeHardlink)
+}
+
+// ImportWith imports like Import, getting the files into the library the
+// given way
+func (s *Service) ImportWith(item *models.LibraryItem, sourcePath string, download *models.Download, mode ImportMode) (*Result, error) {
+	if s.config.LibraryPath == "" && mode != ImportModeInPlace {
+		return nil, ErrLibraryNotDefined
+	}
+	if item.Book.ID == 0 {
+		if err := s.db.Preload("Author").First(&item.Book, item.BookID).Error; err != nil {
+			return nil, fmt.Errorf("failed to find book: %w", err)
+		}
+	}
+
+	files, err := findAudioFiles(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	if mode != ImportModeInPlace {
+		if err := diskspace.Require(s.config.LibraryPath, totalSize(files), s.config.MinFreeLibrary); err != nil {
+			return nil, fmt.Errorf("library folder: %w", err)
+		}
+	}
+
+	destination := s.BookFolder(&item.Book)
+	if mode == ImportModeInPlace {
+		destination = sourcePath
+		if len(files) == 1 && files[0].path == sourcePath {
+			destination = filepath.Dir(sourcePath)
+		}
+	}
+	result := &Result{
+		LibraryItemID: item.ID,
+		BookID:        item.BookID,
+		SourcePath:    sourcePath,
+		Destination:   destination,
+	}
+	for _, file := range files {
+		target := filepath.Join(destination, file.relPath)
+		var size int64
+		var err error
+		switch mode {
+		case ImportModeInPlace:
+			target = file.path
+			size, err = fileSize(file.path)
+		case ImportModeMove:
+			size, err = moveFile(file.path, target)
+		default:
+			size, err = linkOrCopy(file.path, target)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", file.path, err)
+		}
+		result.Files = append(result.Files, target)
+		result.Size += size
+	}
+
+	// A single file is the library item itself, otherwise the folder is
+	filePath := destination
+	if len(result.Files) == 1 {
+		filePath = result.Files[0]
+		if mode != ImportModeInPlace {
+			if size, ok := s.tagFile(item, filePath); ok {
+				result.Size = size
+			}
+		}
+	}
+
+	s.updateAudiobook(item, result.Files)
+
+	// Files imported for an item that already had some replace them
+	result.Upgrade = item.FilePath != ""
+
+	now := time.Now()
+	item.Status = models.LibraryItemStatusAvailable
+	item.FilePath = filePath
+	item.FileSize = result.Size
+	item.CompletedDate = &now
+	if err := s.db.Omit("Book").Save(item).Error; err != nil {
+		return nil, fmt.Errorf("failed to update library item: %w", err)
+	}
+
+	entry := &models.History{
+		EventType:       models.HistoryEventImported,
+		LibraryItemID:   &item.ID,
+		BookID:          &item.BookID,
+		SourcePath:      sourcePath,
+		DestinationPath: filePath,
+		Message:         importMessage(mode, len(result.Files)),
+	}
+	if result.Upgrade {
+		entry.EventType = models.HistoryEventUpgraded
+	}
+	if download != nil && download.ID != 0 {
+		entry.DownloadID = &download.ID
+		entry.DownloadHash = download.QBittorrentHash
+		entry.ReleaseTitle = download.Release.Title
+		entry.Indexer = download.Release.Indexer
+		entry.Quality = download.Release.Quality
+	}
+	// History is best effort and never fails the import
+	_ = s.history.Record(entry)
+
+	payload := events.LibraryItemPayload{
+		LibraryItemID: item.ID,
+		BookID:        item.BookID,
+		Status:        string(item.Status),
+		FilePath:      item.FilePath,
+	}
+	if download != nil {
+		payload.DownloadID = download.ID
+	}
+	s.events.Publish(events.LibraryItemAvailable, payload)
+	if result.Upgrade {
+		s.events.Publish(events.LibraryItemUpgraded, payload)
+	} else {
+		s.events.Publish(events.LibraryItemImported, payload)
+	}
+
+	s.notifyMediaServers(item, destination)
+
+	return result, nil
+}
+
+// ImportBook imports the audio files at sourcePath (a file or a folder) as
+// the given book, outside of any download. The book's library item is
+// created when it has none, and removed again when the import fails.
+func (s *Service) ImportBook(bookID uint, sourcePath string, mode ImportMode) (*Result, error) {
+	var book models.Book
+	if err := s.db.Preload("Author").First(&book, bookID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrBookNotFound
+		}
+		return nil, err
+	}
+
+	var item models.LibraryItem
+	err := s.db.Where("book_id = ?", book.ID).First(&item).Error
+	created := false
+	if err == gorm.ErrRecordNotFound {
+		item = models.LibraryItem{BookID: book.ID, Status: models.LibraryItemStatusProcessing, AddedDate: time.Now()}
+		if err := s.db.Create(&item).Error; err != nil {
+			return nil, fmt.Errorf("failed to create library item: %w", err)
+		}
+		created = true
+	} else if err != nil {
+		return nil, err
+	} else if item.IsAvailable() {
+		return nil, ErrAlreadyAvailable
+	}
+	item.Book = book
+
+	result, err := s.ImportWith(&item, sourcePath, nil, mode)
+	if err != nil {
+		if created {
+			s.db.Delete(&item)
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// Identify reads the tags of the audio files at path (a file or a folder)
+// and looks for the book they belong to: by ASIN, then by title and author
+func (s *Service) Identify(path string) (*Identification, error) {
+	files, err := findAudioFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.path
+	}
+
+	info, err := probe.ProbeFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	identification := &Identification{
+		Path:     path,
+		Files:    len(files),
+		Title:    info.BookTitle(),
+		Author:   info.Author,
+		Narrator: info.Narrator,
+		Series:   info.Series,
+		Sequence: info.Sequence,
+		ASIN:     info.ASIN,
+		Format:   info.Format,
+		Duration: int(info.Duration.Seconds()),
+		Bitrate:  info.Bitrate,
+	}
+
+	book, err := s.matchBook(info)
+	if err != nil {
+		return nil, err
+	}
+	if book != nil {
+		identification.BookID = &book.ID
+	}
+	return identification, nil
+}
+
+// matchBook finds the book described by the tags, or returns nil
+func (s *Service) matchBook(info *probe.Info) (*models.Book, error) {
+	var book models.Book
+	if info.ASIN != "" {
+		err := s.db.
+			Where("books.asin = ? OR books.id IN (?)", info.ASIN,
+				s.db.Model(&models.Audiobook{}).Select("book_id").Where("asin = ?", info.ASIN)).
+			First(&book).Error
+		if err == nil {
+			return &book, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+	}
+
+	title := info.BookTitle()
+	if title == "" || info.Author == "" {
+		return nil, nil
+	}
+	err := s.db.
+		Joins("JOIN authors ON authors.id = books.author_id AND authors.deleted_at IS NULL").
+		Where("LOWER(books.title) = LOWER(?) AND LOWER(authors.name) = LOWER(?)", title, info.Author).
+		First(&book).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// updateAudiobook records the duration, bitrate and format of imported
+// files on the book's audiobook. Files that cannot be read are logged.
+func (s *Service) updateAudiobook(item *models.LibraryItem, files []string) {
+	info, err := probe.ProbeFiles(files)
+	if err != nil {
+		log.Printf("importer: failed to read audio info for book %d: %v", item.BookID, err)
+		return
+	}
+
+	var audiobook models.Audiobook
+	err = s.db.Where(models.Audiobook{BookID: item.BookID}).
+		Assign(map[string]interface{}{
+			"duration": int(info.Duration.Seconds()),
+			"bitrate":  info.Bitrate,
+			"format":   info.Format,
+		}).
+		FirstOrCreate(&audiobook).Error
+	if err != nil {
+		log.Printf("importer: failed to update audiobook for book %d: %v", item.BookID, err)
+	}
+}
+
+// importMessage describes an import in history
+func importMessage(mode ImportMode, files int) string {
+	switch mode {
+	case ImportModeInPlace:
+		return fmt.Sprintf("Imported %d file(s) in place", files)
+	case ImportModeMove:
+		return fmt.Sprintf("Moved %d file(s) into the library", files)
+	}
+	return fmt.Sprintf("Imported %d file(s)", files)
+}
+
+// BookFolder returns the library folder for a book: <library>/<author>/<title>
+func (s *Service) BookFolder(book *models.Book) string {
+	return filepath.Join(s.config.LibraryPath, sanitizeName(book.Author.Name), sanitizeName(book.Title))
+}
+
+// tagFile embeds the book's metadata into an imported file, recording
+// failures in history. It returns the tagged file's size.
+func (s *Service) tagFile(item *models.LibraryItem, path string) (int64, bool) {
+	if s.tagger == nil || !tagger.CanTag(path) {
+		return 0, false
+	}
+	if err := s.tagger.TagFile(path, &item.Book); err != nil {
+		log.Printf("importer: failed to tag %s: %v", path, err)
+		_ = s.history.Record(&models.History{
+			EventType:       models.HistoryEventTaggingFailed,
+			LibraryItemID:   &item.ID,
+			BookID:          &item.BookID,
+			DestinationPath: path,
+			Message:         err.Error(),
+		})
+		return 0, false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	return info.Size(), true
+}
+
+// notifyMediaServers tells every media server about the imported folder,
+// recording failures in history
+func (s *Service) notifyMediaServers(item *models.LibraryItem, dir string) {
+	for _, notifier := range s.notifiers {
+		if err := notifier.Notify(dir); err != nil {
+			log.Printf("importer: %s scan of %s failed: %v", notifier.Name(), dir, err)
+			_ = s.history.Record(&models.History{
+				EventType:       models.HistoryEventMediaServerFailed,
+				LibraryItemID:   &item.ID,
+				BookID:          &item.BookID,
+				DestinationPath: dir,
+				Message:         notifier.Name() + ": " + err.Error(),
+			})
+		}
+	}
+}
+
+// publishTask sends a processing task event on the bus
+func (s *Service) publishTask(eventType events.Type, task *models.ProcessingTask) {
+	s.events.Publish(eventType, events.ProcessingPayload{
+		TaskID:     task.ID,
+		DownloadID: task.DownloadID,
+		Status:     string(task.Status),
+		Progress:   task.Progress,
+		Error:      task.Error,
+	})
+}
+
+// audioFile is an audio file found under an import source
+type audioFile struct {
+	path    string
+	relPath string // Path relative to the source folder, or the file name
+	size    int64
+}
+
+// totalSize adds up the sizes of files
+func totalSize(files []audioFile) uint64 {
+	var total uint64
+	for _, file := range files {
+		total += uint64(file.size)
+	}
+	return total
+}
+
+// requireSpace makes sure the temp folder keeps its free space and the
+// library has room for the audio files at sourcePath. A source without
+// audio files is left for the import to report.
+func (s *Service) requireSpace(sourcePath string) error {
+	if err := diskspace.Require(s.config.TempPath, 0, s.config.MinFreeTemp); err != nil {
+		return fmt.Errorf("temp folder: %w", err)
+	}
+	files, err := findAudioFiles(sourcePath)
+	if err != nil {
+		return nil
+	}
+	if err := diskspace.Require(s.config.LibraryPath, totalSize(files), s.config.MinFreeLibrary); err != nil {
+		return fmt.Errorf("library folder: %w", err)
+	}
+	return nil
+}
+
+// findAudioFiles returns the audio files at path, which may be a single file
+// or a folder searched recursively, in name order
+func findAudioFiles(path string) ([]audioFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import source: %w", err)
+	}
+
+	if !info.IsDir() {
+		if !IsAudioFile(path) {
+			return nil, ErrNoAudioFiles
+		}
+		return []audioFile{{path: path, relPath: filepath.Base(path), size: info.Size()}}, nil
+	}
+
+	var files []audioFile
+	err = filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || !IsAudioFile(p) {
+			return nil
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, audioFile{path: p, relPath: rel, size: fi.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import source: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, ErrNoAudioFiles
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].relPath < files[j].relPath })
+	return files, nil
+}
+
+// IsAudioFile reports whether path has an audio file extension
+func IsAudioFile(path string) bool {
+	return audioExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// linkOrCopy hardlinks src to dst, falling back to a copy when src is on
+// another filesystem. Source files are left in place so torrents keep seeding.
+func linkOrCopy(src, dst string) (int64, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return 0, err
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	if err := os.Link(src, dst); err == nil {
+		return info.Size(), nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return 0, err
+	}
+	return size, nil
+}
+
+// moveFile moves src to dst, falling back to a copy and delete when they
+// are on different filesystems
+func moveFile(src, dst string) (int64, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return info.Size(), nil
+	}
+
+	size, err := linkOrCopy(src, dst)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Remove(src); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// fileSize returns the size of the file at path
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// sanitizeName makes a title or name safe to use as a folder name
+func sanitizeName(name string) string {
+	replacer := strings.NewReplacer(
+		"/", "-", "\\", "-", ":", " -", "*", "", "?", "", "\"", "'", "<", "", ">", "", "|", "-",
+	)
+	name = strings.TrimSpace(replacer.Replace(name))
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "Unknown"
+	}
+	return name
+}
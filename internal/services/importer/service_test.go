@@ -0,0 +1,550 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/events"
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/mediaserver"
+)
+
+// fakeNotifier records notified folders and fails when err is set
+type fakeNotifier struct {
+	name string
+	dirs []string
+	err  error
+}
+
+func (n *fakeNotifier) Name() string { return n.name }
+
+func (n *fakeNotifier) Notify(dir string) error {
+	n.dirs = append(n.dirs, dir)
+	return n.err
+}
+
+// fakeTagger appends the book title to tagged files and fails when err is set
+type fakeTagger struct {
+	tagged []string
+	err    error
+}
+
+func (f *fakeTagger) TagFile(path string, book *models.Book) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.tagged = append(f.tagged, path)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(" " + book.Title)
+	return err
+}
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
+
+	err := db.AutoMigrate(
+		&models.Author{},
+		&models.Book{},
+		&models.Audiobook{},
+		&models.LibraryItem{},
+		&models.Release{},
+		&models.Download{},
+		&models.ProcessingTask{},
+		&models.History{},
+		&models.ImportCandidate{},
+	)
+	require.NoError(t, err)
+
+	return db
+}
+
+// createTaskFixture creates a completed download of a wanted book with a
+// pending processing task for the given input path
+func createTaskFixture(t *testing.T, db *gorm.DB, inputPath string) models.ProcessingTask {
+	author := models.Author{Name: "J.K. Rowling"}
+	require.NoError(t, db.Create(&author).Error)
+	book := models.Book{Title: "Harry Potter: Book 1?", AuthorID: author.ID}
+	require.NoError(t, db.Create(&book).Error)
+	item := models.LibraryItem{BookID: book.ID, Status: models.LibraryItemStatusProcessing, AddedDate: time.Now()}
+	require.NoError(t, db.Create(&item).Error)
+	release := models.Release{BookID: book.ID, Title: "Harry.Potter.1.m4b", Indexer: "test-indexer"}
+	require.NoError(t, db.Create(&release).Error)
+	download := models.Download{
+		LibraryItemID:   item.ID,
+		ReleaseID:       release.ID,
+		Status:          models.DownloadStatusCompleted,
+		QBittorrentHash: "abc123",
+	}
+	require.NoError(t, db.Create(&download).Error)
+	task := models.ProcessingTask{DownloadID: download.ID, Status: models.ProcessingStatusPending, InputPath: inputPath}
+	require.NoError(t, db.Create(&task).Error)
+	return task
+}
+
+// mp3Data returns an MP3 file with the given seconds of 128 kbps audio and
+// ID3v2.3 text frames given as ID and value pairs. TXXX values are written
+// as "description\x00value".
+func mp3Data(seconds int, frames ...string) string {
+	var tag []byte
+	for i := 0; i+1 < len(frames); i += 2 {
+		tag = append(tag, frames[i]...)
+		tag = append(tag, 0, 0, 0, byte(len(frames[i+1])+1), 0, 0, 0)
+		tag = append(tag, frames[i+1]...)
+	}
+	tag = append([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, byte(len(tag))}, tag...)
+
+	audio := make([]byte, 16000*seconds)
+	copy(audio, []byte{0xff, 0xfb, 0x90, 0x64})
+	return string(append(tag, audio...))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestImportTask_SingleFile(t *testing.T) {
+	db := setupTestDB(t)
+	downloads := t.TempDir()
+	library := t.TempDir()
+	source := filepath.Join(downloads, "Harry.Potter.1.m4b")
+	writeFile(t, source, "audio")
+
+	task := createTaskFixture(t, db, source)
+	plex := &fakeNotifier{name: "Plex"}
+	jellyfin := &fakeNotifier{name: "Jellyfin"}
+	service := NewService(db, nil, []mediaserver.Notifier{plex, jellyfin}, nil, Config{LibraryPath: library})
+
+	result, err := service.ImportTask(task.ID)
+	require.NoError(t, err)
+
+	folder := filepath.Join(library, "J.K. Rowling", "Harry Potter - Book 1")
+	expected := filepath.Join(folder, "Harry.Potter.1.m4b")
+	assert.Equal(t, []string{expected}, result.Files)
+	assert.Equal(t, int64(5), result.Size)
+	content, err := os.ReadFile(expected)
+	require.NoError(t, err)
+	assert.Equal(t, "audio", string(content))
+
+	// The download is left in place for seeding
+	assert.FileExists(t, source)
+
+	var item models.LibraryItem
+	db.First(&item, 1)
+	assert.Equal(t, models.LibraryItemStatusAvailable, item.Status)
+	assert.Equal(t, expected, item.FilePath)
+	assert.Equal(t, int64(5), item.FileSize)
+	assert.NotNil(t, item.CompletedDate)
+
+	db.First(&task, task.ID)
+	assert.Equal(t, models.ProcessingStatusCompleted, task.Status)
+	assert.NotNil(t, task.CompletedAt)
+
+	var entry models.History
+	require.NoError(t, db.Where("event_type = ?", models.HistoryEventImported).First(&entry).Error)
+	assert.Equal(t, "Harry.Potter.1.m4b", entry.ReleaseTitle)
+	assert.Equal(t, "abc123", entry.DownloadHash)
+	assert.Equal(t, source, entry.SourcePath)
+	assert.Equal(t, expected, entry.DestinationPath)
+
+	assert.Equal(t, []string{folder}, plex.dirs)
+	assert.Equal(t, []string{folder}, jellyfin.dirs)
+}
+
+func TestImportTask_Folder(t *testing.T) {
+	db := setupTestDB(t)
+	downloads := t.TempDir()
+	library := t.TempDir()
+	writeFile(t, filepath.Join(downloads, "release", "CD1", "01.mp3"), "one")
+	writeFile(t, filepath.Join(downloads, "release", "CD2", "02.mp3"), "two")
+	writeFile(t, filepath.Join(downloads, "release", "cover.jpg"), "image")
+	writeFile(t, filepath.Join(downloads, "release", "info.nfo"), "nfo")
+
+	task := createTaskFixture(t, db, filepath.Join(downloads, "release"))
+	service := NewService(db, nil, nil, nil, Config{LibraryPath: library})
+
+	result, err := service.ImportTask(task.ID)
+	require.NoError(t, err)
+
+	folder := filepath.Join(library, "J.K. Rowling", "Harry Potter - Book 1")
+	assert.Equal(t, []string{
+		filepath.Join(folder, "CD1", "01.mp3"),
+		filepath.Join(folder, "CD2", "02.mp3"),
+	}, result.Files)
+	assert.NoFileExists(t, filepath.Join(folder, "info.nfo"))
+
+	var item models.LibraryItem
+	db.First(&item, 1)
+	assert.Equal(t, folder, item.FilePath)
+	assert.Equal(t, int64(6), item.FileSize)
+}
+
+func TestImportWith_InPlace(t *testing.T) {
+	db := setupTestDB(t)
+	source := filepath.Join(t.TempDir(), "Terry Pratchett", "Mort")
+	file := filepath.Join(source, "Mort.m4b")
+	writeFile(t, file, "audio")
+
+	createTaskFixture(t, db, source)
+	var item models.LibraryItem
+	require.NoError(t, db.First(&item, 1).Error)
+	tagger := &fakeTagger{}
+	plex := &fakeNotifier{name: "Plex"}
+	// No library path is needed when files stay where they are
+	service := NewService(db, tagger, []mediaserver.Notifier{plex}, nil, Config{})
+
+	result, err := service.ImportWith(&item, source, nil, ImportModeInPlace)
+	require.NoError(t, err)
+	assert.Equal(t, source, result.Destination)
+	assert.Equal(t, []string{file}, result.Files)
+	assert.Equal(t, int64(5), result.Size)
+	assert.Empty(t, tagger.tagged, "files imported in place are not modified")
+	assert.Equal(t, []string{source}, plex.dirs)
+
+	db.First(&item, item.ID)
+	assert.Equal(t, models.LibraryItemStatusAvailable, item.Status)
+	assert.Equal(t, file, item.FilePath)
+
+	var entry models.History
+	require.NoError(t, db.Where("event_type = ?", models.HistoryEventImported).First(&entry).Error)
+	assert.Equal(t, "Imported 1 file(s) in place", entry.Message)
+	assert.Nil(t, entry.DownloadID)
+}
+
+func TestImportWith_Move(t *testing.T) {
+	db := setupTestDB(t)
+	library := t.TempDir()
+	source := filepath.Join(t.TempDir(), "book.m4b")
+	writeFile(t, source, "audio")
+
+	createTaskFixture(t, db, source)
+	var item models.LibraryItem
+	require.NoError(t, db.First(&item, 1).Error)
+	service := NewService(db, nil, nil, nil, Config{LibraryPath: library})
+
+	result, err := service.ImportWith(&item, source, nil, ImportModeMove)
+	require.NoError(t, err)
+
+	expected := filepath.Join(library, "J.K. Rowling", "Harry Potter - Book 1", "book.m4b")
+	assert.Equal(t, []string{expected}, result.Files)
+	assert.FileExists(t, expected)
+	assert.NoFileExists(t, source)
+}
+
+func TestImportWith_Upgrade(t *testing.T) {
+	db := setupTestDB(t)
+	source := filepath.Join(t.TempDir(), "book.m4b")
+	writeFile(t, source, "better audio")
+
+	createTaskFixture(t, db, source)
+	var item models.LibraryItem
+	require.NoError(t, db.First(&item, 1).Error)
+	item.FilePath = "/library/old/book.mp3"
+	bus := events.NewBus()
+	received, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+	service := NewService(db, nil, nil, bus, Config{LibraryPath: t.TempDir()})
+
+	result, err := service.ImportWith(&item, source, nil, ImportModeHardlink)
+	require.NoError(t, err)
+	assert.True(t, result.Upgrade)
+
+	var entry models.History
+	require.NoError(t, db.Where("event_type = ?", models.HistoryEventUpgraded).First(&entry).Error)
+	assert.Equal(t, source, entry.SourcePath)
+
+	var types []events.Type
+	for len(received) > 0 {
+		types = append(types, (<-received).Type)
+	}
+	assert.Equal(t, []events.Type{events.LibraryItemAvailable, events.LibraryItemUpgraded}, types)
+}
+
+func TestImportBook(t *testing.T) {
+	db := setupTestDB(t)
+	library := t.TempDir()
+	source := filepath.Join(t.TempDir(), "Mort")
+	writeFile(t, filepath.Join(source, "01.mp3"), "one")
+
+	author := models.Author{Name: "Terry Pratchett"}
+	require.NoError(t, db.Create(&author).Error)
+	book := models.Book{Title: "Mort", AuthorID: author.ID}
+	require.NoError(t, db.Create(&book).Error)
+	service := NewService(db, nil, nil, nil, Config{LibraryPath: library})
+
+	// A failed import leaves no library item behind
+	_, err := service.ImportBook(book.ID, filepath.Join(source, "missing"), ImportModeHardlink)
+	assert.Error(t, err)
+	var count int64
+	db.Model(&models.LibraryItem{}).Count(&count)
+	assert.Zero(t, count)
+
+	result, err := service.ImportBook(book.ID, source, ImportModeHardlink)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(library, "Terry Pratchett", "Mort"), result.Destination)
+
+	var item models.LibraryItem
+	require.NoError(t, db.First(&item, result.LibraryItemID).Error)
+	assert.Equal(t, models.LibraryItemStatusAvailable, item.Status)
+
+	_, err = service.ImportBook(book.ID, source, ImportModeHardlink)
+	assert.ErrorIs(t, err, ErrAlreadyAvailable)
+	_, err = service.ImportBook(999, source, ImportModeHardlink)
+	assert.ErrorIs(t, err, ErrBookNotFound)
+}
+
+func TestImportTask_MediaServerFailureDoesNotFailImport(t *testing.T) {
+	db := setupTestDB(t)
+	source := filepath.Join(t.TempDir(), "book.m4b")
+	writeFile(t, source, "audio")
+
+	task := createTaskFixture(t, db, source)
+	plex := &fakeNotifier{name: "Plex", err: errors.New("plex request failed with status 500")}
+	abs := &fakeNotifier{name: "Audiobookshelf"}
+	service := NewService(db, nil, []mediaserver.Notifier{plex, abs}, nil, Config{LibraryPath: t.TempDir()})
+
+	_, err := service.ImportTask(task.ID)
+	require.NoError(t, err)
+
+	var item models.LibraryItem
+	db.First(&item, 1)
+	assert.Equal(t, models.LibraryItemStatusAvailable, item.Status)
+
+	var entry models.History
+	require.NoError(t, db.Where("event_type = ?", models.HistoryEventMediaServerFailed).First(&entry).Error)
+	assert.Equal(t, "Plex: plex request failed with status 500", entry.Message)
+	require.NotNil(t, entry.AuthorID)
+
+	// A failing server does not stop the others from being notified
+	assert.Len(t, abs.dirs, 1)
+}
+
+func TestImportTask_TagsSingleFile(t *testing.T) {
+	db := setupTestDB(t)
+	source := filepath.Join(t.TempDir(), "book.m4b")
+	writeFile(t, source, "audio")
+
+	task := createTaskFixture(t, db, source)
+	tagger := &fakeTagger{}
+	service := NewService(db, tagger, nil, nil, Config{LibraryPath: t.TempDir()})
+
+	result, err := service.ImportTask(task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, result.Files, tagger.tagged)
+	assert.Equal(t, int64(len("audio Harry Potter: Book 1?")), result.Size)
+
+	var item models.LibraryItem
+	db.First(&item, 1)
+	assert.Equal(t, result.Size, item.FileSize)
+}
+
+func TestImportTask_TaggingFailureDoesNotFailImport(t *testing.T) {
+	db := setupTestDB(t)
+	downloads := t.TempDir()
+	writeFile(t, filepath.Join(downloads, "book.m4b"), "audio")
+	writeFile(t, filepath.Join(downloads, "book.mp3"), "audio")
+
+	tagger := &fakeTagger{err: errors.New("invalid MP4 atom")}
+	service := NewService(db, tagger, nil, nil, Config{LibraryPath: t.TempDir()})
+
+	task := createTaskFixture(t, db, filepath.Join(downloads, "book.m4b"))
+	_, err := service.ImportTask(task.ID)
+	require.NoError(t, err)
+
+	var entry models.History
+	require.NoError(t, db.Where("event_type = ?", models.HistoryEventTaggingFailed).First(&entry).Error)
+	assert.Equal(t, "invalid MP4 atom", entry.Message)
+
+	// Only M4B and M4A files are tagged
+	tagger.err = nil
+	task = createTaskFixture(t, db, filepath.Join(downloads, "book.mp3"))
+	_, err = service.ImportTask(task.ID)
+	require.NoError(t, err)
+	assert.Empty(t, tagger.tagged)
+}
+
+func TestImportTask_RecordsAudioInfo(t *testing.T) {
+	db := setupTestDB(t)
+	source := filepath.Join(t.TempDir(), "release")
+	writeFile(t, filepath.Join(source, "01.mp3"), mp3Data(2, "TALB", "Book"))
+	writeFile(t, filepath.Join(source, "02.mp3"), mp3Data(3, "TALB", "Book"))
+
+	task := createTaskFixture(t, db, source)
+	service := NewService(db, nil, nil, nil, Config{LibraryPath: t.TempDir()})
+	_, err := service.ImportTask(task.ID)
+	require.NoError(t, err)
+
+	var audiobook models.Audiobook
+	require.NoError(t, db.Where("book_id = ?", 1).First(&audiobook).Error)
+	assert.Equal(t, 5, audiobook.Duration)
+	assert.Equal(t, "mp3", audiobook.Format)
+	assert.InDelta(t, 128, audiobook.Bitrate, 1)
+
+	// An existing audiobook keeps its other fields
+	db.Model(&audiobook).Update("narrator", "Jim Dale")
+	require.NoError(t, db.Model(&models.ProcessingTask{}).Where("id = ?", task.ID).Update("status", models.ProcessingStatusPending).Error)
+	_, err = service.ImportTask(task.ID)
+	require.NoError(t, err)
+	var count int64
+	db.Model(&models.Audiobook{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	db.First(&audiobook, audiobook.ID)
+	assert.Equal(t, "Jim Dale", audiobook.Narrator)
+	assert.Equal(t, 5, audiobook.Duration)
+}
+
+func TestIdentify(t *testing.T) {
+	db := setupTestDB(t)
+	createTaskFixture(t, db, "")
+	other := models.Book{Title: "Chamber of Secrets", AuthorID: 1, ASIN: "B017V4IM2Q"}
+	require.NoError(t, db.Create(&other).Error)
+	service := NewService(db, nil, nil, nil, Config{LibraryPath: t.TempDir()})
+
+	t.Run("By title and author", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "unknown.mp3")
+		writeFile(t, path, mp3Data(1, "TALB", "harry potter: book 1?", "TPE1", "J.K. ROWLING"))
+
+		identification, err := service.Identify(path)
+		require.NoError(t, err)
+		assert.Equal(t, "harry potter: book 1?", identification.Title)
+		assert.Equal(t, "J.K. ROWLING", identification.Author)
+		assert.Equal(t, 1, identification.Files)
+		assert.Equal(t, 1, identification.Duration)
+		assert.Equal(t, 128, identification.Bitrate)
+		require.NotNil(t, identification.BookID)
+		assert.Equal(t, uint(1), *identification.BookID)
+	})
+
+	t.Run("By ASIN", func(t *testing.T) {
+		folder := filepath.Join(t.TempDir(), "Book 2")
+		writeFile(t, filepath.Join(folder, "01.mp3"), mp3Data(1, "TALB", "Something Else", "TPE1", "Someone"))
+		writeFile(t, filepath.Join(folder, "02.mp3"), mp3Data(1, "TXXX", "ASIN\x00B0AUDIOBOOK"))
+
+		identification, err := service.Identify(folder)
+		require.NoError(t, err)
+		assert.Nil(t, identification.BookID)
+
+		// The ASIN of the audiobook edition identifies it too
+		require.NoError(t, db.Create(&models.Audiobook{BookID: other.ID, ASIN: "B0AUDIOBOOK"}).Error)
+		identification, err = service.Identify(folder)
+		require.NoError(t, err)
+		assert.Equal(t, 2, identification.Files)
+		assert.Equal(t, "B0AUDIOBOOK", identification.ASIN)
+		require.NotNil(t, identification.BookID)
+		assert.Equal(t, other.ID, *identification.BookID)
+	})
+
+	t.Run("Not audio", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "notes.txt")
+		writeFile(t, path, "text")
+		_, err := service.Identify(path)
+		assert.ErrorIs(t, err, ErrNoAudioFiles)
+	})
+}
+
+func TestImportTask_NoAudioFiles(t *testing.T) {
+	db := setupTestDB(t)
+	source := filepath.Join(t.TempDir(), "release")
+	writeFile(t, filepath.Join(source, "readme.txt"), "text")
+
+	task := createTaskFixture(t, db, source)
+	service := NewService(db, nil, nil, nil, Config{LibraryPath: t.TempDir()})
+
+	_, err := service.ImportTask(task.ID)
+	assert.ErrorIs(t, err, ErrNoAudioFiles)
+
+	db.First(&task, task.ID)
+	assert.Equal(t, models.ProcessingStatusFailed, task.Status)
+	assert.Equal(t, ErrNoAudioFiles.Error(), task.Error)
+
+	var item models.LibraryItem
+	db.First(&item, 1)
+	assert.Equal(t, models.LibraryItemStatusError, item.Status)
+
+	// Only pending tasks are imported
+	_, err = service.ImportTask(task.ID)
+	assert.ErrorIs(t, err, ErrNotImportable)
+	_, err = service.ImportTask(999)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestImportTask_PostponedWithoutSpace(t *testing.T) {
+	db := setupTestDB(t)
+	source := filepath.Join(t.TempDir(), "book.m4b")
+	writeFile(t, source, "audio")
+
+	task := createTaskFixture(t, db, source)
+	library := t.TempDir()
+	service := NewService(db, nil, nil, nil, Config{LibraryPath: library, MinFreeLibrary: 1 << 62})
+
+	service.ProcessPending()
+	_, err := service.ImportTask(task.ID)
+	assert.ErrorIs(t, err, ErrInsufficientSpace)
+	assert.ErrorContains(t, err, "library folder: not enough free disk space")
+
+	var postponed models.ProcessingTask
+	require.NoError(t, db.First(&postponed, task.ID).Error)
+	assert.Equal(t, models.ProcessingStatusPending, postponed.Status, "tried again on the next run")
+	assert.Contains(t, postponed.Error, "not enough free disk space")
+	var item models.LibraryItem
+	require.NoError(t, db.First(&item, 1).Error)
+	assert.Equal(t, models.LibraryItemStatusProcessing, item.Status)
+
+	t.Run("Temp folder", func(t *testing.T) {
+		service := NewService(db, nil, nil, nil, Config{LibraryPath: library, TempPath: t.TempDir(), MinFreeTemp: 1 << 62})
+		_, err := service.ImportTask(task.ID)
+		assert.ErrorContains(t, err, "temp folder: not enough free disk space")
+	})
+
+	t.Run("Manual import", func(t *testing.T) {
+		_, err := service.Import(&item, source, nil)
+		assert.ErrorIs(t, err, ErrInsufficientSpace)
+
+		_, err = service.ImportWith(&item, source, nil, ImportModeInPlace)
+		assert.NoError(t, err, "files imported in place take no space")
+	})
+
+	t.Run("Room again", func(t *testing.T) {
+		service := NewService(db, nil, nil, nil, Config{LibraryPath: library, MinFreeLibrary: 1})
+		_, err := service.ImportTask(task.ID)
+		require.NoError(t, err)
+
+		var imported models.ProcessingTask
+		require.NoError(t, db.First(&imported, task.ID).Error)
+		assert.Equal(t, models.ProcessingStatusCompleted, imported.Status)
+		assert.Empty(t, imported.Error)
+	})
+}
+
+func TestProcessPending(t *testing.T) {
+	db := setupTestDB(t)
+	source := filepath.Join(t.TempDir(), "book.m4b")
+	writeFile(t, source, "audio")
+
+	createTaskFixture(t, db, source)
+	service := NewService(db, nil, nil, nil, Config{LibraryPath: t.TempDir()})
+	service.ProcessPending()
+
+	var count int64
+	db.Model(&models.ProcessingTask{}).Where("status = ?", models.ProcessingStatusCompleted).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestSanitizeName(t *testing.T) {
+	assert.Equal(t, "AC-DC", sanitizeName("AC/DC"))
+	assert.Equal(t, "Book 1 - The Start", sanitizeName("Book 1: The Start"))
+	assert.Equal(t, "Why", sanitizeName("Why?..."))
+	assert.Equal(t, "Unknown", sanitizeName("  "))
+}
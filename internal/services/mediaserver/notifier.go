@@ -0,0 +1,147 @@
+package mediaserver
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/listenarr/listenarr/internal/config"
+	"github.com/listenarr/listenarr/pkg/audiobookshelf"
+	"github.com/listenarr/listenarr/pkg/jellyfin"
+	"github.com/listenarr/listenarr/pkg/plex"
+)
+
+// Media server types accepted in the configuration
+const (
+	TypePlex           = "plex"
+	TypeAudiobookshelf = "audiobookshelf"
+	TypeJellyfin       = "jellyfin"
+)
+
+// Errors returned when building notifiers
+var (
+	ErrUnknownType   = errors.New("unknown media server type")
+	ErrMissingURL    = errors.New("media server URL is required")
+	ErrMissingSecret = errors.New("media server token is required")
+)
+
+// Notifier tells a media server that a folder in its library has changed so
+// newly imported audiobooks show up without waiting for a scheduled scan
+type Notifier interface {
+	Name() string
+	Notify(dir string) error
+}
+
+// PlexNotifier runs a partial scan of the imported folder
+type PlexNotifier struct {
+	name    string
+	client  *plex.Client
+	section string
+}
+
+// Name returns the name shown in history
+func (n *PlexNotifier) Name() string { return n.name }
+
+// Notify scans dir in the configured section, or the section containing it
+func (n *PlexNotifier) Notify(dir string) error {
+	return n.client.ScanFolder(n.section, dir)
+}
+
+// Client returns the underlying Plex client
+func (n *PlexNotifier) Client() *plex.Client { return n.client }
+
+// AudiobookshelfNotifier scans the library the imported folder belongs to
+type AudiobookshelfNotifier struct {
+	name    string
+	client  *audiobookshelf.Client
+	library string
+}
+
+// Name returns the name shown in history
+func (n *AudiobookshelfNotifier) Name() string { return n.name }
+
+// Notify scans the configured library, or the library containing dir
+func (n *AudiobookshelfNotifier) Notify(dir string) error {
+	return n.client.ScanFolder(n.library, dir)
+}
+
+// JellyfinNotifier refreshes all Jellyfin libraries
+type JellyfinNotifier struct {
+	name   string
+	client *jellyfin.Client
+}
+
+// Name returns the name shown in history
+func (n *JellyfinNotifier) Name() string { return n.name }
+
+// Notify starts a library refresh; Jellyfin finds the new folder itself
+func (n *JellyfinNotifier) Notify(dir string) error {
+	return n.client.RefreshLibrary()
+}
+
+// NewNotifier creates the notifier for a configured media server
+func NewNotifier(cfg config.MediaServerConfig) (Notifier, error) {
+	if cfg.URL == "" {
+		return nil, ErrMissingURL
+	}
+	if cfg.Token == "" {
+		return nil, ErrMissingSecret
+	}
+
+	name := cfg.Name
+	switch cfg.Type {
+	case TypePlex:
+		if name == "" {
+			name = "Plex"
+		}
+		return &PlexNotifier{name: name, client: plex.NewClient(cfg.URL, cfg.Token), section: cfg.Library}, nil
+	case TypeAudiobookshelf:
+		if name == "" {
+			name = "Audiobookshelf"
+		}
+		return &AudiobookshelfNotifier{name: name, client: audiobookshelf.NewClient(cfg.URL, cfg.Token), library: cfg.Library}, nil
+	case TypeJellyfin:
+		if name == "" {
+			name = "Jellyfin"
+		}
+		return &JellyfinNotifier{name: name, client: jellyfin.NewClient(cfg.URL, cfg.Token)}, nil
+	}
+
+	return nil, fmt.Errorf("%w %q", ErrUnknownType, cfg.Type)
+}
+
+// NewNotifiersFromConfig creates a notifier for every configured media
+// server. The standalone plex section is included when it has a token.
+// Invalid entries are logged and skipped.
+func NewNotifiersFromConfig(cfg *config.Config) []Notifier {
+	servers := cfg.MediaServers
+	if cfg.Plex.URL != "" && cfg.Plex.Token != "" {
+		servers = append([]config.MediaServerConfig{{
+			Type:    TypePlex,
+			URL:     cfg.Plex.URL,
+			Token:   cfg.Plex.Token,
+			Library: cfg.Plex.Section,
+		}}, servers...)
+	}
+
+	notifiers := make([]Notifier, 0, len(servers))
+	for i, server := range servers {
+		notifier, err := NewNotifier(server)
+		if err != nil {
+			log.Printf("mediaserver: skipping media server %d (%s): %v", i+1, server.Type, err)
+			continue
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	return notifiers
+}
+
+// FindPlexClient returns the client of the first Plex notifier, or nil
+func FindPlexClient(notifiers []Notifier) *plex.Client {
+	for _, notifier := range notifiers {
+		if plexNotifier, ok := notifier.(*PlexNotifier); ok {
+			return plexNotifier.Client()
+		}
+	}
+	return nil
+}
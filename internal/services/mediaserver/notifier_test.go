@@ -0,0 +1,97 @@
+package mediaserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/internal/config"
+)
+
+func TestNewNotifier(t *testing.T) {
+	t.Run("Plex", func(t *testing.T) {
+		notifier, err := NewNotifier(config.MediaServerConfig{Type: TypePlex, URL: "http://plex:32400", Token: "token", Library: "3"})
+		require.NoError(t, err)
+		assert.Equal(t, "Plex", notifier.Name())
+		assert.IsType(t, &PlexNotifier{}, notifier)
+	})
+
+	t.Run("Audiobookshelf with name", func(t *testing.T) {
+		notifier, err := NewNotifier(config.MediaServerConfig{Type: TypeAudiobookshelf, Name: "ABS", URL: "http://abs:13378", Token: "token"})
+		require.NoError(t, err)
+		assert.Equal(t, "ABS", notifier.Name())
+		assert.IsType(t, &AudiobookshelfNotifier{}, notifier)
+	})
+
+	t.Run("Jellyfin", func(t *testing.T) {
+		notifier, err := NewNotifier(config.MediaServerConfig{Type: TypeJellyfin, URL: "http://jellyfin:8096", Token: "key"})
+		require.NoError(t, err)
+		assert.Equal(t, "Jellyfin", notifier.Name())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := NewNotifier(config.MediaServerConfig{Type: "emby", URL: "http://emby", Token: "key"})
+		assert.ErrorIs(t, err, ErrUnknownType)
+		_, err = NewNotifier(config.MediaServerConfig{Type: TypePlex, Token: "token"})
+		assert.ErrorIs(t, err, ErrMissingURL)
+		_, err = NewNotifier(config.MediaServerConfig{Type: TypeJellyfin, URL: "http://jellyfin:8096"})
+		assert.ErrorIs(t, err, ErrMissingSecret)
+	})
+}
+
+func TestNewNotifiersFromConfig(t *testing.T) {
+	cfg := &config.Config{
+		Plex: config.PlexConfig{URL: "http://plex:32400", Token: "token", Section: "3"},
+		MediaServers: []config.MediaServerConfig{
+			{Type: TypeAudiobookshelf, URL: "http://abs:13378", Token: "token"},
+			{Type: "emby", URL: "http://emby", Token: "key"},
+			{Type: TypeJellyfin, URL: "http://jellyfin:8096", Token: "key"},
+		},
+	}
+
+	notifiers := NewNotifiersFromConfig(cfg)
+	require.Len(t, notifiers, 3)
+	assert.Equal(t, "Plex", notifiers[0].Name())
+	assert.Equal(t, "3", notifiers[0].(*PlexNotifier).section)
+	assert.Equal(t, "Audiobookshelf", notifiers[1].Name())
+	assert.Equal(t, "Jellyfin", notifiers[2].Name())
+	assert.NotNil(t, FindPlexClient(notifiers))
+
+	// The plex section without a token is not a media server
+	cfg.Plex.Token = ""
+	notifiers = NewNotifiersFromConfig(cfg)
+	assert.Len(t, notifiers, 2)
+	assert.Nil(t, FindPlexClient(notifiers))
+}
+
+func TestNotify(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch r.URL.Path {
+		case "/api/libraries":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"libraries": [{"id": "lib-1", "name": "Audiobooks", "mediaType": "book",
+				"folders": [{"id": "fol-1", "fullPath": "/audiobooks"}]}]}`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	dir := "/audiobooks/Author/Book"
+	for _, serverType := range []string{TypePlex, TypeAudiobookshelf, TypeJellyfin} {
+		notifier, err := NewNotifier(config.MediaServerConfig{Type: serverType, URL: server.URL, Token: "token", Library: "7"})
+		require.NoError(t, err)
+		require.NoError(t, notifier.Notify(dir), serverType)
+	}
+
+	assert.Equal(t, []string{
+		"GET /library/sections/7/refresh?path=%2Faudiobooks%2FAuthor%2FBook",
+		"POST /api/libraries/7/scan",
+		"POST /Library/Refresh",
+	}, requests)
+}
@@ -0,0 +1,242 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AudnexusProvider looks up audiobook metadata from Audnexus, keyed by Audible ASIN
+type AudnexusProvider struct {
+	baseURL    string
+	region     string
+	httpClient *http.Client
+}
+
+// NewAudnexusProvider creates a new Audnexus provider.
+// An empty baseURL uses the public Audnexus API; region defaults to "us".
+func NewAudnexusProvider(baseURL, region string) *AudnexusProvider {
+	if baseURL == "" {
+		baseURL = "https://api.audnex.us"
+	}
+	if region == "" {
+		region = "us"
+	}
+	return &AudnexusProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		region:  region,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name returns the provider identifier
+func (p *AudnexusProvider) Name() string {
+	return "audnexus"
+}
+
+// audnexusBook represents the response from /books/{asin}
+type audnexusBook struct {
+	ASIN             string           `json:"asin"`
+	Title            string           `json:"title"`
+	Subtitle         string           `json:"subtitle"`
+	Authors          []audnexusPerson `json:"authors"`
+	Narrators        []audnexusPerson `json:"narrators"`
+	Description      string           `json:"description"`
+	Summary          string           `json:"summary"`
+	Image            string           `json:"image"`
+	ISBN             string           `json:"isbn"`
+	Language         string           `json:"language"`
+	PublisherName    string           `json:"publisherName"`
+	ReleaseDate      string           `json:"releaseDate"`
+	RuntimeLengthMin int              `json:"runtimeLengthMin"`
+	Genres           []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"genres"`
+	SeriesPrimary   *audnexusSeries `json:"seriesPrimary"`
+	SeriesSecondary *audnexusSeries `json:"seriesSecondary"`
+}
+
+type audnexusPerson struct {
+	ASIN string `json:"asin"`
+	Name string `json:"name"`
+}
+
+type audnexusSeries struct {
+	ASIN     string `json:"asin"`
+	Name     string `json:"name"`
+	Position string `json:"position"`
+}
+
+// audnexusChapters represents the response from /books/{asin}/chapters
+type audnexusChapters struct {
+	ASIN     string `json:"asin"`
+	Chapters []struct {
+		Title         string `json:"title"`
+		StartOffsetMs int64  `json:"startOffsetMs"`
+		LengthMs      int64  `json:"lengthMs"`
+	} `json:"chapters"`
+}
+
+// audnexusAuthor represents the response from /authors/{asin}
+type audnexusAuthor struct {
+	ASIN        string `json:"asin"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Image       string `json:"image"`
+}
+
+// Search is not supported by Audnexus, which only resolves ASINs
+func (p *AudnexusProvider) Search(query string) ([]BookMetadata, error) {
+	return nil, ErrNotSupported
+}
+
+// LookupISBN is not supported by Audnexus
+func (p *AudnexusProvider) LookupISBN(isbn string) (*BookMetadata, error) {
+	return nil, ErrNotSupported
+}
+
+// LookupASIN returns the audiobook with the given ASIN
+func (p *AudnexusProvider) LookupASIN(asin string) (*BookMetadata, error) {
+	asin = strings.ToUpper(strings.TrimSpace(asin))
+
+	var data audnexusBook
+	if err := p.get("/books/"+url.PathEscape(asin), nil, &data); err != nil {
+		return nil, err
+	}
+
+	book := &BookMetadata{
+		Source:      p.Name(),
+		SourceID:    data.ASIN,
+		Title:       data.Title,
+		Subtitle:    data.Subtitle,
+		ASIN:        data.ASIN,
+		ISBN:        data.ISBN,
+		Description: data.Description,
+		CoverArtURL: data.Image,
+		Language:    data.Language,
+		Publisher:   data.PublisherName,
+		ReleaseDate: parseReleaseDate(data.ReleaseDate),
+		Duration:    data.RuntimeLengthMin * 60,
+	}
+	if book.Description == "" {
+		book.Description = data.Summary
+	}
+	for _, author := range data.Authors {
+		book.Authors = append(book.Authors, author.Name)
+	}
+	for _, narrator := range data.Narrators {
+		book.Narrators = append(book.Narrators, narrator.Name)
+	}
+	for _, series := range []*audnexusSeries{data.SeriesPrimary, data.SeriesSecondary} {
+		if series != nil && series.Name != "" {
+			book.Series = append(book.Series, SeriesInfo{
+				Name:     series.Name,
+				Position: series.Position,
+			})
+		}
+	}
+	for _, genre := range data.Genres {
+		if genre.Type == "genre" {
+			book.Genre = genre.Name
+			break
+		}
+	}
+
+	return book, nil
+}
+
+// LookupChapters returns the chapters of the audiobook with the given ASIN
+func (p *AudnexusProvider) LookupChapters(asin string) ([]Chapter, error) {
+	asin = strings.ToUpper(strings.TrimSpace(asin))
+
+	var data audnexusChapters
+	if err := p.get("/books/"+url.PathEscape(asin)+"/chapters", nil, &data); err != nil {
+		return nil, err
+	}
+	if len(data.Chapters) == 0 {
+		return nil, ErrNotFound
+	}
+
+	chapters := make([]Chapter, len(data.Chapters))
+	for i, chapter := range data.Chapters {
+		chapters[i] = Chapter{
+			Title:    chapter.Title,
+			StartMs:  chapter.StartOffsetMs,
+			LengthMs: chapter.LengthMs,
+		}
+	}
+	return chapters, nil
+}
+
+// LookupAuthor returns information about the named author
+func (p *AudnexusProvider) LookupAuthor(name string) (*AuthorMetadata, error) {
+	params := url.Values{}
+	params.Set("name", name)
+
+	var matches []audnexusPerson
+	if err := p.get("/authors", params, &matches); err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, ErrNotFound
+	}
+
+	var data audnexusAuthor
+	if err := p.get("/authors/"+url.PathEscape(matches[0].ASIN), nil, &data); err != nil {
+		return nil, err
+	}
+
+	return &AuthorMetadata{
+		Source:    p.Name(),
+		SourceID:  data.ASIN,
+		Name:      data.Name,
+		Biography: data.Description,
+		ImageURL:  data.Image,
+	}, nil
+}
+
+// LookupAuthorBooks is not supported by Audnexus, which has no bibliography endpoint
+func (p *AudnexusProvider) LookupAuthorBooks(name string) ([]BookMetadata, error) {
+	return nil, ErrNotSupported
+}
+
+// get performs a GET request and decodes the JSON response
+func (p *AudnexusProvider) get(path string, params url.Values, out interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("region", p.region)
+
+	req, err := http.NewRequest("GET", p.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Audnexus request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query Audnexus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("audnexus request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Audnexus response: %w", err)
+	}
+
+	return nil
+}
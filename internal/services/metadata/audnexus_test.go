@@ -0,0 +1,81 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudnexusProvider_LookupASIN(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{
+		"/books/B017V4IM1G": "audnexus_book.json",
+	})
+	provider := NewAudnexusProvider(server.URL, "")
+
+	book, err := provider.LookupASIN("b017v4im1g")
+	require.NoError(t, err)
+
+	assert.Equal(t, "audnexus", book.Source)
+	assert.Equal(t, "B017V4IM1G", book.ASIN)
+	assert.Equal(t, "Harry Potter and the Sorcerer's Stone, Book 1", book.Title)
+	assert.Equal(t, []string{"J.K. Rowling"}, book.Authors)
+	assert.Equal(t, []string{"Jim Dale"}, book.Narrators)
+	assert.Equal(t, []SeriesInfo{
+		{Name: "Harry Potter", Position: "1"},
+		{Name: "Wizarding World", Position: "1.5"},
+	}, book.Series)
+	assert.Equal(t, "9781781102367", book.ISBN)
+	assert.Equal(t, "Children's Audiobooks", book.Genre)
+	assert.Equal(t, 498*60, book.Duration)
+	assert.Equal(t, "2015-11-20", book.ReleaseDate.Format("2006-01-02"))
+	assert.Contains(t, book.Description, "purple wax seal")
+}
+
+func TestAudnexusProvider_LookupASIN_NotFound(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{})
+	provider := NewAudnexusProvider(server.URL, "")
+
+	_, err := provider.LookupASIN("B000000000")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestAudnexusProvider_LookupChapters(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{
+		"/books/B017V4IM1G/chapters": "audnexus_chapters.json",
+	})
+	provider := NewAudnexusProvider(server.URL, "")
+
+	chapters, err := provider.LookupChapters("b017v4im1g")
+	require.NoError(t, err)
+	require.Len(t, chapters, 3)
+	assert.Equal(t, Chapter{Title: "Chapter 1: The Boy Who Lived", StartMs: 15500, LengthMs: 1856500}, chapters[1])
+
+	_, err = provider.LookupChapters("B000000000")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestAudnexusProvider_LookupAuthor(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{
+		"/authors":            "audnexus_author_search.json",
+		"/authors/B000AP9A6K": "audnexus_author.json",
+	})
+	provider := NewAudnexusProvider(server.URL, "uk")
+
+	author, err := provider.LookupAuthor("J.K. Rowling")
+	require.NoError(t, err)
+	assert.Equal(t, "B000AP9A6K", author.SourceID)
+	assert.Equal(t, "J.K. Rowling", author.Name)
+	assert.Contains(t, author.Biography, "Harry Potter novels")
+	assert.NotEmpty(t, author.ImageURL)
+}
+
+func TestAudnexusProvider_Unsupported(t *testing.T) {
+	provider := NewAudnexusProvider("", "")
+
+	_, err := provider.Search("anything")
+	assert.ErrorIs(t, err, ErrNotSupported)
+
+	_, err = provider.LookupISBN("9780747532699")
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
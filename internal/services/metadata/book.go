@@ -0,0 +1,73 @@
+package metadata
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// FindBook returns the book md describes, with its Author loaded. A book
+// matches when it has the ASIN or ISBN of md, or is by the author with the
+// same title ignoring case and surrounding spaces. It fails with
+// gorm.ErrRecordNotFound when no book matches.
+func FindBook(db *gorm.DB, authorID uint, md *BookMetadata) (*models.Book, error) {
+	query := db.Where("author_id = ? AND LOWER(title) = LOWER(?)", authorID, strings.TrimSpace(md.Title))
+	if md.ASIN != "" {
+		query = query.Or("asin = ?", md.ASIN)
+	}
+	if md.ISBN != "" {
+		query = query.Or("isbn = ?", md.ISBN)
+	}
+	var book models.Book
+	if err := query.Preload("Author").Order("id").First(&book).Error; err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// FindOrCreateBook returns the book md describes as FindBook matches it,
+// creating it for author with its series, contributors and audiobook when
+// there is none yet. created is true when the book is new.
+func FindOrCreateBook(tx *gorm.DB, author *models.Author, md *BookMetadata) (book *models.Book, created bool, err error) {
+	book, err = FindBook(tx, author.ID, md)
+	if err == nil {
+		return book, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	book = &models.Book{Title: strings.TrimSpace(md.Title), AuthorID: author.ID}
+	ApplyToBook(book, md, false)
+	if err := tx.Create(book).Error; err != nil {
+		return nil, false, err
+	}
+	book.Author = *author
+
+	for _, series := range md.Series {
+		if series.Name == "" {
+			continue
+		}
+		if _, err := models.LinkBookToSeries(tx, book.ID, series.Name, series.Position); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := models.CreditContributors(tx, book, md.Authors, md.Narrators); err != nil {
+		return nil, false, err
+	}
+
+	// Narrator and runtime belong to the audiobook
+	if len(md.Narrators) > 0 || md.Duration > 0 {
+		audiobook := models.Audiobook{BookID: book.ID}
+		ApplyToAudiobook(&audiobook, md, false)
+		if err := tx.Create(&audiobook).Error; err != nil {
+			return nil, false, err
+		}
+	}
+
+	return book, true, nil
+}
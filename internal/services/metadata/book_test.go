@@ -0,0 +1,75 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+func setupBookDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
+	require.NoError(t, db.AutoMigrate(
+		&models.Author{},
+		&models.Narrator{},
+		&models.Series{},
+		&models.Book{},
+		&models.BookSeries{},
+		&models.BookContributor{},
+		&models.Audiobook{},
+	))
+	return db
+}
+
+func TestFindOrCreateBook(t *testing.T) {
+	db := setupBookDB(t)
+	author := models.Author{Name: "Andy Weir"}
+	require.NoError(t, db.Create(&author).Error)
+
+	md := BookMetadata{
+		Title:     "Project Hail Mary",
+		Authors:   []string{"Andy Weir"},
+		Narrators: []string{"Ray Porter"},
+		Series:    []SeriesInfo{{Name: "Standalone", Position: "1"}},
+		Duration:  58000,
+	}
+	book, created, err := FindOrCreateBook(db, &author, &md)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "Andy Weir", book.Author.Name)
+
+	var audiobooks, links int64
+	require.NoError(t, db.Model(&models.Audiobook{}).Where("book_id = ?", book.ID).Count(&audiobooks).Error)
+	require.NoError(t, db.Model(&models.BookSeries{}).Where("book_id = ?", book.ID).Count(&links).Error)
+	assert.Equal(t, int64(1), audiobooks)
+	assert.Equal(t, int64(1), links)
+
+	t.Run("Title matches ignoring case and spaces", func(t *testing.T) {
+		again, created, err := FindOrCreateBook(db, &author, &BookMetadata{Title: "  project hail MARY "})
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, book.ID, again.ID)
+		assert.Equal(t, "Andy Weir", again.Author.Name)
+	})
+
+	t.Run("ASIN matches another title", func(t *testing.T) {
+		require.NoError(t, db.Model(book).Update("asin", "B08G9PRS1K").Error)
+		again, created, err := FindOrCreateBook(db, &author, &BookMetadata{Title: "Hail Mary", ASIN: "B08G9PRS1K"})
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, book.ID, again.ID)
+	})
+
+	t.Run("Same title by another author is a new book", func(t *testing.T) {
+		other := models.Author{Name: "Someone Else"}
+		require.NoError(t, db.Create(&other).Error)
+		again, created, err := FindOrCreateBook(db, &other, &BookMetadata{Title: "Project Hail Mary"})
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.NotEqual(t, book.ID, again.ID)
+	})
+}
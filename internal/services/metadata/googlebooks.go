@@ -0,0 +1,162 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GoogleBooksProvider looks up metadata from the Google Books API
+type GoogleBooksProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewGoogleBooksProvider creates a new Google Books provider.
+// An empty baseURL uses the public Google Books API; apiKey is optional.
+func NewGoogleBooksProvider(baseURL, apiKey string) *GoogleBooksProvider {
+	if baseURL == "" {
+		baseURL = "https://www.googleapis.com/books/v1"
+	}
+	return &GoogleBooksProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name returns the provider identifier
+func (p *GoogleBooksProvider) Name() string {
+	return "googlebooks"
+}
+
+// googleBooksResponse represents the response from /volumes
+type googleBooksResponse struct {
+	TotalItems int                 `json:"totalItems"`
+	Items      []googleBooksVolume `json:"items"`
+}
+
+type googleBooksVolume struct {
+	ID         string `json:"id"`
+	VolumeInfo struct {
+		Title               string   `json:"title"`
+		Subtitle            string   `json:"subtitle"`
+		Authors             []string `json:"authors"`
+		Publisher           string   `json:"publisher"`
+		PublishedDate       string   `json:"publishedDate"`
+		Description         string   `json:"description"`
+		Categories          []string `json:"categories"`
+		Language            string   `json:"language"`
+		IndustryIdentifiers []struct {
+			Type       string `json:"type"`
+			Identifier string `json:"identifier"`
+		} `json:"industryIdentifiers"`
+		ImageLinks struct {
+			Thumbnail      string `json:"thumbnail"`
+			SmallThumbnail string `json:"smallThumbnail"`
+		} `json:"imageLinks"`
+	} `json:"volumeInfo"`
+}
+
+// Search finds books matching a free-text query
+func (p *GoogleBooksProvider) Search(query string) ([]BookMetadata, error) {
+	return p.searchVolumes(query)
+}
+
+// LookupISBN returns the book with the given ISBN
+func (p *GoogleBooksProvider) LookupISBN(isbn string) (*BookMetadata, error) {
+	results, err := p.searchVolumes("isbn:" + normalizeISBN(isbn))
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
+	return &results[0], nil
+}
+
+// LookupASIN is not supported by Google Books
+func (p *GoogleBooksProvider) LookupASIN(asin string) (*BookMetadata, error) {
+	return nil, ErrNotSupported
+}
+
+// LookupAuthor is not supported by Google Books
+func (p *GoogleBooksProvider) LookupAuthor(name string) (*AuthorMetadata, error) {
+	return nil, ErrNotSupported
+}
+
+// LookupAuthorBooks returns the volumes credited to the named author
+func (p *GoogleBooksProvider) LookupAuthorBooks(name string) ([]BookMetadata, error) {
+	return p.searchVolumes(fmt.Sprintf("inauthor:%q", name))
+}
+
+// searchVolumes queries /volumes and converts the results
+func (p *GoogleBooksProvider) searchVolumes(query string) ([]BookMetadata, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("maxResults", "20")
+	if p.apiKey != "" {
+		params.Set("key", p.apiKey)
+	}
+
+	req, err := http.NewRequest("GET", p.baseURL+"/volumes?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Google Books request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Google Books: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("google books request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var data googleBooksResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode Google Books response: %w", err)
+	}
+
+	results := make([]BookMetadata, 0, len(data.Items))
+	for _, item := range data.Items {
+		info := item.VolumeInfo
+		book := BookMetadata{
+			Source:      p.Name(),
+			SourceID:    item.ID,
+			Title:       info.Title,
+			Subtitle:    info.Subtitle,
+			Authors:     info.Authors,
+			Publisher:   info.Publisher,
+			Description: info.Description,
+			Language:    info.Language,
+			ReleaseDate: parseReleaseDate(info.PublishedDate),
+		}
+		for _, id := range info.IndustryIdentifiers {
+			// Prefer ISBN-13 over ISBN-10
+			if id.Type == "ISBN_13" || (id.Type == "ISBN_10" && book.ISBN == "") {
+				book.ISBN = id.Identifier
+			}
+		}
+		if len(info.Categories) > 0 {
+			book.Genre = info.Categories[0]
+		}
+		cover := info.ImageLinks.Thumbnail
+		if cover == "" {
+			cover = info.ImageLinks.SmallThumbnail
+		}
+		book.CoverArtURL = strings.Replace(cover, "http://", "https://", 1)
+		results = append(results, book)
+	}
+
+	return results, nil
+}
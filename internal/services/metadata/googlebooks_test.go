@@ -0,0 +1,87 @@
+package metadata
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoogleBooksProvider_LookupISBN(t *testing.T) {
+	var query, key string
+	fixtures := newFixtureServer(t, map[string]string{
+		"/volumes": "googlebooks_volumes.json",
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("q")
+		key = r.URL.Query().Get("key")
+		fixtures.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	provider := NewGoogleBooksProvider(server.URL, "test-key")
+
+	book, err := provider.LookupISBN("978-1-78110-048-6")
+	require.NoError(t, err)
+	assert.Equal(t, "isbn:9781781100486", query)
+	assert.Equal(t, "test-key", key)
+
+	assert.Equal(t, "googlebooks", book.Source)
+	assert.Equal(t, "wrOQLV6xB-wC", book.SourceID)
+	assert.Equal(t, "Harry Potter and the Sorcerer's Stone", book.Title)
+	assert.Equal(t, []string{"J.K. Rowling"}, book.Authors)
+	assert.Equal(t, "9781781100486", book.ISBN)
+	assert.Equal(t, "Juvenile Fiction", book.Genre)
+	assert.Equal(t, "en", book.Language)
+	assert.Equal(t, "2015-12-08", book.ReleaseDate.Format("2006-01-02"))
+	assert.Contains(t, book.CoverArtURL, "https://books.google.com/")
+}
+
+func TestGoogleBooksProvider_LookupAuthorBooks(t *testing.T) {
+	var query string
+	fixtures := newFixtureServer(t, map[string]string{
+		"/volumes": "googlebooks_volumes.json",
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("q")
+		fixtures.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	provider := NewGoogleBooksProvider(server.URL, "")
+
+	books, err := provider.LookupAuthorBooks("J.K. Rowling")
+	require.NoError(t, err)
+	assert.Equal(t, `inauthor:"J.K. Rowling"`, query)
+	assert.NotEmpty(t, books)
+}
+
+func TestGoogleBooksProvider_NoResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"kind": "books#volumes", "totalItems": 0}`))
+	}))
+	defer server.Close()
+
+	provider := NewGoogleBooksProvider(server.URL, "")
+
+	_, err := provider.LookupISBN("0000000000")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	results, err := provider.Search("nothing")
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestGoogleBooksProvider_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	provider := NewGoogleBooksProvider(server.URL, "")
+
+	_, err := provider.Search("anything")
+	assert.Error(t, err)
+}
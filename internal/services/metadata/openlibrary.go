@@ -0,0 +1,293 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OpenLibraryProvider looks up metadata from openlibrary.org
+type OpenLibraryProvider struct {
+	baseURL    string
+	coversURL  string
+	httpClient *http.Client
+}
+
+// NewOpenLibraryProvider creates a new Open Library provider.
+// An empty baseURL uses the public Open Library API.
+func NewOpenLibraryProvider(baseURL string) *OpenLibraryProvider {
+	if baseURL == "" {
+		baseURL = "https://openlibrary.org"
+	}
+	return &OpenLibraryProvider{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		coversURL: "https://covers.openlibrary.org",
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name returns the provider identifier
+func (p *OpenLibraryProvider) Name() string {
+	return "openlibrary"
+}
+
+// openLibrarySearchResponse represents the response from /search.json
+type openLibrarySearchResponse struct {
+	NumFound int                    `json:"numFound"`
+	Docs     []openLibrarySearchDoc `json:"docs"`
+}
+
+type openLibrarySearchDoc struct {
+	Key              string   `json:"key"`
+	Title            string   `json:"title"`
+	Subtitle         string   `json:"subtitle"`
+	AuthorName       []string `json:"author_name"`
+	FirstPublishYear int      `json:"first_publish_year"`
+	ISBN             []string `json:"isbn"`
+	CoverID          int      `json:"cover_i"`
+	Language         []string `json:"language"`
+	Publisher        []string `json:"publisher"`
+	Subject          []string `json:"subject"`
+}
+
+// openLibraryBook represents an entry from /api/books with jscmd=data
+type openLibraryBook struct {
+	Key         string `json:"key"`
+	Title       string `json:"title"`
+	Subtitle    string `json:"subtitle"`
+	PublishDate string `json:"publish_date"`
+	Authors     []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	Publishers []struct {
+		Name string `json:"name"`
+	} `json:"publishers"`
+	Subjects []struct {
+		Name string `json:"name"`
+	} `json:"subjects"`
+	Cover struct {
+		Large  string `json:"large"`
+		Medium string `json:"medium"`
+	} `json:"cover"`
+	Identifiers struct {
+		ISBN10 []string `json:"isbn_10"`
+		ISBN13 []string `json:"isbn_13"`
+	} `json:"identifiers"`
+	Excerpts []struct {
+		Text string `json:"text"`
+	} `json:"excerpts"`
+}
+
+// openLibraryAuthorSearchResponse represents the response from /search/authors.json
+type openLibraryAuthorSearchResponse struct {
+	Docs []struct {
+		Key  string `json:"key"`
+		Name string `json:"name"`
+	} `json:"docs"`
+}
+
+// openLibraryAuthor represents the response from /authors/{key}.json.
+// Bio is either a plain string or a {"type": ..., "value": ...} object.
+type openLibraryAuthor struct {
+	Key    string          `json:"key"`
+	Name   string          `json:"name"`
+	Bio    json.RawMessage `json:"bio"`
+	Photos []int           `json:"photos"`
+}
+
+// Search finds books matching a free-text query
+func (p *OpenLibraryProvider) Search(query string) ([]BookMetadata, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", "20")
+
+	return p.searchDocs(params)
+}
+
+// LookupAuthorBooks returns the works credited to the named author
+func (p *OpenLibraryProvider) LookupAuthorBooks(name string) ([]BookMetadata, error) {
+	params := url.Values{}
+	params.Set("author", name)
+	params.Set("sort", "new")
+	params.Set("limit", "100")
+
+	return p.searchDocs(params)
+}
+
+// searchDocs queries /search.json and converts the matching works
+func (p *OpenLibraryProvider) searchDocs(params url.Values) ([]BookMetadata, error) {
+	var resp openLibrarySearchResponse
+	if err := p.get("/search.json?"+params.Encode(), &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]BookMetadata, 0, len(resp.Docs))
+	for _, doc := range resp.Docs {
+		book := BookMetadata{
+			Source:   p.Name(),
+			SourceID: doc.Key,
+			Title:    doc.Title,
+			Subtitle: doc.Subtitle,
+			Authors:  doc.AuthorName,
+		}
+		if len(doc.ISBN) > 0 {
+			book.ISBN = doc.ISBN[0]
+		}
+		if doc.CoverID > 0 {
+			book.CoverArtURL = fmt.Sprintf("%s/b/id/%d-L.jpg", p.coversURL, doc.CoverID)
+		}
+		if doc.FirstPublishYear > 0 {
+			book.ReleaseDate = parseReleaseDate(fmt.Sprintf("%d", doc.FirstPublishYear))
+		}
+		if len(doc.Language) > 0 {
+			book.Language = doc.Language[0]
+		}
+		if len(doc.Publisher) > 0 {
+			book.Publisher = doc.Publisher[0]
+		}
+		if len(doc.Subject) > 0 {
+			book.Genre = doc.Subject[0]
+		}
+		results = append(results, book)
+	}
+
+	return results, nil
+}
+
+// LookupISBN returns the book with the given ISBN
+func (p *OpenLibraryProvider) LookupISBN(isbn string) (*BookMetadata, error) {
+	isbn = normalizeISBN(isbn)
+	bibkey := "ISBN:" + isbn
+
+	params := url.Values{}
+	params.Set("bibkeys", bibkey)
+	params.Set("format", "json")
+	params.Set("jscmd", "data")
+
+	var resp map[string]openLibraryBook
+	if err := p.get("/api/books?"+params.Encode(), &resp); err != nil {
+		return nil, err
+	}
+
+	data, ok := resp[bibkey]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	book := &BookMetadata{
+		Source:      p.Name(),
+		SourceID:    data.Key,
+		Title:       data.Title,
+		Subtitle:    data.Subtitle,
+		ISBN:        isbn,
+		ReleaseDate: parseReleaseDate(data.PublishDate),
+		CoverArtURL: data.Cover.Large,
+	}
+	if book.CoverArtURL == "" {
+		book.CoverArtURL = data.Cover.Medium
+	}
+	for _, author := range data.Authors {
+		book.Authors = append(book.Authors, author.Name)
+	}
+	if len(data.Publishers) > 0 {
+		book.Publisher = data.Publishers[0].Name
+	}
+	if len(data.Subjects) > 0 {
+		book.Genre = data.Subjects[0].Name
+	}
+	if len(data.Excerpts) > 0 {
+		book.Description = data.Excerpts[0].Text
+	}
+
+	return book, nil
+}
+
+// LookupASIN is not supported by Open Library
+func (p *OpenLibraryProvider) LookupASIN(asin string) (*BookMetadata, error) {
+	return nil, ErrNotSupported
+}
+
+// LookupAuthor returns information about the named author
+func (p *OpenLibraryProvider) LookupAuthor(name string) (*AuthorMetadata, error) {
+	params := url.Values{}
+	params.Set("q", name)
+
+	var search openLibraryAuthorSearchResponse
+	if err := p.get("/search/authors.json?"+params.Encode(), &search); err != nil {
+		return nil, err
+	}
+	if len(search.Docs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	key := search.Docs[0].Key
+	var data openLibraryAuthor
+	if err := p.get("/authors/"+url.PathEscape(key)+".json", &data); err != nil {
+		return nil, err
+	}
+
+	author := &AuthorMetadata{
+		Source:    p.Name(),
+		SourceID:  key,
+		Name:      data.Name,
+		Biography: parseOpenLibraryText(data.Bio),
+	}
+	if len(data.Photos) > 0 && data.Photos[0] > 0 {
+		author.ImageURL = fmt.Sprintf("%s/a/id/%d-L.jpg", p.coversURL, data.Photos[0])
+	}
+
+	return author, nil
+}
+
+// parseOpenLibraryText decodes a text field that may be a string or a typed value object
+func parseOpenLibraryText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var typed struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &typed); err == nil {
+		return typed.Value
+	}
+	return ""
+}
+
+// get performs a GET request and decodes the JSON response
+func (p *OpenLibraryProvider) get(path string, out interface{}) error {
+	req, err := http.NewRequest("GET", p.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Open Library request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query Open Library: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("open library request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Open Library response: %w", err)
+	}
+
+	return nil
+}
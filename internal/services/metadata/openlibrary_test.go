@@ -0,0 +1,95 @@
+package metadata
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenLibraryProvider_Search(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{
+		"/search.json": "openlibrary_search.json",
+	})
+	provider := NewOpenLibraryProvider(server.URL)
+
+	results, err := provider.Search("harry potter")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	book := results[0]
+	assert.Equal(t, "openlibrary", book.Source)
+	assert.Equal(t, "/works/OL82563W", book.SourceID)
+	assert.Equal(t, "Harry Potter and the Philosopher's Stone", book.Title)
+	assert.Equal(t, []string{"J. K. Rowling"}, book.Authors)
+	assert.Equal(t, "9780747532699", book.ISBN)
+	assert.Equal(t, "https://covers.openlibrary.org/b/id/10521270-L.jpg", book.CoverArtURL)
+	assert.Equal(t, 1997, book.ReleaseDate.Year())
+	assert.Equal(t, "Fantasy fiction", book.Genre)
+}
+
+func TestOpenLibraryProvider_LookupISBN(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{
+		"/api/books": "openlibrary_isbn.json",
+	})
+	provider := NewOpenLibraryProvider(server.URL)
+
+	book, err := provider.LookupISBN("978-0-7475-3269-9")
+	require.NoError(t, err)
+	assert.Equal(t, "Harry Potter and the Philosopher's Stone", book.Title)
+	assert.Equal(t, "9780747532699", book.ISBN)
+	assert.Equal(t, []string{"J. K. Rowling"}, book.Authors)
+	assert.Equal(t, "Bloomsbury", book.Publisher)
+	assert.Equal(t, "1997-06-26", book.ReleaseDate.Format("2006-01-02"))
+	assert.Equal(t, "https://covers.openlibrary.org/b/id/10521270-L.jpg", book.CoverArtURL)
+	assert.Contains(t, book.Description, "Privet Drive")
+
+	// The fixture only knows one ISBN
+	_, err = provider.LookupISBN("0000000000")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestOpenLibraryProvider_LookupAuthor(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{
+		"/search/authors.json":   "openlibrary_author_search.json",
+		"/authors/OL23919A.json": "openlibrary_author.json",
+	})
+	provider := NewOpenLibraryProvider(server.URL)
+
+	author, err := provider.LookupAuthor("J. K. Rowling")
+	require.NoError(t, err)
+	assert.Equal(t, "OL23919A", author.SourceID)
+	assert.Equal(t, "J. K. Rowling", author.Name)
+	assert.Equal(t, "Joanne Rowling is a British author and philanthropist.", author.Biography)
+	assert.Equal(t, "https://covers.openlibrary.org/a/id/5543033-L.jpg", author.ImageURL)
+}
+
+func TestOpenLibraryProvider_LookupAuthorBooks(t *testing.T) {
+	var author string
+	fixtures := newFixtureServer(t, map[string]string{
+		"/search.json": "openlibrary_search.json",
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		author = r.URL.Query().Get("author")
+		fixtures.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	provider := NewOpenLibraryProvider(server.URL)
+
+	books, err := provider.LookupAuthorBooks("J. K. Rowling")
+	require.NoError(t, err)
+	assert.Equal(t, "J. K. Rowling", author)
+	require.Len(t, books, 2)
+	assert.Equal(t, "Harry Potter and the Chamber of Secrets", books[1].Title)
+	assert.Equal(t, 1998, books[1].ReleaseDate.Year())
+}
+
+func TestOpenLibraryProvider_LookupASIN(t *testing.T) {
+	provider := NewOpenLibraryProvider("")
+
+	_, err := provider.LookupASIN("B017V4IM1G")
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
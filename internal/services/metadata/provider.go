@@ -0,0 +1,113 @@
+package metadata
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Errors returned by metadata providers
+var (
+	ErrNotFound     = errors.New("metadata not found")
+	ErrNotSupported = errors.New("lookup not supported by provider")
+)
+
+// MetadataProvider looks up book and author information from an external source.
+// Providers return ErrNotSupported for lookups they cannot perform and
+// ErrNotFound when the source has no matching record.
+type MetadataProvider interface {
+	// Name returns the provider identifier used in configuration
+	Name() string
+	// Search finds books matching a free-text query
+	Search(query string) ([]BookMetadata, error)
+	// LookupISBN returns the book with the given ISBN-10 or ISBN-13
+	LookupISBN(isbn string) (*BookMetadata, error)
+	// LookupASIN returns the book with the given Audible ASIN
+	LookupASIN(asin string) (*BookMetadata, error)
+	// LookupAuthor returns information about the named author
+	LookupAuthor(name string) (*AuthorMetadata, error)
+	// LookupAuthorBooks returns the books written by the named author
+	LookupAuthorBooks(name string) ([]BookMetadata, error)
+}
+
+// ChapterProvider is implemented by providers that know the chapters of an
+// audiobook edition
+type ChapterProvider interface {
+	// LookupChapters returns the chapters of the audiobook with the given ASIN
+	LookupChapters(asin string) ([]Chapter, error)
+}
+
+// Chapter is a chapter of an audiobook edition
+type Chapter struct {
+	Title    string `json:"title"`
+	StartMs  int64  `json:"start_ms"`
+	LengthMs int64  `json:"length_ms"`
+}
+
+// SeriesInfo describes a book's membership in a series
+type SeriesInfo struct {
+	Name     string `json:"name"`
+	Position string `json:"position,omitempty"` // Kept as text, positions like "2.5" are common
+}
+
+// BookMetadata is book information returned by a provider
+type BookMetadata struct {
+	Source      string       `json:"source"`
+	SourceID    string       `json:"source_id,omitempty"`
+	Title       string       `json:"title"`
+	Subtitle    string       `json:"subtitle,omitempty"`
+	Authors     []string     `json:"authors,omitempty"`
+	Narrators   []string     `json:"narrators,omitempty"`
+	Series      []SeriesInfo `json:"series,omitempty"`
+	ISBN        string       `json:"isbn,omitempty"`
+	ASIN        string       `json:"asin,omitempty"`
+	Description string       `json:"description,omitempty"`
+	CoverArtURL string       `json:"cover_art_url,omitempty"`
+	ReleaseDate *time.Time   `json:"release_date,omitempty"`
+	Genre       string       `json:"genre,omitempty"`
+	Language    string       `json:"language,omitempty"`
+	Publisher   string       `json:"publisher,omitempty"`
+	Duration    int          `json:"duration,omitempty"` // Duration in seconds
+}
+
+// AuthorMetadata is author information returned by a provider
+type AuthorMetadata struct {
+	Source    string `json:"source"`
+	SourceID  string `json:"source_id,omitempty"`
+	Name      string `json:"name"`
+	Biography string `json:"biography,omitempty"`
+	ImageURL  string `json:"image_url,omitempty"`
+}
+
+// releaseDateLayouts are the date formats used by the supported providers
+var releaseDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05.000Z",
+	"2006-01-02",
+	"2006-01",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"January 2006",
+	"2006",
+}
+
+// parseReleaseDate parses a provider date string, returning nil if it is not recognised
+func parseReleaseDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	for _, layout := range releaseDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// normalizeISBN strips separators from an ISBN
+func normalizeISBN(isbn string) string {
+	isbn = strings.ReplaceAll(isbn, "-", "")
+	isbn = strings.ReplaceAll(isbn, " ", "")
+	return strings.ToUpper(isbn)
+}
@@ -0,0 +1,65 @@
+package metadata
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFixtureServer serves recorded provider responses from testdata,
+// keyed by request path. Unknown paths return 404.
+func newFixtureServer(t *testing.T, fixtures map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := fixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Errorf("failed to read fixture %s: %v", name, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestParseReleaseDate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2015-11-20T00:00:00.000Z", "2015-11-20"},
+		{"2015-12-08", "2015-12-08"},
+		{"June 26, 1997", "1997-06-26"},
+		{"1997", "1997-01-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := parseReleaseDate(tt.input)
+			if assert.NotNil(t, result) {
+				assert.Equal(t, tt.expected, result.Format("2006-01-02"))
+			}
+		})
+	}
+
+	assert.Nil(t, parseReleaseDate(""))
+	assert.Nil(t, parseReleaseDate("sometime"))
+}
+
+func TestNormalizeISBN(t *testing.T) {
+	assert.Equal(t, "9780747532699", normalizeISBN("978-0-7475-3269-9"))
+	assert.Equal(t, "080442957X", normalizeISBN("0 8044 2957 x"))
+}
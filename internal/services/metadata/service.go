@@ -0,0 +1,368 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/listenarr/listenarr/internal/config"
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// Service combines several metadata providers, trying them in order
+type Service struct {
+	providers []MetadataProvider
+}
+
+// NewService creates a new metadata service using the given providers in order
+func NewService(providers ...MetadataProvider) *Service {
+	return &Service{providers: providers}
+}
+
+// NewServiceFromConfig creates a metadata service with the providers named in
+// the configuration. Unknown provider names are logged and skipped.
+func NewServiceFromConfig(cfg config.MetadataConfig) *Service {
+	providers := make([]MetadataProvider, 0, len(cfg.Providers))
+	for _, name := range cfg.Providers {
+		switch strings.ToLower(name) {
+		case "audnexus":
+			providers = append(providers, NewAudnexusProvider("", cfg.AudnexusRegion))
+		case "googlebooks":
+			providers = append(providers, NewGoogleBooksProvider("", cfg.GoogleBooksAPIKey))
+		case "openlibrary":
+			providers = append(providers, NewOpenLibraryProvider(""))
+		default:
+			log.Printf("metadata: ignoring unknown provider %q", name)
+		}
+	}
+	return NewService(providers...)
+}
+
+// Providers returns the names of the configured providers in lookup order
+func (s *Service) Providers() []string {
+	names := make([]string, len(s.providers))
+	for i, provider := range s.providers {
+		names[i] = provider.Name()
+	}
+	return names
+}
+
+// Search queries every provider that supports free-text search and
+// concatenates the results in provider order. An error is only returned
+// when every provider that was tried failed.
+func (s *Service) Search(query string) ([]BookMetadata, error) {
+	results := make([]BookMetadata, 0)
+	var lastErr error
+	succeeded := false
+
+	for _, provider := range s.providers {
+		found, err := provider.Search(query)
+		if errors.Is(err, ErrNotSupported) {
+			continue
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", provider.Name(), err)
+			continue
+		}
+		succeeded = true
+		results = append(results, found...)
+	}
+
+	if !succeeded && lastErr != nil {
+		return nil, lastErr
+	}
+	return results, nil
+}
+
+// LookupISBN returns the book with the given ISBN, filling gaps in the first
+// provider's answer from the others
+func (s *Service) LookupISBN(isbn string) (*BookMetadata, error) {
+	return s.lookupBook(func(p MetadataProvider) (*BookMetadata, error) {
+		return p.LookupISBN(isbn)
+	})
+}
+
+// LookupASIN returns the audiobook with the given ASIN, filling gaps in the
+// first provider's answer from the others
+func (s *Service) LookupASIN(asin string) (*BookMetadata, error) {
+	return s.lookupBook(func(p MetadataProvider) (*BookMetadata, error) {
+		return p.LookupASIN(asin)
+	})
+}
+
+// LookupAuthor returns the named author, filling gaps in the first
+// provider's answer from the others
+func (s *Service) LookupAuthor(name string) (*AuthorMetadata, error) {
+	var result *AuthorMetadata
+	var lastErr error
+
+	for _, provider := range s.providers {
+		found, err := provider.LookupAuthor(name)
+		if errors.Is(err, ErrNotSupported) || errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", provider.Name(), err)
+			continue
+		}
+		if result == nil {
+			result = found
+			continue
+		}
+		if result.Biography == "" {
+			result.Biography = found.Biography
+		}
+		if result.ImageURL == "" {
+			result.ImageURL = found.ImageURL
+		}
+	}
+
+	if result == nil {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, ErrNotFound
+	}
+	return result, nil
+}
+
+// LookupAuthorBooks returns the bibliography of the named author from every
+// provider that supports it. Books credited to other authors are dropped and
+// duplicates across providers are merged by title. An error is only returned
+// when every provider that was tried failed.
+func (s *Service) LookupAuthorBooks(name string) ([]BookMetadata, error) {
+	results := make([]BookMetadata, 0)
+	byTitle := make(map[string]int)
+	var lastErr error
+	succeeded := false
+
+	for _, provider := range s.providers {
+		found, err := provider.LookupAuthorBooks(name)
+		if errors.Is(err, ErrNotSupported) || errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", provider.Name(), err)
+			continue
+		}
+		succeeded = true
+
+		for i := range found {
+			if found[i].Title == "" || !HasAuthor(&found[i], name) {
+				continue
+			}
+			key := strings.ToLower(strings.TrimSpace(found[i].Title))
+			if idx, ok := byTitle[key]; ok {
+				mergeBook(&results[idx], &found[i])
+				continue
+			}
+			byTitle[key] = len(results)
+			results = append(results, found[i])
+		}
+	}
+
+	if !succeeded && lastErr != nil {
+		return nil, lastErr
+	}
+	return results, nil
+}
+
+// HasAuthor reports whether the book credits the named author, ignoring
+// case, spacing and punctuation differences such as "J.K." and "J. K."
+func HasAuthor(book *BookMetadata, name string) bool {
+	want := normalizeName(name)
+	for _, author := range book.Authors {
+		if normalizeName(author) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeName lowercases a person's name and strips everything but letters and digits
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// LookupBook finds metadata for an existing book by ASIN, then ISBN, then
+// an exact title match from a title and author search
+func (s *Service) LookupBook(book *models.Book) (*BookMetadata, error) {
+	if book.ASIN != "" {
+		if found, err := s.LookupASIN(book.ASIN); err == nil {
+			return found, nil
+		} else if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	if book.ISBN != "" {
+		if found, err := s.LookupISBN(book.ISBN); err == nil {
+			return found, nil
+		} else if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	query := book.Title
+	if book.Author.Name != "" {
+		query += " " + book.Author.Name
+	}
+	results, err := s.Search(query)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		if strings.EqualFold(strings.TrimSpace(results[i].Title), strings.TrimSpace(book.Title)) {
+			return &results[i], nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// LookupChapters returns the chapters of the audiobook with the given ASIN
+// from the first provider that knows them
+func (s *Service) LookupChapters(asin string) ([]Chapter, error) {
+	var lastErr error
+	for _, provider := range s.providers {
+		chapterProvider, ok := provider.(ChapterProvider)
+		if !ok {
+			continue
+		}
+		chapters, err := chapterProvider.LookupChapters(asin)
+		if errors.Is(err, ErrNotSupported) || errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", provider.Name(), err)
+			continue
+		}
+		return chapters, nil
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, ErrNotFound
+}
+
+// lookupBook runs a lookup against each provider and merges the answers
+func (s *Service) lookupBook(lookup func(MetadataProvider) (*BookMetadata, error)) (*BookMetadata, error) {
+	var result *BookMetadata
+	var lastErr error
+
+	for _, provider := range s.providers {
+		found, err := lookup(provider)
+		if errors.Is(err, ErrNotSupported) || errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", provider.Name(), err)
+			continue
+		}
+		if result == nil {
+			result = found
+			continue
+		}
+		mergeBook(result, found)
+	}
+
+	if result == nil {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, ErrNotFound
+	}
+	return result, nil
+}
+
+// mergeBook fills empty fields of dst from src
+func mergeBook(dst, src *BookMetadata) {
+	if dst.Subtitle == "" {
+		dst.Subtitle = src.Subtitle
+	}
+	if len(dst.Authors) == 0 {
+		dst.Authors = src.Authors
+	}
+	if len(dst.Narrators) == 0 {
+		dst.Narrators = src.Narrators
+	}
+	if len(dst.Series) == 0 {
+		dst.Series = src.Series
+	}
+	if dst.ISBN == "" {
+		dst.ISBN = src.ISBN
+	}
+	if dst.ASIN == "" {
+		dst.ASIN = src.ASIN
+	}
+	if dst.Description == "" {
+		dst.Description = src.Description
+	}
+	if dst.CoverArtURL == "" {
+		dst.CoverArtURL = src.CoverArtURL
+	}
+	if dst.ReleaseDate == nil {
+		dst.ReleaseDate = src.ReleaseDate
+	}
+	if dst.Genre == "" {
+		dst.Genre = src.Genre
+	}
+	if dst.Language == "" {
+		dst.Language = src.Language
+	}
+	if dst.Publisher == "" {
+		dst.Publisher = src.Publisher
+	}
+	if dst.Duration == 0 {
+		dst.Duration = src.Duration
+	}
+}
+
+// ApplyToBook copies metadata onto a book. Existing values are kept unless
+// overwrite is set. Title and author are never changed.
+func ApplyToBook(book *models.Book, md *BookMetadata, overwrite bool) {
+	setString(&book.ISBN, md.ISBN, overwrite)
+	setString(&book.ASIN, md.ASIN, overwrite)
+	setString(&book.Description, md.Description, overwrite)
+	setString(&book.CoverArtURL, md.CoverArtURL, overwrite)
+	setString(&book.Genre, md.Genre, overwrite)
+	setString(&book.Language, md.Language, overwrite)
+	if md.ReleaseDate != nil && (overwrite || book.ReleaseDate == nil) {
+		book.ReleaseDate = md.ReleaseDate
+	}
+}
+
+// ApplyToAudiobook copies audiobook-specific metadata onto an audiobook.
+// Existing values are kept unless overwrite is set.
+func ApplyToAudiobook(audiobook *models.Audiobook, md *BookMetadata, overwrite bool) {
+	setString(&audiobook.Narrator, strings.Join(md.Narrators, ", "), overwrite)
+	setString(&audiobook.Publisher, md.Publisher, overwrite)
+	setString(&audiobook.Language, md.Language, overwrite)
+	setString(&audiobook.ASIN, md.ASIN, overwrite)
+	if md.Duration > 0 && (overwrite || audiobook.Duration == 0) {
+		audiobook.Duration = md.Duration
+	}
+}
+
+// ApplyToAuthor copies metadata onto an author. Existing values are kept
+// unless overwrite is set. The name is never changed.
+func ApplyToAuthor(author *models.Author, md *AuthorMetadata, overwrite bool) {
+	setString(&author.Biography, md.Biography, overwrite)
+	setString(&author.ImageURL, md.ImageURL, overwrite)
+}
+
+// setString assigns value to dst when value is set and dst is empty or overwrite is true
+func setString(dst *string, value string, overwrite bool) {
+	if value != "" && (overwrite || *dst == "") {
+		*dst = value
+	}
+}
@@ -0,0 +1,276 @@
+package metadata
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/internal/config"
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// fakeProvider returns canned answers for service tests
+type fakeProvider struct {
+	name    string
+	search  []BookMetadata
+	books   map[string]*BookMetadata // keyed by ISBN or ASIN
+	authors map[string]*AuthorMetadata
+	works   map[string][]BookMetadata // keyed by author name
+	err     error
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) Search(query string) ([]BookMetadata, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.search == nil {
+		return nil, ErrNotSupported
+	}
+	return f.search, nil
+}
+
+func (f *fakeProvider) LookupISBN(isbn string) (*BookMetadata, error) {
+	return f.lookup(isbn)
+}
+
+func (f *fakeProvider) LookupASIN(asin string) (*BookMetadata, error) {
+	return f.lookup(asin)
+}
+
+func (f *fakeProvider) LookupAuthor(name string) (*AuthorMetadata, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if author, ok := f.authors[name]; ok {
+		return author, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (f *fakeProvider) LookupAuthorBooks(name string) ([]BookMetadata, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.works == nil {
+		return nil, ErrNotSupported
+	}
+	return f.works[name], nil
+}
+
+func (f *fakeProvider) lookup(id string) (*BookMetadata, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if book, ok := f.books[id]; ok {
+		copied := *book
+		return &copied, nil
+	}
+	return nil, ErrNotFound
+}
+
+func TestNewServiceFromConfig(t *testing.T) {
+	service := NewServiceFromConfig(config.MetadataConfig{
+		Providers: []string{"audnexus", "unknown", "OpenLibrary", "googlebooks"},
+	})
+
+	assert.Equal(t, []string{"audnexus", "openlibrary", "googlebooks"}, service.Providers())
+}
+
+func TestService_LookupISBN_MergesProviders(t *testing.T) {
+	first := &fakeProvider{name: "first", books: map[string]*BookMetadata{
+		"123": {Source: "first", Title: "Book", ISBN: "123"},
+	}}
+	second := &fakeProvider{name: "second", books: map[string]*BookMetadata{
+		"123": {Source: "second", Title: "Other Title", Description: "From second", Genre: "Fantasy"},
+	}}
+	service := NewService(first, second)
+
+	book, err := service.LookupISBN("123")
+	require.NoError(t, err)
+	assert.Equal(t, "first", book.Source)
+	assert.Equal(t, "Book", book.Title)
+	assert.Equal(t, "From second", book.Description)
+	assert.Equal(t, "Fantasy", book.Genre)
+}
+
+func TestService_LookupASIN_NotFound(t *testing.T) {
+	service := NewService(&fakeProvider{name: "empty"})
+
+	_, err := service.LookupASIN("B000000000")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_LookupASIN_ProviderError(t *testing.T) {
+	service := NewService(&fakeProvider{name: "broken", err: errors.New("boom")})
+
+	_, err := service.LookupASIN("B000000000")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.Contains(t, err.Error(), "broken")
+}
+
+func TestService_LookupChapters(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{
+		"/books/B017V4IM1G/chapters": "audnexus_chapters.json",
+	})
+
+	// Providers without chapters are skipped
+	service := NewService(&fakeProvider{name: "first"}, NewAudnexusProvider(server.URL, ""))
+	chapters, err := service.LookupChapters("B017V4IM1G")
+	require.NoError(t, err)
+	assert.Len(t, chapters, 3)
+
+	_, err = service.LookupChapters("B000000000")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = NewService(&fakeProvider{name: "first"}).LookupChapters("B017V4IM1G")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_Search(t *testing.T) {
+	service := NewService(
+		&fakeProvider{name: "unsupported"},
+		&fakeProvider{name: "broken", err: errors.New("boom")},
+		&fakeProvider{name: "working", search: []BookMetadata{{Title: "Found"}}},
+	)
+
+	results, err := service.Search("query")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Found", results[0].Title)
+
+	// Only failing providers is an error
+	service = NewService(&fakeProvider{name: "broken", err: errors.New("boom")})
+	_, err = service.Search("query")
+	assert.Error(t, err)
+}
+
+func TestService_LookupBook(t *testing.T) {
+	provider := &fakeProvider{
+		name: "fake",
+		books: map[string]*BookMetadata{
+			"B017V4IM1G": {Title: "By ASIN"},
+		},
+		search: []BookMetadata{
+			{Title: "Something Else"},
+			{Title: "the hobbit"},
+		},
+	}
+	service := NewService(provider)
+
+	book, err := service.LookupBook(&models.Book{Title: "Ignored", ASIN: "B017V4IM1G"})
+	require.NoError(t, err)
+	assert.Equal(t, "By ASIN", book.Title)
+
+	book, err = service.LookupBook(&models.Book{Title: "The Hobbit"})
+	require.NoError(t, err)
+	assert.Equal(t, "the hobbit", book.Title)
+
+	_, err = service.LookupBook(&models.Book{Title: "Unknown"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_LookupAuthor(t *testing.T) {
+	service := NewService(
+		&fakeProvider{name: "first", authors: map[string]*AuthorMetadata{
+			"Author": {Name: "Author", Biography: "Bio"},
+		}},
+		&fakeProvider{name: "second", authors: map[string]*AuthorMetadata{
+			"Author": {Name: "Author", Biography: "Other bio", ImageURL: "https://example.com/a.jpg"},
+		}},
+	)
+
+	author, err := service.LookupAuthor("Author")
+	require.NoError(t, err)
+	assert.Equal(t, "Bio", author.Biography)
+	assert.Equal(t, "https://example.com/a.jpg", author.ImageURL)
+}
+
+func TestService_LookupAuthorBooks(t *testing.T) {
+	first := &fakeProvider{name: "first", works: map[string][]BookMetadata{
+		"J.K. Rowling": {
+			{Source: "first", Title: "Harry Potter and the Chamber of Secrets", Authors: []string{"J. K. Rowling"}},
+			{Source: "first", Title: "Fantastic Beasts Screenplay", Authors: []string{"Someone Else"}},
+		},
+	}}
+	second := &fakeProvider{name: "second", works: map[string][]BookMetadata{
+		"J.K. Rowling": {
+			{Source: "second", Title: "harry potter and the chamber of secrets", Authors: []string{"J.K. Rowling"}, ISBN: "9781781100509"},
+			{Source: "second", Title: "The Casual Vacancy", Authors: []string{"J.K. Rowling"}},
+		},
+	}}
+	unsupported := &fakeProvider{name: "unsupported"}
+	service := NewService(first, unsupported, second)
+
+	books, err := service.LookupAuthorBooks("J.K. Rowling")
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+
+	// Duplicates are merged into the first provider's answer
+	assert.Equal(t, "first", books[0].Source)
+	assert.Equal(t, "9781781100509", books[0].ISBN)
+	assert.Equal(t, "The Casual Vacancy", books[1].Title)
+
+	// Only fails when every provider failed
+	service = NewService(&fakeProvider{name: "broken", err: errors.New("boom")})
+	_, err = service.LookupAuthorBooks("J.K. Rowling")
+	assert.Error(t, err)
+}
+
+func TestApplyToBook(t *testing.T) {
+	released := time.Date(2015, 11, 20, 0, 0, 0, 0, time.UTC)
+	md := &BookMetadata{
+		Title:       "New Title",
+		ISBN:        "9781781102367",
+		ASIN:        "B017V4IM1G",
+		Description: "New description",
+		CoverArtURL: "https://example.com/cover.jpg",
+		Genre:       "Fantasy",
+		ReleaseDate: &released,
+	}
+
+	book := &models.Book{Title: "Old Title", Description: "Keep me"}
+	ApplyToBook(book, md, false)
+	assert.Equal(t, "Old Title", book.Title)
+	assert.Equal(t, "Keep me", book.Description)
+	assert.Equal(t, "9781781102367", book.ISBN)
+	assert.Equal(t, "https://example.com/cover.jpg", book.CoverArtURL)
+	assert.Equal(t, &released, book.ReleaseDate)
+
+	ApplyToBook(book, md, true)
+	assert.Equal(t, "Old Title", book.Title)
+	assert.Equal(t, "New description", book.Description)
+}
+
+func TestApplyToAudiobook(t *testing.T) {
+	audiobook := &models.Audiobook{}
+	ApplyToAudiobook(audiobook, &BookMetadata{
+		Narrators: []string{"Jim Dale", "Stephen Fry"},
+		Publisher: "Pottermore",
+		Duration:  29880,
+		ASIN:      "B017V4IM1G",
+	}, false)
+
+	assert.Equal(t, "Jim Dale, Stephen Fry", audiobook.Narrator)
+	assert.Equal(t, "Pottermore", audiobook.Publisher)
+	assert.Equal(t, 29880, audiobook.Duration)
+	assert.Equal(t, "B017V4IM1G", audiobook.ASIN)
+}
+
+func TestApplyToAuthor(t *testing.T) {
+	author := &models.Author{Name: "Author", ImageURL: "https://example.com/old.jpg"}
+	ApplyToAuthor(author, &AuthorMetadata{
+		Name:      "Renamed",
+		Biography: "Bio",
+		ImageURL:  "https://example.com/new.jpg",
+	}, false)
+
+	assert.Equal(t, "Author", author.Name)
+	assert.Equal(t, "Bio", author.Biography)
+	assert.Equal(t, "https://example.com/old.jpg", author.ImageURL)
+}
@@ -0,0 +1,169 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/events"
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+)
+
+// ErrAuthorNotFound is returned when refreshing an author that does not exist
+var ErrAuthorNotFound = errors.New("author not found")
+
+// ErrMetadataUnavailable is returned when no metadata provider could list
+// the author's books
+var ErrMetadataUnavailable = errors.New("metadata providers unavailable")
+
+// Service keeps monitored authors' bibliographies in sync with the metadata
+// providers and adds wanted library items for the books they want
+type Service struct {
+	db       *gorm.DB
+	metadata *metadata.Service
+	events   *events.Bus
+}
+
+// NewService creates a new author monitoring service
+func NewService(db *gorm.DB, metadataService *metadata.Service, bus *events.Bus) *Service {
+	return &Service{
+		db:       db,
+		metadata: metadataService,
+		events:   bus,
+	}
+}
+
+// RefreshResult summarises a bibliography refresh for one author
+type RefreshResult struct {
+	AuthorID   uint   `json:"author_id"`
+	AuthorName string `json:"author_name"`
+	BooksFound int    `json:"books_found"` // Books returned by the metadata providers
+	BooksAdded int    `json:"books_added"` // New Book rows
+	ItemsAdded int    `json:"items_added"` // New wanted library items
+}
+
+// RefreshAuthor pulls the author's bibliography, creates missing books and,
+// for monitored authors, adds wanted library items for books matching the
+// author's monitor option. Books whose library item was removed are not re-added.
+func (s *Service) RefreshAuthor(authorID uint) (*RefreshResult, error) {
+	var author models.Author
+	if err := s.db.First(&author, authorID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrAuthorNotFound
+		}
+		return nil, err
+	}
+
+	works, err := s.metadata.LookupAuthorBooks(author.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMetadataUnavailable, err)
+	}
+
+	result := &RefreshResult{
+		AuthorID:   author.ID,
+		AuthorName: author.Name,
+		BooksFound: len(works),
+	}
+	var added []models.LibraryItem
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range works {
+			_, created, err := metadata.FindOrCreateBook(tx, &author, &works[i])
+			if err != nil {
+				return err
+			}
+			if created {
+				result.BooksAdded++
+			}
+		}
+
+		// Apply the monitor rule to the whole bibliography, not just new books
+		var books []models.Book
+		if err := tx.Where("author_id = ?", author.ID).Find(&books).Error; err != nil {
+			return err
+		}
+		for i := range books {
+			if !Wants(&author, &books[i]) {
+				continue
+			}
+
+			var count int64
+			if err := tx.Unscoped().Model(&models.LibraryItem{}).Where("book_id = ?", books[i].ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+
+			item := models.LibraryItem{
+				BookID:    books[i].ID,
+				Status:    models.LibraryItemStatusWanted,
+				AddedDate: time.Now(),
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+			added = append(added, item)
+		}
+
+		now := time.Now()
+		author.LastRefreshedAt = &now
+		return tx.Model(&author).Update("last_refreshed_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.ItemsAdded = len(added)
+	for _, item := range added {
+		s.events.Publish(events.LibraryItemAdded, events.LibraryItemPayload{
+			LibraryItemID: item.ID,
+			BookID:        item.BookID,
+			Status:        string(item.Status),
+		})
+	}
+
+	return result, nil
+}
+
+// RefreshMonitored refreshes every monitored author. Failures are logged and
+// do not stop the remaining authors from being refreshed.
+func (s *Service) RefreshMonitored() []RefreshResult {
+	var authors []models.Author
+	if err := s.db.Where("monitored = ?", true).Find(&authors).Error; err != nil {
+		log.Printf("monitor: failed to list monitored authors: %v", err)
+		return nil
+	}
+
+	results := make([]RefreshResult, 0, len(authors))
+	for _, author := range authors {
+		result, err := s.RefreshAuthor(author.ID)
+		if err != nil {
+			log.Printf("monitor: failed to refresh author %q: %v", author.Name, err)
+			continue
+		}
+		results = append(results, *result)
+	}
+	return results
+}
+
+// Wants reports whether a book matches the author's monitoring rule. Books
+// without a release date never count as future releases.
+func Wants(author *models.Author, book *models.Book) bool {
+	if !author.Monitored {
+		return false
+	}
+	switch author.MonitorOption {
+	case models.AuthorMonitorAll:
+		return true
+	case models.AuthorMonitorFuture:
+		return book.ReleaseDate != nil && author.MonitoredSince != nil &&
+			book.ReleaseDate.After(*author.MonitoredSince)
+	default:
+		return false
+	}
+}
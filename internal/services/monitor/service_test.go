@@ -0,0 +1,184 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+)
+
+// bibliographyProvider serves a fixed bibliography per author name
+type bibliographyProvider struct {
+	works map[string][]metadata.BookMetadata
+}
+
+func (p *bibliographyProvider) Name() string { return "bibliography" }
+
+func (p *bibliographyProvider) Search(query string) ([]metadata.BookMetadata, error) {
+	return nil, metadata.ErrNotSupported
+}
+
+func (p *bibliographyProvider) LookupISBN(isbn string) (*metadata.BookMetadata, error) {
+	return nil, metadata.ErrNotSupported
+}
+
+func (p *bibliographyProvider) LookupASIN(asin string) (*metadata.BookMetadata, error) {
+	return nil, metadata.ErrNotSupported
+}
+
+func (p *bibliographyProvider) LookupAuthor(name string) (*metadata.AuthorMetadata, error) {
+	return nil, metadata.ErrNotSupported
+}
+
+func (p *bibliographyProvider) LookupAuthorBooks(name string) ([]metadata.BookMetadata, error) {
+	return p.works[name], nil
+}
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
+
+	err := db.AutoMigrate(
+		&models.Author{},
+		&models.Series{},
+		&models.Book{},
+		&models.BookSeries{},
+		&models.Narrator{},
+		&models.BookContributor{},
+		&models.Audiobook{},
+		&models.LibraryItem{},
+	)
+	require.NoError(t, err)
+
+	return db
+}
+
+func date(year int) *time.Time {
+	t := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func newTestService(db *gorm.DB) *Service {
+	provider := &bibliographyProvider{works: map[string][]metadata.BookMetadata{
+		"Brandon Sanderson": {
+			{Title: "The Way of Kings", Authors: []string{"Brandon Sanderson"}, ASIN: "B003P2WO5E", ReleaseDate: date(2010),
+				Series: []metadata.SeriesInfo{{Name: "The Stormlight Archive", Position: "1"}}, Narrators: []string{"Michael Kramer", "Kate Reading"}},
+			{Title: "Words of Radiance", Authors: []string{"Brandon Sanderson"}, ReleaseDate: date(2014),
+				Series: []metadata.SeriesInfo{{Name: "The Stormlight Archive", Position: "2"}}},
+			{Title: "Wind and Truth", Authors: []string{"Brandon Sanderson"}, ReleaseDate: date(2030)},
+			{Title: "Untitled Project", Authors: []string{"Brandon Sanderson"}},
+		},
+	}}
+	return NewService(db, metadata.NewService(provider), nil)
+}
+
+func TestRefreshAuthor_All(t *testing.T) {
+	db := setupTestDB(t)
+	service := newTestService(db)
+
+	author := models.Author{Name: "Brandon Sanderson", Monitored: true, MonitorOption: models.AuthorMonitorAll}
+	require.NoError(t, db.Create(&author).Error)
+
+	// An existing book is matched by title, not duplicated
+	existing := models.Book{Title: "the way of kings", AuthorID: author.ID}
+	require.NoError(t, db.Create(&existing).Error)
+
+	result, err := service.RefreshAuthor(author.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.BooksFound)
+	assert.Equal(t, 3, result.BooksAdded)
+	assert.Equal(t, 4, result.ItemsAdded)
+
+	var book models.Book
+	require.NoError(t, db.Preload("SeriesMemberships.Series").Where("title = ?", "Words of Radiance").First(&book).Error)
+	require.Len(t, book.SeriesMemberships, 1)
+	assert.Equal(t, "The Stormlight Archive", book.SeriesMemberships[0].Series.Name)
+	assert.Equal(t, "2", book.SeriesMemberships[0].Position)
+
+	var items []models.LibraryItem
+	db.Find(&items)
+	for _, item := range items {
+		assert.Equal(t, models.LibraryItemStatusWanted, item.Status)
+	}
+
+	var refreshed models.Author
+	db.First(&refreshed, author.ID)
+	assert.NotNil(t, refreshed.LastRefreshedAt)
+
+	// A second refresh is a no-op
+	result, err = service.RefreshAuthor(author.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.BooksAdded)
+	assert.Equal(t, 0, result.ItemsAdded)
+}
+
+func TestRefreshAuthor_Future(t *testing.T) {
+	db := setupTestDB(t)
+	service := newTestService(db)
+
+	author := models.Author{
+		Name:           "Brandon Sanderson",
+		Monitored:      true,
+		MonitorOption:  models.AuthorMonitorFuture,
+		MonitoredSince: date(2020),
+	}
+	require.NoError(t, db.Create(&author).Error)
+
+	result, err := service.RefreshAuthor(author.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.BooksAdded)
+	assert.Equal(t, 1, result.ItemsAdded)
+
+	var item models.LibraryItem
+	require.NoError(t, db.Preload("Book").First(&item).Error)
+	assert.Equal(t, "Wind and Truth", item.Book.Title)
+}
+
+func TestRefreshAuthor_NoneAndUnmonitored(t *testing.T) {
+	db := setupTestDB(t)
+	service := newTestService(db)
+
+	author := models.Author{Name: "Brandon Sanderson", Monitored: true, MonitorOption: models.AuthorMonitorNone}
+	require.NoError(t, db.Create(&author).Error)
+
+	result, err := service.RefreshAuthor(author.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.BooksAdded)
+	assert.Equal(t, 0, result.ItemsAdded)
+
+	// Unmonitored authors are skipped by the scheduled refresh
+	db.Model(&author).Updates(map[string]interface{}{"monitored": false, "monitor_option": models.AuthorMonitorAll})
+	assert.Empty(t, service.RefreshMonitored())
+}
+
+func TestRefreshAuthor_RemovedItemNotReadded(t *testing.T) {
+	db := setupTestDB(t)
+	service := newTestService(db)
+
+	author := models.Author{Name: "Brandon Sanderson", Monitored: true, MonitorOption: models.AuthorMonitorAll}
+	require.NoError(t, db.Create(&author).Error)
+
+	_, err := service.RefreshAuthor(author.ID)
+	require.NoError(t, err)
+
+	var item models.LibraryItem
+	require.NoError(t, db.First(&item).Error)
+	require.NoError(t, db.Delete(&item).Error)
+
+	results := service.RefreshMonitored()
+	require.Len(t, results, 1)
+	assert.Equal(t, 0, results[0].ItemsAdded)
+}
+
+func TestRefreshAuthor_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+	service := newTestService(db)
+
+	_, err := service.RefreshAuthor(999)
+	assert.ErrorIs(t, err, ErrAuthorNotFound)
+}
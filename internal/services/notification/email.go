@@ -0,0 +1,141 @@
+package notification
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// How long connecting to and talking to the mail server may take
+const (
+	smtpDialTimeout = 30 * time.Second
+	smtpTimeout     = time.Minute
+)
+
+// EmailNotifier sends the message by SMTP
+type EmailNotifier struct {
+	host     string
+	port     int
+	username string
+	password string
+	tls      bool
+	from     string
+	to       []string
+}
+
+// newEmailNotifier creates an email notifier. The port defaults to 465
+// with implicit TLS and 587 otherwise.
+func newEmailNotifier(settings models.NotificationSettings) (*EmailNotifier, error) {
+	if settings.Host == "" {
+		return nil, ErrMissingHost
+	}
+	if settings.From == "" {
+		return nil, ErrMissingSender
+	}
+	if len(settings.To) == 0 {
+		return nil, ErrMissingRecipient
+	}
+
+	port := settings.Port
+	if port == 0 {
+		port = 587
+		if settings.TLS {
+			port = 465
+		}
+	}
+
+	return &EmailNotifier{
+		host:     settings.Host,
+		port:     port,
+		username: settings.Username,
+		password: settings.Password,
+		tls:      settings.TLS,
+		from:     settings.From,
+		to:       settings.To,
+	}, nil
+}
+
+// Send mails msg to every recipient. Without implicit TLS the connection is
+// upgraded with STARTTLS when the server offers it.
+func (n *EmailNotifier) Send(msg *Message) error {
+	addr := net.JoinHostPort(n.host, strconv.Itoa(n.port))
+	tlsConfig := &tls.Config{ServerName: n.host}
+
+	var conn net.Conn
+	var err error
+	if n.tls {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: smtpDialTimeout}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, smtpDialTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reach mail server: %w", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if !n.tls {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("failed to start TLS: %w", err)
+			}
+		}
+	}
+	if n.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return fmt.Errorf("mail server rejected the login: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.from); err != nil {
+		return fmt.Errorf("mail server rejected the sender: %w", err)
+	}
+	for _, to := range n.to {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("mail server rejected recipient %s: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if _, err := w.Write(n.compose(msg)); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// compose builds the plain text mail for msg
+func (n *EmailNotifier) compose(msg *Message) []byte {
+	// Line breaks in header values would start new headers
+	header := strings.NewReplacer("\r", " ", "\n", " ")
+
+	var b strings.Builder
+	b.WriteString("From: " + header.Replace(n.from) + "\r\n")
+	b.WriteString("To: " + header.Replace(strings.Join(n.to, ", ")) + "\r\n")
+	b.WriteString("Subject: [Listenarr] " + header.Replace(msg.Title) + "\r\n")
+	b.WriteString("Date: " + msg.Time.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Text(), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
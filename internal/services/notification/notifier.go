@@ -0,0 +1,335 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// Errors returned when building notifiers
+var (
+	ErrUnknownType      = errors.New("unknown notification type")
+	ErrMissingURL       = errors.New("url is required")
+	ErrMissingToken     = errors.New("token is required")
+	ErrMissingTopic     = errors.New("topic is required")
+	ErrMissingUserKey   = errors.New("user_key is required")
+	ErrMissingHost      = errors.New("host is required")
+	ErrMissingSender    = errors.New("from is required")
+	ErrMissingRecipient = errors.New("at least one address in to is required")
+)
+
+// Default servers for the hosted services
+const (
+	DefaultNtfyURL     = "https://ntfy.sh"
+	DefaultPushoverURL = "https://api.pushover.net/1/messages.json"
+)
+
+// EventKind is what a message is about, matching the subscriptions of a
+// notification
+type EventKind string
+
+const (
+	EventGrab        EventKind = "grab"
+	EventImport      EventKind = "import"
+	EventUpgrade     EventKind = "upgrade"
+	EventDelete      EventKind = "delete"
+	EventFailure     EventKind = "failure"
+	EventHealthIssue EventKind = "health_issue"
+	EventTest        EventKind = "test"
+)
+
+// Message is what gets sent to a notification
+type Message struct {
+	Event        EventKind `json:"event"`
+	Title        string    `json:"title"`
+	Body         string    `json:"message"`
+	Book         string    `json:"book,omitempty"`
+	Author       string    `json:"author,omitempty"`
+	ReleaseTitle string    `json:"release_title,omitempty"`
+	DownloadHash string    `json:"download_hash,omitempty"`
+	Path         string    `json:"path,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	Time         time.Time `json:"time"`
+
+	// Records the message is about, kept for history and scripts
+	BookID        uint `json:"book_id,omitempty"`
+	LibraryItemID uint `json:"library_item_id,omitempty"`
+}
+
+// Text returns the body followed by the details, one per line, for channels
+// that only take plain text
+func (m *Message) Text() string {
+	lines := []string{m.Body}
+	for _, field := range m.fields() {
+		lines = append(lines, field[0]+": "+field[1])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fields returns the details that are set as name and value pairs
+func (m *Message) fields() [][2]string {
+	var fields [][2]string
+	for _, field := range [][2]string{
+		{"Book", m.Book},
+		{"Author", m.Author},
+		{"Release", m.ReleaseTitle},
+		{"Path", m.Path},
+		{"Error", m.Error},
+	} {
+		if field[1] != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Notifier sends messages to one notification connection
+type Notifier interface {
+	Send(msg *Message) error
+}
+
+// New creates the notifier for a notification connection
+func New(n *models.Notification) (Notifier, error) {
+	settings := n.GetSettings()
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	switch n.Type {
+	case models.NotificationTypeWebhook:
+		if settings.URL == "" {
+			return nil, ErrMissingURL
+		}
+		return &WebhookNotifier{client: client, url: settings.URL, username: settings.Username, password: settings.Password}, nil
+	case models.NotificationTypeDiscord:
+		if settings.URL == "" {
+			return nil, ErrMissingURL
+		}
+		return &DiscordNotifier{client: client, url: settings.URL}, nil
+	case models.NotificationTypeGotify:
+		if settings.URL == "" {
+			return nil, ErrMissingURL
+		}
+		if settings.Token == "" {
+			return nil, ErrMissingToken
+		}
+		return &GotifyNotifier{client: client, url: strings.TrimSuffix(settings.URL, "/"), token: settings.Token, priority: settings.Priority}, nil
+	case models.NotificationTypeNtfy:
+		if settings.Topic == "" {
+			return nil, ErrMissingTopic
+		}
+		server := settings.URL
+		if server == "" {
+			server = DefaultNtfyURL
+		}
+		return &NtfyNotifier{client: client, url: strings.TrimSuffix(server, "/"), topic: settings.Topic, token: settings.Token, priority: settings.Priority}, nil
+	case models.NotificationTypePushover:
+		if settings.Token == "" {
+			return nil, ErrMissingToken
+		}
+		if settings.UserKey == "" {
+			return nil, ErrMissingUserKey
+		}
+		endpoint := settings.URL
+		if endpoint == "" {
+			endpoint = DefaultPushoverURL
+		}
+		return &PushoverNotifier{client: client, url: endpoint, token: settings.Token, user: settings.UserKey, priority: settings.Priority}, nil
+	case models.NotificationTypeEmail:
+		notifier, err := newEmailNotifier(settings)
+		if err != nil {
+			return nil, err
+		}
+		return notifier, nil
+	case models.NotificationTypeScript:
+		notifier, err := newScriptNotifier(settings)
+		if err != nil {
+			return nil, err
+		}
+		return notifier, nil
+	}
+
+	return nil, fmt.Errorf("%w %q", ErrUnknownType, n.Type)
+}
+
+// WebhookNotifier posts the message as JSON
+type WebhookNotifier struct {
+	client   *http.Client
+	url      string
+	username string
+	password string
+}
+
+// Send posts msg to the webhook URL
+func (n *WebhookNotifier) Send(msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.username != "" {
+		req.SetBasicAuth(n.username, n.password)
+	}
+	return do(n.client, req, "webhook")
+}
+
+// DiscordNotifier posts the message as an embed to a Discord webhook
+type DiscordNotifier struct {
+	client *http.Client
+	url    string
+}
+
+// discordColors gives each kind of message its own embed color
+var discordColors = map[EventKind]int{
+	EventGrab:        0x3498db,
+	EventImport:      0x2ecc71,
+	EventUpgrade:     0x9b59b6,
+	EventDelete:      0x7f8c8d,
+	EventFailure:     0xe74c3c,
+	EventHealthIssue: 0xf1c40f,
+	EventTest:        0x95a5a6,
+}
+
+// Send posts msg to the Discord webhook
+func (n *DiscordNotifier) Send(msg *Message) error {
+	type field struct {
+		Name   string `json:"name"`
+		Value  string `json:"value"`
+		Inline bool   `json:"inline,omitempty"`
+	}
+	type embed struct {
+		Title       string  `json:"title"`
+		Description string  `json:"description"`
+		Color       int     `json:"color"`
+		Timestamp   string  `json:"timestamp"`
+		Fields      []field `json:"fields,omitempty"`
+	}
+	e := embed{
+		Title:       msg.Title,
+		Description: msg.Body,
+		Color:       discordColors[msg.Event],
+		Timestamp:   msg.Time.Format(time.RFC3339),
+	}
+	for _, f := range msg.fields() {
+		e.Fields = append(e.Fields, field{Name: f[0], Value: f[1], Inline: f[0] == "Book" || f[0] == "Author"})
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"username": "Listenarr",
+		"embeds":   []embed{e},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Discord request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return do(n.client, req, "discord")
+}
+
+// GotifyNotifier pushes the message to a Gotify server
+type GotifyNotifier struct {
+	client   *http.Client
+	url      string
+	token    string
+	priority int
+}
+
+// Send pushes msg as a Gotify message
+func (n *GotifyNotifier) Send(msg *Message) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"title":    msg.Title,
+		"message":  msg.Text(),
+		"priority": n.priority,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", n.url+"/message", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Gotify request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", n.token)
+	return do(n.client, req, "gotify")
+}
+
+// NtfyNotifier publishes the message to an ntfy topic
+type NtfyNotifier struct {
+	client   *http.Client
+	url      string
+	topic    string
+	token    string
+	priority int
+}
+
+// Send publishes msg to the topic
+func (n *NtfyNotifier) Send(msg *Message) error {
+	req, err := http.NewRequest("POST", n.url+"/"+url.PathEscape(n.topic), strings.NewReader(msg.Text()))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+	req.Header.Set("Title", msg.Title)
+	req.Header.Set("Tags", string(msg.Event))
+	if n.priority > 0 {
+		req.Header.Set("Priority", strconv.Itoa(n.priority))
+	}
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	return do(n.client, req, "ntfy")
+}
+
+// PushoverNotifier sends the message through the Pushover API
+type PushoverNotifier struct {
+	client   *http.Client
+	url      string
+	token    string
+	user     string
+	priority int
+}
+
+// Send sends msg to the user or group
+func (n *PushoverNotifier) Send(msg *Message) error {
+	form := url.Values{
+		"token":    {n.token},
+		"user":     {n.user},
+		"title":    {msg.Title},
+		"message":  {msg.Text()},
+		"priority": {strconv.Itoa(n.priority)},
+	}
+	req, err := http.NewRequest("POST", n.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create Pushover request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return do(n.client, req, "pushover")
+}
+
+// do sends req and fails on any status other than 2xx
+func do(client *http.Client, req *http.Request, service string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", service, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s request failed with status %d: %s", service, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
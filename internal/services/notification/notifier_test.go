@@ -0,0 +1,319 @@
+package notification
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// request is what a stand-in HTTP server received
+type request struct {
+	method string
+	path   string
+	header http.Header
+	body   string
+}
+
+// standIn is an HTTP server that records the requests it receives
+type standIn struct {
+	URL string
+
+	mu       sync.Mutex
+	requests []request
+}
+
+// Requests returns the requests received so far
+func (s *standIn) Requests() []request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]request(nil), s.requests...)
+}
+
+// newHTTPStandIn starts an HTTP server that records requests and answers
+// with status
+func newHTTPStandIn(t *testing.T, status int) *standIn {
+	standIn := &standIn{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		standIn.mu.Lock()
+		standIn.requests = append(standIn.requests, request{method: r.Method, path: r.URL.Path, header: r.Header, body: string(body)})
+		standIn.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	standIn.URL = server.URL
+	return standIn
+}
+
+// mail is what the stand-in SMTP server received
+type mail struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// newSMTPStandIn starts an SMTP server without TLS that accepts one mail
+// and returns its address
+func newSMTPStandIn(t *testing.T) (string, <-chan mail) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan mail, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var m mail
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch command {
+			case "EHLO", "HELO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				decoded, _ := base64.StdEncoding.DecodeString(strings.Fields(line)[2])
+				m.auth = string(decoded)
+				reply("235 2.7.0 Authentication successful")
+			case "MAIL":
+				m.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+				reply("250 OK")
+			case "RCPT":
+				m.to = append(m.to, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+				reply("250 OK")
+			case "DATA":
+				reply("354 Go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				m.data = data.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 Bye")
+				received <- m
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+// notification creates an unsaved notification with the given settings
+func notification(kind models.NotificationType, settings models.NotificationSettings) *models.Notification {
+	n := &models.Notification{Name: string(kind), Type: kind, Enabled: true}
+	n.SetSettings(settings)
+	return n
+}
+
+func importMessage() *Message {
+	return &Message{
+		Event:  EventImport,
+		Title:  "Imported",
+		Body:   "Imported Mort by Terry Pratchett",
+		Book:   "Mort",
+		Author: "Terry Pratchett",
+		Path:   "/library/Terry Pratchett/Mort",
+		Time:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestMessage_Text(t *testing.T) {
+	assert.Equal(t, "Imported Mort by Terry Pratchett\nBook: Mort\nAuthor: Terry Pratchett\nPath: /library/Terry Pratchett/Mort", importMessage().Text())
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		kind     models.NotificationType
+		settings models.NotificationSettings
+		err      error
+	}{
+		{models.NotificationTypeWebhook, models.NotificationSettings{}, ErrMissingURL},
+		{models.NotificationTypeDiscord, models.NotificationSettings{}, ErrMissingURL},
+		{models.NotificationTypeGotify, models.NotificationSettings{URL: "http://gotify"}, ErrMissingToken},
+		{models.NotificationTypeNtfy, models.NotificationSettings{}, ErrMissingTopic},
+		{models.NotificationTypePushover, models.NotificationSettings{Token: "app"}, ErrMissingUserKey},
+		{models.NotificationTypeEmail, models.NotificationSettings{}, ErrMissingHost},
+		{models.NotificationTypeEmail, models.NotificationSettings{Host: "smtp", From: "a@b"}, ErrMissingRecipient},
+		{"slack", models.NotificationSettings{URL: "http://slack"}, ErrUnknownType},
+	}
+	for _, tt := range tests {
+		_, err := New(notification(tt.kind, tt.settings))
+		assert.ErrorIs(t, err, tt.err, tt.kind)
+	}
+
+	notifier, err := New(notification(models.NotificationTypeNtfy, models.NotificationSettings{Topic: "books"}))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultNtfyURL, notifier.(*NtfyNotifier).url)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	server := newHTTPStandIn(t, http.StatusOK)
+	notifier, err := New(notification(models.NotificationTypeWebhook, models.NotificationSettings{URL: server.URL + "/hook", Username: "user", Password: "secret"}))
+	require.NoError(t, err)
+
+	require.NoError(t, notifier.Send(importMessage()))
+	require.Len(t, server.Requests(), 1)
+	req := server.Requests()[0]
+	assert.Equal(t, "/hook", req.path)
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")), req.header.Get("Authorization"))
+
+	var body Message
+	require.NoError(t, json.Unmarshal([]byte(req.body), &body))
+	assert.Equal(t, EventImport, body.Event)
+	assert.Equal(t, "Mort", body.Book)
+
+	t.Run("Error status", func(t *testing.T) {
+		server := newHTTPStandIn(t, http.StatusInternalServerError)
+		notifier, err := New(notification(models.NotificationTypeWebhook, models.NotificationSettings{URL: server.URL}))
+		require.NoError(t, err)
+		err = notifier.Send(importMessage())
+		assert.ErrorContains(t, err, "status 500")
+	})
+}
+
+func TestDiscordNotifier(t *testing.T) {
+	server := newHTTPStandIn(t, http.StatusNoContent)
+	notifier, err := New(notification(models.NotificationTypeDiscord, models.NotificationSettings{URL: server.URL + "/api/webhooks/1/abc"}))
+	require.NoError(t, err)
+
+	require.NoError(t, notifier.Send(importMessage()))
+	require.Len(t, server.Requests(), 1)
+
+	var body struct {
+		Embeds []struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Color       int    `json:"color"`
+			Fields      []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"fields"`
+		} `json:"embeds"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(server.Requests()[0].body), &body))
+	require.Len(t, body.Embeds, 1)
+	assert.Equal(t, "Imported", body.Embeds[0].Title)
+	assert.Equal(t, discordColors[EventImport], body.Embeds[0].Color)
+	require.Len(t, body.Embeds[0].Fields, 3)
+	assert.Equal(t, "Author", body.Embeds[0].Fields[1].Name)
+	assert.Equal(t, "Terry Pratchett", body.Embeds[0].Fields[1].Value)
+}
+
+func TestGotifyNotifier(t *testing.T) {
+	server := newHTTPStandIn(t, http.StatusOK)
+	notifier, err := New(notification(models.NotificationTypeGotify, models.NotificationSettings{URL: server.URL + "/", Token: "app-token", Priority: 5}))
+	require.NoError(t, err)
+
+	require.NoError(t, notifier.Send(importMessage()))
+	require.Len(t, server.Requests(), 1)
+	req := server.Requests()[0]
+	assert.Equal(t, "/message", req.path)
+	assert.Equal(t, "app-token", req.header.Get("X-Gotify-Key"))
+	assert.Contains(t, req.body, `"priority":5`)
+	assert.Contains(t, req.body, `"title":"Imported"`)
+}
+
+func TestNtfyNotifier(t *testing.T) {
+	server := newHTTPStandIn(t, http.StatusOK)
+	notifier, err := New(notification(models.NotificationTypeNtfy, models.NotificationSettings{URL: server.URL, Topic: "audiobooks", Token: "tk_abc", Priority: 4}))
+	require.NoError(t, err)
+
+	require.NoError(t, notifier.Send(importMessage()))
+	require.Len(t, server.Requests(), 1)
+	req := server.Requests()[0]
+	assert.Equal(t, "/audiobooks", req.path)
+	assert.Equal(t, "Imported", req.header.Get("Title"))
+	assert.Equal(t, "4", req.header.Get("Priority"))
+	assert.Equal(t, "Bearer tk_abc", req.header.Get("Authorization"))
+	assert.Equal(t, importMessage().Text(), req.body)
+}
+
+func TestPushoverNotifier(t *testing.T) {
+	server := newHTTPStandIn(t, http.StatusOK)
+	notifier, err := New(notification(models.NotificationTypePushover, models.NotificationSettings{URL: server.URL + "/1/messages.json", Token: "app", UserKey: "user"}))
+	require.NoError(t, err)
+
+	require.NoError(t, notifier.Send(importMessage()))
+	require.Len(t, server.Requests(), 1)
+	form, err := url.ParseQuery(server.Requests()[0].body)
+	require.NoError(t, err)
+	assert.Equal(t, "app", form.Get("token"))
+	assert.Equal(t, "user", form.Get("user"))
+	assert.Equal(t, "Imported", form.Get("title"))
+}
+
+func TestEmailNotifier(t *testing.T) {
+	addr, received := newSMTPStandIn(t)
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	notifier, err := New(notification(models.NotificationTypeEmail, models.NotificationSettings{
+		Host:     host,
+		Port:     portNumber,
+		Username: "listenarr",
+		Password: "secret",
+		From:     "listenarr@example.com",
+		To:       []string{"me@example.com", "you@example.com"},
+	}))
+	require.NoError(t, err)
+
+	msg := importMessage()
+	msg.Title = "Imported\r\nBcc: evil@example.com"
+	require.NoError(t, notifier.Send(msg))
+
+	select {
+	case m := <-received:
+		assert.Equal(t, "\x00listenarr\x00secret", m.auth)
+		assert.Equal(t, "listenarr@example.com", m.from)
+		assert.Equal(t, []string{"me@example.com", "you@example.com"}, m.to)
+		assert.Contains(t, m.data, "Subject: [Listenarr] Imported  Bcc: evil@example.com\r\n")
+		assert.Contains(t, m.data, "To: me@example.com, you@example.com\r\n")
+		assert.Contains(t, m.data, "\r\n\r\nImported Mort by Terry Pratchett\r\nBook: Mort\r\n")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+	}
+
+	t.Run("Default port", func(t *testing.T) {
+		notifier, err := newEmailNotifier(models.NotificationSettings{Host: "smtp.example.com", TLS: true, From: "a@b", To: []string{"c@d"}})
+		require.NoError(t, err)
+		assert.Equal(t, 465, notifier.port)
+	})
+}
@@ -0,0 +1,182 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// Errors returned when building script notifiers
+var (
+	ErrMissingPath    = errors.New("path is required")
+	ErrRelativePath   = errors.New("path must be absolute")
+	ErrNotExecutable  = errors.New("path is not an executable file")
+	ErrInvalidTimeout = errors.New("timeout must not be negative")
+	ErrScriptTimedOut = errors.New("script timed out")
+)
+
+const (
+	// DefaultScriptTimeout is how long a script may run when no timeout is set
+	DefaultScriptTimeout = 30 * time.Second
+	// MaxScriptOutput is how much of each of stdout and stderr is kept
+	MaxScriptOutput = 4096
+)
+
+// ScriptNotifier runs an executable with the message in its environment.
+// The executable is started directly, so nothing in the message or the
+// arguments is ever interpreted by a shell.
+type ScriptNotifier struct {
+	path    string
+	args    []string
+	timeout time.Duration
+}
+
+// ScriptResult is the outcome of one script run
+type ScriptResult struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+	Duration time.Duration
+}
+
+// newScriptNotifier checks the script settings
+func newScriptNotifier(settings models.NotificationSettings) (*ScriptNotifier, error) {
+	if settings.Path == "" {
+		return nil, ErrMissingPath
+	}
+	if !filepath.IsAbs(settings.Path) {
+		return nil, ErrRelativePath
+	}
+	info, err := os.Stat(settings.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotExecutable, err)
+	}
+	if info.IsDir() || info.Mode().Perm()&0o111 == 0 {
+		return nil, ErrNotExecutable
+	}
+	if settings.Timeout < 0 {
+		return nil, ErrInvalidTimeout
+	}
+
+	timeout := DefaultScriptTimeout
+	if settings.Timeout > 0 {
+		timeout = time.Duration(settings.Timeout) * time.Second
+	}
+	return &ScriptNotifier{path: settings.Path, args: settings.Arguments, timeout: timeout}, nil
+}
+
+// Send runs the script for msg
+func (n *ScriptNotifier) Send(msg *Message) error {
+	_, err := n.Run(msg)
+	return err
+}
+
+// Run runs the script for msg and returns what it printed. A script that
+// exits with a non-zero status or outlives the timeout is an error, but the
+// result still holds its output.
+func (n *ScriptNotifier) Run(msg *Message) (*ScriptResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: MaxScriptOutput}
+	stderr := &limitedBuffer{limit: MaxScriptOutput}
+	cmd := exec.CommandContext(ctx, n.path, n.args...)
+	cmd.Env = append(os.Environ(), scriptEnv(msg)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Children that keep the output open must not hold up the caller
+	cmd.WaitDelay = 5 * time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	result := &ScriptResult{
+		ExitCode: -1,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: time.Since(start),
+	}
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return result, fmt.Errorf("%w after %s", ErrScriptTimedOut, n.timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return result, fmt.Errorf("script exited with status %d", result.ExitCode)
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to run script: %w", err)
+	}
+	return result, nil
+}
+
+// scriptEnv describes msg as LISTENARR_ environment variables. Details that
+// are not known are set to an empty string, so scripts can rely on every
+// variable being present.
+func scriptEnv(msg *Message) []string {
+	vars := [][2]string{
+		{"EVENT_TYPE", string(msg.Event)},
+		{"EVENT_TITLE", msg.Title},
+		{"EVENT_MESSAGE", msg.Body},
+		{"BOOK_ID", formatID(msg.BookID)},
+		{"BOOK_TITLE", msg.Book},
+		{"AUTHOR_NAME", msg.Author},
+		{"LIBRARY_ITEM_ID", formatID(msg.LibraryItemID)},
+		{"FILE_PATH", msg.Path},
+		{"RELEASE_TITLE", msg.ReleaseTitle},
+		{"DOWNLOAD_HASH", msg.DownloadHash},
+		{"ERROR", msg.Error},
+		{"EVENT_TIME", msg.Time.Format(time.RFC3339)},
+	}
+	env := make([]string, len(vars))
+	for i, v := range vars {
+		env[i] = "LISTENARR_" + v[0] + "=" + v[1]
+	}
+	return env
+}
+
+// formatID formats an ID, with "" for unknown
+func formatID(id uint) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest
+type limitedBuffer struct {
+	limit     int
+	buf       strings.Builder
+	truncated bool
+}
+
+// Write never fails, so the script is not stopped by a full buffer
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+// String returns what was kept, marking where output was dropped
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "\n[output truncated]"
+	}
+	return b.buf.String()
+}
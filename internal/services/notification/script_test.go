@@ -0,0 +1,114 @@
+package notification
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+// writeScript writes an executable shell script and returns its path
+func writeScript(t *testing.T, body string) string {
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755))
+	return path
+}
+
+func TestNew_ScriptValidation(t *testing.T) {
+	script := writeScript(t, "exit 0\n")
+	plain := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(plain, []byte("not a script"), 0o644))
+
+	tests := []struct {
+		settings models.NotificationSettings
+		err      error
+	}{
+		{models.NotificationSettings{}, ErrMissingPath},
+		{models.NotificationSettings{Path: "hook.sh"}, ErrRelativePath},
+		{models.NotificationSettings{Path: filepath.Join(t.TempDir(), "missing.sh")}, ErrNotExecutable},
+		{models.NotificationSettings{Path: plain}, ErrNotExecutable},
+		{models.NotificationSettings{Path: t.TempDir()}, ErrNotExecutable},
+		{models.NotificationSettings{Path: script, Timeout: -1}, ErrInvalidTimeout},
+	}
+	for _, tt := range tests {
+		_, err := New(notification(models.NotificationTypeScript, tt.settings))
+		assert.ErrorIs(t, err, tt.err, tt.settings.Path)
+	}
+
+	notifier, err := New(notification(models.NotificationTypeScript, models.NotificationSettings{Path: script}))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultScriptTimeout, notifier.(*ScriptNotifier).timeout)
+}
+
+func TestScriptNotifier(t *testing.T) {
+	script := writeScript(t, `echo "event=$LISTENARR_EVENT_TYPE"
+echo "book=$LISTENARR_BOOK_TITLE"
+echo "author=$LISTENARR_AUTHOR_NAME"
+echo "path=$LISTENARR_FILE_PATH"
+echo "release=$LISTENARR_RELEASE_TITLE"
+echo "hash=$LISTENARR_DOWNLOAD_HASH"
+echo "item=$LISTENARR_LIBRARY_ITEM_ID"
+for arg in "$@"; do echo "arg=$arg"; done
+echo "done" >&2
+`)
+	notifier, err := New(notification(models.NotificationTypeScript, models.NotificationSettings{
+		Path:      script,
+		Arguments: []string{"--flag", "$(touch pwned); `id`"},
+	}))
+	require.NoError(t, err)
+
+	msg := importMessage()
+	msg.Book = "Mort; rm -rf /"
+	msg.ReleaseTitle = "Terry.Pratchett-Mort.m4b"
+	msg.DownloadHash = "abc123"
+	msg.LibraryItemID = 7
+	result, err := notifier.(*ScriptNotifier).Run(msg)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, strings.Join([]string{
+		"event=import",
+		"book=Mort; rm -rf /",
+		"author=Terry Pratchett",
+		"path=/library/Terry Pratchett/Mort",
+		"release=Terry.Pratchett-Mort.m4b",
+		"hash=abc123",
+		"item=7",
+		"arg=--flag",
+		"arg=$(touch pwned); `id`",
+		"",
+	}, "\n"), result.Stdout)
+	assert.Equal(t, "done\n", result.Stderr)
+
+	t.Run("Exit status", func(t *testing.T) {
+		notifier, err := New(notification(models.NotificationTypeScript, models.NotificationSettings{Path: writeScript(t, "echo broken >&2\nexit 3\n")}))
+		require.NoError(t, err)
+		result, err := notifier.(*ScriptNotifier).Run(importMessage())
+		assert.EqualError(t, err, "script exited with status 3")
+		assert.Equal(t, 3, result.ExitCode)
+		assert.Equal(t, "broken\n", result.Stderr)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		notifier, err := New(notification(models.NotificationTypeScript, models.NotificationSettings{Path: writeScript(t, "echo started\nexec sleep 10\n"), Timeout: 1}))
+		require.NoError(t, err)
+		start := time.Now()
+		result, err := notifier.(*ScriptNotifier).Run(importMessage())
+		assert.ErrorIs(t, err, ErrScriptTimedOut)
+		assert.Less(t, time.Since(start), 5*time.Second)
+		assert.Equal(t, "started\n", result.Stdout)
+	})
+
+	t.Run("Output limit", func(t *testing.T) {
+		notifier, err := New(notification(models.NotificationTypeScript, models.NotificationSettings{Path: writeScript(t, "head -c 10000 /dev/zero | tr '\\0' x\n")}))
+		require.NoError(t, err)
+		result, err := notifier.(*ScriptNotifier).Run(importMessage())
+		require.NoError(t, err)
+		assert.Equal(t, strings.Repeat("x", MaxScriptOutput)+"\n[output truncated]", result.Stdout)
+	})
+}
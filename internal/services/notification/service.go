@@ -0,0 +1,338 @@
+// Package notification tells the configured notification connections about
+// grabs, imports, upgrades, deletions, failures and health issues
+package notification
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/events"
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/history"
+)
+
+// Service sends messages for bus events to the subscribed notifications
+type Service struct {
+	db      *gorm.DB
+	history *history.Service
+	events  *events.Bus
+
+	// Events waiting to be delivered, so a slow notification never holds up
+	// the bus subscription and makes it drop events
+	mu      sync.Mutex
+	queue   []events.Event
+	pending chan struct{} // Signalled when the queue gets an event
+}
+
+// NewService creates a new notification service.
+// bus may be nil, in which case nothing is sent until Send is called.
+func NewService(db *gorm.DB, bus *events.Bus) *Service {
+	return &Service{
+		db:      db,
+		history: history.NewService(db),
+		events:  bus,
+		pending: make(chan struct{}, 1),
+	}
+}
+
+// Run sends a message for every notifiable event on the bus until stop is
+// closed. Events are only queued here and delivered by a worker, so the
+// subscription keeps up however long a webhook or script takes, and it only
+// receives the notifiable types, so bursts of download progress cannot fill
+// it up.
+func (s *Service) Run(stop <-chan struct{}) {
+	ch, unsubscribe := s.events.Subscribe(notifiableTypes...)
+	defer unsubscribe()
+
+	quit := make(chan struct{})
+	defer close(quit)
+	go s.deliverQueued(quit)
+
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			s.enqueue(event)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// enqueue adds an event for the worker to deliver
+func (s *Service) enqueue(event events.Event) {
+	s.mu.Lock()
+	s.queue = append(s.queue, event)
+	s.mu.Unlock()
+
+	select {
+	case s.pending <- struct{}{}:
+	default: // The worker is already signalled
+	}
+}
+
+// deliverQueued sends the messages for queued events, oldest first, until
+// quit is closed
+func (s *Service) deliverQueued(quit <-chan struct{}) {
+	for {
+		select {
+		case <-s.pending:
+		case <-quit:
+			return
+		}
+
+		for {
+			s.mu.Lock()
+			if len(s.queue) == 0 {
+				s.mu.Unlock()
+				break
+			}
+			event := s.queue[0]
+			s.queue[0] = events.Event{}
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+
+			if msg := s.messageFor(event); msg != nil {
+				s.Send(msg)
+			}
+		}
+	}
+}
+
+// notifiableTypes are the kinds of bus events messages are sent for
+var notifiableTypes = []events.Type{
+	events.DownloadGrabbed, events.DownloadFailed, events.ProcessingTaskFailed,
+	events.LibraryItemImported, events.LibraryItemUpgraded, events.LibraryItemRemoved,
+	events.HealthIssue,
+}
+
+// Send delivers msg to every enabled notification subscribed to its kind.
+// Failures are logged and recorded in history and do not stop delivery to
+// the other notifications.
+func (s *Service) Send(msg *Message) {
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+
+	column := subscriptionColumn(msg.Event)
+	if column == "" {
+		return
+	}
+	var notifications []models.Notification
+	err := s.db.Where("enabled = ? AND "+column+" = ?", true, true).Order("id").Find(&notifications).Error
+	if err != nil {
+		log.Printf("notification: failed to list notifications: %v", err)
+		return
+	}
+
+	for i := range notifications {
+		if err := s.deliver(&notifications[i], msg); err != nil {
+			log.Printf("notification: failed to notify %s: %v", notifications[i].Name, err)
+			if notifications[i].Type == models.NotificationTypeScript {
+				// The script run is already in history, output and all
+				continue
+			}
+			_ = s.history.Record(&models.History{
+				EventType: models.HistoryEventNotificationFailed,
+				Message:   fmt.Sprintf("%s: %v", notifications[i].Name, err),
+			})
+		}
+	}
+}
+
+// Test sends a test message to a notification, which need not be saved
+func (s *Service) Test(n *models.Notification) error {
+	return s.deliver(n, &Message{
+		Event: EventTest,
+		Title: "Test notification",
+		Body:  fmt.Sprintf("This is a test message from Listenarr for %s", n.Name),
+		Time:  time.Now(),
+	})
+}
+
+// deliver sends msg to one notification. Script runs are recorded in
+// history whether they succeed or not.
+func (s *Service) deliver(n *models.Notification, msg *Message) error {
+	notifier, err := New(n)
+	if err != nil {
+		return err
+	}
+	if script, ok := notifier.(*ScriptNotifier); ok {
+		result, err := script.Run(msg)
+		s.recordScript(n, msg, result, err)
+		return err
+	}
+	return notifier.Send(msg)
+}
+
+// recordScript records a script run with its exit status and output
+func (s *Service) recordScript(n *models.Notification, msg *Message, result *ScriptResult, runErr error) {
+	status := fmt.Sprintf("exited with status 0 after %s", result.Duration.Round(time.Millisecond))
+	if runErr != nil {
+		status = runErr.Error()
+	}
+	lines := []string{fmt.Sprintf("%s: %s for %s", n.Name, status, msg.Event)}
+	if result.Stdout != "" {
+		lines = append(lines, "stdout:", strings.TrimRight(result.Stdout, "\n"))
+	}
+	if result.Stderr != "" {
+		lines = append(lines, "stderr:", strings.TrimRight(result.Stderr, "\n"))
+	}
+
+	entry := &models.History{
+		EventType:       models.HistoryEventScriptRan,
+		Message:         strings.Join(lines, "\n"),
+		ReleaseTitle:    msg.ReleaseTitle,
+		DownloadHash:    msg.DownloadHash,
+		DestinationPath: msg.Path,
+	}
+	if msg.BookID != 0 {
+		entry.BookID = &msg.BookID
+	}
+	if msg.LibraryItemID != 0 {
+		entry.LibraryItemID = &msg.LibraryItemID
+	}
+	_ = s.history.Record(entry)
+}
+
+// subscriptionColumn returns the column of the subscription for a kind of
+// message, or "" when notifications cannot subscribe to it
+func subscriptionColumn(kind EventKind) string {
+	switch kind {
+	case EventGrab:
+		return "on_grab"
+	case EventImport:
+		return "on_import"
+	case EventUpgrade:
+		return "on_upgrade"
+	case EventDelete:
+		return "on_delete"
+	case EventFailure:
+		return "on_failure"
+	case EventHealthIssue:
+		return "on_health_issue"
+	}
+	return ""
+}
+
+// messageFor describes a bus event, or returns nil for events nobody is
+// notified about
+func (s *Service) messageFor(event events.Event) *Message {
+	msg := &Message{Time: event.Time}
+
+	switch payload := event.Data.(type) {
+	case events.DownloadPayload:
+		switch event.Type {
+		case events.DownloadGrabbed:
+			msg.Event = EventGrab
+		case events.DownloadFailed:
+			msg.Event = EventFailure
+			msg.Error = payload.Error
+		default:
+			return nil
+		}
+		var download models.Download
+		if err := s.db.Unscoped().Preload("Release").First(&download, payload.DownloadID).Error; err == nil {
+			msg.ReleaseTitle = download.Release.Title
+			msg.DownloadHash = download.QBittorrentHash
+			msg.Path = download.DownloadPath
+		}
+		s.describeItem(msg, payload.LibraryItemID)
+		if msg.Event == EventGrab {
+			msg.Title = "Grabbed"
+			msg.Body = fmt.Sprintf("Grabbed %s", bookLabel(msg))
+		} else {
+			msg.Title = "Download failed"
+			msg.Body = fmt.Sprintf("Download of %s failed", bookLabel(msg))
+		}
+
+	case events.ProcessingPayload:
+		if event.Type != events.ProcessingTaskFailed {
+			return nil
+		}
+		msg.Event = EventFailure
+		msg.Error = payload.Error
+		var download models.Download
+		if err := s.db.Unscoped().Preload("Release").First(&download, payload.DownloadID).Error; err == nil {
+			msg.ReleaseTitle = download.Release.Title
+			msg.DownloadHash = download.QBittorrentHash
+			s.describeItem(msg, download.LibraryItemID)
+		}
+		var task models.ProcessingTask
+		if err := s.db.Unscoped().First(&task, payload.TaskID).Error; err == nil {
+			msg.Path = task.InputPath
+		}
+		msg.Title = "Import failed"
+		msg.Body = fmt.Sprintf("Import of %s failed", bookLabel(msg))
+
+	case events.LibraryItemPayload:
+		switch event.Type {
+		case events.LibraryItemImported:
+			msg.Event = EventImport
+			msg.Title = "Imported"
+		case events.LibraryItemUpgraded:
+			msg.Event = EventUpgrade
+			msg.Title = "Upgraded"
+		case events.LibraryItemRemoved:
+			msg.Event = EventDelete
+			msg.Title = "Deleted"
+		default:
+			return nil
+		}
+		msg.Path = payload.FilePath
+		if payload.DownloadID != 0 {
+			var download models.Download
+			if err := s.db.Unscoped().Preload("Release").First(&download, payload.DownloadID).Error; err == nil {
+				msg.ReleaseTitle = download.Release.Title
+				msg.DownloadHash = download.QBittorrentHash
+			}
+		}
+		s.describeItem(msg, payload.LibraryItemID)
+		msg.Body = fmt.Sprintf("%s %s", msg.Title, bookLabel(msg))
+
+	case events.HealthPayload:
+		if event.Type != events.HealthIssue {
+			return nil
+		}
+		msg.Event = EventHealthIssue
+		msg.Title = "Health issue"
+		msg.Body = payload.Message
+
+	default:
+		return nil
+	}
+
+	return msg
+}
+
+// describeItem fills in the book and author of a library item
+func (s *Service) describeItem(msg *Message, libraryItemID uint) {
+	var item models.LibraryItem
+	if err := s.db.Unscoped().Preload("Book.Author").First(&item, libraryItemID).Error; err != nil {
+		return
+	}
+	msg.LibraryItemID = item.ID
+	msg.BookID = item.BookID
+	msg.Book = item.Book.Title
+	msg.Author = item.Book.Author.Name
+}
+
+// bookLabel names the book of a message, falling back to the release
+func bookLabel(msg *Message) string {
+	switch {
+	case msg.Book != "" && msg.Author != "":
+		return fmt.Sprintf("%s by %s", msg.Book, msg.Author)
+	case msg.Book != "":
+		return msg.Book
+	case msg.ReleaseTitle != "":
+		return msg.ReleaseTitle
+	}
+	return "unknown book"
+}
@@ -0,0 +1,308 @@
+package notification
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/events"
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
+
+	err := db.AutoMigrate(
+		&models.Author{},
+		&models.Book{},
+		&models.LibraryItem{},
+		&models.Release{},
+		&models.Download{},
+		&models.ProcessingTask{},
+		&models.History{},
+		&models.Notification{},
+	)
+	require.NoError(t, err)
+
+	return db
+}
+
+// createDownloadFixture creates a book with a library item and a download
+func createDownloadFixture(t *testing.T, db *gorm.DB) models.Download {
+	author := models.Author{Name: "Terry Pratchett"}
+	require.NoError(t, db.Create(&author).Error)
+	book := models.Book{Title: "Mort", AuthorID: author.ID}
+	require.NoError(t, db.Create(&book).Error)
+	item := models.LibraryItem{BookID: book.ID, Status: models.LibraryItemStatusDownloading, AddedDate: time.Now()}
+	require.NoError(t, db.Create(&item).Error)
+	release := models.Release{BookID: book.ID, Title: "Terry.Pratchett-Mort.m4b", Indexer: "test-indexer"}
+	require.NoError(t, db.Create(&release).Error)
+	download := models.Download{
+		LibraryItemID:   item.ID,
+		ReleaseID:       release.ID,
+		Status:          models.DownloadStatusFailed,
+		DownloadPath:    "/downloads/Mort",
+		QBittorrentHash: "abc123",
+	}
+	require.NoError(t, db.Create(&download).Error)
+	return download
+}
+
+func TestMessageFor(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, nil)
+	download := createDownloadFixture(t, db)
+	task := models.ProcessingTask{DownloadID: download.ID, InputPath: "/downloads/Mort", Status: models.ProcessingStatusFailed}
+	require.NoError(t, db.Create(&task).Error)
+
+	t.Run("Grab", func(t *testing.T) {
+		msg := service.messageFor(events.Event{Type: events.DownloadGrabbed, Data: events.DownloadPayload{DownloadID: download.ID, LibraryItemID: download.LibraryItemID}})
+		require.NotNil(t, msg)
+		assert.Equal(t, EventGrab, msg.Event)
+		assert.Equal(t, "Grabbed Mort by Terry Pratchett", msg.Body)
+		assert.Equal(t, "Terry.Pratchett-Mort.m4b", msg.ReleaseTitle)
+		assert.Equal(t, "abc123", msg.DownloadHash)
+		assert.Equal(t, download.LibraryItemID, msg.LibraryItemID)
+	})
+
+	t.Run("Download failed", func(t *testing.T) {
+		msg := service.messageFor(events.Event{Type: events.DownloadFailed, Data: events.DownloadPayload{DownloadID: download.ID, LibraryItemID: download.LibraryItemID, Error: "stalled"}})
+		require.NotNil(t, msg)
+		assert.Equal(t, EventFailure, msg.Event)
+		assert.Equal(t, "Download of Mort by Terry Pratchett failed", msg.Body)
+		assert.Equal(t, "stalled", msg.Error)
+	})
+
+	t.Run("Import failed", func(t *testing.T) {
+		msg := service.messageFor(events.Event{Type: events.ProcessingTaskFailed, Data: events.ProcessingPayload{TaskID: task.ID, DownloadID: download.ID, Error: "disk full"}})
+		require.NotNil(t, msg)
+		assert.Equal(t, EventFailure, msg.Event)
+		assert.Equal(t, "Import of Mort by Terry Pratchett failed", msg.Body)
+		assert.Equal(t, "/downloads/Mort", msg.Path)
+	})
+
+	t.Run("Import and upgrade", func(t *testing.T) {
+		payload := events.LibraryItemPayload{LibraryItemID: download.LibraryItemID, FilePath: "/library/Mort"}
+		msg := service.messageFor(events.Event{Type: events.LibraryItemImported, Data: payload})
+		require.NotNil(t, msg)
+		assert.Equal(t, EventImport, msg.Event)
+		assert.Equal(t, "Imported Mort by Terry Pratchett", msg.Body)
+
+		msg = service.messageFor(events.Event{Type: events.LibraryItemUpgraded, Data: payload})
+		require.NotNil(t, msg)
+		assert.Equal(t, EventUpgrade, msg.Event)
+		assert.Equal(t, "/library/Mort", msg.Path)
+		assert.Empty(t, msg.DownloadHash, "no download")
+
+		payload.DownloadID = download.ID
+		msg = service.messageFor(events.Event{Type: events.LibraryItemImported, Data: payload})
+		require.NotNil(t, msg)
+		assert.Equal(t, "abc123", msg.DownloadHash)
+		assert.Equal(t, "Terry.Pratchett-Mort.m4b", msg.ReleaseTitle)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		msg := service.messageFor(events.Event{Type: events.LibraryItemRemoved, Data: events.LibraryItemPayload{LibraryItemID: download.LibraryItemID, FilePath: "/library/Mort"}})
+		require.NotNil(t, msg)
+		assert.Equal(t, EventDelete, msg.Event)
+		assert.Equal(t, "Deleted Mort by Terry Pratchett", msg.Body)
+		assert.Equal(t, "/library/Mort", msg.Path)
+	})
+
+	t.Run("Health issue", func(t *testing.T) {
+		msg := service.messageFor(events.Event{Type: events.HealthIssue, Data: events.HealthPayload{Source: "disk_space", Level: "warning", Message: "Library disk is almost full"}})
+		require.NotNil(t, msg)
+		assert.Equal(t, EventHealthIssue, msg.Event)
+		assert.Equal(t, "Library disk is almost full", msg.Body)
+	})
+
+	t.Run("Ignored events", func(t *testing.T) {
+		assert.Nil(t, service.messageFor(events.Event{Type: events.DownloadProgress, Data: events.DownloadPayload{DownloadID: download.ID}}))
+		assert.Nil(t, service.messageFor(events.Event{Type: events.LibraryItemAvailable, Data: events.LibraryItemPayload{LibraryItemID: download.LibraryItemID}}))
+	})
+}
+
+func TestSend_Subscriptions(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, nil)
+
+	imports := newHTTPStandIn(t, http.StatusOK)
+	grabs := newHTTPStandIn(t, http.StatusOK)
+	broken := newHTTPStandIn(t, http.StatusBadGateway)
+
+	for _, n := range []*models.Notification{
+		{Name: "Imports", OnImport: true, Enabled: true},
+		{Name: "Grabs", OnGrab: true, Enabled: true},
+		{Name: "Disabled", OnImport: true},
+		{Name: "Broken", OnImport: true, Enabled: true},
+	} {
+		n.Type = models.NotificationTypeWebhook
+		switch n.Name {
+		case "Imports", "Disabled":
+			n.SetSettings(models.NotificationSettings{URL: imports.URL})
+		case "Grabs":
+			n.SetSettings(models.NotificationSettings{URL: grabs.URL})
+		case "Broken":
+			n.SetSettings(models.NotificationSettings{URL: broken.URL})
+		}
+		require.NoError(t, db.Create(n).Error)
+	}
+
+	service.Send(importMessage())
+
+	require.Len(t, imports.Requests(), 1, "disabled notifications are skipped")
+	assert.Empty(t, grabs.Requests())
+	var body Message
+	require.NoError(t, json.Unmarshal([]byte(imports.Requests()[0].body), &body))
+	assert.Equal(t, "Imported Mort by Terry Pratchett", body.Body)
+
+	var entry models.History
+	require.NoError(t, db.Where("event_type = ?", models.HistoryEventNotificationFailed).First(&entry).Error)
+	assert.Contains(t, entry.Message, "Broken: webhook request failed with status 502")
+}
+
+func TestSend_Script(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, nil)
+
+	for _, n := range []*models.Notification{
+		{Name: "Hook", OnDelete: true, Enabled: true},
+		{Name: "Broken hook", OnDelete: true, Enabled: true},
+	} {
+		n.Type = models.NotificationTypeScript
+		body := "echo \"deleted $LISTENARR_BOOK_TITLE\"\n"
+		if n.Name == "Broken hook" {
+			body = "echo 'no space left' >&2\nexit 1\n"
+		}
+		n.SetSettings(models.NotificationSettings{Path: writeScript(t, body)})
+		require.NoError(t, db.Create(n).Error)
+	}
+
+	author := models.Author{Name: "Terry Pratchett"}
+	require.NoError(t, db.Create(&author).Error)
+	book := models.Book{Title: "Mort", AuthorID: author.ID}
+	require.NoError(t, db.Create(&book).Error)
+	service.Send(&Message{Event: EventDelete, Title: "Deleted", Body: "Deleted Mort", Book: "Mort", BookID: book.ID, DownloadHash: "abc123"})
+
+	var entries []models.History
+	require.NoError(t, db.Order("id").Find(&entries).Error)
+	require.Len(t, entries, 2, "script failures are not recorded twice")
+	for _, entry := range entries {
+		assert.Equal(t, models.HistoryEventScriptRan, entry.EventType)
+		assert.Equal(t, "abc123", entry.DownloadHash)
+		require.NotNil(t, entry.BookID)
+		assert.Equal(t, book.ID, *entry.BookID)
+	}
+	assert.Contains(t, entries[0].Message, "Hook: exited with status 0")
+	assert.Contains(t, entries[0].Message, "stdout:\ndeleted Mort")
+	assert.Equal(t, "Broken hook: script exited with status 1 for delete\nstderr:\nno space left", entries[1].Message)
+}
+
+func TestRun(t *testing.T) {
+	db := setupTestDB(t)
+	bus := events.NewBus()
+	service := NewService(db, bus)
+
+	server := newHTTPStandIn(t, http.StatusOK)
+	n := models.Notification{Name: "Health", Type: models.NotificationTypeWebhook, Enabled: true, OnHealthIssue: true}
+	n.SetSettings(models.NotificationSettings{URL: server.URL})
+	require.NoError(t, db.Create(&n).Error)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		service.Run(stop)
+		close(done)
+	}()
+	require.Eventually(t, func() bool { return bus.SubscriberCount() == 1 }, time.Second, time.Millisecond)
+
+	bus.Publish(events.HealthIssue, events.HealthPayload{Source: "disk_space", Level: "error", Message: "Library disk is full"})
+	require.Eventually(t, func() bool { return len(server.Requests()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	close(stop)
+	<-done
+
+	require.Len(t, server.Requests(), 1)
+	assert.Contains(t, server.Requests()[0].body, `"event":"health_issue"`)
+}
+
+func TestRun_SlowNotification(t *testing.T) {
+	db := setupTestDB(t)
+	bus := events.NewBus()
+	service := NewService(db, bus)
+	download := createDownloadFixture(t, db)
+
+	// A webhook that takes its time with every request
+	var mu sync.Mutex
+	var received []string
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, string(body))
+		mu.Unlock()
+	}))
+	defer slow.Close()
+	defer close(release)
+	n := models.Notification{Name: "Slow", Type: models.NotificationTypeWebhook, Enabled: true, OnGrab: true, OnFailure: true}
+	n.SetSettings(models.NotificationSettings{URL: slow.URL})
+	require.NoError(t, db.Create(&n).Error)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go service.Run(stop)
+	require.Eventually(t, func() bool { return bus.SubscriberCount() == 1 }, time.Second, time.Millisecond)
+
+	// Far more progress at once than the subscription buffers, while the
+	// first grab is still being delivered
+	payload := events.DownloadPayload{DownloadID: download.ID, LibraryItemID: download.LibraryItemID}
+	bus.Publish(events.DownloadGrabbed, payload)
+	for i := 0; i < 320; i++ {
+		bus.Publish(events.DownloadProgress, payload)
+	}
+	bus.Publish(events.DownloadFailed, payload)
+	bus.Publish(events.DownloadGrabbed, payload)
+
+	for i := 0; i < 3; i++ {
+		select {
+		case release <- struct{}{}:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of 3 notifications were sent", i)
+		}
+	}
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 3
+	}, time.Second, time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 3)
+	assert.Contains(t, received[0], `"event":"grab"`)
+	assert.Contains(t, received[1], `"event":"failure"`)
+	assert.Contains(t, received[2], `"event":"grab"`)
+}
+
+func TestTest(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, nil)
+
+	server := newHTTPStandIn(t, http.StatusOK)
+	n := notification(models.NotificationTypeWebhook, models.NotificationSettings{URL: server.URL})
+	n.Name = "My webhook"
+	require.NoError(t, service.Test(n))
+	require.Len(t, server.Requests(), 1)
+	assert.Contains(t, server.Requests()[0].body, `"event":"test"`)
+	assert.Contains(t, server.Requests()[0].body, "My webhook")
+
+	assert.ErrorIs(t, service.Test(notification(models.NotificationTypeWebhook, models.NotificationSettings{})), ErrMissingURL)
+}
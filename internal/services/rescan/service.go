@@ -0,0 +1,347 @@
+// Package rescan checks the library against the files on disk
+package rescan
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/events"
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/history"
+	"github.com/listenarr/listenarr/internal/services/importer"
+)
+
+// ErrLibraryUnavailable is returned when the library folder cannot be read,
+// so that an unmounted disk does not mark every book missing
+var ErrLibraryUnavailable = errors.New("library folder is not available")
+
+// What happens to items whose files are gone
+const (
+	MissingActionMissing = "missing" // Mark them missing until the files come back
+	MissingActionWanted  = "wanted"  // Put them back on the wanted list
+)
+
+// Config holds configuration for the rescan service
+type Config struct {
+	LibraryPath   string
+	MissingAction string
+}
+
+// ItemReport describes a library item whose files changed on disk
+type ItemReport struct {
+	LibraryItemID uint   `json:"library_item_id"`
+	BookID        uint   `json:"book_id"`
+	Title         string `json:"title"`
+	FilePath      string `json:"file_path"`
+	ExpectedSize  int64  `json:"expected_size,omitempty"`
+	ActualSize    int64  `json:"actual_size,omitempty"`
+}
+
+// Result describes a completed rescan
+type Result struct {
+	StartedAt   time.Time    `json:"started_at"`
+	CompletedAt time.Time    `json:"completed_at"`
+	Checked     int          `json:"checked"`
+	Missing     []ItemReport `json:"missing"`
+	Changed     []ItemReport `json:"changed"`
+	Restored    []ItemReport `json:"restored"`
+	ExtraFiles  []string     `json:"extra_files"` // Audio files in the library folder that belong to no library item
+}
+
+// Summary describes the result in one line
+func (r *Result) Summary() string {
+	return fmt.Sprintf("Checked %d item(s): %d missing, %d changed, %d restored, %d extra file(s)",
+		r.Checked, len(r.Missing), len(r.Changed), len(r.Restored), len(r.ExtraFiles))
+}
+
+// Service reconciles library items with the files on disk
+type Service struct {
+	db      *gorm.DB
+	config  Config
+	history *history.Service
+	events  *events.Bus
+
+	running sync.Mutex // Held for the duration of a rescan
+	mu      sync.Mutex // Guards last
+	last    *Result
+}
+
+// NewService creates a new rescan service.
+// bus may be nil when nobody listens for library events.
+func NewService(db *gorm.DB, bus *events.Bus, config Config) *Service {
+	if config.MissingAction == "" {
+		config.MissingAction = MissingActionMissing
+	}
+	return &Service{
+		db:      db,
+		config:  config,
+		history: history.NewService(db),
+		events:  bus,
+	}
+}
+
+// LastResult returns the result of the last rescan, or nil before the first
+func (s *Service) LastResult() *Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Rescan checks every available and missing library item against its files.
+// Items whose files are gone are marked missing or wanted again, items
+// whose size changed get the new size, and missing items whose files are
+// back become available. Every change and a summary are recorded in
+// history. Concurrent calls wait for the running rescan.
+func (s *Service) Rescan() (*Result, error) {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	if s.config.LibraryPath != "" {
+		if _, err := os.Stat(s.config.LibraryPath); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrLibraryUnavailable, err)
+		}
+	}
+
+	result := &Result{
+		StartedAt:  time.Now(),
+		Missing:    []ItemReport{},
+		Changed:    []ItemReport{},
+		Restored:   []ItemReport{},
+		ExtraFiles: []string{},
+	}
+
+	var items []models.LibraryItem
+	err := s.db.Preload("Book").
+		Where("status IN ? AND file_path <> ''", []models.LibraryItemStatus{
+			models.LibraryItemStatusAvailable,
+			models.LibraryItemStatusMissing,
+		}).
+		Order("id").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		item := &items[i]
+		result.Checked++
+
+		size, err := audioSize(item.FilePath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			if item.IsAvailable() {
+				result.Missing = append(result.Missing, s.markMissing(item))
+			}
+		case err != nil:
+			log.Printf("rescan: failed to check %s: %v", item.FilePath, err)
+		case item.Status == models.LibraryItemStatusMissing:
+			result.Restored = append(result.Restored, s.markRestored(item, size))
+		case size != item.FileSize:
+			report := s.updateSize(item, size)
+			// Items imported before sizes were recorded are not reported
+			if report.ExpectedSize != 0 {
+				result.Changed = append(result.Changed, report)
+			}
+		}
+	}
+
+	if s.config.LibraryPath != "" {
+		extra, err := s.findExtraFiles()
+		if err != nil {
+			log.Printf("rescan: failed to look for extra files: %v", err)
+		}
+		result.ExtraFiles = append(result.ExtraFiles, extra...)
+	}
+
+	result.CompletedAt = time.Now()
+	// History is best effort and never fails the rescan
+	_ = s.history.Record(&models.History{
+		EventType:  models.HistoryEventLibraryRescanned,
+		SourcePath: s.config.LibraryPath,
+		Message:    result.Summary(),
+	})
+
+	s.mu.Lock()
+	s.last = result
+	s.mu.Unlock()
+
+	return result, nil
+}
+
+// markMissing applies the missing action to an item whose files are gone
+func (s *Service) markMissing(item *models.LibraryItem) ItemReport {
+	report := reportFor(item)
+
+	message := "Files no longer on disk; marked missing"
+	if s.config.MissingAction == MissingActionWanted {
+		message = "Files no longer on disk; wanted again"
+		item.Status = models.LibraryItemStatusWanted
+		item.FilePath = ""
+		item.FileSize = 0
+		item.CompletedDate = nil
+	} else {
+		item.Status = models.LibraryItemStatusMissing
+	}
+	if err := s.db.Omit("Book").Save(item).Error; err != nil {
+		log.Printf("rescan: failed to update library item %d: %v", item.ID, err)
+	}
+
+	_ = s.history.Record(&models.History{
+		EventType:       models.HistoryEventFileMissing,
+		LibraryItemID:   &item.ID,
+		BookID:          &item.BookID,
+		DestinationPath: report.FilePath,
+		Message:         message,
+	})
+	s.events.Publish(events.LibraryItemMissing, events.LibraryItemPayload{
+		LibraryItemID: item.ID,
+		BookID:        item.BookID,
+		Status:        string(item.Status),
+		FilePath:      report.FilePath,
+	})
+
+	return report
+}
+
+// markRestored makes a missing item whose files are back available again
+func (s *Service) markRestored(item *models.LibraryItem, size int64) ItemReport {
+	report := reportFor(item)
+	report.ActualSize = size
+
+	item.Status = models.LibraryItemStatusAvailable
+	item.FileSize = size
+	if err := s.db.Omit("Book").Save(item).Error; err != nil {
+		log.Printf("rescan: failed to update library item %d: %v", item.ID, err)
+	}
+
+	_ = s.history.Record(&models.History{
+		EventType:       models.HistoryEventFileRestored,
+		LibraryItemID:   &item.ID,
+		BookID:          &item.BookID,
+		DestinationPath: item.FilePath,
+		Message:         "Files are back on disk",
+	})
+	s.events.Publish(events.LibraryItemAvailable, events.LibraryItemPayload{
+		LibraryItemID: item.ID,
+		BookID:        item.BookID,
+		Status:        string(item.Status),
+		FilePath:      item.FilePath,
+	})
+
+	return report
+}
+
+// updateSize records the size an item's files have on disk now
+func (s *Service) updateSize(item *models.LibraryItem, size int64) ItemReport {
+	report := reportFor(item)
+	report.ActualSize = size
+
+	item.FileSize = size
+	if err := s.db.Omit("Book").Save(item).Error; err != nil {
+		log.Printf("rescan: failed to update library item %d: %v", item.ID, err)
+	}
+
+	if report.ExpectedSize != 0 {
+		_ = s.history.Record(&models.History{
+			EventType:       models.HistoryEventFileChanged,
+			LibraryItemID:   &item.ID,
+			BookID:          &item.BookID,
+			DestinationPath: item.FilePath,
+			Message:         fmt.Sprintf("Size changed from %d to %d bytes", report.ExpectedSize, size),
+		})
+	}
+
+	return report
+}
+
+// findExtraFiles returns the audio files in the library folder that are not
+// part of any library item. Hidden folders are skipped.
+func (s *Service) findExtraFiles() ([]string, error) {
+	var known []string
+	if err := s.db.Model(&models.LibraryItem{}).Where("file_path <> ''").Pluck("file_path", &known).Error; err != nil {
+		return nil, err
+	}
+
+	var extra []string
+	err := filepath.WalkDir(s.config.LibraryPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != s.config.LibraryPath && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if importer.IsAudioFile(path) && !within(path, known) {
+			extra = append(extra, path)
+		}
+		return nil
+	})
+	return extra, err
+}
+
+// reportFor describes an item as it is recorded before the rescan
+func reportFor(item *models.LibraryItem) ItemReport {
+	return ItemReport{
+		LibraryItemID: item.ID,
+		BookID:        item.BookID,
+		Title:         item.Book.Title,
+		FilePath:      item.FilePath,
+		ExpectedSize:  item.FileSize,
+	}
+}
+
+// audioSize returns the size of the file at path, or the total size of the
+// audio files in the folder at path. A folder without audio files counts
+// as not existing.
+func audioSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		return info.Size(), nil
+	}
+
+	var size int64
+	files := 0
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !importer.IsAudioFile(p) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += fi.Size()
+		files++
+		return nil
+	})
+	if err == nil && files == 0 {
+		err = fmt.Errorf("%w: no audio files in %s", fs.ErrNotExist, path)
+	}
+	return size, err
+}
+
+// within reports whether path is, or is inside, one of the given paths
+func within(path string, paths []string) bool {
+	for _, p := range paths {
+		if path == p || strings.HasPrefix(path, p+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package rescan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/events"
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
+
+	err := db.AutoMigrate(
+		&models.Author{},
+		&models.Book{},
+		&models.LibraryItem{},
+		&models.History{},
+	)
+	require.NoError(t, err)
+
+	return db
+}
+
+// writeFile creates a file with the given content and any missing folders
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+// createItem creates an available library item for a book with the given
+// title whose files are at path
+func createItem(t *testing.T, db *gorm.DB, title, path string, size int64) models.LibraryItem {
+	author := models.Author{Name: "Terry Pratchett"}
+	require.NoError(t, db.FirstOrCreate(&author, author).Error)
+	book := models.Book{Title: title, AuthorID: author.ID}
+	require.NoError(t, db.Create(&book).Error)
+	now := time.Now()
+	item := models.LibraryItem{
+		BookID:        book.ID,
+		Status:        models.LibraryItemStatusAvailable,
+		FilePath:      path,
+		FileSize:      size,
+		AddedDate:     now,
+		CompletedDate: &now,
+	}
+	require.NoError(t, db.Create(&item).Error)
+	return item
+}
+
+func TestRescan(t *testing.T) {
+	db := setupTestDB(t)
+	library := t.TempDir()
+	bus := events.NewBus()
+	sub, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	mort := filepath.Join(library, "Terry Pratchett", "Mort")
+	writeFile(t, filepath.Join(mort, "01.mp3"), "audio")
+	writeFile(t, filepath.Join(mort, "02.mp3"), "audio")
+	writeFile(t, filepath.Join(mort, "cover.jpg"), "image")
+	mortItem := createItem(t, db, "Mort", mort, 10)
+
+	guards := filepath.Join(library, "Terry Pratchett", "Guards! Guards!", "guards.m4b")
+	writeFile(t, guards, "longer audio")
+	guardsItem := createItem(t, db, "Guards! Guards!", guards, 5)
+
+	sourcery := filepath.Join(library, "Terry Pratchett", "Sourcery", "sourcery.m4b")
+	sourceryItem := createItem(t, db, "Sourcery", sourcery, 5)
+
+	extra := filepath.Join(library, "Terry Pratchett", "Eric.m4b")
+	writeFile(t, extra, "audio")
+	writeFile(t, filepath.Join(library, ".trash", "old.mp3"), "audio")
+
+	service := NewService(db, bus, Config{LibraryPath: library})
+	assert.Nil(t, service.LastResult())
+
+	result, err := service.Rescan()
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Checked)
+	assert.Equal(t, []string{extra}, result.ExtraFiles)
+
+	require.Len(t, result.Missing, 1)
+	assert.Equal(t, sourceryItem.ID, result.Missing[0].LibraryItemID)
+	assert.Equal(t, "Sourcery", result.Missing[0].Title)
+
+	require.Len(t, result.Changed, 1)
+	assert.Equal(t, guardsItem.ID, result.Changed[0].LibraryItemID)
+	assert.Equal(t, int64(5), result.Changed[0].ExpectedSize)
+	assert.Equal(t, int64(12), result.Changed[0].ActualSize)
+	assert.Same(t, result, service.LastResult())
+
+	var missing, changed, unchanged models.LibraryItem
+	require.NoError(t, db.First(&missing, sourceryItem.ID).Error)
+	assert.Equal(t, models.LibraryItemStatusMissing, missing.Status)
+	assert.Equal(t, sourcery, missing.FilePath, "path is kept so the files can be found again")
+	require.NoError(t, db.First(&changed, guardsItem.ID).Error)
+	assert.Equal(t, int64(12), changed.FileSize)
+	require.NoError(t, db.First(&unchanged, mortItem.ID).Error)
+	assert.Equal(t, models.LibraryItemStatusAvailable, unchanged.Status)
+
+	select {
+	case event := <-sub:
+		assert.Equal(t, events.LibraryItemMissing, event.Type)
+	default:
+		t.Fatal("expected a library.item_missing event")
+	}
+
+	var entries []models.History
+	require.NoError(t, db.Order("id").Find(&entries).Error)
+	require.Len(t, entries, 3)
+	assert.Equal(t, models.HistoryEventFileChanged, entries[0].EventType)
+	assert.Equal(t, models.HistoryEventFileMissing, entries[1].EventType)
+	assert.Equal(t, models.HistoryEventLibraryRescanned, entries[2].EventType)
+	assert.Equal(t, "Checked 3 item(s): 1 missing, 1 changed, 0 restored, 1 extra file(s)", entries[2].Message)
+
+	t.Run("Missing files come back", func(t *testing.T) {
+		writeFile(t, sourcery, "audio")
+
+		result, err := service.Rescan()
+		require.NoError(t, err)
+		assert.Empty(t, result.Missing)
+		assert.Empty(t, result.Changed)
+		require.Len(t, result.Restored, 1)
+		assert.Equal(t, sourceryItem.ID, result.Restored[0].LibraryItemID)
+
+		var item models.LibraryItem
+		require.NoError(t, db.First(&item, sourceryItem.ID).Error)
+		assert.Equal(t, models.LibraryItemStatusAvailable, item.Status)
+		assert.Equal(t, int64(5), item.FileSize)
+	})
+
+	t.Run("Folder without audio files is missing", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(mort, "01.mp3")))
+		require.NoError(t, os.Remove(filepath.Join(mort, "02.mp3")))
+
+		result, err := service.Rescan()
+		require.NoError(t, err)
+		require.Len(t, result.Missing, 1)
+		assert.Equal(t, mortItem.ID, result.Missing[0].LibraryItemID)
+	})
+}
+
+func TestRescan_WantedAction(t *testing.T) {
+	db := setupTestDB(t)
+	library := t.TempDir()
+	path := filepath.Join(library, "Terry Pratchett", "Mort", "mort.m4b")
+	created := createItem(t, db, "Mort", path, 5)
+
+	service := NewService(db, nil, Config{LibraryPath: library, MissingAction: MissingActionWanted})
+	result, err := service.Rescan()
+	require.NoError(t, err)
+	require.Len(t, result.Missing, 1)
+	assert.Equal(t, path, result.Missing[0].FilePath)
+
+	var item models.LibraryItem
+	require.NoError(t, db.First(&item, created.ID).Error)
+	assert.Equal(t, models.LibraryItemStatusWanted, item.Status)
+	assert.Empty(t, item.FilePath)
+	assert.Zero(t, item.FileSize)
+	assert.Nil(t, item.CompletedDate)
+
+	// Wanted items are no longer checked
+	result, err = service.Rescan()
+	require.NoError(t, err)
+	assert.Zero(t, result.Checked)
+}
+
+func TestRescan_LibraryUnavailable(t *testing.T) {
+	db := setupTestDB(t)
+	library := filepath.Join(t.TempDir(), "unmounted")
+	created := createItem(t, db, "Mort", filepath.Join(library, "Mort", "mort.m4b"), 5)
+
+	service := NewService(db, nil, Config{LibraryPath: library})
+	_, err := service.Rescan()
+	assert.ErrorIs(t, err, ErrLibraryUnavailable)
+	assert.Nil(t, service.LastResult())
+
+	var item models.LibraryItem
+	require.NoError(t, db.First(&item, created.ID).Error)
+	assert.Equal(t, models.LibraryItemStatusAvailable, item.Status)
+}
@@ -0,0 +1,535 @@
+// Package scanner finds audiobooks already on disk and imports them into
+// the library after review
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/importer"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+)
+
+// Errors returned by the scanner service
+var (
+	ErrScanRunning       = errors.New("library scan already running")
+	ErrCandidateNotFound = errors.New("import candidate not found")
+	ErrNotPending        = errors.New("import candidate is not pending")
+	ErrBookNotFound      = importer.ErrBookNotFound
+	ErrAlreadyAvailable  = importer.ErrAlreadyAvailable
+	ErrUnidentified      = errors.New("import candidate has no title or author; choose a book")
+)
+
+// discFolder matches the folders a book is split over, such as "CD 1",
+// "Disc2" or "Part 03"
+var discFolder = regexp.MustCompile(`(?i)^(cd|dis[ck]|part)\s*\d+$`)
+
+// MetadataLookup finds book metadata for scanned files
+type MetadataLookup interface {
+	LookupASIN(asin string) (*metadata.BookMetadata, error)
+	Search(query string) ([]metadata.BookMetadata, error)
+}
+
+// Options configures a scan
+type Options struct {
+	Root           string // Folder to scan
+	LookupMetadata bool   // Match candidates without a book against metadata providers
+}
+
+// Status describes the running or last scan
+type Status struct {
+	Running     bool       `json:"running"`
+	Root        string     `json:"root,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Found       int        `json:"found"` // Candidates found, excluding files already in the library
+	Added       int        `json:"added"` // Candidates not seen by an earlier scan
+	Error       string     `json:"error,omitempty"`
+}
+
+// ConfirmOptions configures the import of a candidate
+type ConfirmOptions struct {
+	BookID *uint               // Book the files belong to, overriding the match
+	Mode   importer.ImportMode // How the files get into the library; defaults to in place
+}
+
+// Service scans folders for audiobooks and imports confirmed candidates
+type Service struct {
+	db       *gorm.DB
+	metadata MetadataLookup
+	importer *importer.Service
+
+	mu     sync.Mutex
+	status Status
+}
+
+// NewService creates a new scanner service.
+// lookup may be nil when candidates should only be matched against the database.
+func NewService(db *gorm.DB, lookup MetadataLookup, imports *importer.Service) *Service {
+	return &Service{
+		db:       db,
+		metadata: lookup,
+		importer: imports,
+	}
+}
+
+// Status returns the state of the running or last scan
+func (s *Service) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// Scan walks the root folder and records a pending candidate for every book
+// found that is not already in the library. Candidates that were imported
+// or skipped are left alone; pending ones are refreshed.
+func (s *Service) Scan(opts Options) (Status, error) {
+	if err := s.begin(opts); err != nil {
+		return Status{}, err
+	}
+	err := s.scan(opts)
+	return s.Status(), err
+}
+
+// Start runs a scan in the background, returning ErrScanRunning when one is
+// already running
+func (s *Service) Start(opts Options) error {
+	if err := s.begin(opts); err != nil {
+		return err
+	}
+	go func() {
+		if err := s.scan(opts); err != nil {
+			log.Printf("scanner: scan of %s failed: %v", opts.Root, err)
+		}
+	}()
+	return nil
+}
+
+// begin marks a scan as running
+func (s *Service) begin(opts Options) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status.Running {
+		return ErrScanRunning
+	}
+	now := time.Now()
+	s.status = Status{Running: true, Root: opts.Root, StartedAt: &now}
+	return nil
+}
+
+// scan does the work of a scan and records its outcome in the status
+func (s *Service) scan(opts Options) error {
+	found, added, err := s.scanFolder(opts)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.status.Running = false
+	s.status.CompletedAt = &now
+	s.status.Found = found
+	s.status.Added = added
+	if err != nil {
+		s.status.Error = err.Error()
+	}
+	return err
+}
+
+// scanFolder records the candidates under the root folder, returning how
+// many were found and how many are new
+func (s *Service) scanFolder(opts Options) (int, int, error) {
+	groups, err := findBooks(opts.Root)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var libraryPaths []string
+	if err := s.db.Model(&models.LibraryItem{}).Where("file_path <> ''").Pluck("file_path", &libraryPaths).Error; err != nil {
+		return 0, 0, err
+	}
+
+	found, added := 0, 0
+	for _, group := range groups {
+		if inLibrary(group.path, libraryPaths) {
+			continue
+		}
+
+		var candidate models.ImportCandidate
+		err := s.db.Where("path = ?", group.path).First(&candidate).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return found, added, err
+		}
+		isNew := err == gorm.ErrRecordNotFound
+		if !isNew && !candidate.IsPending() {
+			continue
+		}
+
+		s.identify(&candidate, opts, group)
+		if err := s.db.Save(&candidate).Error; err != nil {
+			return found, added, fmt.Errorf("failed to save candidate %s: %w", group.path, err)
+		}
+		found++
+		if isNew {
+			added++
+		}
+	}
+
+	return found, added, nil
+}
+
+// identify fills a candidate from the tags of its files, falling back to
+// folder names, and matches it against the database and metadata providers
+func (s *Service) identify(candidate *models.ImportCandidate, opts Options, group bookFiles) {
+	title, author := guessFromPath(opts.Root, group.path)
+	*candidate = models.ImportCandidate{
+		ID:        candidate.ID,
+		CreatedAt: candidate.CreatedAt,
+		Path:      group.path,
+		Files:     len(group.files),
+		Size:      group.size,
+		Title:     title,
+		Author:    author,
+		Status:    models.ImportCandidateStatusPending,
+	}
+
+	identification, err := s.importer.Identify(group.path)
+	if err != nil {
+		candidate.Error = err.Error()
+	} else {
+		setString(&candidate.Title, identification.Title)
+		setString(&candidate.Author, identification.Author)
+		candidate.Narrator = identification.Narrator
+		candidate.Series = identification.Series
+		candidate.Sequence = identification.Sequence
+		candidate.ASIN = identification.ASIN
+		candidate.Format = identification.Format
+		candidate.Duration = identification.Duration
+		candidate.Bitrate = identification.Bitrate
+		candidate.BookID = identification.BookID
+	}
+
+	if candidate.BookID != nil || !opts.LookupMetadata || s.metadata == nil {
+		return
+	}
+	if md := s.lookup(candidate); md != nil {
+		if data, err := json.Marshal(md); err == nil {
+			candidate.Metadata = string(data)
+			candidate.MatchSource = md.Source
+		}
+	}
+}
+
+// lookup finds metadata for a candidate by ASIN, then by an exact title
+// match from a title and author search
+func (s *Service) lookup(candidate *models.ImportCandidate) *metadata.BookMetadata {
+	if candidate.ASIN != "" {
+		if md, err := s.metadata.LookupASIN(candidate.ASIN); err == nil {
+			return md
+		}
+	}
+	if candidate.Title == "" {
+		return nil
+	}
+
+	results, err := s.metadata.Search(strings.TrimSpace(candidate.Title + " " + candidate.Author))
+	if err != nil {
+		log.Printf("scanner: metadata search for %s failed: %v", candidate.Path, err)
+		return nil
+	}
+	authors := models.SplitCredits(candidate.Author)
+	for i := range results {
+		if !strings.EqualFold(strings.TrimSpace(results[i].Title), strings.TrimSpace(candidate.Title)) {
+			continue
+		}
+		if len(authors) == 0 || metadata.HasAuthor(&results[i], authors[0]) {
+			return &results[i]
+		}
+	}
+	return nil
+}
+
+// Metadata returns the provider metadata a candidate was matched with, or nil
+func Metadata(candidate *models.ImportCandidate) *metadata.BookMetadata {
+	if candidate.Metadata == "" {
+		return nil
+	}
+	var md metadata.BookMetadata
+	if err := json.Unmarshal([]byte(candidate.Metadata), &md); err != nil {
+		return nil
+	}
+	return &md
+}
+
+// Skip marks a pending candidate as not to be imported
+func (s *Service) Skip(id uint) (*models.ImportCandidate, error) {
+	candidate, err := s.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	candidate.Status = models.ImportCandidateStatusSkipped
+	if err := s.db.Save(candidate).Error; err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+// Confirm imports a pending candidate. The book is the one given, the one
+// matched, or one created from the matched metadata or the tags. The files
+// stay where they are unless opts.Mode says otherwise.
+func (s *Service) Confirm(id uint, opts ConfirmOptions) (*importer.Result, error) {
+	candidate, err := s.pending(id)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := s.resolveBook(candidate, opts.BookID)
+	if err != nil {
+		return nil, err
+	}
+	candidate.BookID = &book.ID
+
+	mode := opts.Mode
+	if mode == "" {
+		mode = importer.ImportModeInPlace
+	}
+	result, err := s.importer.ImportBook(book.ID, candidate.Path, mode)
+	if err != nil {
+		candidate.Error = err.Error()
+		s.db.Save(candidate)
+		return nil, err
+	}
+
+	candidate.Status = models.ImportCandidateStatusImported
+	candidate.LibraryItemID = &result.LibraryItemID
+	candidate.Error = ""
+	if err := s.db.Save(candidate).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pending returns the pending candidate with the given ID
+func (s *Service) pending(id uint) (*models.ImportCandidate, error) {
+	var candidate models.ImportCandidate
+	if err := s.db.First(&candidate, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrCandidateNotFound
+		}
+		return nil, err
+	}
+	if !candidate.IsPending() {
+		return nil, ErrNotPending
+	}
+	return &candidate, nil
+}
+
+// resolveBook returns the candidate's book with its author, creating the
+// author and book when no book was given or matched
+func (s *Service) resolveBook(candidate *models.ImportCandidate, bookID *uint) (*models.Book, error) {
+	if bookID == nil {
+		bookID = candidate.BookID
+	}
+	if bookID != nil {
+		var book models.Book
+		if err := s.db.Preload("Author").First(&book, *bookID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, ErrBookNotFound
+			}
+			return nil, err
+		}
+		return &book, nil
+	}
+
+	md := Metadata(candidate)
+	title := candidate.Title
+	authors := models.SplitCredits(candidate.Author)
+	var narrators []string
+	if md != nil {
+		title = md.Title
+		authors = md.Authors
+		narrators = md.Narrators
+	} else {
+		narrators = models.SplitCredits(candidate.Narrator)
+	}
+	if title == "" || len(authors) == 0 {
+		return nil, ErrUnidentified
+	}
+
+	var book *models.Book
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		book, err = createBook(tx, candidate, md, title, authors, narrators)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create book: %w", err)
+	}
+	return book, nil
+}
+
+// createBook finds or creates the author and book described by a candidate
+func createBook(tx *gorm.DB, candidate *models.ImportCandidate, md *metadata.BookMetadata, title string, authors, narrators []string) (*models.Book, error) {
+	author, err := models.FindOrCreateAuthor(tx, authors[0])
+	if err != nil {
+		return nil, err
+	}
+
+	described := metadata.BookMetadata{
+		Title:     title,
+		Authors:   authors,
+		Narrators: narrators,
+		Series:    []metadata.SeriesInfo{{Name: candidate.Series, Position: candidate.Sequence}},
+		ASIN:      candidate.ASIN,
+	}
+	if md != nil {
+		described = *md
+		if described.ASIN == "" {
+			described.ASIN = candidate.ASIN
+		}
+	}
+
+	book, _, err := metadata.FindOrCreateBook(tx, author, &described)
+	return book, err
+}
+
+// bookFiles is a group of audio files holding one book
+type bookFiles struct {
+	path  string // Book folder, or the file itself
+	files []string
+	size  int64
+}
+
+// findBooks groups the audio files under root into books. Each folder of
+// audio files is a book, including any disc folders below it. Files
+// directly in root, and files in a folder holding several M4B files or
+// other books, are books of their own. Hidden folders are skipped.
+func findBooks(root string) ([]bookFiles, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a folder", root)
+	}
+
+	folders := make(map[string][]string)
+	sizes := make(map[string]int64)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !importer.IsAudioFile(path) {
+			return nil
+		}
+
+		folder := filepath.Dir(path)
+		for folder != root && discFolder.MatchString(filepath.Base(folder)) {
+			folder = filepath.Dir(folder)
+		}
+		folders[folder] = append(folders[folder], path)
+		if fi, err := d.Info(); err == nil {
+			sizes[path] = fi.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A folder is split into single files when another book is below it
+	nested := make(map[string]bool)
+	for folder := range folders {
+		for parent := filepath.Dir(folder); len(parent) > len(root); parent = filepath.Dir(parent) {
+			if _, ok := folders[parent]; ok {
+				nested[parent] = true
+			}
+		}
+	}
+
+	var books []bookFiles
+	for folder, files := range folders {
+		sort.Strings(files)
+		if folder == root || nested[folder] || severalBooks(files) {
+			for _, file := range files {
+				books = append(books, bookFiles{path: file, files: []string{file}, size: sizes[file]})
+			}
+			continue
+		}
+		group := bookFiles{path: folder, files: files}
+		for _, file := range files {
+			group.size += sizes[file]
+		}
+		books = append(books, group)
+	}
+
+	sort.Slice(books, func(i, j int) bool { return books[i].path < books[j].path })
+	return books, nil
+}
+
+// severalBooks reports whether files in one folder are separate books:
+// several M4B files, each a whole book
+func severalBooks(files []string) bool {
+	if len(files) < 2 {
+		return false
+	}
+	for _, file := range files {
+		if !strings.EqualFold(filepath.Ext(file), ".m4b") {
+			return false
+		}
+	}
+	return true
+}
+
+// inLibrary reports whether path is, or is inside, a library item's path
+func inLibrary(path string, libraryPaths []string) bool {
+	for _, libraryPath := range libraryPaths {
+		if path == libraryPath || strings.HasPrefix(path, libraryPath+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// guessFromPath returns the title and author suggested by a book's path:
+// <author>/[<series>/]<title>, or a "<author> - <title>" name
+func guessFromPath(root, path string) (string, string) {
+	name := filepath.Base(path)
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	if rel, err := filepath.Rel(root, path); err == nil {
+		if parts := strings.Split(rel, string(filepath.Separator)); len(parts) > 1 {
+			return name, parts[0]
+		}
+	}
+	if author, title, ok := strings.Cut(name, " - "); ok {
+		return strings.TrimSpace(title), strings.TrimSpace(author)
+	}
+	return name, ""
+}
+
+// setString assigns value to dst when value is set
+func setString(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
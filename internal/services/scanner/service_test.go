@@ -0,0 +1,332 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/importer"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+)
+
+// fakeLookup serves fixed metadata and records the searches made
+type fakeLookup struct {
+	asins    map[string]*metadata.BookMetadata
+	results  []metadata.BookMetadata
+	searches []string
+}
+
+func (f *fakeLookup) LookupASIN(asin string) (*metadata.BookMetadata, error) {
+	if md, ok := f.asins[asin]; ok {
+		return md, nil
+	}
+	return nil, metadata.ErrNotFound
+}
+
+func (f *fakeLookup) Search(query string) ([]metadata.BookMetadata, error) {
+	f.searches = append(f.searches, query)
+	return f.results, nil
+}
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
+
+	err := db.AutoMigrate(
+		&models.Author{},
+		&models.Series{},
+		&models.Book{},
+		&models.BookSeries{},
+		&models.Narrator{},
+		&models.BookContributor{},
+		&models.Audiobook{},
+		&models.LibraryItem{},
+		&models.History{},
+		&models.ImportCandidate{},
+	)
+	require.NoError(t, err)
+
+	return db
+}
+
+// mp3Data returns an MP3 file with one second of audio and ID3v2.3 text
+// frames given as ID and value pairs
+func mp3Data(frames ...string) string {
+	var tag []byte
+	for i := 0; i+1 < len(frames); i += 2 {
+		tag = append(tag, frames[i]...)
+		tag = append(tag, 0, 0, 0, byte(len(frames[i+1])+1), 0, 0, 0)
+		tag = append(tag, frames[i+1]...)
+	}
+	tag = append([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, byte(len(tag))}, tag...)
+
+	audio := make([]byte, 16000)
+	copy(audio, []byte{0xff, 0xfb, 0x90, 0x64})
+	return string(append(tag, audio...))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func newTestService(db *gorm.DB, lookup MetadataLookup, library string) *Service {
+	imports := importer.NewService(db, nil, nil, nil, importer.Config{LibraryPath: library})
+	return NewService(db, lookup, imports)
+}
+
+func TestFindBooks(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "Loose Book.m4b"), "audio")
+	writeFile(t, filepath.Join(root, "Terry Pratchett", "Mort", "01.mp3"), "one")
+	writeFile(t, filepath.Join(root, "Terry Pratchett", "Mort", "CD 2", "02.mp3"), "two")
+	writeFile(t, filepath.Join(root, "Terry Pratchett", "Mort", "cover.jpg"), "image")
+	writeFile(t, filepath.Join(root, "Brandon Sanderson", "Mistborn", "Book 1.m4b"), "one")
+	writeFile(t, filepath.Join(root, "Brandon Sanderson", "Mistborn", "Book 2.m4b"), "two")
+	writeFile(t, filepath.Join(root, "Neil Gaiman", "Stardust.mp3"), "audio")
+	writeFile(t, filepath.Join(root, "Neil Gaiman", "Coraline", "Coraline.mp3"), "audio")
+	writeFile(t, filepath.Join(root, ".trash", "Deleted.mp3"), "audio")
+
+	books, err := findBooks(root)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, book := range books {
+		paths = append(paths, book.path)
+	}
+	assert.Equal(t, []string{
+		filepath.Join(root, "Brandon Sanderson", "Mistborn", "Book 1.m4b"),
+		filepath.Join(root, "Brandon Sanderson", "Mistborn", "Book 2.m4b"),
+		filepath.Join(root, "Loose Book.m4b"),
+		filepath.Join(root, "Neil Gaiman", "Coraline"),
+		filepath.Join(root, "Neil Gaiman", "Stardust.mp3"),
+		filepath.Join(root, "Terry Pratchett", "Mort"),
+	}, paths)
+
+	mort := books[5]
+	assert.Len(t, mort.files, 2, "disc folders belong to the book")
+	assert.Equal(t, int64(6), mort.size)
+
+	_, err = findBooks(filepath.Join(root, "Loose Book.m4b"))
+	assert.Error(t, err)
+}
+
+func TestGuessFromPath(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "Neil Gaiman - Stardust.mp3"), "audio")
+
+	title, author := guessFromPath(root, filepath.Join(root, "Terry Pratchett", "Discworld", "Mort"))
+	assert.Equal(t, "Mort", title)
+	assert.Equal(t, "Terry Pratchett", author)
+
+	title, author = guessFromPath(root, filepath.Join(root, "Neil Gaiman - Stardust.mp3"))
+	assert.Equal(t, "Stardust", title)
+	assert.Equal(t, "Neil Gaiman", author)
+}
+
+func TestScan(t *testing.T) {
+	db := setupTestDB(t)
+	root := t.TempDir()
+
+	// Already in the database, matched by its tags
+	author := models.Author{Name: "Terry Pratchett"}
+	require.NoError(t, db.Create(&author).Error)
+	mort := models.Book{Title: "Mort", AuthorID: author.ID}
+	require.NoError(t, db.Create(&mort).Error)
+	writeFile(t, filepath.Join(root, "Terry Pratchett", "Mort", "01.mp3"), mp3Data("TALB", "Mort", "TPE1", "Terry Pratchett"))
+
+	// Matched by metadata search, with the author from the folder name
+	writeFile(t, filepath.Join(root, "Neil Gaiman", "Stardust", "Stardust.mp3"), mp3Data("TALB", "Stardust"))
+
+	// Already in the library
+	library := models.Book{Title: "Guards! Guards!", AuthorID: author.ID}
+	require.NoError(t, db.Create(&library).Error)
+	owned := filepath.Join(root, "Terry Pratchett", "Guards! Guards!")
+	writeFile(t, filepath.Join(owned, "01.mp3"), mp3Data())
+	require.NoError(t, db.Create(&models.LibraryItem{
+		BookID: library.ID, Status: models.LibraryItemStatusAvailable, FilePath: owned, AddedDate: time.Now(),
+	}).Error)
+
+	lookup := &fakeLookup{results: []metadata.BookMetadata{
+		{Source: "audnexus", Title: "Stardust", Authors: []string{"Someone Else"}},
+		{Source: "audnexus", Title: "Stardust", Authors: []string{"Neil Gaiman"}, ASIN: "B0036NCYKM"},
+	}}
+	service := newTestService(db, lookup, "")
+
+	status, err := service.Scan(Options{Root: root, LookupMetadata: true})
+	require.NoError(t, err)
+	assert.False(t, status.Running)
+	assert.Equal(t, 2, status.Found)
+	assert.Equal(t, 2, status.Added)
+	assert.NotNil(t, status.CompletedAt)
+	assert.Equal(t, []string{"Stardust Neil Gaiman"}, lookup.searches, "matched books are not looked up")
+
+	var candidates []models.ImportCandidate
+	require.NoError(t, db.Order("path").Find(&candidates).Error)
+	require.Len(t, candidates, 2)
+
+	stardust := candidates[0]
+	assert.Equal(t, "Stardust", stardust.Title)
+	assert.Equal(t, "Neil Gaiman", stardust.Author)
+	assert.Nil(t, stardust.BookID)
+	assert.Equal(t, "audnexus", stardust.MatchSource)
+	assert.Equal(t, "B0036NCYKM", Metadata(&stardust).ASIN)
+	assert.Equal(t, models.ImportCandidateStatusPending, stardust.Status)
+
+	matched := candidates[1]
+	assert.Equal(t, filepath.Join(root, "Terry Pratchett", "Mort"), matched.Path)
+	require.NotNil(t, matched.BookID)
+	assert.Equal(t, mort.ID, *matched.BookID)
+	assert.Equal(t, 1, matched.Duration)
+	assert.Equal(t, "mp3", matched.Format)
+
+	// Skipped candidates stay skipped on the next scan
+	_, err = service.Skip(stardust.ID)
+	require.NoError(t, err)
+	status, err = service.Scan(Options{Root: root})
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Found)
+	assert.Equal(t, 0, status.Added)
+
+	db.First(&stardust, stardust.ID)
+	assert.Equal(t, models.ImportCandidateStatusSkipped, stardust.Status)
+	_, err = service.Skip(stardust.ID)
+	assert.ErrorIs(t, err, ErrNotPending)
+}
+
+func TestStart_AlreadyRunning(t *testing.T) {
+	service := newTestService(setupTestDB(t), nil, "")
+	require.NoError(t, service.begin(Options{Root: "/books"}))
+
+	assert.ErrorIs(t, service.Start(Options{Root: "/books"}), ErrScanRunning)
+	assert.True(t, service.Status().Running)
+}
+
+func TestConfirm_InPlace(t *testing.T) {
+	db := setupTestDB(t)
+	root := t.TempDir()
+	folder := filepath.Join(root, "Neil Gaiman", "Stardust")
+	writeFile(t, filepath.Join(folder, "01.mp3"), mp3Data("TALB", "Stardust", "TCOM", "Neil Gaiman"))
+	writeFile(t, filepath.Join(folder, "02.mp3"), mp3Data())
+
+	lookup := &fakeLookup{results: []metadata.BookMetadata{{
+		Source:    "audnexus",
+		Title:     "Stardust",
+		Authors:   []string{"Neil Gaiman"},
+		Narrators: []string{"Neil Gaiman"},
+		Series:    []metadata.SeriesInfo{{Name: "Faerie", Position: "1"}},
+		ASIN:      "B0036NCYKM",
+		Genre:     "Fantasy",
+	}}}
+	service := newTestService(db, lookup, "")
+	_, err := service.Scan(Options{Root: root, LookupMetadata: true})
+	require.NoError(t, err)
+
+	var candidate models.ImportCandidate
+	require.NoError(t, db.First(&candidate).Error)
+
+	result, err := service.Confirm(candidate.ID, ConfirmOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, folder, result.Destination)
+	assert.FileExists(t, filepath.Join(folder, "01.mp3"), "files are not moved")
+
+	var book models.Book
+	require.NoError(t, db.Preload("Author").Preload("SeriesMemberships.Series").First(&book, result.BookID).Error)
+	assert.Equal(t, "Stardust", book.Title)
+	assert.Equal(t, "Neil Gaiman", book.Author.Name)
+	assert.Equal(t, "B0036NCYKM", book.ASIN)
+	assert.Equal(t, "Fantasy", book.Genre)
+	require.Len(t, book.SeriesMemberships, 1)
+	assert.Equal(t, "Faerie", book.SeriesMemberships[0].Series.Name)
+
+	var item models.LibraryItem
+	require.NoError(t, db.First(&item, result.LibraryItemID).Error)
+	assert.Equal(t, models.LibraryItemStatusAvailable, item.Status)
+	assert.Equal(t, folder, item.FilePath)
+
+	var audiobook models.Audiobook
+	require.NoError(t, db.Where("book_id = ?", book.ID).First(&audiobook).Error)
+	assert.Equal(t, "Neil Gaiman", audiobook.Narrator)
+	assert.Equal(t, 2, audiobook.Duration)
+
+	var entry models.History
+	require.NoError(t, db.Where("event_type = ?", models.HistoryEventImported).First(&entry).Error)
+	assert.Equal(t, folder, entry.SourcePath)
+
+	db.First(&candidate, candidate.ID)
+	assert.Equal(t, models.ImportCandidateStatusImported, candidate.Status)
+	assert.Equal(t, &item.ID, candidate.LibraryItemID)
+
+	_, err = service.Confirm(candidate.ID, ConfirmOptions{})
+	assert.ErrorIs(t, err, ErrNotPending)
+	_, err = service.Confirm(999, ConfirmOptions{})
+	assert.ErrorIs(t, err, ErrCandidateNotFound)
+
+	// The imported folder is not offered again
+	status, err := service.Scan(Options{Root: root})
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Found)
+}
+
+func TestConfirm_Move(t *testing.T) {
+	db := setupTestDB(t)
+	root := t.TempDir()
+	library := t.TempDir()
+	source := filepath.Join(root, "Mort.mp3")
+	writeFile(t, source, mp3Data("TALB", "Mort", "TPE1", "Terry Pratchett"))
+
+	service := newTestService(db, nil, library)
+	_, err := service.Scan(Options{Root: root})
+	require.NoError(t, err)
+
+	var candidate models.ImportCandidate
+	require.NoError(t, db.First(&candidate).Error)
+	assert.Nil(t, candidate.BookID)
+
+	result, err := service.Confirm(candidate.ID, ConfirmOptions{Mode: importer.ImportModeMove})
+	require.NoError(t, err)
+	expected := filepath.Join(library, "Terry Pratchett", "Mort", "Mort.mp3")
+	assert.Equal(t, []string{expected}, result.Files)
+	assert.FileExists(t, expected)
+	assert.NoFileExists(t, source)
+}
+
+func TestConfirm_ChosenBook(t *testing.T) {
+	db := setupTestDB(t)
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "untagged.mp3"), mp3Data())
+	writeFile(t, filepath.Join(root, "again.mp3"), mp3Data())
+
+	author := models.Author{Name: "Terry Pratchett"}
+	require.NoError(t, db.Create(&author).Error)
+	book := models.Book{Title: "Mort", AuthorID: author.ID}
+	require.NoError(t, db.Create(&book).Error)
+
+	service := newTestService(db, nil, "")
+	_, err := service.Scan(Options{Root: root})
+	require.NoError(t, err)
+
+	var candidates []models.ImportCandidate
+	require.NoError(t, db.Order("path").Find(&candidates).Error)
+	require.Len(t, candidates, 2)
+
+	// A root file has no author to create a book from
+	_, err = service.Confirm(candidates[0].ID, ConfirmOptions{})
+	assert.ErrorIs(t, err, ErrUnidentified)
+	missing := uint(999)
+	_, err = service.Confirm(candidates[0].ID, ConfirmOptions{BookID: &missing})
+	assert.ErrorIs(t, err, ErrBookNotFound)
+
+	result, err := service.Confirm(candidates[0].ID, ConfirmOptions{BookID: &book.ID})
+	require.NoError(t, err)
+	assert.Equal(t, book.ID, result.BookID)
+
+	_, err = service.Confirm(candidates[1].ID, ConfirmOptions{BookID: &book.ID})
+	assert.ErrorIs(t, err, ErrAlreadyAvailable)
+}
@@ -0,0 +1,322 @@
+// Package scheduler runs the periodic background tasks and keeps their
+// schedule in the database
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+var (
+	// ErrTaskNotFound is returned for names no task is registered under
+	ErrTaskNotFound = errors.New("scheduled task not found")
+
+	// ErrTaskRunning is returned when a task is triggered while it is
+	// already queued or running
+	ErrTaskRunning = errors.New("task is already running")
+)
+
+// Names of the built-in tasks
+const (
+	TaskRefreshAuthors   = "refresh_authors"
+	TaskMonitorDownloads = "monitor_downloads"
+	TaskProcessImports   = "process_imports"
+	TaskLibraryRescan    = "library_rescan"
+	TaskHealthCheck      = "health_check"
+	TaskBackup           = "backup"
+)
+
+// How often due tasks are looked for
+const checkInterval = 30 * time.Second
+
+// How many finished commands are kept for the command queue
+const commandHistory = 50
+
+// Task is a background job the scheduler runs
+type Task struct {
+	Name     string
+	Interval time.Duration // 0 runs the task only when it is triggered
+	Run      func() error
+}
+
+// CommandStatus represents the state of a command in the queue
+type CommandStatus string
+
+const (
+	CommandStatusQueued    CommandStatus = "queued"
+	CommandStatusRunning   CommandStatus = "running"
+	CommandStatusCompleted CommandStatus = "completed"
+	CommandStatusFailed    CommandStatus = "failed"
+)
+
+// What started a command
+const (
+	TriggerScheduled = "scheduled"
+	TriggerManual    = "manual"
+)
+
+// Command is a single run of a task
+type Command struct {
+	ID          uint          `json:"id"`
+	Name        string        `json:"name"`
+	Trigger     string        `json:"trigger"`
+	Status      CommandStatus `json:"status"`
+	QueuedAt    time.Time     `json:"queued_at"`
+	StartedAt   *time.Time    `json:"started_at,omitempty"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+	Duration    int64         `json:"duration"` // Milliseconds
+	Error       string        `json:"error,omitempty"`
+}
+
+// IsActive returns true if the command is queued or running
+func (c *Command) IsActive() bool {
+	return c.Status == CommandStatusQueued || c.Status == CommandStatusRunning
+}
+
+// Service runs registered tasks on their schedule or when triggered, never
+// more than one run of the same task at a time
+type Service struct {
+	db *gorm.DB
+
+	mu       sync.Mutex
+	tasks    map[string]Task
+	commands []*Command // Oldest first
+	nextID   uint
+	wg       sync.WaitGroup
+}
+
+// NewService creates a new scheduler service
+func NewService(db *gorm.DB) *Service {
+	return &Service{
+		db:    db,
+		tasks: make(map[string]Task),
+	}
+}
+
+// Register adds a task and stores its schedule. A task that ran before keeps
+// its last run, and runs next one interval after it.
+func (s *Service) Register(task Task) error {
+	var record models.ScheduledTask
+	err := s.db.Where(models.ScheduledTask{Name: task.Name}).FirstOrInit(&record).Error
+	if err != nil {
+		return err
+	}
+
+	record.Interval = int(task.Interval / time.Second)
+	record.NextRunAt = nil
+	if record.IsEnabled() {
+		next := time.Now().Add(task.Interval)
+		if record.LastRunAt != nil {
+			next = record.LastRunAt.Add(task.Interval)
+		}
+		record.NextRunAt = &next
+	}
+	if err := s.db.Save(&record).Error; err != nil {
+		return fmt.Errorf("failed to save scheduled task %s: %w", task.Name, err)
+	}
+
+	s.mu.Lock()
+	s.tasks[task.Name] = task
+	s.mu.Unlock()
+	return nil
+}
+
+// Tasks returns the schedule of the registered tasks ordered by name
+func (s *Service) Tasks() ([]models.ScheduledTask, error) {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.tasks))
+	for name := range s.tasks {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+
+	var tasks []models.ScheduledTask
+	err := s.db.Where("name IN ?", names).Order("name").Find(&tasks).Error
+	return tasks, err
+}
+
+// Commands returns the queued, running and recently finished commands,
+// newest first
+func (s *Service) Commands() []Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	commands := make([]Command, len(s.commands))
+	for i, command := range s.commands {
+		commands[len(s.commands)-1-i] = *command
+	}
+	return commands
+}
+
+// Trigger queues a run of the named task now
+func (s *Service) Trigger(name string) (*Command, error) {
+	return s.enqueue(name, TriggerManual)
+}
+
+// RunDue queues every task whose next run is due and returns the commands
+func (s *Service) RunDue() []Command {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.tasks))
+	for name := range s.tasks {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+	sort.Strings(names)
+
+	var tasks []models.ScheduledTask
+	if err := s.db.Where("name IN ?", names).Find(&tasks).Error; err != nil {
+		log.Printf("scheduler: failed to load scheduled tasks: %v", err)
+		return nil
+	}
+
+	now := time.Now()
+	var queued []Command
+	for i := range tasks {
+		if !tasks[i].IsDue(now) {
+			continue
+		}
+		command, err := s.enqueue(tasks[i].Name, TriggerScheduled)
+		if err != nil {
+			continue // Still running from the last time
+		}
+		queued = append(queued, *command)
+	}
+	return queued
+}
+
+// Run queues due tasks until stop is closed
+func (s *Service) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	s.RunDue()
+	for {
+		select {
+		case <-ticker.C:
+			s.RunDue()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Wait blocks until every queued and running command has finished
+func (s *Service) Wait() {
+	s.wg.Wait()
+}
+
+// enqueue adds a command for the named task and runs it in the background
+func (s *Service) enqueue(name, trigger string) (*Command, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[name]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	for _, command := range s.commands {
+		if command.Name == name && command.IsActive() {
+			return nil, ErrTaskRunning
+		}
+	}
+
+	s.nextID++
+	command := &Command{
+		ID:       s.nextID,
+		Name:     name,
+		Trigger:  trigger,
+		Status:   CommandStatusQueued,
+		QueuedAt: time.Now(),
+	}
+	s.commands = append(s.commands, command)
+	s.trimCommands()
+
+	s.wg.Add(1)
+	go s.execute(task, command)
+
+	copied := *command
+	return &copied, nil
+}
+
+// execute runs a queued command and records the run on the task's schedule
+func (s *Service) execute(task Task, command *Command) {
+	defer s.wg.Done()
+
+	started := time.Now()
+	s.mu.Lock()
+	command.Status = CommandStatusRunning
+	command.StartedAt = &started
+	s.mu.Unlock()
+
+	err := runTask(task)
+
+	completed := time.Now()
+	duration := completed.Sub(started).Milliseconds()
+	s.mu.Lock()
+	command.CompletedAt = &completed
+	command.Duration = duration
+	command.Status = CommandStatusCompleted
+	if err != nil {
+		command.Status = CommandStatusFailed
+		command.Error = err.Error()
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Printf("scheduler: %s failed: %v", task.Name, err)
+	}
+
+	updates := map[string]interface{}{
+		"last_run_at":   started,
+		"last_duration": duration,
+		"last_error":    "",
+		"next_run_at":   nil,
+	}
+	if err != nil {
+		updates["last_error"] = err.Error()
+	}
+	if task.Interval > 0 {
+		updates["next_run_at"] = started.Add(task.Interval)
+	}
+	err = s.db.Model(&models.ScheduledTask{}).Where("name = ?", task.Name).Updates(updates).Error
+	if err != nil {
+		log.Printf("scheduler: failed to update scheduled task %s: %v", task.Name, err)
+	}
+}
+
+// trimCommands drops the oldest finished commands beyond commandHistory.
+// Must be called with mu held.
+func (s *Service) trimCommands() {
+	excess := len(s.commands) - commandHistory
+	if excess <= 0 {
+		return
+	}
+	kept := s.commands[:0]
+	for _, command := range s.commands {
+		if excess > 0 && !command.IsActive() {
+			excess--
+			continue
+		}
+		kept = append(kept, command)
+	}
+	s.commands = kept
+}
+
+// runTask runs a task, turning a panic into an error so that one broken
+// task cannot take the scheduler down
+func runTask(task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return task.Run()
+}
@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/models"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
+
+	require.NoError(t, db.AutoMigrate(&models.ScheduledTask{}))
+	return db
+}
+
+func TestRegister(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db)
+
+	require.NoError(t, service.Register(Task{Name: TaskLibraryRescan, Interval: time.Hour, Run: func() error { return nil }}))
+	require.NoError(t, service.Register(Task{Name: TaskProcessImports, Run: func() error { return nil }}))
+
+	tasks, err := service.Tasks()
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+
+	assert.Equal(t, TaskLibraryRescan, tasks[0].Name)
+	assert.Equal(t, 3600, tasks[0].Interval)
+	require.NotNil(t, tasks[0].NextRunAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *tasks[0].NextRunAt, time.Minute)
+
+	assert.Equal(t, TaskProcessImports, tasks[1].Name)
+	assert.Nil(t, tasks[1].NextRunAt, "tasks without an interval only run when triggered")
+
+	t.Run("Schedule survives a restart", func(t *testing.T) {
+		last := time.Now().Add(-50 * time.Minute)
+		require.NoError(t, db.Model(&models.ScheduledTask{}).Where("name = ?", TaskLibraryRescan).Update("last_run_at", last).Error)
+
+		restarted := NewService(db)
+		require.NoError(t, restarted.Register(Task{Name: TaskLibraryRescan, Interval: time.Hour, Run: func() error { return nil }}))
+
+		tasks, err := restarted.Tasks()
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.WithinDuration(t, last.Add(time.Hour), *tasks[0].NextRunAt, time.Second)
+
+		var count int64
+		db.Model(&models.ScheduledTask{}).Count(&count)
+		assert.Equal(t, int64(2), count)
+	})
+}
+
+func TestTrigger(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db)
+
+	release := make(chan struct{})
+	var runs int32
+	require.NoError(t, service.Register(Task{
+		Name:     TaskLibraryRescan,
+		Interval: time.Hour,
+		Run: func() error {
+			atomic.AddInt32(&runs, 1)
+			<-release
+			return errors.New("library folder is not available")
+		},
+	}))
+
+	_, err := service.Trigger("unknown")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	command, err := service.Trigger(TaskLibraryRescan)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerManual, command.Trigger)
+
+	// Only one run of a task at a time
+	_, err = service.Trigger(TaskLibraryRescan)
+	assert.ErrorIs(t, err, ErrTaskRunning)
+	assert.Empty(t, service.RunDue())
+
+	close(release)
+	service.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+
+	commands := service.Commands()
+	require.Len(t, commands, 1)
+	assert.Equal(t, CommandStatusFailed, commands[0].Status)
+	assert.Equal(t, "library folder is not available", commands[0].Error)
+	assert.NotNil(t, commands[0].CompletedAt)
+
+	tasks, err := service.Tasks()
+	require.NoError(t, err)
+	require.NotNil(t, tasks[0].LastRunAt)
+	assert.Equal(t, "library folder is not available", tasks[0].LastError)
+	assert.WithinDuration(t, tasks[0].LastRunAt.Add(time.Hour), *tasks[0].NextRunAt, time.Second)
+}
+
+func TestRunDue(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db)
+
+	var rescans, imports int32
+	require.NoError(t, service.Register(Task{
+		Name:     TaskLibraryRescan,
+		Interval: time.Hour,
+		Run:      func() error { atomic.AddInt32(&rescans, 1); return nil },
+	}))
+	require.NoError(t, service.Register(Task{
+		Name:     TaskProcessImports,
+		Interval: time.Minute,
+		Run:      func() error { atomic.AddInt32(&imports, 1); panic("boom") },
+	}))
+
+	assert.Empty(t, service.RunDue(), "nothing is due right after registering")
+
+	past := time.Now().Add(-time.Second)
+	require.NoError(t, db.Model(&models.ScheduledTask{}).Where("name = ?", TaskProcessImports).Update("next_run_at", past).Error)
+
+	queued := service.RunDue()
+	require.Len(t, queued, 1)
+	assert.Equal(t, TaskProcessImports, queued[0].Name)
+	assert.Equal(t, TriggerScheduled, queued[0].Trigger)
+	service.Wait()
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&rescans))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&imports))
+
+	commands := service.Commands()
+	require.Len(t, commands, 1)
+	assert.Equal(t, CommandStatusFailed, commands[0].Status)
+	assert.Equal(t, "panic: boom", commands[0].Error)
+
+	// The next run is one interval later
+	assert.Empty(t, service.RunDue())
+}
+
+func TestCommands_Trimmed(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db)
+	require.NoError(t, service.Register(Task{Name: TaskLibraryRescan, Run: func() error { return nil }}))
+
+	for i := 0; i < commandHistory+5; i++ {
+		_, err := service.Trigger(TaskLibraryRescan)
+		require.NoError(t, err)
+		service.Wait()
+	}
+
+	commands := service.Commands()
+	require.Len(t, commands, commandHistory)
+	assert.Equal(t, uint(commandHistory+5), commands[0].ID, "newest first")
+	for _, command := range commands {
+		assert.Equal(t, CommandStatusCompleted, command.Status)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"gorm.io/gorm"
 
+	"github.com/listenarr/listenarr/internal/database"
 	"github.com/listenarr/listenarr/internal/models"
 	"github.com/listenarr/listenarr/pkg/jackett"
 )
@@ -71,7 +72,7 @@ func (s *Service) SearchAudiobooks(query string) ([]SearchResult, error) {
 
 	// Also search local database for books
 	var books []models.Book
-	s.db.Where("title LIKE ?", "%"+query+"%").
+	s.db.Where(database.Contains("title", query)).
 		Or("isbn = ?", query).
 		Or("asin = ?", query).
 		Preload("Author").
@@ -0,0 +1,198 @@
+package tagger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+	"github.com/listenarr/listenarr/pkg/mp4"
+)
+
+// maxCoverSize is the largest cover image embedded into a file
+const maxCoverSize = 10 << 20
+
+// Errors returned by the tagging service
+var (
+	ErrUnsupportedFile  = errors.New("only M4B and M4A files can be tagged")
+	ErrUnsupportedCover = errors.New("cover is not a JPEG or PNG image")
+)
+
+// ChapterSource looks up the published chapters of an audiobook
+type ChapterSource interface {
+	LookupChapters(asin string) ([]metadata.Chapter, error)
+}
+
+// Service embeds book metadata, cover art and chapters into audio files
+type Service struct {
+	db         *gorm.DB
+	chapters   ChapterSource
+	httpClient *http.Client
+}
+
+// NewService creates a new tagging service.
+// chapters may be nil when chapters should not be looked up.
+func NewService(db *gorm.DB, chapters ChapterSource) *Service {
+	return &Service{
+		db:       db,
+		chapters: chapters,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// CanTag reports whether the file at path is a format the service can tag
+func CanTag(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m4b", ".m4a":
+		return true
+	}
+	return false
+}
+
+// TagFile writes the book's metadata into the M4B or M4A file at path.
+// Tags the book has no value for keep the file's value. The cover is
+// downloaded from the book's cover URL, and published chapters are only
+// written to files without chapters of their own, since another edition's
+// timings would not match the audio.
+func (s *Service) TagFile(path string, book *models.Book) error {
+	if !CanTag(path) {
+		return ErrUnsupportedFile
+	}
+
+	var full models.Book
+	err := s.db.
+		Preload("Author").
+		Preload("Audiobook").
+		Preload("SeriesMemberships", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("SeriesMemberships.Series").
+		Preload("Contributors", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Contributors.Author").
+		Preload("Contributors.Narrator").
+		First(&full, book.ID).Error
+	if err != nil {
+		return fmt.Errorf("failed to load book: %w", err)
+	}
+
+	tags, err := mp4.ReadTags(path)
+	if err != nil {
+		return fmt.Errorf("failed to read tags: %w", err)
+	}
+	ApplyBook(tags, &full)
+
+	if full.CoverArtURL != "" {
+		cover, err := s.fetchCover(full.CoverArtURL)
+		if err != nil {
+			log.Printf("tagger: keeping existing cover of %s: %v", path, err)
+		} else {
+			tags.Cover = cover
+		}
+	}
+
+	if asin := bookASIN(&full); len(tags.Chapters) == 0 && asin != "" && s.chapters != nil {
+		chapters, err := s.chapters.LookupChapters(asin)
+		if err != nil && !errors.Is(err, metadata.ErrNotFound) {
+			log.Printf("tagger: failed to look up chapters for %s: %v", asin, err)
+		}
+		for _, chapter := range chapters {
+			tags.Chapters = append(tags.Chapters, mp4.Chapter{
+				Start: time.Duration(chapter.StartMs) * time.Millisecond,
+				Title: chapter.Title,
+			})
+		}
+	}
+
+	if err := mp4.WriteTags(path, tags); err != nil {
+		return fmt.Errorf("failed to write tags: %w", err)
+	}
+	return nil
+}
+
+// ApplyBook sets the tags the book has values for. The book should have its
+// author, audiobook, series memberships and contributors loaded.
+func ApplyBook(tags *mp4.Tags, book *models.Book) {
+	setTag(&tags.Title, book.Title)
+	setTag(&tags.Album, book.Title)
+	setTag(&tags.Description, book.Description)
+	setTag(&tags.Genre, book.Genre)
+	setTag(&tags.ASIN, bookASIN(book))
+	if book.ReleaseDate != nil {
+		setTag(&tags.Year, book.ReleaseDate.Format("2006"))
+	}
+
+	var authors, narrators []string
+	if book.Author.Name != "" {
+		authors = append(authors, book.Author.Name)
+	}
+	for _, contributor := range book.Contributors {
+		switch {
+		case contributor.Role == models.ContributorRoleAuthor && contributor.AuthorID != nil && *contributor.AuthorID != book.AuthorID:
+			authors = append(authors, contributor.Name())
+		case contributor.Role.IsNarration():
+			narrators = append(narrators, contributor.Name())
+		}
+	}
+	if len(narrators) == 0 && book.Audiobook != nil && book.Audiobook.Narrator != "" {
+		narrators = []string{book.Audiobook.Narrator}
+	}
+	setTag(&tags.Artist, strings.Join(authors, ", "))
+	setTag(&tags.AlbumArtist, strings.Join(authors, ", "))
+	setTag(&tags.Composer, strings.Join(narrators, ", "))
+
+	// The first series the book was linked to is the one it is known by
+	if len(book.SeriesMemberships) > 0 {
+		membership := book.SeriesMemberships[0]
+		setTag(&tags.Series, membership.Series.Name)
+		setTag(&tags.Sequence, membership.Position)
+	}
+}
+
+// setTag sets a tag unless value is empty
+func setTag(tag *string, value string) {
+	if value != "" {
+		*tag = value
+	}
+}
+
+// bookASIN returns the ASIN of the audiobook edition, or of the book
+func bookASIN(book *models.Book) string {
+	if book.Audiobook != nil && book.Audiobook.ASIN != "" {
+		return book.Audiobook.ASIN
+	}
+	return book.ASIN
+}
+
+// fetchCover downloads a JPEG or PNG cover image
+func (s *Service) fetchCover(url string) ([]byte, error) {
+	resp, err := s.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download cover: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cover download failed with status %d", resp.StatusCode)
+	}
+
+	image, err := io.ReadAll(io.LimitReader(resp.Body, maxCoverSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download cover: %w", err)
+	}
+	if len(image) > maxCoverSize {
+		return nil, fmt.Errorf("cover is larger than %d bytes", maxCoverSize)
+	}
+	switch http.DetectContentType(image) {
+	case "image/jpeg", "image/png":
+		return image, nil
+	}
+	return nil, ErrUnsupportedCover
+}
@@ -0,0 +1,195 @@
+package tagger
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/listenarr/listenarr/internal/database/dbtest"
+	"github.com/listenarr/listenarr/internal/models"
+	"github.com/listenarr/listenarr/internal/services/metadata"
+	"github.com/listenarr/listenarr/pkg/mp4"
+)
+
+var jpeg = []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00cover")
+
+// fakeChapters returns fixed chapters and records the ASINs looked up
+type fakeChapters struct {
+	lookups  []string
+	chapters []metadata.Chapter
+	err      error
+}
+
+func (f *fakeChapters) LookupChapters(asin string) ([]metadata.Chapter, error) {
+	f.lookups = append(f.lookups, asin)
+	return f.chapters, f.err
+}
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
+
+	err := db.AutoMigrate(
+		&models.Author{},
+		&models.Narrator{},
+		&models.Series{},
+		&models.Book{},
+		&models.BookSeries{},
+		&models.BookContributor{},
+		&models.Audiobook{},
+	)
+	require.NoError(t, err)
+
+	return db
+}
+
+// createBook creates a fully described book with a cover at coverURL
+func createBook(t *testing.T, db *gorm.DB, coverURL string) *models.Book {
+	released := time.Date(1997, 6, 26, 0, 0, 0, 0, time.UTC)
+	author := models.Author{Name: "J.K. Rowling"}
+	require.NoError(t, db.Create(&author).Error)
+	book := models.Book{
+		Title:       "Harry Potter and the Sorcerer's Stone",
+		AuthorID:    author.ID,
+		Description: "Harry Potter has never even heard of Hogwarts.",
+		Genre:       "Fantasy",
+		ReleaseDate: &released,
+		CoverArtURL: coverURL,
+	}
+	require.NoError(t, db.Create(&book).Error)
+	require.NoError(t, db.Create(&models.Audiobook{BookID: book.ID, Narrator: "Jim Dale", ASIN: "B017V4IM1G"}).Error)
+	require.NoError(t, models.CreditContributors(db, &book, []string{"J.K. Rowling", "Mary GrandPré"}, []string{"Jim Dale"}))
+
+	series := models.Series{Name: "Harry Potter"}
+	require.NoError(t, db.Create(&series).Error)
+	require.NoError(t, db.Create(&models.BookSeries{BookID: book.ID, SeriesID: series.ID, Position: "1"}).Error)
+	universe := models.Series{Name: "Wizarding World"}
+	require.NoError(t, db.Create(&universe).Error)
+	require.NoError(t, db.Create(&models.BookSeries{BookID: book.ID, SeriesID: universe.ID, Position: "1.5"}).Error)
+
+	return &book
+}
+
+// box returns an MP4 atom with the given payload
+func box(typ string, payload []byte) []byte {
+	out := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(out, uint32(8+len(payload)))
+	copy(out[4:], typ)
+	return append(out, payload...)
+}
+
+// writeM4B writes a minimal untagged M4B file
+func writeM4B(t *testing.T, path string) {
+	var file []byte
+	file = append(file, box("ftyp", []byte("M4B \x00\x00\x02\x00isomM4B "))...)
+	file = append(file, box("moov", box("mvhd", make([]byte, 100)))...)
+	file = append(file, box("mdat", []byte("audio"))...)
+	require.NoError(t, os.WriteFile(path, file, 0644))
+}
+
+func newCoverServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cover.jpg":
+			w.Write(jpeg)
+		case "/cover.html":
+			w.Write([]byte("<html>not an image</html>"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTagFile(t *testing.T) {
+	db := setupTestDB(t)
+	server := newCoverServer(t)
+	book := createBook(t, db, server.URL+"/cover.jpg")
+	path := filepath.Join(t.TempDir(), "book.m4b")
+	writeM4B(t, path)
+
+	chapters := &fakeChapters{chapters: []metadata.Chapter{
+		{Title: "Opening Credits", StartMs: 0, LengthMs: 15500},
+		{Title: "Chapter 1: The Boy Who Lived", StartMs: 15500, LengthMs: 1856500},
+	}}
+	service := NewService(db, chapters)
+	require.NoError(t, service.TagFile(path, book))
+
+	tags, err := mp4.ReadTags(path)
+	require.NoError(t, err)
+	assert.Equal(t, &mp4.Tags{
+		Title:       "Harry Potter and the Sorcerer's Stone",
+		Album:       "Harry Potter and the Sorcerer's Stone",
+		Artist:      "J.K. Rowling, Mary GrandPré",
+		AlbumArtist: "J.K. Rowling, Mary GrandPré",
+		Composer:    "Jim Dale",
+		Series:      "Harry Potter",
+		Sequence:    "1",
+		Year:        "1997",
+		Description: "Harry Potter has never even heard of Hogwarts.",
+		Genre:       "Fantasy",
+		ASIN:        "B017V4IM1G",
+		Cover:       jpeg,
+		Chapters: []mp4.Chapter{
+			{Start: 0, Title: "Opening Credits"},
+			{Start: 15500 * time.Millisecond, Title: "Chapter 1: The Boy Who Lived"},
+		},
+	}, tags)
+	assert.Equal(t, []string{"B017V4IM1G"}, chapters.lookups)
+
+	// Chapters already in the file are kept
+	chapters.chapters = []metadata.Chapter{{Title: "Other edition"}}
+	require.NoError(t, service.TagFile(path, book))
+	tags, err = mp4.ReadTags(path)
+	require.NoError(t, err)
+	assert.Len(t, tags.Chapters, 2)
+	assert.Len(t, chapters.lookups, 1)
+}
+
+func TestTagFile_KeepsFileValues(t *testing.T) {
+	db := setupTestDB(t)
+	server := newCoverServer(t)
+	author := models.Author{Name: "Unknown Author"}
+	require.NoError(t, db.Create(&author).Error)
+	book := models.Book{Title: "Sparse Book", AuthorID: author.ID, CoverArtURL: server.URL + "/cover.html"}
+	require.NoError(t, db.Create(&book).Error)
+
+	path := filepath.Join(t.TempDir(), "book.m4a")
+	writeM4B(t, path)
+	require.NoError(t, mp4.WriteTags(path, &mp4.Tags{Genre: "Mystery", Composer: "File Narrator", Cover: []byte("\x89PNG old")}))
+
+	service := NewService(db, &fakeChapters{err: errors.New("audnexus unavailable")})
+	require.NoError(t, service.TagFile(path, &book))
+
+	tags, err := mp4.ReadTags(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Sparse Book", tags.Title)
+	assert.Equal(t, "Unknown Author", tags.Artist)
+	assert.Equal(t, "Mystery", tags.Genre)
+	assert.Equal(t, "File Narrator", tags.Composer)
+	assert.Equal(t, []byte("\x89PNG old"), tags.Cover, "a cover that is not an image is not embedded")
+	assert.Empty(t, tags.Chapters)
+}
+
+func TestTagFile_Unsupported(t *testing.T) {
+	db := setupTestDB(t)
+	book := createBook(t, db, "")
+	service := NewService(db, nil)
+
+	err := service.TagFile(filepath.Join(t.TempDir(), "book.mp3"), book)
+	assert.ErrorIs(t, err, ErrUnsupportedFile)
+
+	path := filepath.Join(t.TempDir(), "book.m4b")
+	require.NoError(t, os.WriteFile(path, []byte("not an mp4 file"), 0644))
+	err = service.TagFile(path, book)
+	assert.ErrorIs(t, err, mp4.ErrInvalidAtom)
+}
@@ -0,0 +1,152 @@
+package audiobookshelf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNoMatchingLibrary is returned when no library has a folder containing a path
+var ErrNoMatchingLibrary = errors.New("no Audiobookshelf library contains the path")
+
+// Client represents an Audiobookshelf API client
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Audiobookshelf API client authenticating with an API token
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Library represents an Audiobookshelf library
+type Library struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	MediaType string   `json:"mediaType"` // book or podcast
+	Folders   []Folder `json:"folders"`
+}
+
+// Folder is a folder that belongs to a library
+type Folder struct {
+	ID       string `json:"id"`
+	FullPath string `json:"fullPath"`
+}
+
+// librariesResponse represents the response from /api/libraries
+type librariesResponse struct {
+	Libraries []Library `json:"libraries"`
+}
+
+// GetLibraries returns the server's libraries
+func (c *Client) GetLibraries() ([]Library, error) {
+	resp, err := c.do("GET", "/api/libraries")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var data librariesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode libraries: %w", err)
+	}
+
+	return data.Libraries, nil
+}
+
+// FindLibrary returns the library with a folder containing dir. When
+// several match, the one with the longest (most specific) folder wins.
+func (c *Client) FindLibrary(dir string) (*Library, error) {
+	libraries, err := c.GetLibraries()
+	if err != nil {
+		return nil, err
+	}
+
+	var match *Library
+	longest := -1
+	for i := range libraries {
+		for _, folder := range libraries[i].Folders {
+			if containsPath(folder.FullPath, dir) && len(folder.FullPath) > longest {
+				match = &libraries[i]
+				longest = len(folder.FullPath)
+			}
+		}
+	}
+	if match == nil {
+		return nil, ErrNoMatchingLibrary
+	}
+
+	return match, nil
+}
+
+// ScanLibrary starts a scan of a library for new and changed items
+func (c *Client) ScanLibrary(libraryID string) error {
+	resp, err := c.do("POST", "/api/libraries/"+url.PathEscape(libraryID)+"/scan")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// ScanFolder scans the given library, or the library with a folder
+// containing dir when libraryID is empty
+func (c *Client) ScanFolder(libraryID, dir string) error {
+	if libraryID == "" {
+		library, err := c.FindLibrary(dir)
+		if err != nil {
+			return err
+		}
+		libraryID = library.ID
+	}
+
+	return c.ScanLibrary(libraryID)
+}
+
+// do performs an authenticated request, returning the response on 200 OK
+func (c *Client) do(method, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.baseURL+endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Audiobookshelf request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Audiobookshelf: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("audiobookshelf rejected the token")
+		}
+		return nil, fmt.Errorf("audiobookshelf request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return resp, nil
+}
+
+// containsPath reports whether dir is root or inside it
+func containsPath(root, dir string) bool {
+	root = path.Clean(strings.ReplaceAll(root, "\\", "/"))
+	dir = path.Clean(strings.ReplaceAll(dir, "\\", "/"))
+	return dir == root || strings.HasPrefix(dir, strings.TrimSuffix(root, "/")+"/")
+}
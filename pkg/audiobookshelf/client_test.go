@@ -0,0 +1,94 @@
+package audiobookshelf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const librariesJSON = `{"libraries": [
+	{"id": "lib_podcasts", "name": "Podcasts", "mediaType": "podcast",
+	 "folders": [{"id": "fol_1", "fullPath": "/podcasts"}]},
+	{"id": "lib_books", "name": "Audiobooks", "mediaType": "book",
+	 "folders": [{"id": "fol_2", "fullPath": "/audiobooks"}, {"id": "fol_3", "fullPath": "/mnt/more-audiobooks"}]}
+]}`
+
+// newTestServer serves /api/libraries and records library scans
+func newTestServer(t *testing.T, scans *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/libraries":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(librariesJSON))
+		case r.Method == "POST" && (r.URL.Path == "/api/libraries/lib_books/scan" || r.URL.Path == "/api/libraries/lib_podcasts/scan"):
+			*scans = append(*scans, r.URL.Path)
+			w.Write([]byte("OK"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestNewClient(t *testing.T) {
+	client := NewClient("http://localhost:13378/", "test-token")
+	assert.NotNil(t, client)
+	assert.Equal(t, "http://localhost:13378", client.baseURL)
+	assert.Equal(t, "test-token", client.token)
+}
+
+func TestClient_GetLibraries(t *testing.T) {
+	var scans []string
+	server := newTestServer(t, &scans)
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	libraries, err := client.GetLibraries()
+	require.NoError(t, err)
+	require.Len(t, libraries, 2)
+	assert.Equal(t, "lib_books", libraries[1].ID)
+	assert.Equal(t, "book", libraries[1].MediaType)
+	require.Len(t, libraries[1].Folders, 2)
+	assert.Equal(t, "/audiobooks", libraries[1].Folders[0].FullPath)
+
+	_, err = NewClient(server.URL, "wrong-token").GetLibraries()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "token")
+}
+
+func TestClient_ScanFolder(t *testing.T) {
+	var scans []string
+	server := newTestServer(t, &scans)
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	t.Run("Scan matching library", func(t *testing.T) {
+		require.NoError(t, client.ScanFolder("", "/mnt/more-audiobooks/Author/Book"))
+		assert.Equal(t, []string{"/api/libraries/lib_books/scan"}, scans)
+	})
+
+	t.Run("Scan configured library", func(t *testing.T) {
+		scans = nil
+		require.NoError(t, client.ScanFolder("lib_podcasts", "/audiobooks/Author/Book"))
+		assert.Equal(t, []string{"/api/libraries/lib_podcasts/scan"}, scans)
+	})
+
+	t.Run("No matching library", func(t *testing.T) {
+		scans = nil
+		err := client.ScanFolder("", "/audiobooks-old/Book")
+		assert.ErrorIs(t, err, ErrNoMatchingLibrary)
+		assert.Empty(t, scans)
+	})
+
+	t.Run("Unknown library", func(t *testing.T) {
+		assert.Error(t, client.ScanLibrary("lib_missing"))
+	})
+}
@@ -0,0 +1,64 @@
+// Package diskspace reports how much space is left on the volume holding a
+// path.
+package diskspace
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnsupported is returned on platforms where free space cannot be read
+	ErrUnsupported = errors.New("disk space is not supported on this platform")
+
+	// ErrInsufficientSpace is returned when a volume is too full for a file
+	ErrInsufficientSpace = errors.New("not enough free disk space")
+)
+
+// Usage is the size of a volume and the space left on it, in bytes
+type Usage struct {
+	Free  uint64 `json:"free"`  // Available to unprivileged users
+	Total uint64 `json:"total"` // Size of the volume
+}
+
+// Get returns the usage of the volume holding path
+func Get(path string) (Usage, error) {
+	usage, err := get(path)
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to read free space of %s: %w", path, err)
+	}
+	return usage, nil
+}
+
+// Require returns an error wrapping ErrInsufficientSpace unless the volume
+// holding path has room for size bytes with minFree bytes left over. Space
+// that cannot be read is not checked, so a missing folder fails later with a
+// clearer error instead.
+func Require(path string, size, minFree uint64) error {
+	if path == "" {
+		return nil
+	}
+	usage, err := get(path)
+	if err != nil {
+		return nil
+	}
+	if usage.Free < size || usage.Free-size < minFree {
+		return fmt.Errorf("%w: %s has %s free, %s needed (%s plus %s kept free)", ErrInsufficientSpace, path,
+			FormatBytes(usage.Free), FormatBytes(size+minFree), FormatBytes(size), FormatBytes(minFree))
+	}
+	return nil
+}
+
+// FormatBytes formats a size with a binary unit, such as "1.5 GiB"
+func FormatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package diskspace
+
+func get(path string) (Usage, error) {
+	return Usage{}, ErrUnsupported
+}
@@ -0,0 +1,41 @@
+package diskspace
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	usage, err := Get(t.TempDir())
+	require.NoError(t, err)
+	assert.NotZero(t, usage.Total)
+	assert.LessOrEqual(t, usage.Free, usage.Total)
+
+	_, err = Get(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorContains(t, err, "failed to read free space")
+}
+
+func TestRequire(t *testing.T) {
+	dir := t.TempDir()
+	usage, err := Get(dir)
+	require.NoError(t, err)
+
+	assert.NoError(t, Require(dir, 1, 1))
+	assert.NoError(t, Require("", 1<<62, 0), "no path, nothing to check")
+	assert.NoError(t, Require(filepath.Join(dir, "missing"), 1<<62, 0), "unreadable space is not checked")
+
+	err = Require(dir, usage.Total, 0)
+	assert.ErrorIs(t, err, ErrInsufficientSpace)
+	assert.ErrorContains(t, err, dir+" has ")
+
+	assert.ErrorIs(t, Require(dir, 0, usage.Total), ErrInsufficientSpace, "the minimum alone does not fit")
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", FormatBytes(512))
+	assert.Equal(t, "1.5 KiB", FormatBytes(1536))
+	assert.Equal(t, "2.0 GiB", FormatBytes(2<<30))
+}
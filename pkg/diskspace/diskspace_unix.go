@@ -0,0 +1,17 @@
+//go:build unix
+
+package diskspace
+
+import "syscall"
+
+func get(path string) (Usage, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return Usage{}, err
+	}
+	blockSize := uint64(stat.Bsize)
+	return Usage{
+		Free:  uint64(stat.Bavail) * blockSize,
+		Total: uint64(stat.Blocks) * blockSize,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package jellyfin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client represents a Jellyfin API client
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Jellyfin API client authenticating with an API key
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// RefreshLibrary starts a scan of all libraries for new and changed items
+func (c *Client) RefreshLibrary() error {
+	req, err := http.NewRequest("POST", c.baseURL+"/Library/Refresh", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Jellyfin request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf(`MediaBrowser Token="%s"`, c.apiKey))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Jellyfin: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("jellyfin rejected the API key")
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("jellyfin request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
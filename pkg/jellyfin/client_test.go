@@ -0,0 +1,51 @@
+package jellyfin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClient(t *testing.T) {
+	client := NewClient("http://localhost:8096/", "test-key")
+	assert.NotNil(t, client)
+	assert.Equal(t, "http://localhost:8096", client.baseURL)
+	assert.Equal(t, "test-key", client.apiKey)
+}
+
+func TestClient_RefreshLibrary(t *testing.T) {
+	refreshes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != `MediaBrowser Token="test-key"` {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == "POST" && r.URL.Path == "/Library/Refresh" {
+			refreshes++
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	t.Run("Refresh library", func(t *testing.T) {
+		err := NewClient(server.URL, "test-key").RefreshLibrary()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, refreshes)
+	})
+
+	t.Run("Wrong API key", func(t *testing.T) {
+		err := NewClient(server.URL, "wrong-key").RefreshLibrary()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "API key")
+	})
+
+	t.Run("Server error", func(t *testing.T) {
+		err := NewClient(server.URL+"/missing", "test-key").RefreshLibrary()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+}
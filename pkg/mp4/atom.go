@@ -0,0 +1,247 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Errors returned when parsing MP4 files
+var (
+	ErrInvalidAtom = errors.New("invalid MP4 atom")
+	ErrNoMovie     = errors.New("no moov atom found")
+)
+
+// containerAtoms are the atoms whose payload is a list of child atoms
+var containerAtoms = map[string]bool{
+	"moov": true,
+	"trak": true,
+	"mdia": true,
+	"minf": true,
+	"stbl": true,
+	"dinf": true,
+	"edts": true,
+	"udta": true,
+	"meta": true, // Preceded by a 4 byte version and flags
+	"ilst": true,
+}
+
+// atom is a parsed MP4 box. Containers hold children, other atoms their raw payload.
+type atom struct {
+	typ      string
+	data     []byte
+	children []*atom
+}
+
+// header is the position of a top-level atom in a file
+type header struct {
+	typ        string
+	offset     int64
+	size       int64 // Including the header
+	headerSize int64
+}
+
+// readHeaders lists the top-level atoms of an MP4 file without reading their payload
+func readHeaders(r io.ReadSeeker) ([]header, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var headers []header
+	for offset := int64(0); offset < end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		var buf [16]byte
+		if _, err := io.ReadFull(r, buf[:8]); err != nil {
+			return nil, fmt.Errorf("%w: truncated header at %d", ErrInvalidAtom, offset)
+		}
+
+		h := header{
+			typ:        string(buf[4:8]),
+			offset:     offset,
+			size:       int64(binary.BigEndian.Uint32(buf[:4])),
+			headerSize: 8,
+		}
+		switch h.size {
+		case 0: // Extends to the end of the file
+			h.size = end - offset
+		case 1: // 64-bit size follows the type
+			if _, err := io.ReadFull(r, buf[8:16]); err != nil {
+				return nil, fmt.Errorf("%w: truncated header at %d", ErrInvalidAtom, offset)
+			}
+			h.size = int64(binary.BigEndian.Uint64(buf[8:16]))
+			h.headerSize = 16
+		}
+		if h.size < h.headerSize || offset+h.size > end {
+			return nil, fmt.Errorf("%w: %q at %d has size %d", ErrInvalidAtom, h.typ, offset, h.size)
+		}
+
+		headers = append(headers, h)
+		offset += h.size
+	}
+
+	return headers, nil
+}
+
+// findHeader returns the first top-level atom of the given type
+func findHeader(headers []header, typ string) (header, bool) {
+	for _, h := range headers {
+		if h.typ == typ {
+			return h, true
+		}
+	}
+	return header{}, false
+}
+
+// readAtom reads and parses a top-level atom
+func readAtom(r io.ReadSeeker, h header) (*atom, error) {
+	if _, err := r.Seek(h.offset+h.headerSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, h.size-h.headerSize)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return parseAtom(h.typ, payload)
+}
+
+// parseAtom builds an atom from its type and payload, descending into containers
+func parseAtom(typ string, payload []byte) (*atom, error) {
+	a := &atom{typ: typ}
+	if !containerAtoms[typ] {
+		a.data = payload
+		return a, nil
+	}
+
+	// The iTunes meta atom starts with a version and flags, the QuickTime one does not
+	if typ == "meta" && !(len(payload) >= 8 && string(payload[4:8]) == "hdlr") {
+		if len(payload) < 4 {
+			return nil, fmt.Errorf("%w: short meta", ErrInvalidAtom)
+		}
+		a.data = payload[:4]
+		payload = payload[4:]
+	}
+
+	children, err := parseChildren(payload)
+	if err != nil {
+		return nil, err
+	}
+	a.children = children
+
+	// Each item in an iTunes item list holds data (and for freeform items
+	// mean and name) atoms
+	if typ == "ilst" {
+		for _, item := range children {
+			if item.children, err = parseChildren(item.data); err != nil {
+				return nil, err
+			}
+			item.data = nil
+		}
+	}
+
+	return a, nil
+}
+
+// parseChildren parses a sequence of atoms
+func parseChildren(payload []byte) ([]*atom, error) {
+	var children []*atom
+	for len(payload) > 0 {
+		// Some writers pad containers with zero bytes
+		if len(payload) < 8 {
+			break
+		}
+		size := int(binary.BigEndian.Uint32(payload[:4]))
+		typ := string(payload[4:8])
+		headerSize := 8
+		if size == 1 {
+			if len(payload) < 16 {
+				return nil, fmt.Errorf("%w: truncated %q", ErrInvalidAtom, typ)
+			}
+			size = int(binary.BigEndian.Uint64(payload[8:16]))
+			headerSize = 16
+		} else if size == 0 {
+			size = len(payload)
+		}
+		if size < headerSize || size > len(payload) {
+			return nil, fmt.Errorf("%w: %q has size %d", ErrInvalidAtom, typ, size)
+		}
+
+		child, err := parseAtom(typ, payload[headerSize:size])
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+		payload = payload[size:]
+	}
+	return children, nil
+}
+
+// bytes serializes the atom with a 32-bit size header
+func (a *atom) bytes() []byte {
+	payload := a.data
+	if containerAtoms[a.typ] || a.children != nil {
+		payload = append([]byte{}, a.data...)
+		for _, child := range a.children {
+			payload = append(payload, child.bytes()...)
+		}
+	}
+
+	out := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(out[:4], uint32(8+len(payload)))
+	copy(out[4:8], a.typ)
+	return append(out, payload...)
+}
+
+// child returns the first child of the given type, or nil
+func (a *atom) child(typ string) *atom {
+	for _, c := range a.children {
+		if c.typ == typ {
+			return c
+		}
+	}
+	return nil
+}
+
+// path returns the descendant at the given path of atom types, or nil
+func (a *atom) path(types ...string) *atom {
+	current := a
+	for _, typ := range types {
+		if current = current.child(typ); current == nil {
+			return nil
+		}
+	}
+	return current
+}
+
+// ensureChild returns the first child of the given type, appending a new
+// one when there is none
+func (a *atom) ensureChild(typ string) *atom {
+	if c := a.child(typ); c != nil {
+		return c
+	}
+	c := &atom{typ: typ}
+	a.children = append(a.children, c)
+	return c
+}
+
+// removeChildren drops the children of the given type
+func (a *atom) removeChildren(typ string) {
+	kept := a.children[:0]
+	for _, c := range a.children {
+		if c.typ != typ {
+			kept = append(kept, c)
+		}
+	}
+	a.children = kept
+}
+
+// walk calls fn for the atom and all of its descendants
+func (a *atom) walk(fn func(*atom)) {
+	fn(a)
+	for _, c := range a.children {
+		c.walk(fn)
+	}
+}
@@ -0,0 +1,78 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"os"
+	"time"
+)
+
+// Info describes an MP4 file's audio and tags
+type Info struct {
+	Tags
+	Narrator string // From the ©nrt atom some tools write; Composer otherwise holds it
+	Duration time.Duration
+	Bitrate  int // Average kbps over the media data
+}
+
+// ReadInfo reads the duration, average bitrate and tags of the MP4 file at path
+func ReadInfo(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	headers, err := readHeaders(f)
+	if err != nil {
+		return nil, err
+	}
+	h, ok := findHeader(headers, "moov")
+	if !ok {
+		return nil, ErrNoMovie
+	}
+	moov, err := readAtom(f, h)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{Tags: *parseTags(moov)}
+	if ilst := moov.path("udta", "meta", "ilst"); ilst != nil {
+		if item := ilst.child(atomNarrator); item != nil {
+			if value, _, ok := itemData(item); ok {
+				info.Narrator = string(value)
+			}
+		}
+	}
+	if mvhd := moov.child("mvhd"); mvhd != nil {
+		info.Duration = movieDuration(mvhd.data)
+	}
+
+	var mediaSize int64
+	for _, h := range headers {
+		if h.typ == "mdat" {
+			mediaSize += h.size - h.headerSize
+		}
+	}
+	if seconds := info.Duration.Seconds(); seconds > 0 {
+		info.Bitrate = int(float64(mediaSize*8) / seconds / 1000)
+	}
+
+	return info, nil
+}
+
+// movieDuration decodes the duration in an mvhd payload
+func movieDuration(data []byte) time.Duration {
+	var timescale, duration uint64
+	switch {
+	case len(data) >= 32 && data[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(data[20:24]))
+		duration = binary.BigEndian.Uint64(data[24:32])
+	case len(data) >= 20:
+		timescale = uint64(binary.BigEndian.Uint32(data[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(data[16:20]))
+	}
+	if timescale == 0 {
+		return 0
+	}
+	return time.Duration(duration * uint64(time.Second) / timescale)
+}
@@ -0,0 +1,56 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// movieHeader returns a version 0 mvhd payload
+func movieHeader(timescale, duration uint32) []byte {
+	data := make([]byte, 100)
+	binary.BigEndian.PutUint32(data[12:16], timescale)
+	binary.BigEndian.PutUint32(data[16:20], duration)
+	return data
+}
+
+func TestReadInfo(t *testing.T) {
+	// 4000 bytes of audio over one second
+	ilst := &atom{typ: "ilst", children: []*atom{
+		dataItem(atomTitle, dataTypeUTF8, []byte("Chapter 1")),
+		dataItem(atomNarrator, dataTypeUTF8, []byte("Jim Dale")),
+	}}
+	meta := &atom{typ: "meta", data: []byte{0, 0, 0, 0}, children: []*atom{metadataHandler(), ilst}}
+	moov := box("moov", box("mvhd", movieHeader(44100, 44100)), box("udta", meta.bytes()))
+	file := append(box("ftyp", []byte("M4A ")), moov...)
+	file = append(file, box("mdat", make([]byte, 4000))...)
+
+	path := filepath.Join(t.TempDir(), "book.m4a")
+	require.NoError(t, os.WriteFile(path, file, 0644))
+
+	info, err := ReadInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, info.Duration)
+	assert.Equal(t, 32, info.Bitrate)
+	assert.Equal(t, "Chapter 1", info.Title)
+	assert.Equal(t, "Jim Dale", info.Narrator)
+}
+
+func TestMovieDuration(t *testing.T) {
+	assert.Equal(t, 90*time.Minute, movieDuration(movieHeader(600, 600*90*60)))
+
+	// Version 1 headers use 64-bit times and durations
+	data := make([]byte, 112)
+	data[0] = 1
+	binary.BigEndian.PutUint32(data[20:24], 1000)
+	binary.BigEndian.PutUint64(data[24:32], 36_000_000)
+	assert.Equal(t, 10*time.Hour, movieDuration(data))
+
+	assert.Zero(t, movieDuration(movieHeader(0, 100)))
+	assert.Zero(t, movieDuration(nil))
+}
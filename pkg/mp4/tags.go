@@ -0,0 +1,423 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// iTunes item atoms; \xa9 is the © that prefixes the classic tag names
+const (
+	atomTitle       = "\xa9nam"
+	atomAlbum       = "\xa9alb"
+	atomArtist      = "\xa9ART"
+	atomAlbumArtist = "aART"
+	atomComposer    = "\xa9wrt"
+	atomYear        = "\xa9day"
+	atomDescription = "desc"
+	atomGenre       = "\xa9gen"
+	atomCover       = "covr"
+	atomNarrator    = "\xa9nrt"
+	atomFreeform    = "----"
+)
+
+// Freeform tags are stored under this mean, as Audiobookshelf and Mp3tag do
+const freeformMean = "com.apple.iTunes"
+
+// Freeform tag names
+const (
+	freeformSeries   = "SERIES"
+	freeformSequence = "SERIES-PART"
+	freeformASIN     = "ASIN"
+)
+
+// Data atom type indicators
+const (
+	dataTypeUTF8 = 1
+	dataTypeJPEG = 13
+	dataTypePNG  = 14
+)
+
+// maxChapters is the most chapters a Nero chapter list can hold
+const maxChapters = 255
+
+// Chapter is a named position in the audio
+type Chapter struct {
+	Start time.Duration
+	Title string
+}
+
+// Tags is the metadata stored in an MP4 file's iTunes item list and Nero
+// chapter list. Empty fields are not written.
+type Tags struct {
+	Title       string
+	Album       string
+	Artist      string // Author
+	AlbumArtist string
+	Composer    string // Narrator, by audiobook convention
+	Series      string
+	Sequence    string // Position in the series, "1" or "2.5"
+	Year        string
+	Description string
+	Genre       string
+	ASIN        string
+	Cover       []byte // JPEG or PNG image
+	Chapters    []Chapter
+}
+
+// textAtoms maps the classic item atoms to their Tags fields
+func (t *Tags) textAtoms() map[string]*string {
+	return map[string]*string{
+		atomTitle:       &t.Title,
+		atomAlbum:       &t.Album,
+		atomArtist:      &t.Artist,
+		atomAlbumArtist: &t.AlbumArtist,
+		atomComposer:    &t.Composer,
+		atomYear:        &t.Year,
+		atomDescription: &t.Description,
+		atomGenre:       &t.Genre,
+	}
+}
+
+// freeformFields maps the freeform tag names to their Tags fields
+func (t *Tags) freeformFields() map[string]*string {
+	return map[string]*string{
+		freeformSeries:   &t.Series,
+		freeformSequence: &t.Sequence,
+		freeformASIN:     &t.ASIN,
+	}
+}
+
+// ReadTags reads the tags of the MP4 file at path
+func ReadTags(path string) (*Tags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	moov, err := readMovie(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTags(moov), nil
+}
+
+// readMovie reads the moov atom of an MP4 file
+func readMovie(r io.ReadSeeker) (*atom, error) {
+	headers, err := readHeaders(r)
+	if err != nil {
+		return nil, err
+	}
+	h, ok := findHeader(headers, "moov")
+	if !ok {
+		return nil, ErrNoMovie
+	}
+	return readAtom(r, h)
+}
+
+// parseTags extracts the tags from a moov atom
+func parseTags(moov *atom) *Tags {
+	tags := &Tags{}
+
+	if ilst := moov.path("udta", "meta", "ilst"); ilst != nil {
+		textAtoms := tags.textAtoms()
+		freeform := tags.freeformFields()
+		for _, item := range ilst.children {
+			switch {
+			case textAtoms[item.typ] != nil:
+				if value, _, ok := itemData(item); ok {
+					*textAtoms[item.typ] = string(value)
+				}
+			case item.typ == atomCover:
+				if value, _, ok := itemData(item); ok {
+					tags.Cover = value
+				}
+			case item.typ == atomFreeform:
+				mean, name := freeformName(item)
+				if mean == freeformMean && freeform[name] != nil {
+					if value, _, ok := itemData(item); ok {
+						*freeform[name] = string(value)
+					}
+				}
+			}
+		}
+	}
+
+	if chpl := moov.path("udta", "chpl"); chpl != nil {
+		tags.Chapters = parseChapters(chpl.data)
+	}
+
+	return tags
+}
+
+// itemData returns the value and type of an item's first data atom
+func itemData(item *atom) ([]byte, uint32, bool) {
+	for _, c := range item.children {
+		if c.typ == "data" && len(c.data) >= 8 {
+			return c.data[8:], binary.BigEndian.Uint32(c.data[:4]) & 0xffffff, true
+		}
+	}
+	return nil, 0, false
+}
+
+// freeformName returns the mean and name of a freeform item
+func freeformName(item *atom) (string, string) {
+	var mean, name string
+	for _, c := range item.children {
+		if len(c.data) < 4 {
+			continue
+		}
+		switch c.typ {
+		case "mean":
+			mean = string(c.data[4:])
+		case "name":
+			name = string(c.data[4:])
+		}
+	}
+	return mean, name
+}
+
+// parseChapters decodes a Nero chpl payload
+func parseChapters(data []byte) []Chapter {
+	if len(data) < 5 {
+		return nil
+	}
+	pos := 4
+	if data[0] != 0 { // Version 1 has 4 reserved bytes
+		pos += 4
+	}
+	if len(data) <= pos {
+		return nil
+	}
+	count := int(data[pos])
+	pos++
+
+	chapters := make([]Chapter, 0, count)
+	for i := 0; i < count && pos+9 <= len(data); i++ {
+		start := binary.BigEndian.Uint64(data[pos : pos+8])
+		length := int(data[pos+8])
+		pos += 9
+		if pos+length > len(data) {
+			break
+		}
+		chapters = append(chapters, Chapter{
+			Start: time.Duration(start) * 100, // 100ns units
+			Title: string(data[pos : pos+length]),
+		})
+		pos += length
+	}
+	return chapters
+}
+
+// WriteTags replaces the tags of the MP4 file at path. Tags that are empty
+// are removed; items this package does not manage are kept. The file is
+// rewritten to a temporary file and renamed over the original, so a
+// hardlinked original (a torrent that is still seeding) is never modified.
+func WriteTags(path string, tags *Tags) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	headers, err := readHeaders(in)
+	if err != nil {
+		return err
+	}
+	moovHeader, ok := findHeader(headers, "moov")
+	if !ok {
+		return ErrNoMovie
+	}
+	moov, err := readAtom(in, moovHeader)
+	if err != nil {
+		return err
+	}
+
+	applyTags(moov, tags)
+	newMoov := moov.bytes()
+
+	// Media data after the moov atom moves by the change in its size
+	delta := int64(len(newMoov)) - moovHeader.size
+	if delta != 0 {
+		if err := shiftChunkOffsets(moov, moovHeader.offset+moovHeader.size, delta); err != nil {
+			return err
+		}
+		newMoov = moov.bytes()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tags-*"+filepath.Ext(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	for _, h := range headers {
+		if h.offset == moovHeader.offset {
+			_, err = tmp.Write(newMoov)
+		} else {
+			_, err = io.Copy(tmp, io.NewSectionReader(in, h.offset, h.size))
+		}
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// applyTags replaces the managed items and chapters in a moov atom
+func applyTags(moov *atom, tags *Tags) {
+	udta := moov.ensureChild("udta")
+	meta := udta.child("meta")
+	if meta == nil {
+		meta = &atom{typ: "meta", data: []byte{0, 0, 0, 0}}
+		udta.children = append(udta.children, meta)
+	}
+	if meta.child("hdlr") == nil {
+		meta.children = append([]*atom{metadataHandler()}, meta.children...)
+	}
+	ilst := meta.ensureChild("ilst")
+
+	textAtoms := tags.textAtoms()
+	freeform := tags.freeformFields()
+	kept := ilst.children[:0]
+	for _, item := range ilst.children {
+		if textAtoms[item.typ] != nil || item.typ == atomCover {
+			continue
+		}
+		if item.typ == atomFreeform {
+			if mean, name := freeformName(item); mean == freeformMean && freeform[name] != nil {
+				continue
+			}
+		}
+		kept = append(kept, item)
+	}
+	ilst.children = kept
+
+	for _, typ := range []string{atomTitle, atomAlbum, atomArtist, atomAlbumArtist, atomComposer, atomYear, atomDescription, atomGenre} {
+		if value := *textAtoms[typ]; value != "" {
+			ilst.children = append(ilst.children, dataItem(typ, dataTypeUTF8, []byte(value)))
+		}
+	}
+	for _, name := range []string{freeformSeries, freeformSequence, freeformASIN} {
+		if value := *freeform[name]; value != "" {
+			ilst.children = append(ilst.children, freeformItem(name, value))
+		}
+	}
+	if len(tags.Cover) > 0 {
+		ilst.children = append(ilst.children, dataItem(atomCover, coverType(tags.Cover), tags.Cover))
+	}
+
+	udta.removeChildren("chpl")
+	if len(tags.Chapters) > 0 {
+		udta.children = append(udta.children, &atom{typ: "chpl", data: encodeChapters(tags.Chapters)})
+	}
+}
+
+// metadataHandler returns the hdlr atom iTunes writes in udta/meta
+func metadataHandler() *atom {
+	data := make([]byte, 25)
+	copy(data[8:12], "mdir")
+	copy(data[12:16], "appl")
+	return &atom{typ: "hdlr", data: data}
+}
+
+// dataItem returns an item atom holding a single data atom
+func dataItem(typ string, dataType uint32, value []byte) *atom {
+	data := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint32(data[:4], dataType)
+	data = append(data, value...)
+	return &atom{typ: typ, children: []*atom{{typ: "data", data: data}}}
+}
+
+// freeformItem returns a ---- item with the iTunes mean
+func freeformItem(name, value string) *atom {
+	item := dataItem(atomFreeform, dataTypeUTF8, []byte(value))
+	item.children = append([]*atom{
+		{typ: "mean", data: append([]byte{0, 0, 0, 0}, freeformMean...)},
+		{typ: "name", data: append([]byte{0, 0, 0, 0}, name...)},
+	}, item.children...)
+	return item
+}
+
+// coverType returns the data type of an image from its signature
+func coverType(image []byte) uint32 {
+	if bytes.HasPrefix(image, []byte("\x89PNG")) {
+		return dataTypePNG
+	}
+	return dataTypeJPEG
+}
+
+// encodeChapters returns a version 1 Nero chpl payload. The format holds at
+// most 255 chapters with titles of up to 255 bytes; the rest is dropped.
+func encodeChapters(chapters []Chapter) []byte {
+	if len(chapters) > maxChapters {
+		chapters = chapters[:maxChapters]
+	}
+
+	data := []byte{1, 0, 0, 0, 0, 0, 0, 0, byte(len(chapters))}
+	for _, chapter := range chapters {
+		title := chapter.Title
+		if len(title) > 255 {
+			title = title[:255]
+		}
+		var start [8]byte
+		binary.BigEndian.PutUint64(start[:], uint64(chapter.Start/100))
+		data = append(data, start[:]...)
+		data = append(data, byte(len(title)))
+		data = append(data, title...)
+	}
+	return data
+}
+
+// shiftChunkOffsets adds delta to the chunk offsets at or after from
+func shiftChunkOffsets(moov *atom, from, delta int64) error {
+	var err error
+	moov.walk(func(a *atom) {
+		if err != nil || len(a.data) < 8 {
+			return
+		}
+		switch a.typ {
+		case "stco":
+			count := int(binary.BigEndian.Uint32(a.data[4:8]))
+			for i := 0; i < count && 8+i*4+4 <= len(a.data); i++ {
+				entry := a.data[8+i*4 : 8+i*4+4]
+				offset := int64(binary.BigEndian.Uint32(entry))
+				if offset < from {
+					continue
+				}
+				if offset+delta > 0xffffffff {
+					err = fmt.Errorf("%w: chunk offset overflows stco", ErrInvalidAtom)
+					return
+				}
+				binary.BigEndian.PutUint32(entry, uint32(offset+delta))
+			}
+		case "co64":
+			count := int(binary.BigEndian.Uint32(a.data[4:8]))
+			for i := 0; i < count && 8+i*8+8 <= len(a.data); i++ {
+				entry := a.data[8+i*8 : 8+i*8+8]
+				if offset := int64(binary.BigEndian.Uint64(entry)); offset >= from {
+					binary.BigEndian.PutUint64(entry, uint64(offset+delta))
+				}
+			}
+		}
+	})
+	return err
+}
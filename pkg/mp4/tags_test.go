@@ -0,0 +1,202 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const audioData = "not really AAC frames"
+
+// box returns an atom with the given payload
+func box(typ string, payload ...[]byte) []byte {
+	var data []byte
+	for _, p := range payload {
+		data = append(data, p...)
+	}
+	return (&atom{typ: typ, data: data}).bytes()
+}
+
+// chunkOffsets returns an stco payload
+func chunkOffsets(offsets ...uint32) []byte {
+	data := make([]byte, 8+4*len(offsets))
+	binary.BigEndian.PutUint32(data[4:8], uint32(len(offsets)))
+	for i, offset := range offsets {
+		binary.BigEndian.PutUint32(data[8+i*4:], offset)
+	}
+	return data
+}
+
+// writeTestFile writes a minimal M4B with one chunk of audio in mdat and
+// the moov atom before or after it, returning the path
+func writeTestFile(t *testing.T, moovFirst bool, udta []byte) string {
+	ftyp := box("ftyp", []byte("M4B \x00\x00\x02\x00isomM4B "))
+	mdat := box("mdat", []byte(audioData))
+
+	moov := func(chunkOffset uint32) []byte {
+		stbl := box("stbl", box("stco", chunkOffsets(chunkOffset)))
+		trak := box("trak", box("mdia", box("minf", stbl)))
+		return box("moov", box("mvhd", make([]byte, 100)), trak, udta)
+	}
+
+	var file []byte
+	if moovFirst {
+		size := len(moov(0))
+		file = append(append(ftyp, moov(uint32(len(ftyp)+size+8))...), mdat...)
+	} else {
+		file = append(append(ftyp, mdat...), moov(uint32(len(ftyp)+8))...)
+	}
+
+	path := filepath.Join(t.TempDir(), "book.m4b")
+	require.NoError(t, os.WriteFile(path, file, 0640))
+	return path
+}
+
+// assertAudioIntact checks that the chunk offset still points at the audio
+func assertAudioIntact(t *testing.T, path string) {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	moov, err := readMovie(f)
+	require.NoError(t, err)
+	stco := moov.path("trak", "mdia", "minf", "stbl", "stco")
+	require.NotNil(t, stco)
+	offset := int64(binary.BigEndian.Uint32(stco.data[8:12]))
+
+	buf := make([]byte, len(audioData))
+	_, err = f.ReadAt(buf, offset)
+	require.NoError(t, err)
+	assert.Equal(t, audioData, string(buf))
+}
+
+func testTags() *Tags {
+	return &Tags{
+		Title:       "Harry Potter and the Sorcerer's Stone",
+		Album:       "Harry Potter and the Sorcerer's Stone",
+		Artist:      "J.K. Rowling",
+		AlbumArtist: "J.K. Rowling",
+		Composer:    "Jim Dale",
+		Series:      "Harry Potter",
+		Sequence:    "1",
+		Year:        "1997",
+		Description: "Harry Potter has never even heard of Hogwarts…",
+		Genre:       "Fantasy",
+		ASIN:        "B017V4IM1G",
+		Cover:       []byte("\xff\xd8\xff\xe0 jpeg"),
+		Chapters: []Chapter{
+			{Start: 0, Title: "Opening Credits"},
+			{Start: 15500 * time.Millisecond, Title: "Chapter 1: The Boy Who Lived"},
+			{Start: 31*time.Minute + 12*time.Second, Title: "Chapter 2: The Vanishing Glass"},
+		},
+	}
+}
+
+func TestWriteTags_RoundTrip(t *testing.T) {
+	for _, moovFirst := range []bool{true, false} {
+		name := "moov after mdat"
+		if moovFirst {
+			name = "moov before mdat"
+		}
+		t.Run(name, func(t *testing.T) {
+			path := writeTestFile(t, moovFirst, nil)
+
+			require.NoError(t, WriteTags(path, testTags()))
+
+			tags, err := ReadTags(path)
+			require.NoError(t, err)
+			assert.Equal(t, testTags(), tags)
+			assertAudioIntact(t, path)
+
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+		})
+	}
+}
+
+func TestWriteTags_ReplacesManagedTags(t *testing.T) {
+	// An existing tag this package does not manage
+	other := dataItem("\xa9too", dataTypeUTF8, []byte("encoder"))
+	udta := box("udta", (&atom{typ: "meta", data: []byte{0, 0, 0, 0}, children: []*atom{
+		metadataHandler(),
+		{typ: "ilst", children: []*atom{other, dataItem(atomTitle, dataTypeUTF8, []byte("Old Title"))}},
+	}}).bytes())
+	path := writeTestFile(t, true, udta)
+
+	tags, err := ReadTags(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Old Title", tags.Title)
+
+	require.NoError(t, WriteTags(path, testTags()))
+	require.NoError(t, WriteTags(path, &Tags{Title: "New Title", Cover: []byte("\x89PNG image")}))
+
+	tags, err = ReadTags(path)
+	require.NoError(t, err)
+	assert.Equal(t, &Tags{Title: "New Title", Cover: []byte("\x89PNG image")}, tags)
+	assertAudioIntact(t, path)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	moov, err := readMovie(f)
+	require.NoError(t, err)
+
+	ilst := moov.path("udta", "meta", "ilst")
+	require.NotNil(t, ilst)
+	assert.NotNil(t, ilst.child("\xa9too"))
+	_, dataType, _ := itemData(ilst.child(atomCover))
+	assert.Equal(t, uint32(dataTypePNG), dataType)
+	assert.Len(t, moov.path("udta", "meta").children, 2, "one hdlr and one ilst")
+}
+
+func TestWriteTags_LeavesHardlinkedSourceUntouched(t *testing.T) {
+	path := writeTestFile(t, true, nil)
+	seeding := filepath.Join(t.TempDir(), "seeding.m4b")
+	require.NoError(t, os.Link(path, seeding))
+	before, err := os.ReadFile(seeding)
+	require.NoError(t, err)
+
+	require.NoError(t, WriteTags(path, testTags()))
+
+	after, err := os.ReadFile(seeding)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func TestWriteTags_ChapterLimits(t *testing.T) {
+	path := writeTestFile(t, false, nil)
+	chapters := make([]Chapter, 300)
+	for i := range chapters {
+		chapters[i] = Chapter{Start: time.Duration(i) * time.Minute, Title: "Chapter"}
+	}
+	chapters[0].Title = string(make([]byte, 300))
+
+	require.NoError(t, WriteTags(path, &Tags{Chapters: chapters}))
+
+	tags, err := ReadTags(path)
+	require.NoError(t, err)
+	require.Len(t, tags.Chapters, maxChapters)
+	assert.Len(t, tags.Chapters[0].Title, 255)
+	assert.Equal(t, 254*time.Minute, tags.Chapters[254].Start)
+}
+
+func TestReadTags_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "book.m4b")
+
+	require.NoError(t, os.WriteFile(path, box("ftyp", []byte("M4B ")), 0644))
+	_, err := ReadTags(path)
+	assert.ErrorIs(t, err, ErrNoMovie)
+
+	require.NoError(t, os.WriteFile(path, []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), 0644))
+	_, err = ReadTags(path)
+	assert.ErrorIs(t, err, ErrInvalidAtom)
+
+	err = WriteTags(path, testTags())
+	assert.ErrorIs(t, err, ErrInvalidAtom)
+}
@@ -0,0 +1,163 @@
+package plex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNoMatchingSection is returned when no library section contains a path
+var ErrNoMatchingSection = errors.New("no Plex library section contains the path")
+
+// Client represents a Plex Media Server API client
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Plex API client authenticating with an X-Plex-Token
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Section represents a library section (a "library" in the Plex UI)
+type Section struct {
+	Key       string     `json:"key"`
+	Title     string     `json:"title"`
+	Type      string     `json:"type"` // artist for music and audiobook libraries
+	Agent     string     `json:"agent"`
+	Locations []Location `json:"Location"`
+}
+
+// Location is a folder that belongs to a library section
+type Location struct {
+	ID   int    `json:"id"`
+	Path string `json:"path"`
+}
+
+// sectionsResponse represents the response from /library/sections
+type sectionsResponse struct {
+	MediaContainer struct {
+		Directory []Section `json:"Directory"`
+	} `json:"MediaContainer"`
+}
+
+// GetSections returns the server's library sections
+func (c *Client) GetSections() ([]Section, error) {
+	resp, err := c.get("/library/sections", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var data sectionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode sections: %w", err)
+	}
+
+	return data.MediaContainer.Directory, nil
+}
+
+// FindSection returns the section with a location containing dir. When
+// several match, the one with the longest (most specific) location wins.
+func (c *Client) FindSection(dir string) (*Section, error) {
+	sections, err := c.GetSections()
+	if err != nil {
+		return nil, err
+	}
+
+	var match *Section
+	longest := -1
+	for i := range sections {
+		for _, location := range sections[i].Locations {
+			if containsPath(location.Path, dir) && len(location.Path) > longest {
+				match = &sections[i]
+				longest = len(location.Path)
+			}
+		}
+	}
+	if match == nil {
+		return nil, ErrNoMatchingSection
+	}
+
+	return match, nil
+}
+
+// ScanPath triggers a partial scan of a single folder in a section
+func (c *Client) ScanPath(sectionKey, dir string) error {
+	params := url.Values{}
+	params.Set("path", dir)
+
+	resp, err := c.get("/library/sections/"+url.PathEscape(sectionKey)+"/refresh", params)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// ScanFolder triggers a partial scan of dir in the given section, or in the
+// section whose location contains dir when sectionKey is empty
+func (c *Client) ScanFolder(sectionKey, dir string) error {
+	if sectionKey == "" {
+		section, err := c.FindSection(dir)
+		if err != nil {
+			return err
+		}
+		sectionKey = section.Key
+	}
+
+	return c.ScanPath(sectionKey, dir)
+}
+
+// get performs an authenticated GET request, returning the response on 200 OK
+func (c *Client) get(endpoint string, params url.Values) (*http.Response, error) {
+	reqURL := c.baseURL + endpoint
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest("GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Plex request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Plex-Token", c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Plex: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("plex rejected the token")
+		}
+		return nil, fmt.Errorf("plex request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return resp, nil
+}
+
+// containsPath reports whether dir is root or inside it
+func containsPath(root, dir string) bool {
+	root = path.Clean(strings.ReplaceAll(root, "\\", "/"))
+	dir = path.Clean(strings.ReplaceAll(dir, "\\", "/"))
+	return dir == root || strings.HasPrefix(dir, strings.TrimSuffix(root, "/")+"/")
+}
@@ -0,0 +1,133 @@
+package plex
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sectionsJSON = `{"MediaContainer": {"size": 3, "Directory": [
+	{"key": "1", "title": "Movies", "type": "movie", "agent": "tv.plex.agents.movie",
+	 "Location": [{"id": 1, "path": "/data/movies"}]},
+	{"key": "2", "title": "Media", "type": "artist", "agent": "tv.plex.agents.music",
+	 "Location": [{"id": 2, "path": "/data"}]},
+	{"key": "3", "title": "Audiobooks", "type": "artist", "agent": "com.plexapp.agents.audnexus",
+	 "Location": [{"id": 3, "path": "/data/audiobooks"}, {"id": 4, "path": "/mnt/more-audiobooks"}]}
+]}}`
+
+// newTestServer serves /library/sections and records refresh requests
+func newTestServer(t *testing.T, refreshes *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Plex-Token") != "test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+
+		switch {
+		case r.URL.Path == "/library/sections":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(sectionsJSON))
+		case r.URL.Path == "/library/sections/3/refresh" || r.URL.Path == "/library/sections/2/refresh":
+			*refreshes = append(*refreshes, r.URL.Path+"?path="+r.URL.Query().Get("path"))
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestNewClient(t *testing.T) {
+	client := NewClient("http://localhost:32400/", "test-token")
+	assert.NotNil(t, client)
+	assert.Equal(t, "http://localhost:32400", client.baseURL)
+	assert.Equal(t, "test-token", client.token)
+}
+
+func TestClient_GetSections(t *testing.T) {
+	var refreshes []string
+	server := newTestServer(t, &refreshes)
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	sections, err := client.GetSections()
+	require.NoError(t, err)
+	require.Len(t, sections, 3)
+	assert.Equal(t, "3", sections[2].Key)
+	assert.Equal(t, "Audiobooks", sections[2].Title)
+	assert.Equal(t, "artist", sections[2].Type)
+	require.Len(t, sections[2].Locations, 2)
+	assert.Equal(t, "/mnt/more-audiobooks", sections[2].Locations[1].Path)
+}
+
+func TestClient_BadToken(t *testing.T) {
+	var refreshes []string
+	server := newTestServer(t, &refreshes)
+	defer server.Close()
+
+	client := NewClient(server.URL, "wrong-token")
+	_, err := client.GetSections()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "token")
+}
+
+func TestClient_FindSection(t *testing.T) {
+	var refreshes []string
+	server := newTestServer(t, &refreshes)
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	// The most specific location wins over the catch-all /data section
+	section, err := client.FindSection("/data/audiobooks/Author/Book")
+	require.NoError(t, err)
+	assert.Equal(t, "3", section.Key)
+
+	section, err = client.FindSection("/mnt/more-audiobooks/Author/Book")
+	require.NoError(t, err)
+	assert.Equal(t, "3", section.Key)
+
+	section, err = client.FindSection("/data/other")
+	require.NoError(t, err)
+	assert.Equal(t, "2", section.Key)
+
+	// Prefixes only match on folder boundaries
+	_, err = client.FindSection("/mnt/more-audiobooks-old/Book")
+	assert.ErrorIs(t, err, ErrNoMatchingSection)
+}
+
+func TestClient_ScanFolder(t *testing.T) {
+	var refreshes []string
+	server := newTestServer(t, &refreshes)
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	t.Run("Scan matching section", func(t *testing.T) {
+		err := client.ScanFolder("", "/data/audiobooks/J.K. Rowling/Harry Potter")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/library/sections/3/refresh?path=/data/audiobooks/J.K. Rowling/Harry Potter"}, refreshes)
+	})
+
+	t.Run("Scan configured section", func(t *testing.T) {
+		refreshes = nil
+		err := client.ScanFolder("2", "/data/audiobooks/Author/Book")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/library/sections/2/refresh?path=/data/audiobooks/Author/Book"}, refreshes)
+	})
+
+	t.Run("No matching section", func(t *testing.T) {
+		refreshes = nil
+		err := client.ScanFolder("", "/elsewhere/Book")
+		assert.ErrorIs(t, err, ErrNoMatchingSection)
+		assert.Empty(t, refreshes)
+	})
+
+	t.Run("Unknown section", func(t *testing.T) {
+		err := client.ScanPath("99", "/data/audiobooks/Book")
+		assert.Error(t, err)
+	})
+}
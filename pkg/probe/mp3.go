@@ -0,0 +1,392 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// id3v22Frames maps ID3v2.2 three letter frame IDs to their v2.3 names
+var id3v22Frames = map[string]string{
+	"TT2": "TIT2",
+	"TAL": "TALB",
+	"TP1": "TPE1",
+	"TP2": "TPE2",
+	"TCM": "TCOM",
+	"TYE": "TYER",
+	"TCO": "TCON",
+	"TXX": "TXXX",
+}
+
+// id3Frames maps ID3 text frames to Vorbis comment names
+var id3Frames = map[string]string{
+	"TIT2": "TITLE",
+	"TALB": "ALBUM",
+	"TPE1": "ARTIST",
+	"TPE2": "ALBUMARTIST",
+	"TCOM": "COMPOSER",
+	"TYER": "YEAR",
+	"TDRC": "DATE",
+	"TCON": "GENRE",
+	"MVNM": "MOVEMENTNAME",
+	"MVIN": "MOVEMENT",
+}
+
+// MPEG audio Layer III bitrates in kbps by bitrate index, for MPEG-1 and MPEG-2/2.5
+var (
+	mpeg1Bitrates = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mpeg2Bitrates = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+)
+
+// MPEG audio sample rates by version bits and sample rate index
+var sampleRates = map[byte][3]int{
+	3: {44100, 48000, 32000}, // MPEG-1
+	2: {22050, 24000, 16000}, // MPEG-2
+	0: {11025, 12000, 8000},  // MPEG-2.5
+}
+
+// maxSyncSearch is how far past the tag the first audio frame is looked for
+const maxSyncSearch = 64 << 10
+
+// probeMP3 reads an MP3 file's ID3v2 tag and its audio frames
+func probeMP3(f *os.File, size int64) (*Info, error) {
+	info := &Info{Format: FormatMP3}
+
+	audioStart, tags, chapters, err := readID3v2(f)
+	if err != nil {
+		return nil, err
+	}
+	applyTextTags(info, tags)
+	info.Chapters = chapters
+
+	// An ID3v1 tag takes the last 128 bytes
+	audioEnd := size
+	if size >= 128 {
+		trailer := make([]byte, 3)
+		if _, err := f.ReadAt(trailer, size-128); err == nil && string(trailer) == "TAG" {
+			audioEnd -= 128
+		}
+	}
+
+	buf := make([]byte, maxSyncSearch)
+	n, err := f.ReadAt(buf, audioStart)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		frame, ok := parseFrameHeader(buf[i:])
+		if !ok {
+			continue
+		}
+		audioBytes := audioEnd - audioStart - int64(i)
+		if frames := frame.vbrFrames(buf[i:]); frames > 0 {
+			info.Duration = time.Duration(int64(frames) * int64(frame.samplesPerFrame) * int64(time.Second) / int64(frame.sampleRate))
+			info.Bitrate = bitrate(audioBytes, info.Duration)
+		} else {
+			info.Bitrate = frame.bitrate
+			info.Duration = time.Duration(audioBytes * 8 * int64(time.Second) / int64(frame.bitrate*1000))
+		}
+		return info, nil
+	}
+
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("%w: no MPEG audio frames found", ErrUnsupportedFormat)
+	}
+	return info, nil
+}
+
+// mpegFrame is a decoded MPEG audio Layer III frame header
+type mpegFrame struct {
+	mpeg1           bool
+	mono            bool
+	bitrate         int // kbps
+	sampleRate      int
+	samplesPerFrame int
+}
+
+// isFrameSync reports whether data starts with an MPEG audio frame sync
+func isFrameSync(data []byte) bool {
+	_, ok := parseFrameHeader(data)
+	return ok
+}
+
+// parseFrameHeader decodes an MPEG audio Layer III frame header
+func parseFrameHeader(data []byte) (mpegFrame, bool) {
+	if len(data) < 4 || data[0] != 0xff || data[1]&0xe0 != 0xe0 {
+		return mpegFrame{}, false
+	}
+	version := (data[1] >> 3) & 3
+	layer := (data[1] >> 1) & 3
+	bitrateIndex := data[2] >> 4
+	sampleRateIndex := (data[2] >> 2) & 3
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return mpegFrame{}, false
+	}
+
+	frame := mpegFrame{
+		mpeg1:           version == 3,
+		mono:            data[3]>>6 == 3,
+		sampleRate:      sampleRates[version][sampleRateIndex],
+		samplesPerFrame: 576,
+	}
+	if frame.mpeg1 {
+		frame.bitrate = mpeg1Bitrates[bitrateIndex]
+		frame.samplesPerFrame = 1152
+	} else {
+		frame.bitrate = mpeg2Bitrates[bitrateIndex]
+	}
+	return frame, true
+}
+
+// vbrFrames returns the frame count from a Xing, Info or VBRI header in the
+// first frame, or 0 for constant bitrate files without one
+func (f mpegFrame) vbrFrames(data []byte) int {
+	sideInfo := 32
+	switch {
+	case f.mpeg1 && f.mono, !f.mpeg1 && !f.mono:
+		sideInfo = 17
+	case !f.mpeg1 && f.mono:
+		sideInfo = 9
+	}
+
+	if xing := 4 + sideInfo; len(data) >= xing+12 {
+		tag := string(data[xing : xing+4])
+		flags := binary.BigEndian.Uint32(data[xing+4 : xing+8])
+		if (tag == "Xing" || tag == "Info") && flags&1 != 0 {
+			return int(binary.BigEndian.Uint32(data[xing+8 : xing+12]))
+		}
+	}
+	if vbri := 4 + 32; len(data) >= vbri+18 && string(data[vbri:vbri+4]) == "VBRI" {
+		return int(binary.BigEndian.Uint32(data[vbri+14 : vbri+18]))
+	}
+	return 0
+}
+
+// readID3v2 reads the ID3v2 tag at the start of r, returning where the
+// audio starts, the text frames as Vorbis comment names and the chapters.
+// Files without a tag return an audio start of 0.
+func readID3v2(r io.ReaderAt) (int64, map[string]string, []Chapter, error) {
+	header := make([]byte, 10)
+	if _, err := r.ReadAt(header, 0); err != nil || string(header[:3]) != "ID3" {
+		return 0, nil, nil, nil
+	}
+
+	version := header[3]
+	flags := header[5]
+	size := syncsafe(header[6:10])
+	if version < 2 || version > 4 {
+		return 0, nil, nil, fmt.Errorf("%w: ID3v2.%d", ErrUnsupportedFormat, version)
+	}
+
+	audioStart := int64(10 + size)
+	if flags&0x10 != 0 { // Footer
+		audioStart += 10
+	}
+
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, 10); err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to read ID3 tag: %w", err)
+	}
+	if flags&0x80 != 0 && version < 4 {
+		data = removeUnsync(data)
+	}
+	if flags&0x40 != 0 && version > 2 && len(data) >= 4 { // Extended header
+		extended := int(binary.BigEndian.Uint32(data[:4])) + 4
+		if version == 4 {
+			extended = syncsafe(data[:4])
+		}
+		if extended > len(data) {
+			extended = len(data)
+		}
+		data = data[extended:]
+	}
+
+	tags := make(map[string]string)
+	var chapters []Chapter
+	for _, frame := range parseID3Frames(data, version) {
+		switch {
+		case frame.id == "TXXX":
+			if description, value, ok := decodeUserText(frame.data); ok {
+				tags[strings.ToUpper(description)] = value
+			}
+		case frame.id == "CHAP":
+			if chapter, ok := decodeChapter(frame.data, version); ok {
+				chapters = append(chapters, chapter)
+			}
+		case id3Frames[frame.id] != "":
+			if len(frame.data) > 0 {
+				tags[id3Frames[frame.id]] = decodeText(frame.data[0], frame.data[1:])
+			}
+		}
+	}
+
+	return audioStart, tags, chapters, nil
+}
+
+// id3Frame is a frame of an ID3v2 tag
+type id3Frame struct {
+	id   string
+	data []byte
+}
+
+// parseID3Frames splits tag data into frames, stopping at the padding
+func parseID3Frames(data []byte, version byte) []id3Frame {
+	headerSize := 10
+	if version == 2 {
+		headerSize = 6
+	}
+
+	var frames []id3Frame
+	for len(data) >= headerSize && data[0] != 0 {
+		var id string
+		var size int
+		var formatFlags byte
+		if version == 2 {
+			id = id3v22Frames[string(data[:3])]
+			size = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		} else {
+			id = string(data[:4])
+			size = int(binary.BigEndian.Uint32(data[4:8]))
+			if version == 4 {
+				size = syncsafe(data[4:8])
+			}
+			formatFlags = data[9]
+		}
+		if size < 0 || headerSize+size > len(data) {
+			break
+		}
+
+		body := data[headerSize : headerSize+size]
+		data = data[headerSize+size:]
+
+		if version == 4 {
+			if formatFlags&0x0c != 0 { // Compressed or encrypted
+				continue
+			}
+			if formatFlags&0x02 != 0 {
+				body = removeUnsync(body)
+			}
+			if formatFlags&0x01 != 0 && len(body) >= 4 { // Data length indicator
+				body = body[4:]
+			}
+		} else if version == 3 && formatFlags&0xc0 != 0 {
+			continue
+		}
+		if id != "" {
+			frames = append(frames, id3Frame{id: id, data: body})
+		}
+	}
+	return frames
+}
+
+// decodeChapter decodes a CHAP frame and the title in its TIT2 sub-frame
+func decodeChapter(data []byte, version byte) (Chapter, bool) {
+	end := bytes.IndexByte(data, 0)
+	if end < 0 || len(data) < end+17 {
+		return Chapter{}, false
+	}
+	chapter := Chapter{
+		Start: time.Duration(binary.BigEndian.Uint32(data[end+1:end+5])) * time.Millisecond,
+		Title: string(data[:end]), // Element ID until a title is found
+	}
+	for _, frame := range parseID3Frames(data[end+17:], version) {
+		if frame.id == "TIT2" && len(frame.data) > 0 {
+			chapter.Title = decodeText(frame.data[0], frame.data[1:])
+		}
+	}
+	return chapter, true
+}
+
+// decodeUserText decodes a TXXX frame into its description and value
+func decodeUserText(data []byte) (string, string, bool) {
+	if len(data) < 2 {
+		return "", "", false
+	}
+	encoding := data[0]
+	data = data[1:]
+
+	// UTF-16 strings end with two zero bytes on a character boundary
+	terminator := []byte{0}
+	step := 1
+	if encoding == 1 || encoding == 2 {
+		terminator = []byte{0, 0}
+		step = 2
+	}
+	for i := 0; i+len(terminator) <= len(data); i += step {
+		if bytes.Equal(data[i:i+len(terminator)], terminator) {
+			description := decodeText(encoding, data[:i])
+			value := data[i+len(terminator):]
+			// Each UTF-16 string has its own byte order mark
+			return description, decodeText(encoding, value), true
+		}
+	}
+	return "", "", false
+}
+
+// decodeText decodes an ID3 text value. Values holding several strings
+// separated by zero bytes are joined with commas.
+func decodeText(encoding byte, data []byte) string {
+	var text string
+	switch encoding {
+	case 0: // ISO-8859-1
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		text = string(runes)
+	case 1, 2: // UTF-16 with a byte order mark, UTF-16BE
+		bigEndian := encoding == 2
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			switch {
+			case data[i] == 0xfe && data[i+1] == 0xff:
+				bigEndian = true
+				continue
+			case data[i] == 0xff && data[i+1] == 0xfe:
+				bigEndian = false
+				continue
+			}
+			if bigEndian {
+				units = append(units, binary.BigEndian.Uint16(data[i:]))
+			} else {
+				units = append(units, binary.LittleEndian.Uint16(data[i:]))
+			}
+		}
+		text = string(utf16.Decode(units))
+	default: // UTF-8
+		text = string(data)
+	}
+
+	var values []string
+	for _, value := range strings.Split(text, "\x00") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// syncsafe decodes a 28-bit integer stored in the low 7 bits of 4 bytes
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+// removeUnsync reverses ID3 unsynchronisation, which inserts a zero byte
+// after every 0xff
+func removeUnsync(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		out = append(out, data[i])
+		if data[i] == 0xff && i+1 < len(data) && data[i+1] == 0 {
+			i++
+		}
+	}
+	return out
+}
@@ -0,0 +1,181 @@
+package probe
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// id3Tag returns an ID3v2 tag of the given version holding frames
+func id3Tag(version byte, frames ...[]byte) []byte {
+	var body []byte
+	for _, frame := range frames {
+		body = append(body, frame...)
+	}
+	body = append(body, make([]byte, 32)...) // Padding
+
+	size := len(body)
+	header := []byte{'I', 'D', '3', version, 0, 0,
+		byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	return append(header, body...)
+}
+
+// id3FrameBytes returns an ID3v2.3 frame, or a v2.4 frame with a syncsafe size
+func id3FrameBytes(id string, syncsafeSize bool, data []byte) []byte {
+	frame := make([]byte, 10, 10+len(data))
+	copy(frame, id)
+	size := len(data)
+	if syncsafeSize {
+		frame[4], frame[5], frame[6], frame[7] = byte(size>>21&0x7f), byte(size>>14&0x7f), byte(size>>7&0x7f), byte(size&0x7f)
+	} else {
+		binary.BigEndian.PutUint32(frame[4:8], uint32(size))
+	}
+	return append(frame, data...)
+}
+
+// latin1 returns an ISO-8859-1 text frame payload
+func latin1(text string) []byte {
+	return append([]byte{0}, text...)
+}
+
+// utf16BOM returns a UTF-16 text payload with a little endian byte order mark
+func utf16BOM(text string) []byte {
+	data := []byte{0xff, 0xfe}
+	for _, unit := range utf16.Encode([]rune(text)) {
+		data = append(data, byte(unit), byte(unit>>8))
+	}
+	return data
+}
+
+// chapFrame returns an ID3v2.3 CHAP frame with a TIT2 sub-frame
+func chapFrame(id string, start time.Duration, title string) []byte {
+	data := append([]byte(id), 0)
+	times := make([]byte, 16)
+	binary.BigEndian.PutUint32(times[0:4], uint32(start.Milliseconds()))
+	binary.BigEndian.PutUint32(times[8:16], 0xffffffff)
+	data = append(data, times...)
+	data = append(data, id3FrameBytes("TIT2", false, latin1(title))...)
+	return id3FrameBytes("CHAP", false, data)
+}
+
+// mpegAudio returns size bytes of MPEG-1 Layer III audio at 128 kbps,
+// 44.1 kHz, with a Xing header counting frames when frames is not zero
+func mpegAudio(size, frames int) []byte {
+	audio := make([]byte, size)
+	copy(audio, []byte{0xff, 0xfb, 0x90, 0x64})
+	if frames > 0 {
+		copy(audio[36:], "Xing")
+		binary.BigEndian.PutUint32(audio[40:44], 1)
+		binary.BigEndian.PutUint32(audio[44:48], uint32(frames))
+	}
+	return audio
+}
+
+func writeFile(t *testing.T, name string, parts ...[]byte) string {
+	var data []byte
+	for _, part := range parts {
+		data = append(data, part...)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	return path
+}
+
+func TestProbe_MP3ID3v23(t *testing.T) {
+	tag := id3Tag(3,
+		id3FrameBytes("TIT2", false, latin1("Part 1")),
+		id3FrameBytes("TALB", false, latin1("Harry Potter and the Sorcerer's Stone")),
+		id3FrameBytes("TPE1", false, append([]byte{1}, utf16BOM("J.K. Rowling")...)),
+		id3FrameBytes("TCOM", false, latin1("Jim Dale")),
+		id3FrameBytes("TYER", false, latin1("1997")),
+		id3FrameBytes("TCON", false, latin1("Fantasy")),
+		id3FrameBytes("TXXX", false, append(latin1("SERIES\x00"), "Harry Potter"...)),
+		id3FrameBytes("TXXX", false, append(append([]byte{1}, utf16BOM("series-part")...), append([]byte{0, 0}, utf16BOM("1")...)...)),
+		id3FrameBytes("TXXX", false, append(latin1("ASIN\x00"), "B017V4IM1G"...)),
+		id3FrameBytes("APIC", false, []byte("\x00image/jpeg\x00\x03\x00jpeg")),
+		chapFrame("ch0", 0, "Opening Credits"),
+		chapFrame("ch1", 15500*time.Millisecond, "Chapter 1"),
+	)
+	// One second of 128 kbps audio followed by an ID3v1 tag
+	id3v1 := append([]byte("TAG"), make([]byte, 125)...)
+	path := writeFile(t, "book.mp3", tag, mpegAudio(16000, 0), id3v1)
+
+	info, err := Probe(path)
+	require.NoError(t, err)
+	assert.Equal(t, &Info{
+		Format:   FormatMP3,
+		Title:    "Part 1",
+		Album:    "Harry Potter and the Sorcerer's Stone",
+		Author:   "J.K. Rowling",
+		Narrator: "Jim Dale",
+		Series:   "Harry Potter",
+		Sequence: "1",
+		Year:     "1997",
+		Genre:    "Fantasy",
+		ASIN:     "B017V4IM1G",
+		Duration: time.Second,
+		Bitrate:  128,
+		Chapters: []Chapter{
+			{Start: 0, Title: "Opening Credits"},
+			{Start: 15500 * time.Millisecond, Title: "Chapter 1"},
+		},
+	}, info)
+	assert.Equal(t, "Harry Potter and the Sorcerer's Stone", info.BookTitle())
+}
+
+func TestProbe_MP3ID3v24VBR(t *testing.T) {
+	tag := id3Tag(4,
+		id3FrameBytes("TIT2", true, append([]byte{3}, "Ein Buch über Zauberer"...)),
+		id3FrameBytes("TPE1", true, append([]byte{3}, "Author One\x00Author Two"...)),
+		id3FrameBytes("TDRC", true, append([]byte{3}, "2001-06-01"...)),
+		id3FrameBytes("MVNM", true, append([]byte{3}, "Discworld"...)),
+		id3FrameBytes("MVIN", true, append([]byte{3}, "12"...)),
+	)
+	// 38 frames of 1152 samples at 44.1 kHz
+	path := writeFile(t, "book.mp3", tag, mpegAudio(12000, 38))
+
+	info, err := Probe(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Ein Buch über Zauberer", info.BookTitle())
+	assert.Equal(t, "Author One, Author Two", info.Author)
+	assert.Equal(t, "2001", info.Year)
+	assert.Equal(t, "Discworld", info.Series)
+	assert.Equal(t, "12", info.Sequence)
+	assert.Equal(t, 992653061*time.Nanosecond, info.Duration)
+	assert.Equal(t, 96, info.Bitrate)
+}
+
+func TestProbe_MP3ID3v22(t *testing.T) {
+	frame := func(id, text string) []byte {
+		data := latin1(text)
+		return append([]byte{id[0], id[1], id[2], 0, 0, byte(len(data))}, data...)
+	}
+	tag := id3Tag(2, frame("TT2", "Old Book"), frame("TP1", "Old Author"))
+	path := writeFile(t, "book.mp3", tag, mpegAudio(1600, 0))
+
+	info, err := Probe(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Old Book", info.Title)
+	assert.Equal(t, "Old Author", info.Author)
+	assert.Equal(t, 100*time.Millisecond, info.Duration)
+}
+
+func TestProbe_MP3WithoutTag(t *testing.T) {
+	path := writeFile(t, "book.mp3", mpegAudio(32000, 0))
+
+	info, err := Probe(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatMP3, info.Format)
+	assert.Equal(t, 2*time.Second, info.Duration)
+	assert.Empty(t, info.Title)
+}
+
+func TestRemoveUnsync(t *testing.T) {
+	assert.Equal(t, []byte{0xff, 0xe0, 0xff, 0x00, 0x01}, removeUnsync([]byte{0xff, 0x00, 0xe0, 0xff, 0x00, 0x00, 0x01}))
+}
@@ -0,0 +1,240 @@
+// Package probe reads the format, duration, bitrate, tags and chapters of
+// audio files without external tools. It understands MP3 files with ID3v2
+// tags, MP4 (M4B/M4A) files, FLAC files and Ogg Vorbis and Opus files.
+package probe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/listenarr/listenarr/pkg/mp4"
+)
+
+// ErrUnsupportedFormat is returned for files that are not a supported audio format
+var ErrUnsupportedFormat = errors.New("unsupported audio format")
+
+// Audio formats reported in Info.Format
+const (
+	FormatMP3  = "mp3"
+	FormatM4B  = "m4b"
+	FormatM4A  = "m4a"
+	FormatFLAC = "flac"
+	FormatOgg  = "ogg"
+	FormatOpus = "opus"
+)
+
+// Chapter is a named position in the audio
+type Chapter struct {
+	Start time.Duration
+	Title string
+}
+
+// Info describes an audio file
+type Info struct {
+	Format   string
+	Title    string // Track title; a chapter name in multi-file books
+	Album    string // Book title, by audiobook convention
+	Author   string
+	Narrator string
+	Series   string
+	Sequence string // Position in the series, "1" or "2.5"
+	Year     string
+	Genre    string
+	ASIN     string
+	Duration time.Duration
+	Bitrate  int // Average kbps
+	Chapters []Chapter
+}
+
+// BookTitle returns the title of the book the file belongs to: the album,
+// or the track title for files without one
+func (i *Info) BookTitle() string {
+	if i.Album != "" {
+		return i.Album
+	}
+	return i.Title
+}
+
+// Probe reads the audio file at path. The format is detected from the
+// file's content rather than its extension.
+func Probe(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	magic := make([]byte, 12)
+	n, err := io.ReadFull(f, magic)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("fLaC")):
+		return probeFLAC(f, stat.Size())
+	case bytes.HasPrefix(magic, []byte("OggS")):
+		return probeOgg(f, stat.Size())
+	case len(magic) >= 8 && string(magic[4:8]) == "ftyp":
+		return probeMP4(path, magic)
+	case bytes.HasPrefix(magic, []byte("ID3")) || isFrameSync(magic):
+		return probeMP3(f, stat.Size())
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+// ProbeFiles reads the files of a book split over several files, in
+// playing order. Each tag comes from the first file that has it, durations
+// are summed, and chapters are offset by the length of the files before
+// them. Files that cannot be read are skipped; an error is only returned
+// when none can be.
+func ProbeFiles(paths []string) (*Info, error) {
+	var combined *Info
+	var lastErr error
+	var size int64
+	var probed, lastBitrate int
+
+	for _, path := range paths {
+		info, err := Probe(path)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", filepath.Base(path), err)
+			continue
+		}
+		if stat, err := os.Stat(path); err == nil {
+			size += stat.Size()
+		}
+		probed++
+		lastBitrate = info.Bitrate
+
+		if combined == nil {
+			combined = &Info{Format: info.Format}
+		}
+		mergeTags(combined, info)
+		for _, chapter := range info.Chapters {
+			chapter.Start += combined.Duration
+			combined.Chapters = append(combined.Chapters, chapter)
+		}
+		combined.Duration += info.Duration
+	}
+
+	if combined == nil {
+		if lastErr == nil {
+			lastErr = ErrUnsupportedFormat
+		}
+		return nil, lastErr
+	}
+	// A single file knows its audio bitrate better than its size does
+	if probed == 1 {
+		combined.Bitrate = lastBitrate
+	} else {
+		combined.Bitrate = bitrate(size, combined.Duration)
+	}
+	return combined, nil
+}
+
+// mergeTags fills the empty tags of dst from src
+func mergeTags(dst, src *Info) {
+	for _, field := range []struct{ dst, src *string }{
+		{&dst.Title, &src.Title},
+		{&dst.Album, &src.Album},
+		{&dst.Author, &src.Author},
+		{&dst.Narrator, &src.Narrator},
+		{&dst.Series, &src.Series},
+		{&dst.Sequence, &src.Sequence},
+		{&dst.Year, &src.Year},
+		{&dst.Genre, &src.Genre},
+		{&dst.ASIN, &src.ASIN},
+	} {
+		if *field.dst == "" {
+			*field.dst = *field.src
+		}
+	}
+}
+
+// probeMP4 reads an M4B or M4A file
+func probeMP4(path string, magic []byte) (*Info, error) {
+	data, err := mp4.ReadInfo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{
+		Format:   FormatM4A,
+		Title:    data.Title,
+		Album:    data.Album,
+		Author:   firstNonEmpty(data.Artist, data.AlbumArtist),
+		Narrator: firstNonEmpty(data.Narrator, data.Composer),
+		Series:   data.Series,
+		Sequence: data.Sequence,
+		Year:     yearOf(data.Year),
+		Genre:    data.Genre,
+		ASIN:     data.ASIN,
+		Duration: data.Duration,
+		Bitrate:  data.Bitrate,
+	}
+	if (len(magic) >= 12 && string(magic[8:12]) == "M4B ") || strings.EqualFold(filepath.Ext(path), ".m4b") {
+		info.Format = FormatM4B
+	}
+	for _, chapter := range data.Chapters {
+		info.Chapters = append(info.Chapters, Chapter{Start: chapter.Start, Title: chapter.Title})
+	}
+
+	return info, nil
+}
+
+// applyTextTags fills info from tags keyed by upper case Vorbis comment
+// names. ID3 frames are mapped to the same names before calling this.
+func applyTextTags(info *Info, tags map[string]string) {
+	info.Title = tags["TITLE"]
+	info.Album = tags["ALBUM"]
+	info.Author = firstNonEmpty(tags["ARTIST"], tags["ALBUMARTIST"], tags["AUTHOR"])
+	info.Narrator = firstNonEmpty(tags["NARRATOR"], tags["NARRATEDBY"], tags["COMPOSER"], tags["PERFORMER"])
+	info.Series = firstNonEmpty(tags["SERIES"], tags["MOVEMENTNAME"])
+	info.Sequence = firstNonEmpty(tags["SERIES-PART"], tags["SERIESPART"], tags["MOVEMENT"])
+	info.Year = yearOf(firstNonEmpty(tags["DATE"], tags["YEAR"], tags["RELEASEDATE"]))
+	info.Genre = tags["GENRE"]
+	info.ASIN = firstNonEmpty(tags["ASIN"], tags["AUDIBLE_ASIN"])
+}
+
+// firstNonEmpty returns the first value that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// yearOf returns the year a date tag starts with, or the tag as is
+func yearOf(date string) string {
+	date = strings.TrimSpace(date)
+	if len(date) >= 4 {
+		if _, err := strconv.Atoi(date[:4]); err == nil {
+			return date[:4]
+		}
+	}
+	return date
+}
+
+// bitrate returns the average kbps of size bytes played over duration
+func bitrate(size int64, duration time.Duration) int {
+	if duration <= 0 || size <= 0 {
+		return 0
+	}
+	return int(float64(size*8) / duration.Seconds() / 1000)
+}
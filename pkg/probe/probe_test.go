@@ -0,0 +1,92 @@
+package probe
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/listenarr/listenarr/pkg/mp4"
+)
+
+// mp4Box returns an MP4 atom with the given payload
+func mp4Box(typ string, payload ...[]byte) []byte {
+	var data []byte
+	for _, p := range payload {
+		data = append(data, p...)
+	}
+	header := binary.BigEndian.AppendUint32(nil, uint32(8+len(data)))
+	return append(append(header, typ...), data...)
+}
+
+func TestProbe_MP4(t *testing.T) {
+	// Two seconds of audio at a 1 kHz timescale
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:16], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:20], 2000)
+	path := writeFile(t, "book.m4a",
+		mp4Box("ftyp", []byte("M4B \x00\x00\x02\x00")),
+		mp4Box("moov", mp4Box("mvhd", mvhd)),
+		mp4Box("mdat", make([]byte, 16000)),
+	)
+	require.NoError(t, mp4.WriteTags(path, &mp4.Tags{
+		Album:       "Going Postal",
+		AlbumArtist: "Terry Pratchett",
+		Composer:    "Stephen Briggs",
+		Year:        "2004-09-25",
+		ASIN:        "B002V0QK4C",
+		Chapters:    []mp4.Chapter{{Start: time.Second, Title: "Chapter 1"}},
+	}))
+
+	info, err := Probe(path)
+	require.NoError(t, err)
+	assert.Equal(t, &Info{
+		Format:   FormatM4B, // From the brand, whatever the extension
+		Album:    "Going Postal",
+		Author:   "Terry Pratchett",
+		Narrator: "Stephen Briggs",
+		Year:     "2004",
+		ASIN:     "B002V0QK4C",
+		Duration: 2 * time.Second,
+		Bitrate:  64,
+		Chapters: []Chapter{{Start: time.Second, Title: "Chapter 1"}},
+	}, info)
+}
+
+func TestProbe_Unsupported(t *testing.T) {
+	_, err := Probe(writeFile(t, "cover.jpg", []byte("\xff\xd8\xff\xe0 not audio")))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = Probe(writeFile(t, "empty.mp3"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = Probe(writeFile(t, "broken.flac", []byte("fLaC\x00")))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestProbeFiles(t *testing.T) {
+	first := writeFile(t, "01.mp3", id3Tag(3,
+		id3FrameBytes("TALB", false, latin1("Small Gods")),
+		chapFrame("ch0", 0, "Part 1"),
+	), mpegAudio(16000, 0))
+	second := writeFile(t, "02.mp3", id3Tag(3,
+		id3FrameBytes("TALB", false, latin1("Small Gods")),
+		id3FrameBytes("TPE1", false, latin1("Terry Pratchett")),
+		chapFrame("ch0", 0, "Part 2"),
+	), mpegAudio(32000, 0))
+	unreadable := writeFile(t, "notes.mp3", []byte("not audio"))
+
+	info, err := ProbeFiles([]string{first, unreadable, second})
+	require.NoError(t, err)
+	assert.Equal(t, "Small Gods", info.Album)
+	assert.Equal(t, "Terry Pratchett", info.Author)
+	assert.Equal(t, 3*time.Second, info.Duration)
+	assert.Equal(t, []Chapter{{Start: 0, Title: "Part 1"}, {Start: time.Second, Title: "Part 2"}}, info.Chapters)
+	assert.InDelta(t, 128, info.Bitrate, 2, "tags count towards the size")
+
+	_, err = ProbeFiles([]string{unreadable})
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	assert.Contains(t, err.Error(), "notes.mp3")
+}
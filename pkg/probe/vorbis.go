@@ -0,0 +1,215 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// probeFLAC reads a FLAC file's stream info and Vorbis comments
+func probeFLAC(f *os.File, size int64) (*Info, error) {
+	info := &Info{Format: FormatFLAC}
+
+	var sampleRate, totalSamples int64
+	offset := int64(4)
+	for {
+		header := make([]byte, 4)
+		if _, err := f.ReadAt(header, offset); err != nil {
+			return nil, fmt.Errorf("%w: truncated FLAC metadata", ErrUnsupportedFormat)
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7f
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		offset += 4
+
+		switch blockType {
+		case 0: // STREAMINFO
+			block := make([]byte, 18)
+			if _, err := f.ReadAt(block, offset); err != nil {
+				return nil, fmt.Errorf("%w: truncated FLAC stream info", ErrUnsupportedFormat)
+			}
+			sampleRate = int64(block[10])<<12 | int64(block[11])<<4 | int64(block[12])>>4
+			totalSamples = int64(block[13]&0x0f)<<32 | int64(binary.BigEndian.Uint32(block[14:18]))
+		case 4: // VORBIS_COMMENT
+			block := make([]byte, length)
+			if _, err := f.ReadAt(block, offset); err != nil {
+				return nil, fmt.Errorf("%w: truncated FLAC comments", ErrUnsupportedFormat)
+			}
+			applyVorbisComments(info, parseVorbisComments(block))
+		}
+
+		offset += length
+		if last {
+			break
+		}
+	}
+
+	if sampleRate > 0 {
+		info.Duration = time.Duration(totalSamples * int64(time.Second) / sampleRate)
+		info.Bitrate = bitrate(size-offset, info.Duration)
+	}
+	return info, nil
+}
+
+// probeOgg reads an Ogg Vorbis or Opus file's identification and comment
+// headers, and its duration from the granule position of the last page
+func probeOgg(f *os.File, size int64) (*Info, error) {
+	packets, err := readOggPackets(io.NewSectionReader(f, 0, size), 2)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	var rate, preSkip int64
+	identification, comments := packets[0], packets[1]
+	switch {
+	case bytes.HasPrefix(identification, []byte("\x01vorbis")) && len(identification) >= 16:
+		info.Format = FormatOgg
+		rate = int64(binary.LittleEndian.Uint32(identification[12:16]))
+		if bytes.HasPrefix(comments, []byte("\x03vorbis")) {
+			applyVorbisComments(info, parseVorbisComments(comments[7:]))
+		}
+	case bytes.HasPrefix(identification, []byte("OpusHead")) && len(identification) >= 12:
+		info.Format = FormatOpus
+		rate = 48000 // Opus granule positions always count 48 kHz samples
+		preSkip = int64(binary.LittleEndian.Uint16(identification[10:12]))
+		if bytes.HasPrefix(comments, []byte("OpusTags")) {
+			applyVorbisComments(info, parseVorbisComments(comments[8:]))
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown Ogg codec", ErrUnsupportedFormat)
+	}
+
+	if granule := lastGranule(f, size); granule > preSkip && rate > 0 {
+		info.Duration = time.Duration((granule - preSkip) * int64(time.Second) / rate)
+		info.Bitrate = bitrate(size, info.Duration)
+	}
+	return info, nil
+}
+
+// readOggPackets returns the first count packets of the first logical stream
+func readOggPackets(r io.Reader, count int) ([][]byte, error) {
+	var packets [][]byte
+	var current []byte
+	header := make([]byte, 27)
+	for len(packets) < count {
+		if _, err := io.ReadFull(r, header); err != nil || string(header[:4]) != "OggS" {
+			return nil, fmt.Errorf("%w: invalid Ogg page", ErrUnsupportedFormat)
+		}
+		segments := make([]byte, header[26])
+		if _, err := io.ReadFull(r, segments); err != nil {
+			return nil, fmt.Errorf("%w: invalid Ogg page", ErrUnsupportedFormat)
+		}
+		for _, lacing := range segments {
+			segment := make([]byte, lacing)
+			if _, err := io.ReadFull(r, segment); err != nil {
+				return nil, fmt.Errorf("%w: truncated Ogg page", ErrUnsupportedFormat)
+			}
+			current = append(current, segment...)
+			// A lacing value below 255 ends the packet
+			if lacing < 255 {
+				packets = append(packets, current)
+				current = nil
+			}
+		}
+	}
+	return packets[:count], nil
+}
+
+// lastGranule returns the granule position of the last Ogg page
+func lastGranule(f *os.File, size int64) int64 {
+	start := size - 65536
+	if start < 0 {
+		start = 0
+	}
+	tail := make([]byte, size-start)
+	if _, err := f.ReadAt(tail, start); err != nil && err != io.EOF {
+		return 0
+	}
+	i := bytes.LastIndex(tail, []byte("OggS"))
+	if i < 0 || len(tail) < i+14 {
+		return 0
+	}
+	return int64(binary.LittleEndian.Uint64(tail[i+6 : i+14]))
+}
+
+// vorbisComments are the comments of a Vorbis comment block, keyed by upper
+// case name. Repeated names are joined with commas.
+type vorbisComments map[string]string
+
+// parseVorbisComments decodes a Vorbis comment block
+func parseVorbisComments(data []byte) vorbisComments {
+	comments := make(vorbisComments)
+	if len(data) < 4 {
+		return comments
+	}
+	vendor := int(binary.LittleEndian.Uint32(data[:4]))
+	if len(data) < 8+vendor {
+		return comments
+	}
+	data = data[4+vendor:]
+	count := int(binary.LittleEndian.Uint32(data[:4]))
+	data = data[4:]
+
+	for i := 0; i < count && len(data) >= 4; i++ {
+		length := int(binary.LittleEndian.Uint32(data[:4]))
+		if len(data) < 4+length {
+			break
+		}
+		comment := string(data[4 : 4+length])
+		data = data[4+length:]
+
+		name, value, ok := strings.Cut(comment, "=")
+		if !ok || value == "" {
+			continue
+		}
+		name = strings.ToUpper(name)
+		if existing := comments[name]; existing != "" {
+			value = existing + ", " + value
+		}
+		comments[name] = value
+	}
+	return comments
+}
+
+// applyVorbisComments fills info from comments, including CHAPTERxxx and
+// CHAPTERxxxNAME chapter marks
+func applyVorbisComments(info *Info, comments vorbisComments) {
+	applyTextTags(info, comments)
+
+	var chapters []Chapter
+	for name, value := range comments {
+		if len(name) != 10 || !strings.HasPrefix(name, "CHAPTER") {
+			continue
+		}
+		start, ok := parseChapterTime(value)
+		if !ok {
+			continue
+		}
+		chapters = append(chapters, Chapter{Start: start, Title: comments[name+"NAME"]})
+	}
+	sort.Slice(chapters, func(i, j int) bool { return chapters[i].Start < chapters[j].Start })
+	info.Chapters = chapters
+}
+
+// parseChapterTime parses an HH:MM:SS.mmm chapter start
+func parseChapterTime(value string) (time.Duration, bool) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	hours, err1 := strconv.Atoi(parts[0])
+	minutes, err2 := strconv.Atoi(parts[1])
+	seconds, err3 := strconv.ParseFloat(parts[2], 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, false
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)), true
+}
@@ -0,0 +1,158 @@
+package probe
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vorbisCommentBlock returns a Vorbis comment block
+func vorbisCommentBlock(comments ...string) []byte {
+	vendor := "listenarr test"
+	data := binary.LittleEndian.AppendUint32(nil, uint32(len(vendor)))
+	data = append(data, vendor...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(comments)))
+	for _, comment := range comments {
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(comment)))
+		data = append(data, comment...)
+	}
+	return data
+}
+
+// flacBlock returns a FLAC metadata block
+func flacBlock(blockType byte, last bool, data []byte) []byte {
+	if last {
+		blockType |= 0x80
+	}
+	return append([]byte{blockType, byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))}, data...)
+}
+
+// streamInfo returns a STREAMINFO block payload
+func streamInfo(sampleRate, channels, bitsPerSample, totalSamples uint64) []byte {
+	data := make([]byte, 34)
+	packed := sampleRate<<44 | (channels-1)<<41 | (bitsPerSample-1)<<36 | totalSamples
+	binary.BigEndian.PutUint64(data[10:18], packed)
+	return data
+}
+
+// oggPage returns an Ogg page holding a single packet
+func oggPage(sequence uint32, granule uint64, packet []byte) []byte {
+	var lacing []byte
+	remaining := len(packet)
+	for remaining >= 255 {
+		lacing = append(lacing, 255)
+		remaining -= 255
+	}
+	lacing = append(lacing, byte(remaining))
+
+	page := append([]byte("OggS"), 0, 0)
+	page = binary.LittleEndian.AppendUint64(page, granule)
+	page = binary.LittleEndian.AppendUint32(page, 1234)
+	page = binary.LittleEndian.AppendUint32(page, sequence)
+	page = binary.LittleEndian.AppendUint32(page, 0) // Checksums are not verified
+	page = append(page, byte(len(lacing)))
+	page = append(page, lacing...)
+	return append(page, packet...)
+}
+
+func TestProbe_FLAC(t *testing.T) {
+	comments := vorbisCommentBlock(
+		"TITLE=The Colour of Magic",
+		"album=The Colour of Magic",
+		"ARTIST=Terry Pratchett",
+		"NARRATOR=Nigel Planer",
+		"SERIES=Discworld",
+		"SERIES-PART=1",
+		"DATE=1983-11-24",
+		"GENRE=Fantasy",
+		"CHAPTER001=00:00:00.000",
+		"CHAPTER001NAME=Prologue",
+		"CHAPTER002=00:12:30.500",
+		"CHAPTER002NAME=The Colour of Magic",
+		"EMPTY=",
+	)
+	// Ten seconds of 44.1 kHz stereo with 20000 bytes of frames
+	path := writeFile(t, "book.flac",
+		[]byte("fLaC"),
+		flacBlock(0, false, streamInfo(44100, 2, 16, 441000)),
+		flacBlock(4, true, comments),
+		make([]byte, 20000),
+	)
+
+	info, err := Probe(path)
+	require.NoError(t, err)
+	assert.Equal(t, &Info{
+		Format:   FormatFLAC,
+		Title:    "The Colour of Magic",
+		Album:    "The Colour of Magic",
+		Author:   "Terry Pratchett",
+		Narrator: "Nigel Planer",
+		Series:   "Discworld",
+		Sequence: "1",
+		Year:     "1983",
+		Genre:    "Fantasy",
+		Duration: 10 * time.Second,
+		Bitrate:  16,
+		Chapters: []Chapter{
+			{Start: 0, Title: "Prologue"},
+			{Start: 12*time.Minute + 30500*time.Millisecond, Title: "The Colour of Magic"},
+		},
+	}, info)
+}
+
+func TestProbe_Opus(t *testing.T) {
+	head := append([]byte("OpusHead"), 1, 2)
+	head = binary.LittleEndian.AppendUint16(head, 312)
+	head = binary.LittleEndian.AppendUint32(head, 44100)
+	head = append(head, 0, 0, 0)
+	tags := append([]byte("OpusTags"), vorbisCommentBlock("TITLE=Mort", "ARTIST=Terry Pratchett", "ARTIST=Co Author", "COMPOSER=Nigel Planer")...)
+
+	// Five seconds after the pre-skip, with a comment packet over two segments
+	tags = append(tags, make([]byte, 300)...)
+	path := writeFile(t, "book.opus",
+		oggPage(0, 0, head),
+		oggPage(1, 0, tags),
+		oggPage(2, 100000, make([]byte, 1000)),
+		oggPage(3, 48000*5+312, make([]byte, 1000)),
+	)
+
+	info, err := Probe(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatOpus, info.Format)
+	assert.Equal(t, "Mort", info.Title)
+	assert.Equal(t, "Terry Pratchett, Co Author", info.Author)
+	assert.Equal(t, "Nigel Planer", info.Narrator)
+	assert.Equal(t, 5*time.Second, info.Duration)
+	assert.Greater(t, info.Bitrate, 0)
+}
+
+func TestProbe_OggVorbis(t *testing.T) {
+	identification := append([]byte("\x01vorbis"), 0, 0, 0, 0, 2)
+	identification = binary.LittleEndian.AppendUint32(identification, 22050)
+	identification = append(identification, make([]byte, 14)...)
+	comments := append([]byte("\x03vorbis"), vorbisCommentBlock("ALBUM=Guards! Guards!")...)
+
+	path := writeFile(t, "book.ogg",
+		oggPage(0, 0, identification),
+		oggPage(1, 0, comments),
+		oggPage(2, 22050*3, make([]byte, 100)),
+	)
+
+	info, err := Probe(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatOgg, info.Format)
+	assert.Equal(t, "Guards! Guards!", info.BookTitle())
+	assert.Equal(t, 3*time.Second, info.Duration)
+}
+
+func TestParseChapterTime(t *testing.T) {
+	start, ok := parseChapterTime("01:02:03.250")
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour+2*time.Minute+3250*time.Millisecond, start)
+
+	_, ok = parseChapterTime("62.5")
+	assert.False(t, ok)
+}
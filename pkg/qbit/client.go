@@ -2,6 +2,7 @@ package qbit
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +11,9 @@ import (
 	"time"
 )
 
+// ErrTorrentNotFound is returned when qBittorrent has no torrent with the requested hash
+var ErrTorrentNotFound = errors.New("torrent not found")
+
 // Client represents a qBittorrent API client
 type Client struct {
 	baseURL    string
@@ -306,7 +310,7 @@ func (c *Client) GetTorrentInfo(hash string) (*TorrentInfo, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("torrent with hash %s not found", hash)
+	return nil, fmt.Errorf("%w: %s", ErrTorrentNotFound, hash)
 }
 
 // DeleteTorrent deletes a torrent from qBittorrent
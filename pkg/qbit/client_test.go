@@ -206,6 +206,6 @@ func TestClient_GetTorrentInfo_NotFound(t *testing.T) {
 	assert.NoError(t, err)
 
 	torrent, err := client.GetTorrentInfo("nonexistent")
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrTorrentNotFound)
 	assert.Nil(t, torrent)
 }